@@ -28,6 +28,14 @@ func init() {
 			if err := s.Convert(&in.Revision, &out.Revision, 0); err != nil {
 				return err
 			}
+			out.CompletionDeadlineSeconds = in.CompletionDeadlineSeconds
+			if err := s.Convert(&in.PostCommit, &out.PostCommit, 0); err != nil {
+				return err
+			}
+			if err := s.Convert(&in.Resources, &out.Resources, 0); err != nil {
+				return err
+			}
+			out.NodeSelector = in.NodeSelector
 			return nil
 		},
 		func(in *BuildParameters, out *newer.BuildParameters, s conversion.Scope) error {
@@ -47,6 +55,14 @@ func init() {
 			if err := s.Convert(&in.Revision, &out.Revision, 0); err != nil {
 				return err
 			}
+			out.CompletionDeadlineSeconds = in.CompletionDeadlineSeconds
+			if err := s.Convert(&in.PostCommit, &out.PostCommit, 0); err != nil {
+				return err
+			}
+			if err := s.Convert(&in.Resources, &out.Resources, 0); err != nil {
+				return err
+			}
+			out.NodeSelector = in.NodeSelector
 			return nil
 		},
 		// Rename STIBuildStrategy.BuildImage to STIBuildStrategy.Image
@@ -55,11 +71,13 @@ func init() {
 			out.Image = in.Image
 			out.Scripts = in.Scripts
 			out.Clean = !in.Incremental
+			out.PullSecretName = in.PullSecretName
 			return s.Convert(&in.Env, &out.Env, 0)
 		},
 		func(in *STIBuildStrategy, out *newer.STIBuildStrategy, s conversion.Scope) error {
 			out.Scripts = in.Scripts
 			out.Incremental = !in.Clean
+			out.PullSecretName = in.PullSecretName
 			if len(in.Image) != 0 {
 				out.Image = in.Image
 			} else {
@@ -71,7 +89,9 @@ func init() {
 		func(in *newer.DockerBuildStrategy, out *DockerBuildStrategy, s conversion.Scope) error {
 			out.NoCache = in.NoCache
 			out.BaseImage = in.Image
-			return nil
+			out.DockerfilePath = in.DockerfilePath
+			out.PullSecretName = in.PullSecretName
+			return s.Convert(&in.Env, &out.Env, 0)
 		},
 		func(in *DockerBuildStrategy, out *newer.DockerBuildStrategy, s conversion.Scope) error {
 			out.NoCache = in.NoCache
@@ -80,7 +100,9 @@ func init() {
 			} else {
 				out.Image = in.BaseImage
 			}
-			return nil
+			out.DockerfilePath = in.DockerfilePath
+			out.PullSecretName = in.PullSecretName
+			return s.Convert(&in.Env, &out.Env, 0)
 		},
 		// Deprecate ImageTag and Registry, replace with To / Tag / DockerImageReference
 		func(in *newer.BuildOutput, out *BuildOutput, s conversion.Scope) error {
@@ -88,6 +110,7 @@ func init() {
 				return err
 			}
 			out.Tag = in.Tag
+			out.PushSecretName = in.PushSecretName
 			if len(in.DockerImageReference) > 0 {
 				out.DockerImageReference = in.DockerImageReference
 				ref, err := image.ParseDockerImageReference(in.DockerImageReference)
@@ -105,6 +128,7 @@ func init() {
 				return err
 			}
 			out.Tag = in.Tag
+			out.PushSecretName = in.PushSecretName
 			if len(in.DockerImageReference) > 0 {
 				out.DockerImageReference = in.DockerImageReference
 				return nil
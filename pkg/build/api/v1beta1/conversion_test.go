@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta3"
 
 	newer "github.com/openshift/origin/pkg/build/api"
@@ -34,7 +35,9 @@ func TestSTIBuildStrategyConversion(t *testing.T) {
 func TestDockerBuildStrategyConversion(t *testing.T) {
 	var actual newer.DockerBuildStrategy
 	oldVersion := current.DockerBuildStrategy{
-		BaseImage: "testimage",
+		BaseImage:      "testimage",
+		DockerfilePath: "docker/Dockerfile.prod",
+		Env:            []kapi.EnvVar{{Name: "RACK_ENV", Value: "production"}},
 	}
 	err := Convert(&oldVersion, &actual)
 	if err != nil {
@@ -43,6 +46,12 @@ func TestDockerBuildStrategyConversion(t *testing.T) {
 	if actual.Image != oldVersion.BaseImage {
 		t.Errorf("expected %v, actual %v", oldVersion.BaseImage, actual.Image)
 	}
+	if actual.DockerfilePath != oldVersion.DockerfilePath {
+		t.Errorf("expected %v, actual %v", oldVersion.DockerfilePath, actual.DockerfilePath)
+	}
+	if len(actual.Env) != 1 || actual.Env[0].Name != "RACK_ENV" || actual.Env[0].Value != "production" {
+		t.Errorf("expected %v, actual %v", oldVersion.Env, actual.Env)
+	}
 }
 
 func TestContextDirConversion(t *testing.T) {
@@ -108,3 +117,84 @@ func TestImageChangeTriggerFromRename(t *testing.T) {
 		t.Errorf("expected %#v, actual %#v", old, actual)
 	}
 }
+
+func TestCompletionDeadlineSecondsConversion(t *testing.T) {
+	deadline := int64(600)
+	var actual newer.BuildParameters
+	oldVersion := current.BuildParameters{CompletionDeadlineSeconds: &deadline}
+	if err := Convert(&oldVersion, &actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.CompletionDeadlineSeconds == nil || *actual.CompletionDeadlineSeconds != deadline {
+		t.Errorf("expected %v, actual %v", deadline, actual.CompletionDeadlineSeconds)
+	}
+
+	var converted current.BuildParameters
+	if err := Convert(&actual, &converted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if converted.CompletionDeadlineSeconds == nil || *converted.CompletionDeadlineSeconds != deadline {
+		t.Errorf("expected %v, actual %v", deadline, converted.CompletionDeadlineSeconds)
+	}
+}
+
+func TestPostCommitConversion(t *testing.T) {
+	var actual newer.BuildParameters
+	oldVersion := current.BuildParameters{
+		PostCommit: &current.BuildPostCommitSpec{Script: "bundle exec rake test", Args: []string{"--verbose"}},
+	}
+	if err := Convert(&oldVersion, &actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.PostCommit == nil || actual.PostCommit.Script != "bundle exec rake test" || len(actual.PostCommit.Args) != 1 {
+		t.Errorf("expected %#v, actual %#v", oldVersion.PostCommit, actual.PostCommit)
+	}
+}
+
+func TestRegistrySecretConversion(t *testing.T) {
+	var actual newer.BuildParameters
+	oldVersion := current.BuildParameters{
+		Strategy: current.BuildStrategy{
+			Type:        current.STIBuildStrategyType,
+			STIStrategy: &current.STIBuildStrategy{Image: "builder/image", PullSecretName: "pull"},
+		},
+		Output: current.BuildOutput{DockerImageReference: "registry.example.com/app", PushSecretName: "push"},
+	}
+	if err := Convert(&oldVersion, &actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.Strategy.STIStrategy.PullSecretName != "pull" {
+		t.Errorf("expected pull, actual %v", actual.Strategy.STIStrategy.PullSecretName)
+	}
+	if actual.Output.PushSecretName != "push" {
+		t.Errorf("expected push, actual %v", actual.Output.PushSecretName)
+	}
+
+	var converted current.BuildParameters
+	if err := Convert(&actual, &converted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if converted.Strategy.STIStrategy.PullSecretName != "pull" || converted.Output.PushSecretName != "push" {
+		t.Errorf("expected the secrets to be kept, actual %#v", converted)
+	}
+}
+
+func TestResourcesConversion(t *testing.T) {
+	var actual newer.BuildParameters
+	oldVersion := current.BuildParameters{
+		Resources: kapi.ResourceRequirements{
+			Limits: kapi.ResourceList{kapi.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		NodeSelector: map[string]string{"region": "builds"},
+	}
+	if err := Convert(&oldVersion, &actual); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	memory := actual.Resources.Limits[api.ResourceMemory]
+	if memory.String() != "1Gi" {
+		t.Errorf("expected 1Gi, actual %v", memory.String())
+	}
+	if actual.NodeSelector["region"] != "builds" {
+		t.Errorf("expected %v, actual %v", oldVersion.NodeSelector, actual.NodeSelector)
+	}
+}
@@ -20,12 +20,19 @@ type Build struct {
 	// A human readable message indicating details about why the build has this status
 	Message string `json:"message,omitempty"`
 
+	// Reason is a brief CamelCase string explaining why the build has this status.
+	Reason BuildStatusReason `json:"reason,omitempty"`
+
 	// PodName is the name of the pod that is used to execute the build
 	PodName string `json:"podName,omitempty"`
 
 	// Cancelled describes if a cancelling event was triggered for the build.
 	Cancelled bool `json:"cancelled,omitempty"`
 
+	// Number is the position of this build among the builds created from its BuildConfig.
+	// It is assigned when the build is created and is zero for builds without a config.
+	Number int `json:"number,omitempty"`
+
 	// StartTimestamp is a timestamp representing the server time when this Build started
 	// running in a Pod.
 	// It is represented in RFC3339 form and is in UTC.
@@ -36,6 +43,77 @@ type Build struct {
 	// the Pod running the Build terminated.
 	// It is represented in RFC3339 form and is in UTC.
 	CompletionTimestamp *util.Time `json:"completionTimestamp,omitempty"`
+
+	// Notifications is the delivery status of the completion notifications sent for this
+	// build to the URLs of its BuildConfig.
+	Notifications []BuildNotificationStatus `json:"notifications,omitempty"`
+
+	// Causes are the reasons the build was started, recorded by the trigger that created it.
+	Causes []BuildCause `json:"causes,omitempty"`
+}
+
+// BuildCause describes why a build was started.
+type BuildCause struct {
+	// Type is the type of the trigger that started the build, or manual for builds started
+	// by a user.
+	Type BuildTriggerType `json:"type"`
+
+	// WebHook holds the details of the webhook call, if a webhook started the build.
+	WebHook *BuildCauseWebHook `json:"webHook,omitempty"`
+
+	// ImageTrigger holds the image that changed, if an image change started the build.
+	ImageTrigger *BuildCauseImageTrigger `json:"imageTrigger,omitempty"`
+
+	// Manual holds who started the build, if a user started it.
+	Manual *BuildCauseManual `json:"manual,omitempty"`
+}
+
+// BuildCauseWebHook holds the details of the webhook call that started a build.
+type BuildCauseWebHook struct {
+	// Revision is the source revision sent by the webhook, if any.
+	Revision *SourceRevision `json:"revision,omitempty"`
+}
+
+// BuildCauseImageTrigger holds the image whose change started a build.
+type BuildCauseImageTrigger struct {
+	// ImageID is the ID of the image the tag points to, or its pull spec when the tag has
+	// no image.
+	ImageID string `json:"imageID,omitempty"`
+
+	// From is the ImageRepository whose tag changed.
+	From kapi.ObjectReference `json:"from"`
+
+	// Tag is the tag of the ImageRepository that changed.
+	Tag string `json:"tag,omitempty"`
+}
+
+// BuildCauseManual holds who started a build.
+type BuildCauseManual struct {
+	// User is the name of the user who started the build. It is set by the server to the user
+	// creating the build.
+	User string `json:"user,omitempty"`
+
+	// FromBuild is the name of the build that was run again, if any.
+	FromBuild string `json:"fromBuild,omitempty"`
+}
+
+// BuildNotificationStatus is the delivery status of the completion notification of a build
+// to one URL.
+type BuildNotificationStatus struct {
+	// URL is where the notification was sent.
+	URL string `json:"url"`
+
+	// Delivered is true once the URL accepted the notification with a 2xx response.
+	Delivered bool `json:"delivered"`
+
+	// Attempts is the number of times the notification was sent.
+	Attempts int `json:"attempts"`
+
+	// Message describes the outcome of the last attempt.
+	Message string `json:"message,omitempty"`
+
+	// Timestamp is the time of the last attempt.
+	Timestamp *util.Time `json:"timestamp,omitempty"`
 }
 
 // BuildParameters encapsulates all the inputs necessary to represent a build.
@@ -52,6 +130,37 @@ type BuildParameters struct {
 
 	// Output describes the Docker image the Strategy should produce.
 	Output BuildOutput `json:"output,omitempty"`
+
+	// CompletionDeadlineSeconds is the number of seconds the build pod may exist before the
+	// build is failed and the pod is deleted. The build runs until it finishes when unset.
+	CompletionDeadlineSeconds *int64 `json:"completionDeadlineSeconds,omitempty"`
+
+	// PostCommit is a hook run in a container of the built image before it is pushed. The
+	// build fails and the image is not pushed when the hook exits with a non-zero code.
+	PostCommit *BuildPostCommitSpec `json:"postCommit,omitempty"`
+
+	// Resources are the compute resource limits of the build pod. Limits that are not set
+	// default to the build defaults of the master.
+	Resources kapi.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector selects the nodes the build pod may run on. It defaults to the build
+	// defaults of the master.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+// BuildPostCommitSpec holds the command run in a container of a freshly built image, for
+// example to run its tests. Either Script or Command may be set, and Args alone run the
+// entrypoint of the image with these arguments.
+type BuildPostCommitSpec struct {
+	// Command replaces the entrypoint of the image.
+	Command []string `json:"command,omitempty"`
+
+	// Args are the arguments of Command, of the entrypoint of the image, or the positional
+	// parameters of Script.
+	Args []string `json:"args,omitempty"`
+
+	// Script is a shell script run with /bin/sh -c.
+	Script string `json:"script,omitempty"`
 }
 
 // BuildStatus represents the status of a build at a point in time.
@@ -82,6 +191,16 @@ const (
 	BuildStatusCancelled BuildStatus = "Cancelled"
 )
 
+// BuildStatusReason is a brief CamelCase string that describes why a build has its status.
+type BuildStatusReason string
+
+// Valid values for BuildStatusReason.
+const (
+	// BuildStatusReasonTimedOut indicates that the build did not finish within its
+	// completion deadline.
+	BuildStatusReasonTimedOut BuildStatusReason = "TimedOut"
+)
+
 // BuildSourceType is the type of SCM used
 type BuildSourceType string
 
@@ -89,6 +208,10 @@ type BuildSourceType string
 const (
 	//BuildSourceGit is a Git SCM
 	BuildSourceGit BuildSourceType = "Git"
+
+	// BuildSourceBinary indicates the build will receive its source as a file or
+	// archive uploaded by the client when the build is instantiated.
+	BuildSourceBinary BuildSourceType = "Binary"
 )
 
 // BuildSource is the SCM used for the build
@@ -96,10 +219,57 @@ type BuildSource struct {
 	Type BuildSourceType `json:"type,omitempty"`
 	Git  *GitBuildSource `json:"git,omitempty"`
 
+	// Binary describes how the uploaded content is placed into the build context
+	// when Type is Binary.
+	Binary *BinaryBuildSource `json:"binary,omitempty"`
+
 	// Specify the sub-directory where the source code for the application exists.
 	// This allows to have buildable sources in directory other than root of
 	// repository.
 	ContextDir string `json:"contextDir,omitempty"`
+
+	// Dockerfile is the content of a Dockerfile used instead of the one of the source, for
+	// repositories that do not have one. It is written to the Dockerfile path of the build.
+	Dockerfile string `json:"dockerfile,omitempty"`
+
+	// SourceSecretName is the name of a Secret in the namespace of the build holding the
+	// credentials used to clone a private Git repository: an SSH private key with the
+	// known_hosts entries of the Git server, or a username and password or token for HTTPS,
+	// plus an optional CA certificate and .gitconfig, under the keys ssh-privatekey,
+	// known_hosts, username, password, ca.crt and gitconfig.
+	SourceSecretName string `json:"sourceSecretName,omitempty"`
+
+	// Images are images whose files are copied into the context directory before the build,
+	// for example to package artifacts produced by another build.
+	Images []ImageSource `json:"images,omitempty"`
+}
+
+// ImageSource describes an image whose files are copied into the context directory of a
+// build. The image is pulled with the credentials of the pull secret of the build.
+type ImageSource struct {
+	// From is the ImageRepository holding the image. The namespace may be empty, in which case
+	// the namespace of the build is used. When set, DockerImageReference is resolved from it
+	// when the build starts, unless the image change trigger creating the build set it.
+	From *kapi.ObjectReference `json:"from,omitempty"`
+
+	// Tag is the tag of From holding the image, latest when empty.
+	Tag string `json:"tag,omitempty"`
+
+	// DockerImageReference is the full name of the image, used when From is not set.
+	DockerImageReference string `json:"dockerImageReference,omitempty"`
+
+	// Paths are the files and directories copied from the image.
+	Paths []ImageSourcePath `json:"paths"`
+}
+
+// ImageSourcePath is a file or directory copied from an image into the context directory.
+type ImageSourcePath struct {
+	// SourcePath is the absolute path of the file or directory in the image.
+	SourcePath string `json:"sourcePath"`
+
+	// DestinationDir is the directory, relative to the context directory, the file or
+	// directory is copied into. It defaults to the context directory itself.
+	DestinationDir string `json:"destinationDir,omitempty"`
 }
 
 // SourceRevision is the revision or commit information from the source for the build
@@ -131,6 +301,27 @@ type GitBuildSource struct {
 
 	// Ref is the branch/tag/ref to build.
 	Ref string `json:"ref,omitempty"`
+
+	// HTTPProxy is the proxy used to reach the repository over http. The default proxy of the
+	// master is used when it is empty.
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the proxy used to reach the repository over https. The default proxy of the
+	// master is used when it is empty.
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is the comma separated list of hosts and domains reached without the proxies.
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// BinaryBuildSource describes content streamed to the build by the client
+// instead of being fetched from a source repository.
+type BinaryBuildSource struct {
+	// AsFile indicates that the uploaded content should be saved as a single file
+	// with this name at the root of the build context (for example "Dockerfile").
+	// When empty, the content is treated as a tar, tar.gz or zip archive and is
+	// extracted into the build context.
+	AsFile string `json:"asFile,omitempty"`
 }
 
 // SourceControlUser defines the identity of a user of source control
@@ -183,6 +374,10 @@ type CustomBuildStrategy struct {
 	// inside the Docker container.
 	// TODO: Allow admins to enforce 'false' for this option
 	ExposeDockerSocket bool `json:"exposeDockerSocket,omitempty"`
+
+	// PullSecretName is the name of a Secret whose .dockercfg is mounted in the builder
+	// container, with its path in PULL_DOCKERCFG_PATH.
+	PullSecretName string `json:"pullSecretName,omitempty"`
 }
 
 // DockerBuildStrategy defines input parameters specific to Docker build.
@@ -205,6 +400,19 @@ type DockerBuildStrategy struct {
 	// build should "FROM".  If present, the build process will substitute this value
 	// into the FROM line of the dockerfile.
 	Image string `json:"image,omitempty"`
+
+	// DockerfilePath is the path of the Dockerfile to build, relative to the context
+	// directory of the source. Defaults to Dockerfile.
+	DockerfilePath string `json:"dockerfilePath,omitempty"`
+
+	// Env contains additional environment variables set in the built image as ENV
+	// instructions of the Dockerfile.
+	Env []kapi.EnvVar `json:"env,omitempty"`
+
+	// PullSecretName is the name of a Secret in the namespace of the build holding the
+	// .dockercfg used to pull images from private registries during the build, under the
+	// key dockercfg. Credentials are selected by the registry host of each image.
+	PullSecretName string `json:"pullSecretName,omitempty"`
 }
 
 // STIBuildStrategy defines input parameters specific to an STI build.
@@ -225,6 +433,10 @@ type STIBuildStrategy struct {
 	// Clean flag forces the STI build to not do incremental builds if true.
 	// Deprecated: in v1beta2 it will be replaced by Incremental.
 	Clean bool `json:"clean,omitempty"`
+
+	// PullSecretName is the name of a Secret holding the .dockercfg used to pull the builder
+	// image, as for the Docker strategy.
+	PullSecretName string `json:"pullSecretName,omitempty"`
 }
 
 // BuildOutput is input to a build strategy and describes the Docker image that the strategy
@@ -255,6 +467,11 @@ type BuildOutput struct {
 	// Registry is the Docker registry which should receive the resulting built image via push.
 	// DEPRECATED: use DockerImageReference
 	Registry string `json:"registry,omitempty"`
+
+	// PushSecretName is the name of a Secret in the namespace of the build holding the
+	// .dockercfg used to push the output image, under the key dockercfg. The credentials of
+	// the registry host of the output image are used.
+	PushSecretName string `json:"pushSecretName,omitempty"`
 }
 
 // BuildConfigLabel is the key of a Build label whose value is the ID of a BuildConfig
@@ -273,12 +490,72 @@ type BuildConfig struct {
 	// Parameters holds all the input necessary to produce a new build. A build config may only
 	// define either the Output.To or Output.DockerImageReference fields, but not both.
 	Parameters BuildParameters `json:"parameters,omitempty"`
+
+	// RunPolicy describes how new builds created from this BuildConfig are scheduled to run
+	// relative to the other builds of the config. Defaults to Parallel when unset.
+	RunPolicy BuildRunPolicy `json:"runPolicy,omitempty"`
+
+	// LastVersion is the number of the last build created from this BuildConfig.
+	LastVersion int `json:"lastVersion"`
+
+	// SuccessfulBuildsHistoryLimit is the number of complete builds of this config to keep.
+	// Older complete builds are deleted along with their pods. All builds are kept when unset.
+	SuccessfulBuildsHistoryLimit *int `json:"successfulBuildsHistoryLimit,omitempty"`
+
+	// FailedBuildsHistoryLimit is the number of failed, errored or cancelled builds of this
+	// config to keep. Older ones are deleted along with their pods. All builds are kept when unset.
+	FailedBuildsHistoryLimit *int `json:"failedBuildsHistoryLimit,omitempty"`
+
+	// Notifications are sent when the builds of this config complete, fail, error or are
+	// cancelled.
+	Notifications []BuildNotification `json:"notifications,omitempty"`
 }
 
+// BuildNotification is a URL receiving a signed JSON payload describing each finished build
+// of a BuildConfig.
+type BuildNotification struct {
+	// URL is the http or https URL the payload is POSTed to.
+	URL string `json:"url"`
+
+	// SecretName is the name of the Secret, in the namespace of the BuildConfig, whose "secret"
+	// entry is the key of the HMAC-SHA256 signature of the payload, sent in the
+	// X-OpenShift-Signature header as "sha256=<hex>".
+	SecretName string `json:"secretName"`
+}
+
+// BuildRunPolicy defines how new builds of a BuildConfig are started.
+type BuildRunPolicy string
+
+const (
+	// BuildRunPolicyParallel starts every new build as soon as it is created, so that
+	// builds of the same config may run concurrently.
+	BuildRunPolicyParallel BuildRunPolicy = "Parallel"
+
+	// BuildRunPolicySerial runs the builds of a config one at a time, in the order
+	// they were created.
+	BuildRunPolicySerial BuildRunPolicy = "Serial"
+
+	// BuildRunPolicySerialLatestOnly runs the builds of a config one at a time and
+	// cancels queued builds that are superseded by a newer build.
+	BuildRunPolicySerialLatestOnly BuildRunPolicy = "SerialLatestOnly"
+)
+
 // WebHookTrigger is a trigger that gets invoked using a webhook type of post
 type WebHookTrigger struct {
 	// Secret used to validate requests.
 	Secret string `json:"secret,omitempty"`
+	// RequireSignature rejects requests that are not signed with an HMAC of their body keyed
+	// with the secret, so that knowing the webhook URL is not enough to trigger builds.
+	// Only honored by webhook types that support signed requests. GitLab requests are not
+	// signed, they must carry the secret in the X-Gitlab-Token header instead.
+	RequireSignature bool `json:"requireSignature,omitempty"`
+	// AllowEnv are the names of the environment variables that requests may set on the strategy
+	// of the build they trigger. Requests setting other variables are rejected. Only honored
+	// by the generic webhook.
+	AllowEnv []string `json:"allowEnv,omitempty"`
+	// AllowRefOverride builds the ref given by requests instead of ignoring requests for refs
+	// other than the ref of the source. Only honored by the generic webhook.
+	AllowRefOverride bool `json:"allowRefOverride,omitempty"`
 }
 
 // ImageChangeTrigger allows builds to be triggered when an ImageRepository changes
@@ -314,8 +591,40 @@ type BuildTriggerPolicy struct {
 	// GenericWebHook contains the parameters for a Generic webhook type of trigger
 	GenericWebHook *WebHookTrigger `json:"generic,omitempty"`
 
+	// GitLabWebHook contains the parameters for a GitLab webhook type of trigger
+	GitLabWebHook *WebHookTrigger `json:"gitlab,omitempty"`
+
+	// BitbucketWebHook contains the parameters for a Bitbucket webhook type of trigger
+	BitbucketWebHook *WebHookTrigger `json:"bitbucket,omitempty"`
+
+	// GogsWebHook contains the parameters for a Gogs webhook type of trigger
+	GogsWebHook *WebHookTrigger `json:"gogs,omitempty"`
+
 	// ImageChange contains parameters for an ImageChange type of trigger
 	ImageChange *ImageChangeTrigger `json:"imageChange,omitempty"`
+
+	// SCMPoll contains the state of an SCMPoll type of trigger. It may be omitted.
+	SCMPoll *SCMPollTrigger `json:"scmPoll,omitempty"`
+
+	// ConfigChange contains the state of a ConfigChange type of trigger. It may be omitted.
+	ConfigChange *ConfigChangeTrigger `json:"configChange,omitempty"`
+}
+
+// ConfigChangeTrigger launches a build when its BuildConfig is created and whenever the
+// Parameters of the BuildConfig change.
+type ConfigChangeTrigger struct {
+	// LastTriggeredParametersHash is the hash of the Parameters the last build launched by this
+	// trigger was created from. It is set by the config change controller.
+	LastTriggeredParametersHash string `json:"lastTriggeredParametersHash,omitempty"`
+}
+
+// SCMPollTrigger launches builds when the Git ref of the build source points to a new commit,
+// for repositories that cannot send webhooks. The repository is polled by the master, so it
+// must be reachable from the master without credentials.
+type SCMPollTrigger struct {
+	// LastBuiltCommit is the commit of the source ref that the last build launched by this
+	// trigger was created for. It is set by the poll controller.
+	LastBuiltCommit string `json:"lastBuiltCommit,omitempty"`
 }
 
 // BuildTriggerType refers to a specific BuildTriggerPolicy implementation.
@@ -330,9 +639,33 @@ const (
 	// generic webhook invocations
 	GenericWebHookBuildTriggerType BuildTriggerType = "generic"
 
+	// GitLabWebHookBuildTriggerType represents a trigger that launches builds on
+	// GitLab webhook invocations
+	GitLabWebHookBuildTriggerType BuildTriggerType = "gitlab"
+
+	// BitbucketWebHookBuildTriggerType represents a trigger that launches builds on
+	// Bitbucket webhook invocations
+	BitbucketWebHookBuildTriggerType BuildTriggerType = "bitbucket"
+
+	// GogsWebHookBuildTriggerType represents a trigger that launches builds on
+	// Gogs webhook invocations
+	GogsWebHookBuildTriggerType BuildTriggerType = "gogs"
+
 	// ImageChangeBuildTriggerType represents a trigger that launches builds on
 	// availability of a new version of an image
 	ImageChangeBuildTriggerType BuildTriggerType = "imageChange"
+
+	// SCMPollBuildTriggerType represents a trigger that launches builds when polling the
+	// Git repository of the source finds a new commit for its ref
+	SCMPollBuildTriggerType BuildTriggerType = "scmPoll"
+
+	// ConfigChangeBuildTriggerType represents a trigger that launches builds when a
+	// BuildConfig is created or its Parameters change
+	ConfigChangeBuildTriggerType BuildTriggerType = "configChange"
+
+	// ManualBuildTriggerType is the type of the cause of builds started by a user. It is
+	// not a valid type of BuildTriggerPolicy.
+	ManualBuildTriggerType BuildTriggerType = "manual"
 )
 
 // BuildList is a collection of Builds.
@@ -356,6 +689,10 @@ type GenericWebHookEvent struct {
 
 	// Git is the git information if the Type is BuildSourceGit
 	Git *GitInfo `json:"git,omitempty"`
+
+	// Env are environment variables set on the strategy of the triggered build, which the
+	// trigger must allow.
+	Env []kapi.EnvVar `json:"env,omitempty"`
 }
 
 // GitInfo is the aggregated git information for a generic webhook post
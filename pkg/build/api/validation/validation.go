@@ -2,7 +2,11 @@ package validation
 
 import (
 	"net/url"
+	"path"
+	"regexp"
+	"strings"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	errs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
@@ -45,9 +49,48 @@ func ValidateBuildConfig(config *buildapi.BuildConfig) errs.ValidationErrorList
 	allErrs = append(allErrs, validation.ValidateLabels(config.Labels, "labels")...)
 	for i := range config.Triggers {
 		allErrs = append(allErrs, validateTrigger(&config.Triggers[i]).PrefixIndex(i).Prefix("triggers")...)
+		if config.Triggers[i].Type == buildapi.SCMPollBuildTriggerType && config.Parameters.Source.Git == nil {
+			allErrs = append(allErrs, errs.ValidationErrorList{errs.NewFieldInvalid("type", config.Triggers[i].Type, "polling requires a Git source")}.PrefixIndex(i).Prefix("triggers")...)
+		}
+		// the image is only optional for triggers of image source inputs
+		if change := config.Triggers[i].ImageChange; config.Triggers[i].Type == buildapi.ImageChangeBuildTriggerType && change != nil && len(change.Image) == 0 && !triggersImageSource(change, config.Parameters.Source.Images) {
+			allErrs = append(allErrs, errs.ValidationErrorList{errs.NewFieldRequired("image")}.Prefix("imageChange").PrefixIndex(i).Prefix("triggers")...)
+		}
 	}
 	allErrs = append(allErrs, validateBuildParameters(&config.Parameters).Prefix("parameters")...)
 	allErrs = append(allErrs, validateBuildConfigOutput(&config.Parameters.Output).Prefix("parameters.output")...)
+	switch config.RunPolicy {
+	case "", buildapi.BuildRunPolicyParallel, buildapi.BuildRunPolicySerial, buildapi.BuildRunPolicySerialLatestOnly:
+	default:
+		allErrs = append(allErrs, errs.NewFieldNotSupported("runPolicy", config.RunPolicy))
+	}
+	if config.LastVersion < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("lastVersion", config.LastVersion, "lastVersion cannot be negative"))
+	}
+	if limit := config.SuccessfulBuildsHistoryLimit; limit != nil && *limit < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("successfulBuildsHistoryLimit", *limit, "successfulBuildsHistoryLimit cannot be negative"))
+	}
+	if limit := config.FailedBuildsHistoryLimit; limit != nil && *limit < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("failedBuildsHistoryLimit", *limit, "failedBuildsHistoryLimit cannot be negative"))
+	}
+	for i := range config.Notifications {
+		allErrs = append(allErrs, validateNotification(&config.Notifications[i]).PrefixIndex(i).Prefix("notifications")...)
+	}
+	return allErrs
+}
+
+func validateNotification(notification *buildapi.BuildNotification) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(notification.URL) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("url"))
+	} else if u, err := url.Parse(notification.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("url", notification.URL, "url must be an absolute http or https URL"))
+	}
+	if len(notification.SecretName) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("secretName"))
+	} else {
+		allErrs = append(allErrs, validateSecretName("secretName", notification.SecretName)...)
+	}
 	return allErrs
 }
 
@@ -64,21 +107,132 @@ func validateBuildParameters(params *buildapi.BuildParameters) errs.ValidationEr
 		}
 	}
 
+	if isCustomBuild && params.Source.Type == buildapi.BuildSourceBinary {
+		allErrs = append(allErrs, errs.NewFieldInvalid("source.type", params.Source.Type, "binary source is not supported by the Custom build strategy"))
+	}
+
+	if len(params.Source.Dockerfile) > 0 && params.Strategy.Type == buildapi.STIBuildStrategyType {
+		allErrs = append(allErrs, errs.NewFieldInvalid("source.dockerfile", "", "a Dockerfile is not supported by the STI build strategy"))
+	}
+
 	allErrs = append(allErrs, validateOutput(&params.Output).Prefix("output")...)
 	allErrs = append(allErrs, validateStrategy(&params.Strategy).Prefix("strategy")...)
 
+	if params.CompletionDeadlineSeconds != nil && *params.CompletionDeadlineSeconds <= 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("completionDeadlineSeconds", *params.CompletionDeadlineSeconds, "completionDeadlineSeconds must be a positive number of seconds"))
+	}
+
+	if params.PostCommit != nil {
+		allErrs = append(allErrs, validatePostCommit(params.PostCommit).Prefix("postCommit")...)
+	}
+
+	allErrs = append(allErrs, ValidateResourceLimits(params.Resources.Limits).Prefix("resources.limits")...)
+	allErrs = append(allErrs, validation.ValidateLabels(params.NodeSelector, "nodeSelector")...)
+
+	return allErrs
+}
+
+// ValidateResourceLimits checks that limits only limit the CPU and memory of build pods to
+// non negative quantities.
+func ValidateResourceLimits(limits kapi.ResourceList) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	for name, quantity := range limits {
+		switch name {
+		case kapi.ResourceCPU, kapi.ResourceMemory:
+			if quantity.Value() < 0 {
+				allErrs = append(allErrs, errs.NewFieldInvalid(string(name), quantity.String(), "limits must not be negative"))
+			}
+		default:
+			allErrs = append(allErrs, errs.NewFieldNotSupported(string(name), name))
+		}
+	}
+	return allErrs
+}
+
+func validatePostCommit(hook *buildapi.BuildPostCommitSpec) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	switch {
+	case len(hook.Script) > 0 && len(hook.Command) > 0:
+		allErrs = append(allErrs, errs.NewFieldInvalid("script", hook.Script, "script and command cannot be used together"))
+	case len(hook.Script) == 0 && len(hook.Command) == 0 && len(hook.Args) == 0:
+		allErrs = append(allErrs, errs.NewFieldRequired("script"))
+	}
 	return allErrs
 }
 
 func validateSource(input *buildapi.BuildSource) errs.ValidationErrorList {
 	allErrs := errs.ValidationErrorList{}
-	if input.Type != buildapi.BuildSourceGit {
+	switch input.Type {
+	case buildapi.BuildSourceGit:
+		if input.Git == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("git"))
+		} else {
+			allErrs = append(allErrs, validateGitSource(input.Git).Prefix("git")...)
+		}
+	case buildapi.BuildSourceBinary:
+		if input.Binary == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("binary"))
+		} else {
+			allErrs = append(allErrs, validateBinarySource(input.Binary).Prefix("binary")...)
+		}
+	default:
 		allErrs = append(allErrs, errs.NewFieldRequired("type"))
 	}
-	if input.Git == nil {
-		allErrs = append(allErrs, errs.NewFieldRequired("git"))
-	} else {
-		allErrs = append(allErrs, validateGitSource(input.Git).Prefix("git")...)
+	if len(input.SourceSecretName) > 0 {
+		if input.Type != buildapi.BuildSourceGit {
+			allErrs = append(allErrs, errs.NewFieldInvalid("sourceSecretName", input.SourceSecretName, "a source secret can only be used with a Git source"))
+		} else {
+			allErrs = append(allErrs, validateSecretName("sourceSecretName", input.SourceSecretName)...)
+		}
+	}
+	for i := range input.Images {
+		allErrs = append(allErrs, validateImageSource(&input.Images[i]).PrefixIndex(i).Prefix("images")...)
+	}
+	return allErrs
+}
+
+func validateImageSource(image *buildapi.ImageSource) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if image.From == nil {
+		if len(image.DockerImageReference) == 0 {
+			allErrs = append(allErrs, errs.NewFieldRequired("from"))
+		}
+	} else if len(image.From.Name) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("from.name"))
+	}
+	if len(image.DockerImageReference) > 0 {
+		if _, err := imageapi.ParseDockerImageReference(image.DockerImageReference); err != nil {
+			allErrs = append(allErrs, errs.NewFieldInvalid("dockerImageReference", image.DockerImageReference, err.Error()))
+		}
+	}
+	if len(image.Paths) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("paths"))
+	}
+	for i, p := range image.Paths {
+		pathErrs := errs.ValidationErrorList{}
+		if len(p.SourcePath) == 0 {
+			pathErrs = append(pathErrs, errs.NewFieldRequired("sourcePath"))
+		} else if !path.IsAbs(p.SourcePath) {
+			pathErrs = append(pathErrs, errs.NewFieldInvalid("sourcePath", p.SourcePath, "sourcePath must be an absolute path"))
+		}
+		if len(p.DestinationDir) > 0 {
+			dir := path.Clean(p.DestinationDir)
+			if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+				pathErrs = append(pathErrs, errs.NewFieldInvalid("destinationDir", p.DestinationDir, "destinationDir must be a relative path within the context directory"))
+			}
+		}
+		allErrs = append(allErrs, pathErrs.PrefixIndex(i).Prefix("paths")...)
+	}
+	return allErrs
+}
+
+func validateBinarySource(binary *buildapi.BinaryBuildSource) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(binary.AsFile) == 0 {
+		return allErrs
+	}
+	if binary.AsFile == "." || binary.AsFile == ".." || strings.ContainsAny(binary.AsFile, `/\`) {
+		allErrs = append(allErrs, errs.NewFieldInvalid("asFile", binary.AsFile, "asFile must be a file name without any path segments"))
 	}
 	return allErrs
 }
@@ -90,6 +244,39 @@ func validateGitSource(git *buildapi.GitBuildSource) errs.ValidationErrorList {
 	} else if !isValidURL(git.URI) {
 		allErrs = append(allErrs, errs.NewFieldInvalid("uri", git.URI, "uri is not a valid url"))
 	}
+	allErrs = append(allErrs, ValidateProxyURL("httpProxy", git.HTTPProxy)...)
+	allErrs = append(allErrs, ValidateProxyURL("httpsProxy", git.HTTPSProxy)...)
+	allErrs = append(allErrs, ValidateNoProxy("noProxy", git.NoProxy)...)
+	return allErrs
+}
+
+// ValidateProxyURL checks that proxy, if set, is the http or https URL of a proxy server.
+func ValidateProxyURL(field, proxy string) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(proxy) == 0 {
+		return allErrs
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid(field, proxy, "the proxy must be an http or https URL"))
+	}
+	return allErrs
+}
+
+// ValidateNoProxy checks that noProxy, if set, is a comma separated list of hosts, domains or
+// addresses, which git and curl read from the environment.
+func ValidateNoProxy(field, noProxy string) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(noProxy) == 0 {
+		return allErrs
+	}
+	for _, host := range strings.Split(noProxy, ",") {
+		host = strings.TrimSpace(host)
+		if len(host) == 0 || strings.ContainsAny(host, " \t\r\n/?#@") {
+			allErrs = append(allErrs, errs.NewFieldInvalid(field, noProxy, "must be a comma separated list of hosts, domains or addresses"))
+			break
+		}
+	}
 	return allErrs
 }
 
@@ -130,6 +317,16 @@ func validateOutput(output *buildapi.BuildOutput) errs.ValidationErrorList {
 			allErrs = append(allErrs, errs.NewFieldInvalid("dockerImageReference", output.DockerImageReference, err.Error()))
 		}
 	}
+	allErrs = append(allErrs, validateSecretName("pushSecretName", output.PushSecretName)...)
+	return allErrs
+}
+
+// validateSecretName checks the optional name of a secret referenced by field.
+func validateSecretName(field, name string) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(name) != 0 && !util.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, errs.NewFieldInvalid(field, name, field+" must be a valid subdomain"))
+	}
 	return allErrs
 }
 
@@ -160,6 +357,7 @@ func validateStrategy(strategy *buildapi.BuildStrategy) errs.ValidationErrorList
 		if strategy.DockerStrategy == nil {
 			strategy.DockerStrategy = &buildapi.DockerBuildStrategy{}
 		}
+		allErrs = append(allErrs, validateDockerStrategy(strategy.DockerStrategy).Prefix("dockerStrategy")...)
 	case buildapi.CustomBuildStrategyType:
 		if strategy.CustomStrategy == nil {
 			allErrs = append(allErrs, errs.NewFieldRequired("customStrategy"))
@@ -168,6 +366,7 @@ func validateStrategy(strategy *buildapi.BuildStrategy) errs.ValidationErrorList
 			if len(strategy.CustomStrategy.Image) == 0 {
 				allErrs = append(allErrs, errs.NewFieldRequired("image"))
 			}
+			allErrs = append(allErrs, validateSecretName("pullSecretName", strategy.CustomStrategy.PullSecretName).Prefix("customStrategy")...)
 		}
 	default:
 		allErrs = append(allErrs, errs.NewFieldInvalid("type", strategy.Type, "type is not in the enumerated list"))
@@ -176,11 +375,44 @@ func validateStrategy(strategy *buildapi.BuildStrategy) errs.ValidationErrorList
 	return allErrs
 }
 
+func validateDockerStrategy(strategy *buildapi.DockerBuildStrategy) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(strategy.DockerfilePath) > 0 {
+		cleaned := path.Clean(strategy.DockerfilePath)
+		if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			allErrs = append(allErrs, errs.NewFieldInvalid("dockerfilePath", strategy.DockerfilePath, "dockerfilePath must be a relative path within the context directory"))
+		}
+	}
+	allErrs = append(allErrs, validateEnv(strategy.Env).Prefix("env")...)
+	allErrs = append(allErrs, validateSecretName("pullSecretName", strategy.PullSecretName)...)
+	return allErrs
+}
+
+// validateEnv checks the environment of the Docker strategy. Values are written to the
+// Dockerfile, which is line based, so they may not span several lines.
+func validateEnv(vars []kapi.EnvVar) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	for i, ev := range vars {
+		vErrs := errs.ValidationErrorList{}
+		if len(ev.Name) == 0 {
+			vErrs = append(vErrs, errs.NewFieldRequired("name"))
+		} else if !util.IsCIdentifier(ev.Name) {
+			vErrs = append(vErrs, errs.NewFieldInvalid("name", ev.Name, "name must be a C identifier"))
+		}
+		if strings.ContainsAny(ev.Value, "\r\n") {
+			vErrs = append(vErrs, errs.NewFieldInvalid("value", ev.Value, "value may not contain line breaks"))
+		}
+		allErrs = append(allErrs, vErrs.PrefixIndex(i)...)
+	}
+	return allErrs
+}
+
 func validateSTIStrategy(strategy *buildapi.STIBuildStrategy) errs.ValidationErrorList {
 	allErrs := errs.ValidationErrorList{}
 	if len(strategy.Image) == 0 {
 		allErrs = append(allErrs, errs.NewFieldRequired("image"))
 	}
+	allErrs = append(allErrs, validateSecretName("pullSecretName", strategy.PullSecretName)...)
 	return allErrs
 }
 
@@ -193,8 +425,13 @@ func validateTrigger(trigger *buildapi.BuildTriggerPolicy) errs.ValidationErrorL
 
 	// Ensure that only parameters for the trigger's type are present
 	triggerPresence := map[buildapi.BuildTriggerType]bool{
-		buildapi.GithubWebHookBuildTriggerType:  trigger.GithubWebHook != nil,
-		buildapi.GenericWebHookBuildTriggerType: trigger.GenericWebHook != nil,
+		buildapi.GithubWebHookBuildTriggerType:    trigger.GithubWebHook != nil,
+		buildapi.GenericWebHookBuildTriggerType:   trigger.GenericWebHook != nil,
+		buildapi.GitLabWebHookBuildTriggerType:    trigger.GitLabWebHook != nil,
+		buildapi.BitbucketWebHookBuildTriggerType: trigger.BitbucketWebHook != nil,
+		buildapi.GogsWebHookBuildTriggerType:      trigger.GogsWebHook != nil,
+		buildapi.SCMPollBuildTriggerType:          trigger.SCMPoll != nil,
+		buildapi.ConfigChangeBuildTriggerType:     trigger.ConfigChange != nil,
 	}
 	allErrs = append(allErrs, validateTriggerPresence(triggerPresence, trigger.Type)...)
 
@@ -212,12 +449,36 @@ func validateTrigger(trigger *buildapi.BuildTriggerPolicy) errs.ValidationErrorL
 		} else {
 			allErrs = append(allErrs, validateWebHook(trigger.GenericWebHook).Prefix("generic")...)
 		}
+	case buildapi.GitLabWebHookBuildTriggerType:
+		if trigger.GitLabWebHook == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("gitlab"))
+		} else {
+			allErrs = append(allErrs, validateWebHook(trigger.GitLabWebHook).Prefix("gitlab")...)
+		}
+	case buildapi.BitbucketWebHookBuildTriggerType:
+		if trigger.BitbucketWebHook == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("bitbucket"))
+		} else {
+			allErrs = append(allErrs, validateWebHook(trigger.BitbucketWebHook).Prefix("bitbucket")...)
+		}
+	case buildapi.GogsWebHookBuildTriggerType:
+		if trigger.GogsWebHook == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("gogs"))
+		} else {
+			allErrs = append(allErrs, validateWebHook(trigger.GogsWebHook).Prefix("gogs")...)
+		}
 	case buildapi.ImageChangeBuildTriggerType:
 		if trigger.ImageChange == nil {
 			allErrs = append(allErrs, errs.NewFieldRequired("imageChange"))
 		} else {
 			allErrs = append(allErrs, validateImageChange(trigger.ImageChange).Prefix("imageChange")...)
 		}
+	case buildapi.SCMPollBuildTriggerType:
+		// the trigger only holds the state recorded by the poll controller
+	case buildapi.ConfigChangeBuildTriggerType:
+		// the trigger only holds the state recorded by the config change controller
+	default:
+		allErrs = append(allErrs, errs.NewFieldNotSupported("type", trigger.Type))
 	}
 	return allErrs
 }
@@ -234,9 +495,6 @@ func validateTriggerPresence(params map[buildapi.BuildTriggerType]bool, t builda
 
 func validateImageChange(imageChange *buildapi.ImageChangeTrigger) errs.ValidationErrorList {
 	allErrs := errs.ValidationErrorList{}
-	if len(imageChange.Image) == 0 {
-		allErrs = append(allErrs, errs.NewFieldRequired("image"))
-	}
 	if len(imageChange.From.Name) == 0 {
 		allErrs = append(allErrs, errs.NewFieldRequired("from"))
 	} else if len(imageChange.From.Name) == 0 {
@@ -245,15 +503,44 @@ func validateImageChange(imageChange *buildapi.ImageChangeTrigger) errs.Validati
 	return allErrs
 }
 
+// triggersImageSource returns true if change refers to the same ImageRepository tag as one of
+// images.
+func triggersImageSource(change *buildapi.ImageChangeTrigger, images []buildapi.ImageSource) bool {
+	for _, image := range images {
+		if image.From == nil || image.From.Name != change.From.Name || image.From.Namespace != change.From.Namespace {
+			continue
+		}
+		if image.Tag == change.Tag || (len(image.Tag) == 0 && change.Tag == "latest") || (image.Tag == "latest" && len(change.Tag) == 0) {
+			return true
+		}
+	}
+	return false
+}
+
 func validateWebHook(webHook *buildapi.WebHookTrigger) errs.ValidationErrorList {
 	allErrs := errs.ValidationErrorList{}
 	if len(webHook.Secret) == 0 {
 		allErrs = append(allErrs, errs.NewFieldRequired("secret"))
 	}
+	for i, name := range webHook.AllowEnv {
+		if !util.IsCIdentifier(name) {
+			allErrs = append(allErrs, errs.ValidationErrorList{errs.NewFieldInvalid("", name, "must be a valid environment variable name")}.PrefixIndex(i).Prefix("allowEnv")...)
+		}
+	}
 	return allErrs
 }
 
+// scpLikeURLPattern matches the user@host:path form of ssh clone URLs, which url.Parse rejects.
+var scpLikeURLPattern = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^:]`)
+
 func isValidURL(uri string) bool {
+	// git would read a leading dash as an option
+	if strings.HasPrefix(uri, "-") {
+		return false
+	}
+	if scpLikeURLPattern.MatchString(uri) {
+		return true
+	}
 	_, err := url.Parse(uri)
 	return err == nil
 }
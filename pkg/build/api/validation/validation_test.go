@@ -5,6 +5,7 @@ import (
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	errs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
 
 	buildapi "github.com/openshift/origin/pkg/build/api"
 )
@@ -137,6 +138,165 @@ func TestBuildConfigValidationOutputFailure(t *testing.T) {
 	}
 }
 
+func TestBuildConfigValidationRunPolicy(t *testing.T) {
+	buildConfig := &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "config-id", Namespace: "namespace"},
+		Parameters: buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type: buildapi.BuildSourceGit,
+				Git: &buildapi.GitBuildSource{
+					URI: "http://github.com/my/repository",
+				},
+			},
+			Strategy: buildapi.BuildStrategy{
+				Type:           buildapi.DockerBuildStrategyType,
+				DockerStrategy: &buildapi.DockerBuildStrategy{},
+			},
+		},
+	}
+	for _, policy := range []buildapi.BuildRunPolicy{"", buildapi.BuildRunPolicyParallel, buildapi.BuildRunPolicySerial, buildapi.BuildRunPolicySerialLatestOnly} {
+		buildConfig.RunPolicy = policy
+		if result := ValidateBuildConfig(buildConfig); len(result) > 0 {
+			t.Errorf("Unexpected validation error for run policy %q: %v", policy, result)
+		}
+	}
+
+	buildConfig.RunPolicy = "Sometimes"
+	result := ValidateBuildConfig(buildConfig)
+	if len(result) != 1 {
+		t.Fatalf("Unexpected validation result %v", result)
+	}
+	if err := result[0].(*errs.ValidationError); err.Type != errs.ValidationErrorTypeNotSupported || err.Field != "runPolicy" {
+		t.Errorf("Unexpected validation error %v", err)
+	}
+}
+
+func TestBuildConfigValidationHistoryLimits(t *testing.T) {
+	valid, invalid := 0, -1
+	buildConfig := &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "config-id", Namespace: "namespace"},
+		Parameters: buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type: buildapi.BuildSourceGit,
+				Git: &buildapi.GitBuildSource{
+					URI: "http://github.com/my/repository",
+				},
+			},
+			Strategy: buildapi.BuildStrategy{
+				Type:           buildapi.DockerBuildStrategyType,
+				DockerStrategy: &buildapi.DockerBuildStrategy{},
+			},
+		},
+		SuccessfulBuildsHistoryLimit: &valid,
+		FailedBuildsHistoryLimit:     &valid,
+	}
+	if result := ValidateBuildConfig(buildConfig); len(result) > 0 {
+		t.Errorf("Unexpected validation error returned %v", result)
+	}
+
+	buildConfig.SuccessfulBuildsHistoryLimit = &invalid
+	buildConfig.FailedBuildsHistoryLimit = &invalid
+	result := ValidateBuildConfig(buildConfig)
+	if len(result) != 2 {
+		t.Fatalf("Unexpected validation result %v", result)
+	}
+	for i, field := range []string{"successfulBuildsHistoryLimit", "failedBuildsHistoryLimit"} {
+		if err := result[i].(*errs.ValidationError); err.Type != errs.ValidationErrorTypeInvalid || err.Field != field {
+			t.Errorf("Unexpected validation error %v", err)
+		}
+	}
+}
+
+func TestBuildConfigValidationSCMPollRequiresGit(t *testing.T) {
+	buildConfig := &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "config-id", Namespace: "namespace"},
+		Triggers:   []buildapi.BuildTriggerPolicy{{Type: buildapi.SCMPollBuildTriggerType}},
+		Parameters: buildapi.BuildParameters{
+			Strategy: buildapi.BuildStrategy{
+				Type:           buildapi.CustomBuildStrategyType,
+				CustomStrategy: &buildapi.CustomBuildStrategy{Image: "builder"},
+			},
+			Output: buildapi.BuildOutput{DockerImageReference: "repository/data"},
+		},
+	}
+	result := ValidateBuildConfig(buildConfig)
+	if len(result) != 1 {
+		t.Fatalf("Unexpected validation result %v", result)
+	}
+	if err := result[0].(*errs.ValidationError); err.Type != errs.ValidationErrorTypeInvalid || err.Field != "triggers[0].type" {
+		t.Errorf("Unexpected validation error %v", err)
+	}
+}
+
+func TestBuildConfigValidationImageSourceTrigger(t *testing.T) {
+	buildConfig := &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "config-id", Namespace: "namespace"},
+		Triggers: []buildapi.BuildTriggerPolicy{{
+			Type:        buildapi.ImageChangeBuildTriggerType,
+			ImageChange: &buildapi.ImageChangeTrigger{From: kapi.ObjectReference{Name: "artifacts"}, Tag: "latest"},
+		}},
+		Parameters: buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type:   buildapi.BuildSourceBinary,
+				Binary: &buildapi.BinaryBuildSource{},
+				Images: []buildapi.ImageSource{{
+					From:  &kapi.ObjectReference{Name: "artifacts"},
+					Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts/app.war", DestinationDir: "deployments"}},
+				}},
+			},
+			Strategy: buildapi.BuildStrategy{
+				Type:           buildapi.DockerBuildStrategyType,
+				DockerStrategy: &buildapi.DockerBuildStrategy{},
+			},
+			Output: buildapi.BuildOutput{DockerImageReference: "repository/data"},
+		},
+	}
+	if result := ValidateBuildConfig(buildConfig); len(result) != 0 {
+		t.Fatalf("Unexpected validation errors %v", result)
+	}
+
+	// triggers of other repositories must name the image they replace
+	buildConfig.Triggers[0].ImageChange.From.Name = "builder"
+	result := ValidateBuildConfig(buildConfig)
+	if len(result) != 1 {
+		t.Fatalf("Unexpected validation result %v", result)
+	}
+	if err := result[0].(*errs.ValidationError); err.Type != errs.ValidationErrorTypeRequired || err.Field != "triggers[0].imageChange.image" {
+		t.Errorf("Unexpected validation error %v", err)
+	}
+}
+
+func TestValidateNotification(t *testing.T) {
+	tests := []struct {
+		notification buildapi.BuildNotification
+		errorType    errs.ValidationErrorType
+		field        string
+	}{
+		{buildapi.BuildNotification{URL: "https://chat.example.com/hooks/builds", SecretName: "notification-secret"}, "", ""},
+		{buildapi.BuildNotification{SecretName: "notification-secret"}, errs.ValidationErrorTypeRequired, "url"},
+		{buildapi.BuildNotification{URL: "ftp://example.com/builds", SecretName: "notification-secret"}, errs.ValidationErrorTypeInvalid, "url"},
+		{buildapi.BuildNotification{URL: "/hooks/builds", SecretName: "notification-secret"}, errs.ValidationErrorTypeInvalid, "url"},
+		{buildapi.BuildNotification{URL: "https://chat.example.com/hooks/builds"}, errs.ValidationErrorTypeRequired, "secretName"},
+		{buildapi.BuildNotification{URL: "https://chat.example.com/hooks/builds", SecretName: "Not_A_Secret"}, errs.ValidationErrorTypeInvalid, "secretName"},
+	}
+	for i, test := range tests {
+		result := validateNotification(&test.notification)
+		if len(test.field) == 0 {
+			if len(result) > 0 {
+				t.Errorf("%d: unexpected validation errors %v", i, result)
+			}
+			continue
+		}
+		if len(result) != 1 {
+			t.Errorf("%d: expected one validation error, got %v", i, result)
+			continue
+		}
+		if err := result[0].(*errs.ValidationError); err.Type != test.errorType || err.Field != test.field {
+			t.Errorf("%d: unexpected validation error %v", i, err)
+		}
+	}
+}
+
 func TestValidateSource(t *testing.T) {
 	errorCases := map[string]*buildapi.BuildSource{
 		string(errs.ValidationErrorTypeRequired) + "git.uri": {
@@ -151,6 +311,72 @@ func TestValidateSource(t *testing.T) {
 				URI: "::",
 			},
 		},
+		string(errs.ValidationErrorTypeInvalid) + "git.httpProxy": {
+			Type: buildapi.BuildSourceGit,
+			Git: &buildapi.GitBuildSource{
+				URI:       "https://github.com/my/repository",
+				HTTPProxy: "proxy.example.com:3128",
+			},
+		},
+		string(errs.ValidationErrorTypeInvalid) + "git.httpsProxy": {
+			Type: buildapi.BuildSourceGit,
+			Git: &buildapi.GitBuildSource{
+				URI:        "https://github.com/my/repository",
+				HTTPSProxy: "ftp://proxy.example.com",
+			},
+		},
+		string(errs.ValidationErrorTypeInvalid) + "git.noProxy": {
+			Type: buildapi.BuildSourceGit,
+			Git: &buildapi.GitBuildSource{
+				URI:     "https://github.com/my/repository",
+				NoProxy: ".example.com,,http://localhost",
+			},
+		},
+		string(errs.ValidationErrorTypeRequired) + "binary": {
+			Type: buildapi.BuildSourceBinary,
+		},
+		string(errs.ValidationErrorTypeInvalid) + "binary.asFile": {
+			Type: buildapi.BuildSourceBinary,
+			Binary: &buildapi.BinaryBuildSource{
+				AsFile: "../Dockerfile",
+			},
+		},
+		string(errs.ValidationErrorTypeRequired) + "type": {
+			Type: "Unknown",
+		},
+		string(errs.ValidationErrorTypeInvalid) + "sourceSecretName": {
+			Type: buildapi.BuildSourceGit,
+			Git: &buildapi.GitBuildSource{
+				URI: "http://github.com/my/repository",
+			},
+			SourceSecretName: "Not_A_Secret",
+		},
+		string(errs.ValidationErrorTypeRequired) + "images[0].from": {
+			Type:   buildapi.BuildSourceBinary,
+			Binary: &buildapi.BinaryBuildSource{},
+			Images: []buildapi.ImageSource{{Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts"}}}},
+		},
+		string(errs.ValidationErrorTypeRequired) + "images[0].paths": {
+			Type:   buildapi.BuildSourceBinary,
+			Binary: &buildapi.BinaryBuildSource{},
+			Images: []buildapi.ImageSource{{From: &kapi.ObjectReference{Name: "artifacts"}}},
+		},
+		string(errs.ValidationErrorTypeInvalid) + "images[0].paths[0].sourcePath": {
+			Type:   buildapi.BuildSourceBinary,
+			Binary: &buildapi.BinaryBuildSource{},
+			Images: []buildapi.ImageSource{{
+				From:  &kapi.ObjectReference{Name: "artifacts"},
+				Paths: []buildapi.ImageSourcePath{{SourcePath: "artifacts"}},
+			}},
+		},
+		string(errs.ValidationErrorTypeInvalid) + "images[0].paths[0].destinationDir": {
+			Type:   buildapi.BuildSourceBinary,
+			Binary: &buildapi.BinaryBuildSource{},
+			Images: []buildapi.ImageSource{{
+				DockerImageReference: "registry.example.com/builds/artifacts",
+				Paths:                []buildapi.ImageSourcePath{{SourcePath: "/artifacts", DestinationDir: "lib/../../lib"}},
+			}},
+		},
 	}
 	for desc, config := range errorCases {
 		errors := validateSource(config)
@@ -165,6 +391,42 @@ func TestValidateSource(t *testing.T) {
 	}
 }
 
+func TestValidateSourceURIOption(t *testing.T) {
+	source := &buildapi.BuildSource{
+		Type: buildapi.BuildSourceGit,
+		Git: &buildapi.GitBuildSource{
+			URI: "--upload-pack=touch /tmp/test",
+		},
+	}
+	errors := validateSource(source)
+	if len(errors) != 1 || errors[0].(*errs.ValidationError).Field != "git.uri" {
+		t.Errorf("Expected a URI starting with a dash to be invalid, got %v", errors)
+	}
+}
+
+func TestValidateSourceSecret(t *testing.T) {
+	source := &buildapi.BuildSource{
+		Type: buildapi.BuildSourceGit,
+		Git: &buildapi.GitBuildSource{
+			URI: "git@github.com:my/repository.git",
+		},
+		SourceSecretName: "github-deploy-key",
+	}
+	if errors := validateSource(source); len(errors) != 0 {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	source = &buildapi.BuildSource{
+		Type:             buildapi.BuildSourceBinary,
+		Binary:           &buildapi.BinaryBuildSource{},
+		SourceSecretName: "github-deploy-key",
+	}
+	errors := validateSource(source)
+	if len(errors) != 1 || errors[0].(*errs.ValidationError).Field != "sourceSecretName" {
+		t.Errorf("Expected a sourceSecretName error for a binary source, got %v", errors)
+	}
+}
+
 func TestValidateBuildParameters(t *testing.T) {
 	errorCases := []struct {
 		err string
@@ -294,6 +556,205 @@ func TestValidateBuildParameters(t *testing.T) {
 				},
 			},
 		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "source.type",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type:   buildapi.BuildSourceBinary,
+					Binary: &buildapi.BinaryBuildSource{},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.CustomBuildStrategyType,
+					CustomStrategy: &buildapi.CustomBuildStrategy{
+						Image: "builder/image",
+					},
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "completionDeadlineSeconds",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type:           buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+				CompletionDeadlineSeconds: new(int64),
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "strategy.dockerStrategy.dockerfilePath",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{
+						DockerfilePath: "docker/../../Dockerfile",
+					},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "strategy.dockerStrategy.env[0].name",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{
+						Env: []kapi.EnvVar{{Name: "NOT-VALID", Value: "value"}},
+					},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "strategy.dockerStrategy.env[0].value",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{
+						Env: []kapi.EnvVar{{Name: "VALUE", Value: "value\nRUN rm -rf /"}},
+					},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "postCommit.script",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type:           buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+				PostCommit: &buildapi.BuildPostCommitSpec{
+					Script:  "make test",
+					Command: []string{"make"},
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeRequired) + "postCommit.script",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type:           buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+				PostCommit: &buildapi.BuildPostCommitSpec{},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "output.pushSecretName",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type:           buildapi.DockerBuildStrategyType,
+					DockerStrategy: &buildapi.DockerBuildStrategy{},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "registry.example.com/repository/data",
+					PushSecretName:       "Not_A_Secret",
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "strategy.stiStrategy.pullSecretName",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.STIBuildStrategyType,
+					STIStrategy: &buildapi.STIBuildStrategy{
+						Image:          "registry.example.com/builder/image",
+						PullSecretName: "Not_A_Secret",
+					},
+				},
+			},
+		},
+		{
+			string(errs.ValidationErrorTypeInvalid) + "source.dockerfile",
+			&buildapi.BuildParameters{
+				Source: buildapi.BuildSource{
+					Type: buildapi.BuildSourceGit,
+					Git: &buildapi.GitBuildSource{
+						URI: "http://github.com/my/repository",
+					},
+					Dockerfile: "FROM scratch",
+				},
+				Output: buildapi.BuildOutput{
+					DockerImageReference: "repository/data",
+				},
+				Strategy: buildapi.BuildStrategy{
+					Type: buildapi.STIBuildStrategyType,
+					STIStrategy: &buildapi.STIBuildStrategy{
+						Image: "builder/image",
+					},
+				},
+			},
+		},
 	}
 
 	for _, config := range errorCases {
@@ -374,6 +835,77 @@ func TestValidateTrigger(t *testing.T) {
 				},
 			},
 		},
+		"gitlab trigger with no gitlab webhook": {
+			trigger:  buildapi.BuildTriggerPolicy{Type: buildapi.GitLabWebHookBuildTriggerType},
+			expected: []*errs.ValidationError{errs.NewFieldRequired("gitlab")},
+		},
+		"bitbucket trigger with no secret": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:             buildapi.BitbucketWebHookBuildTriggerType,
+				BitbucketWebHook: &buildapi.WebHookTrigger{},
+			},
+			expected: []*errs.ValidationError{errs.NewFieldRequired("bitbucket.secret")},
+		},
+		"gogs trigger with gitlab webhook": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:          buildapi.GogsWebHookBuildTriggerType,
+				GogsWebHook:   &buildapi.WebHookTrigger{Secret: "secret101"},
+				GitLabWebHook: &buildapi.WebHookTrigger{Secret: "secret101"},
+			},
+			expected: []*errs.ValidationError{errs.NewFieldInvalid("gitlab", "", "triggerType wasn't found")},
+		},
+		"valid scm poll trigger": {
+			trigger: buildapi.BuildTriggerPolicy{Type: buildapi.SCMPollBuildTriggerType},
+		},
+		"scm poll trigger with generic webhook": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:           buildapi.SCMPollBuildTriggerType,
+				GenericWebHook: &buildapi.WebHookTrigger{Secret: "secret101"},
+			},
+			expected: []*errs.ValidationError{errs.NewFieldInvalid("generic", "", "triggerType wasn't found")},
+		},
+		"valid config change trigger": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:         buildapi.ConfigChangeBuildTriggerType,
+				ConfigChange: &buildapi.ConfigChangeTrigger{LastTriggeredParametersHash: "1a2b3c"},
+			},
+		},
+		"config change trigger with scm poll state": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:    buildapi.ConfigChangeBuildTriggerType,
+				SCMPoll: &buildapi.SCMPollTrigger{},
+			},
+			expected: []*errs.ValidationError{errs.NewFieldInvalid("scmPoll", "", "triggerType wasn't found")},
+		},
+		"generic trigger allowing an invalid variable": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:           buildapi.GenericWebHookBuildTriggerType,
+				GenericWebHook: &buildapi.WebHookTrigger{Secret: "secret101", AllowEnv: []string{"VERSION", "NOT-A-NAME"}},
+			},
+			expected: []*errs.ValidationError{errs.NewFieldInvalid("generic.allowEnv[1]", "NOT-A-NAME", "")},
+		},
+		"valid gitlab trigger": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:          buildapi.GitLabWebHookBuildTriggerType,
+				GitLabWebHook: &buildapi.WebHookTrigger{Secret: "secret101"},
+			},
+		},
+		"valid bitbucket trigger": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:             buildapi.BitbucketWebHookBuildTriggerType,
+				BitbucketWebHook: &buildapi.WebHookTrigger{Secret: "secret101"},
+			},
+		},
+		"valid gogs trigger": {
+			trigger: buildapi.BuildTriggerPolicy{
+				Type:        buildapi.GogsWebHookBuildTriggerType,
+				GogsWebHook: &buildapi.WebHookTrigger{Secret: "secret101"},
+			},
+		},
+		"unknown trigger type": {
+			trigger:  buildapi.BuildTriggerPolicy{Type: "svn"},
+			expected: []*errs.ValidationError{errs.NewFieldNotSupported("type", "svn")},
+		},
 	}
 	for desc, test := range tests {
 		errors := validateTrigger(&test.trigger)
@@ -393,3 +925,99 @@ func TestValidateTrigger(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateBuildParametersResources(t *testing.T) {
+	params := &buildapi.BuildParameters{
+		Source: buildapi.BuildSource{
+			Type: buildapi.BuildSourceGit,
+			Git: &buildapi.GitBuildSource{
+				URI: "http://github.com/my/repository",
+			},
+		},
+		Strategy: buildapi.BuildStrategy{
+			Type:           buildapi.DockerBuildStrategyType,
+			DockerStrategy: &buildapi.DockerBuildStrategy{},
+		},
+		Output: buildapi.BuildOutput{
+			DockerImageReference: "repository/data",
+		},
+		Resources: kapi.ResourceRequirements{
+			Limits: kapi.ResourceList{
+				kapi.ResourceCPU:    resource.MustParse("500m"),
+				kapi.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		},
+		NodeSelector: map[string]string{"region": "builds"},
+	}
+	if errors := validateBuildParameters(params); len(errors) != 0 {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	params.Resources.Limits["gpu"] = resource.MustParse("1")
+	params.Resources.Limits[kapi.ResourceMemory] = resource.MustParse("-1")
+	params.NodeSelector = map[string]string{"not a label": "builds"}
+	expected := map[string]bool{
+		"resources.limits.gpu":    true,
+		"resources.limits.memory": true,
+		"nodeSelector":            true,
+	}
+	errors := validateBuildParameters(params)
+	if len(errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errors)
+	}
+	for _, err := range errors {
+		if field := err.(*errs.ValidationError).Field; !expected[field] {
+			t.Errorf("Unexpected error for %s: %v", field, err)
+		}
+	}
+}
+
+func TestValidateBuildParametersSTIAndCustomEnv(t *testing.T) {
+	env := []kapi.EnvVar{{Name: "NOT-A-C-IDENTIFIER", Value: "multi\r\nline"}}
+	strategies := []buildapi.BuildStrategy{
+		{
+			Type: buildapi.STIBuildStrategyType,
+			STIStrategy: &buildapi.STIBuildStrategy{
+				Image: "builder/image",
+				Env:   env,
+			},
+		},
+		{
+			Type: buildapi.CustomBuildStrategyType,
+			CustomStrategy: &buildapi.CustomBuildStrategy{
+				Image: "builder/image",
+				Env:   env,
+			},
+		},
+	}
+	for _, strategy := range strategies {
+		params := &buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type: buildapi.BuildSourceGit,
+				Git: &buildapi.GitBuildSource{
+					URI: "http://github.com/my/repository",
+				},
+			},
+			Strategy: strategy,
+			Output: buildapi.BuildOutput{
+				DockerImageReference: "repository/data",
+			},
+		}
+		if errors := validateBuildParameters(params); len(errors) != 0 {
+			t.Errorf("%s: unexpected validation errors: %v", strategy.Type, errors)
+		}
+	}
+}
+
+func TestValidateNoProxy(t *testing.T) {
+	for _, noProxy := range []string{"", "localhost", ".example.com, 10.0.0.1,[::1]:8080"} {
+		if errors := ValidateNoProxy("noProxy", noProxy); len(errors) != 0 {
+			t.Errorf("%q: unexpected validation errors: %v", noProxy, errors)
+		}
+	}
+	for _, noProxy := range []string{",", "example.com,", "http://example.com", "example.com\nlocalhost"} {
+		if errors := ValidateNoProxy("noProxy", noProxy); len(errors) != 1 {
+			t.Errorf("%q: expected a validation error, got %v", noProxy, errors)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"code.google.com/p/go-uuid/uuid"
+	restful "github.com/emicklei/go-restful"
+	"github.com/golang/glog"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/remotecommand"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	kutil "github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/openshift/origin/pkg/authorization/authorizer"
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildclient "github.com/openshift/origin/pkg/build/client"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+// ReceiveBuildInputCommand is run inside the builder container to hand it the
+// uploaded build input, which is streamed to the command's standard input.
+var ReceiveBuildInputCommand = []string{"/usr/bin/openshift", "infra", "receive-build-input"}
+
+const (
+	// defaultPodTimeout is how long an uploaded input waits for the builder pod to
+	// start running before the build is failed.
+	defaultPodTimeout = 5 * time.Minute
+	// DefaultMaxInputSize is the largest input accepted when no limit is configured.
+	DefaultMaxInputSize = 500 * 1024 * 1024
+)
+
+// PodExecutor runs a command in a container of a running pod.
+type PodExecutor interface {
+	Exec(pod *kapi.Pod, container string, command []string, in io.Reader, out, errOut io.Writer) error
+}
+
+// BuildClient provides the build operations needed to start a binary build.
+type BuildClient interface {
+	buildclient.BuildCreator
+	buildclient.BuildUpdater
+	Get(namespace, name string) (*buildapi.Build, error)
+	List(namespace string, label labels.Selector, field fields.Selector) (*buildapi.BuildList, error)
+}
+
+// PodGetter provides methods for getting pods
+type PodGetter interface {
+	Get(namespace, name string) (*kapi.Pod, error)
+}
+
+// Controller starts builds from content uploaded by a client. The controller uses privileged
+// clients, so the access of the uploading user to the build config is checked with the
+// Authorizer first. The upload is kept in a temporary file and the request returns once the
+// build is created; the content is streamed to the builder pod when it starts running.
+type Controller struct {
+	BuildConfigGetter buildclient.BuildConfigGetter
+	BuildClient       BuildClient
+	ImageRepoGetter   osclient.ImageRepositoryNamespaceGetter
+	PodGetter         PodGetter
+	Executor          PodExecutor
+	Codec             runtime.Codec
+	// ContextMapper, if set, gives the user uploading the content, who is recorded as the
+	// user starting the build.
+	ContextMapper kapi.RequestContextMapper
+	// Authorizer, if set, must allow the uploading user to get the build config and to
+	// create builds in its namespace.
+	Authorizer authorizer.Authorizer
+
+	// PodTimeout is how long to wait for the build pod to start running.
+	PodTimeout time.Duration
+	// PollInterval is how often the build and its pod are checked while waiting.
+	PollInterval time.Duration
+	// MaxInputSize is the largest upload accepted, in bytes.
+	MaxInputSize int64
+
+	// background runs the streaming of the input once the build is created. Defaults to
+	// running it in a new goroutine.
+	background func(func())
+}
+
+// NewController creates a Controller that creates builds and streams their input using the
+// provided clients. The config is used to execute commands in builder pods.
+func NewController(osClient osclient.Interface, kubeClient *kclient.Client, kubeConfig *kclient.Config, codec runtime.Codec) *Controller {
+	return &Controller{
+		BuildConfigGetter: buildclient.NewOSClientBuildConfigClient(osClient),
+		BuildClient:       osClientBuildClient{buildclient.NewOSClientBuildClient(osClient)},
+		ImageRepoGetter:   osClient.ImageRepositories(kapi.NamespaceAll).(osclient.ImageRepositoryNamespaceGetter),
+		PodGetter:         kubePodGetter{kubeClient},
+		Executor:          &remoteExecutor{client: kubeClient, config: kubeConfig},
+		Codec:             codec,
+		PodTimeout:        defaultPodTimeout,
+		PollInterval:      time.Second,
+		MaxInputSize:      DefaultMaxInputSize,
+	}
+}
+
+// Run periodically fails the binary builds whose input was never streamed, for instance
+// because the master holding the upload was restarted.
+func (c *Controller) Run() {
+	go kutil.Forever(c.FailAbandonedBuilds, c.PodTimeout)
+}
+
+// ServeRequest handles a POST to buildConfigs/{name}/instantiatebinary. The request body is the
+// content to build; the optional asFile query parameter names the file the content is saved as.
+func (c *Controller) ServeRequest(req *restful.Request, resp *restful.Response) {
+	namespace := req.Request.URL.Query().Get("namespace")
+	if len(namespace) == 0 {
+		namespace = kapi.NamespaceDefault
+	}
+	name := req.PathParameter("name")
+	asFile := req.Request.URL.Query().Get("asFile")
+	ctx := kapi.NewContext()
+	if c.ContextMapper != nil {
+		if requestCtx, ok := c.ContextMapper.Get(req.Request); ok {
+			ctx = requestCtx
+		}
+	}
+	userName := ""
+	if user, ok := kapi.UserFrom(ctx); ok {
+		userName = user.GetName()
+	}
+
+	if err := c.authorize(kapi.WithNamespace(ctx, namespace), name); err != nil {
+		glog.V(4).Infof("Refused binary build from %s/%s: %v", namespace, name, err)
+		c.writeError(resp.ResponseWriter, err)
+		return
+	}
+
+	build, err := c.Instantiate(namespace, name, asFile, userName, req.Request.Body)
+	if err != nil {
+		glog.V(4).Infof("Failed to start binary build from %s/%s: %v", namespace, name, err)
+		c.writeError(resp.ResponseWriter, err)
+		return
+	}
+	c.writeObject(resp.ResponseWriter, http.StatusCreated, build)
+}
+
+// authorize checks that the user of ctx may get the named build config and create builds in
+// the namespace of ctx.
+func (c *Controller) authorize(ctx kapi.Context, name string) error {
+	if c.Authorizer == nil {
+		return nil
+	}
+	for _, attributes := range []authorizer.DefaultAuthorizationAttributes{
+		{Verb: "get", Resource: "buildConfigs", ResourceName: name},
+		{Verb: "create", Resource: "builds"},
+	} {
+		allowed, reason, err := c.Authorizer.Authorize(ctx, attributes)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return kerrors.NewForbidden("buildConfigs", name, fmt.Errorf("%s", reason))
+		}
+	}
+	return nil
+}
+
+// Instantiate creates a new build from the named BuildConfig with a binary source and returns
+// it. The content of in, at most MaxInputSize bytes, is saved to a temporary file, which is streamed to the builder
+// container once its pod is running. The build is recorded as started by user.
+func (c *Controller) Instantiate(namespace, name, asFile, user string, in io.Reader) (*buildapi.Build, error) {
+	config, err := c.BuildConfigGetter.Get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if config.Parameters.Strategy.Type == buildapi.CustomBuildStrategyType {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("build config %s/%s uses the Custom strategy, which does not accept binary input", namespace, name))
+	}
+
+	build, err := buildutil.GenerateBuildWithImageTag(config, nil, c.ImageRepoGetter)
+	if err != nil {
+		return nil, err
+	}
+	// binary builds are named up front so that the build can be found again once created
+	build.Name = fmt.Sprintf("%s-%s", config.Name, uuid.NewUUID().String())
+	build.Parameters.Source.Type = buildapi.BuildSourceBinary
+	build.Parameters.Source.Git = nil
+	build.Parameters.Source.Binary = &buildapi.BinaryBuildSource{AsFile: asFile}
+	build.Causes = []buildapi.BuildCause{{Type: buildapi.ManualBuildTriggerType, Manual: &buildapi.BuildCauseManual{User: user}}}
+
+	input, err := ioutil.TempFile("", "binary-build-")
+	if err != nil {
+		return nil, err
+	}
+	// read one byte past the limit to tell a complete upload from a truncated one
+	size, err := io.Copy(input, io.LimitReader(in, c.MaxInputSize+1))
+	if err != nil {
+		closeInput(input)
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("unable to read the uploaded content: %v", err))
+	}
+	if size > c.MaxInputSize {
+		closeInput(input)
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("the uploaded content is larger than the limit of %d bytes", c.MaxInputSize))
+	}
+
+	if err := c.BuildClient.Create(namespace, build); err != nil {
+		closeInput(input)
+		return nil, err
+	}
+
+	background := c.background
+	if background == nil {
+		background = func(f func()) { go f() }
+	}
+	background(func() {
+		defer closeInput(input)
+		if err := c.streamInput(namespace, build.Name, input); err != nil {
+			glog.Errorf("Failed to stream the binary input of build %s/%s: %v", namespace, build.Name, err)
+			c.failBuild(namespace, build.Name, err)
+		}
+	})
+
+	return build, nil
+}
+
+// streamInput waits for the pod of the named build to run and streams input to its builder
+// container.
+func (c *Controller) streamInput(namespace, name string, input *os.File) error {
+	pod, err := c.waitForRunningPod(namespace, name)
+	if err != nil {
+		return err
+	}
+	if _, err := input.Seek(0, 0); err != nil {
+		return err
+	}
+
+	glog.V(4).Infof("Streaming binary input to build %s/%s in pod %s", namespace, name, pod.Name)
+	out := &bytes.Buffer{}
+	if err := c.Executor.Exec(pod, pod.Spec.Containers[0].Name, ReceiveBuildInputCommand, input, out, out); err != nil {
+		return fmt.Errorf("unable to stream input to build %s: %v: %s", name, err, out.String())
+	}
+	return nil
+}
+
+// failBuild marks the named build failed with the reason its input could not be streamed,
+// unless it already finished.
+func (c *Controller) failBuild(namespace, name string, reason error) {
+	build, err := c.BuildClient.Get(namespace, name)
+	if err != nil {
+		glog.Errorf("Unable to get build %s/%s: %v", namespace, name, err)
+		return
+	}
+	switch build.Status {
+	case buildapi.BuildStatusComplete, buildapi.BuildStatusFailed, buildapi.BuildStatusError, buildapi.BuildStatusCancelled:
+		return
+	}
+	build.Status = buildapi.BuildStatusFailed
+	build.Message = fmt.Sprintf("The uploaded input could not be streamed to the build: %v", reason)
+	if err := c.BuildClient.Update(namespace, build); err != nil {
+		glog.Errorf("Unable to mark build %s/%s failed: %v", namespace, name, err)
+	}
+}
+
+// FailAbandonedBuilds marks failed the binary builds that have waited longer than the pod
+// timeout for their input. The input of a build that is still waiting after that long will
+// never be streamed: the upload it was read from is gone.
+func (c *Controller) FailAbandonedBuilds() {
+	builds, err := c.BuildClient.List(kapi.NamespaceAll, labels.Everything(), fields.Everything())
+	if err != nil {
+		glog.Errorf("Unable to list builds: %v", err)
+		return
+	}
+	deadline := time.Now().Add(-c.PodTimeout)
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		if build.Parameters.Source.Type != buildapi.BuildSourceBinary || !build.CreationTimestamp.Time.Before(deadline) {
+			continue
+		}
+		switch build.Status {
+		case buildapi.BuildStatusNew, buildapi.BuildStatusPending:
+			glog.V(2).Infof("Failing build %s/%s: its binary input was abandoned", build.Namespace, build.Name)
+			c.failBuild(build.Namespace, build.Name, fmt.Errorf("the input was not streamed within %v", c.PodTimeout))
+		}
+	}
+}
+
+// closeInput closes and removes the temporary file holding an uploaded input.
+func closeInput(input *os.File) {
+	input.Close()
+	os.Remove(input.Name())
+}
+
+// waitForRunningPod waits until the pod executing the named build is running and returns it.
+func (c *Controller) waitForRunningPod(namespace, name string) (*kapi.Pod, error) {
+	deadline := time.Now().Add(c.PodTimeout)
+	for {
+		build, err := c.BuildClient.Get(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		switch build.Status {
+		case buildapi.BuildStatusComplete, buildapi.BuildStatusFailed, buildapi.BuildStatusError, buildapi.BuildStatusCancelled:
+			return nil, kerrors.NewBadRequest(fmt.Sprintf("build %s finished with status %s before its input could be uploaded", name, build.Status))
+		}
+		if len(build.PodName) != 0 {
+			pod, err := c.PodGetter.Get(namespace, build.PodName)
+			if err != nil && !kerrors.IsNotFound(err) {
+				return nil, err
+			}
+			if err == nil && pod.Status.Phase == kapi.PodRunning {
+				return pod, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, kerrors.NewTimeoutError(fmt.Sprintf("timed out waiting for build %s to start", name))
+		}
+		time.Sleep(c.PollInterval)
+	}
+}
+
+func (c *Controller) writeError(w http.ResponseWriter, err error) {
+	statusErr, ok := err.(*kerrors.StatusError)
+	if !ok {
+		statusErr = kerrors.NewInternalError(err).(*kerrors.StatusError)
+	}
+	status := statusErr.Status()
+	c.writeObject(w, status.Code, &status)
+}
+
+func (c *Controller) writeObject(w http.ResponseWriter, code int, obj runtime.Object) {
+	data, err := c.Codec.Encode(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// osClientBuildClient adds build retrieval to the OpenShift client backed BuildCreator.
+type osClientBuildClient struct {
+	*buildclient.OSClientBuildClient
+}
+
+func (c osClientBuildClient) Get(namespace, name string) (*buildapi.Build, error) {
+	return c.Client.Builds(namespace).Get(name)
+}
+
+func (c osClientBuildClient) List(namespace string, label labels.Selector, field fields.Selector) (*buildapi.BuildList, error) {
+	return c.Client.Builds(namespace).List(label, field)
+}
+
+// kubePodGetter gets pods using the Kubernetes client.
+type kubePodGetter struct {
+	client kclient.PodsNamespacer
+}
+
+func (p kubePodGetter) Get(namespace, name string) (*kapi.Pod, error) {
+	return p.client.Pods(namespace).Get(name)
+}
+
+// remoteExecutor executes commands in pods through the node proxy of the API server.
+type remoteExecutor struct {
+	client *kclient.Client
+	config *kclient.Config
+}
+
+func (e *remoteExecutor) Exec(pod *kapi.Pod, container string, command []string, in io.Reader, out, errOut io.Writer) error {
+	req := e.client.RESTClient.Get().
+		Prefix("proxy").
+		Resource("minions").
+		Name(pod.Status.Host).
+		Suffix("exec", pod.Namespace, pod.Name, container)
+	return remotecommand.New(req, e.config, command, in, out, errOut, false).Execute()
+}
@@ -0,0 +1,352 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/openshift/origin/pkg/authorization/authorizer"
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+type testBuildConfigGetter struct {
+	config *buildapi.BuildConfig
+}
+
+func (g *testBuildConfigGetter) Get(namespace, name string) (*buildapi.BuildConfig, error) {
+	if g.config == nil || g.config.Name != name {
+		return nil, kerrors.NewNotFound("BuildConfig", name)
+	}
+	return g.config, nil
+}
+
+type testBuildClient struct {
+	created *buildapi.Build
+	updated *buildapi.Build
+	status  buildapi.BuildStatus
+}
+
+func (c *testBuildClient) Create(namespace string, build *buildapi.Build) error {
+	c.created = build
+	return nil
+}
+
+func (c *testBuildClient) Update(namespace string, build *buildapi.Build) error {
+	c.updated = build
+	return nil
+}
+
+func (c *testBuildClient) Get(namespace, name string) (*buildapi.Build, error) {
+	if c.created == nil || c.created.Name != name {
+		return nil, kerrors.NewNotFound("Build", name)
+	}
+	build := *c.created
+	build.Status = c.status
+	build.PodName = "build-" + name
+	return &build, nil
+}
+
+func (c *testBuildClient) List(namespace string, label labels.Selector, field fields.Selector) (*buildapi.BuildList, error) {
+	list := &buildapi.BuildList{}
+	if c.created != nil {
+		build, _ := c.Get(namespace, c.created.Name)
+		list.Items = append(list.Items, *build)
+	}
+	return list, nil
+}
+
+type testPodGetter struct {
+	phase kapi.PodPhase
+}
+
+func (g *testPodGetter) Get(namespace, name string) (*kapi.Pod, error) {
+	return &kapi.Pod{
+		ObjectMeta: kapi.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: kapi.PodSpec{
+			Containers: []kapi.Container{{Name: "docker-build"}},
+		},
+		Status: kapi.PodStatus{Phase: g.phase},
+	}, nil
+}
+
+type testExecutor struct {
+	pod       *kapi.Pod
+	container string
+	command   []string
+	input     string
+}
+
+func (e *testExecutor) Exec(pod *kapi.Pod, container string, command []string, in io.Reader, out, errOut io.Writer) error {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	e.pod, e.container, e.command, e.input = pod, container, command, string(data)
+	return nil
+}
+
+type testImageRepoGetter struct{}
+
+func (testImageRepoGetter) GetByNamespace(namespace, name string) (*imageapi.ImageRepository, error) {
+	return nil, kerrors.NewNotFound("ImageRepository", name)
+}
+
+func testConfig(strategy buildapi.BuildStrategyType) *buildapi.BuildConfig {
+	return &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "app", Namespace: "test"},
+		Parameters: buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type:       buildapi.BuildSourceGit,
+				Git:        &buildapi.GitBuildSource{URI: "git://github.com/openshift/ruby-hello-world.git"},
+				ContextDir: "app",
+			},
+			Strategy: buildapi.BuildStrategy{
+				Type:           strategy,
+				DockerStrategy: &buildapi.DockerBuildStrategy{},
+				CustomStrategy: &buildapi.CustomBuildStrategy{Image: "builder"},
+			},
+			Output: buildapi.BuildOutput{DockerImageReference: "test/app"},
+		},
+	}
+}
+
+func testController(config *buildapi.BuildConfig, builds *testBuildClient, pods *testPodGetter, executor *testExecutor) *Controller {
+	return &Controller{
+		BuildConfigGetter: &testBuildConfigGetter{config},
+		BuildClient:       builds,
+		ImageRepoGetter:   testImageRepoGetter{},
+		PodGetter:         pods,
+		Executor:          executor,
+		PodTimeout:        10 * time.Millisecond,
+		PollInterval:      time.Millisecond,
+		MaxInputSize:      16,
+		background:        func(f func()) { f() },
+	}
+}
+
+type testAuthorizer struct {
+	allowed  map[string]bool
+	requests []string
+}
+
+func (a *testAuthorizer) Authorize(ctx kapi.Context, attributes authorizer.AuthorizationAttributes) (bool, string, error) {
+	namespace, _ := kapi.NamespaceFrom(ctx)
+	request := fmt.Sprintf("%s %s %s/%s", attributes.GetVerb(), attributes.GetResource(), namespace, attributes.GetResourceName())
+	a.requests = append(a.requests, request)
+	return a.allowed[request], "denied", nil
+}
+
+func (a *testAuthorizer) GetAllowedSubjects(ctx kapi.Context, attributes authorizer.AuthorizationAttributes) (util.StringSet, util.StringSet, error) {
+	return nil, nil, nil
+}
+
+func TestInstantiateStreamsInputToBuildPod(t *testing.T) {
+	builds := &testBuildClient{status: buildapi.BuildStatusRunning}
+	executor := &testExecutor{}
+	c := testController(testConfig(buildapi.DockerBuildStrategyType), builds, &testPodGetter{kapi.PodRunning}, executor)
+
+	build, err := c.Instantiate("test", "app", "Dockerfile", "jdoe", bytes.NewBufferString("FROM scratch"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if builds.created == nil {
+		t.Fatalf("Expected a build to be created")
+	}
+	if !strings.HasPrefix(build.Name, "app-") {
+		t.Errorf("Expected the build to be named after its config, got %s", build.Name)
+	}
+	source := builds.created.Parameters.Source
+	if source.Type != buildapi.BuildSourceBinary || source.Git != nil {
+		t.Errorf("Expected a binary source without git, got %#v", source)
+	}
+	if causes := builds.created.Causes; len(causes) != 1 || causes[0].Manual == nil || causes[0].Manual.User != "jdoe" {
+		t.Errorf("Expected the uploading user to be recorded as the cause, got %#v", causes)
+	}
+	if source.Binary == nil || source.Binary.AsFile != "Dockerfile" {
+		t.Errorf("Expected the binary source to be saved as Dockerfile, got %#v", source.Binary)
+	}
+	if source.ContextDir != "app" {
+		t.Errorf("Expected the context dir to be kept, got %s", source.ContextDir)
+	}
+	if builds.created.Labels[buildapi.BuildConfigLabel] != "app" {
+		t.Errorf("Expected the build to be labeled with its config, got %v", builds.created.Labels)
+	}
+
+	if executor.pod == nil || executor.pod.Name != "build-"+build.Name {
+		t.Fatalf("Expected input to be streamed to the build pod, got %#v", executor.pod)
+	}
+	if executor.container != "docker-build" {
+		t.Errorf("Expected input to be streamed to the build container, got %s", executor.container)
+	}
+	if strings.Join(executor.command, " ") != strings.Join(ReceiveBuildInputCommand, " ") {
+		t.Errorf("Unexpected command %v", executor.command)
+	}
+	if executor.input != "FROM scratch" {
+		t.Errorf("Unexpected input %q", executor.input)
+	}
+	if builds.updated != nil {
+		t.Errorf("Unexpected build update %#v", builds.updated)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := kapi.WithNamespace(kapi.NewContext(), "test")
+	c := &Controller{Authorizer: &testAuthorizer{allowed: map[string]bool{
+		"get buildConfigs test/app": true,
+		"create builds test/":       true,
+	}}}
+	if err := c.authorize(ctx, "app"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	a := &testAuthorizer{allowed: map[string]bool{"get buildConfigs test/app": true}}
+	c.Authorizer = a
+	if err := c.authorize(ctx, "app"); err == nil || !kerrors.IsForbidden(err) {
+		t.Errorf("Expected a forbidden error, got %v", err)
+	}
+	if e, act := []string{"get buildConfigs test/app", "create builds test/"}, a.requests; !reflect.DeepEqual(e, act) {
+		t.Errorf("Expected requests %v, got %v", e, act)
+	}
+}
+
+func TestInstantiateFailures(t *testing.T) {
+	tests := map[string]struct {
+		config *buildapi.BuildConfig
+		input  string
+		check  func(error) bool
+	}{
+		"missing config": {
+			check: kerrors.IsNotFound,
+		},
+		"custom strategy": {
+			config: testConfig(buildapi.CustomBuildStrategyType),
+			check:  kerrors.IsBadRequest,
+		},
+		"input too large": {
+			config: testConfig(buildapi.DockerBuildStrategyType),
+			input:  strings.Repeat("data", 5),
+			check:  kerrors.IsBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		executor := &testExecutor{}
+		builds := &testBuildClient{status: buildapi.BuildStatusRunning}
+		c := testController(test.config, builds, &testPodGetter{kapi.PodRunning}, executor)
+		input := test.input
+		if len(input) == 0 {
+			input = "data"
+		}
+		_, err := c.Instantiate("test", "app", "", "jdoe", bytes.NewBufferString(input))
+		if err == nil || !test.check(err) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if builds.created != nil {
+			t.Errorf("%s: expected no build to be created", name)
+		}
+		if executor.pod != nil {
+			t.Errorf("%s: expected no input to be streamed", name)
+		}
+	}
+}
+
+func TestInstantiateStreamingFailures(t *testing.T) {
+	tests := map[string]struct {
+		status   buildapi.BuildStatus
+		phase    kapi.PodPhase
+		expected buildapi.BuildStatus
+	}{
+		"build failed before upload": {
+			status: buildapi.BuildStatusError,
+			phase:  kapi.PodFailed,
+		},
+		"pod never runs": {
+			status:   buildapi.BuildStatusPending,
+			phase:    kapi.PodPending,
+			expected: buildapi.BuildStatusFailed,
+		},
+	}
+
+	for name, test := range tests {
+		executor := &testExecutor{}
+		builds := &testBuildClient{status: test.status}
+		c := testController(testConfig(buildapi.DockerBuildStrategyType), builds, &testPodGetter{test.phase}, executor)
+		if _, err := c.Instantiate("test", "app", "", "jdoe", bytes.NewBufferString("data")); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if executor.pod != nil {
+			t.Errorf("%s: expected no input to be streamed", name)
+		}
+		switch {
+		case len(test.expected) == 0 && builds.updated != nil:
+			t.Errorf("%s: unexpected build update %#v", name, builds.updated)
+		case len(test.expected) > 0 && (builds.updated == nil || builds.updated.Status != test.expected):
+			t.Errorf("%s: expected the build to be updated to %s, got %#v", name, test.expected, builds.updated)
+		}
+	}
+}
+
+func TestFailAbandonedBuilds(t *testing.T) {
+	tests := map[string]struct {
+		status   buildapi.BuildStatus
+		age      time.Duration
+		source   buildapi.BuildSourceType
+		expected buildapi.BuildStatus
+	}{
+		"abandoned": {
+			status:   buildapi.BuildStatusPending,
+			age:      time.Hour,
+			source:   buildapi.BuildSourceBinary,
+			expected: buildapi.BuildStatusFailed,
+		},
+		"still waiting": {
+			status: buildapi.BuildStatusPending,
+			age:    0,
+			source: buildapi.BuildSourceBinary,
+		},
+		"running": {
+			status: buildapi.BuildStatusRunning,
+			age:    time.Hour,
+			source: buildapi.BuildSourceBinary,
+		},
+		"git source": {
+			status: buildapi.BuildStatusNew,
+			age:    time.Hour,
+			source: buildapi.BuildSourceGit,
+		},
+	}
+
+	for name, test := range tests {
+		build := &buildapi.Build{
+			ObjectMeta: kapi.ObjectMeta{
+				Name:              "app-1",
+				Namespace:         "test",
+				CreationTimestamp: util.NewTime(time.Now().Add(-test.age)),
+			},
+			Parameters: buildapi.BuildParameters{Source: buildapi.BuildSource{Type: test.source}},
+		}
+		builds := &testBuildClient{created: build, status: test.status}
+		c := testController(nil, builds, &testPodGetter{kapi.PodPending}, &testExecutor{})
+		c.PodTimeout = time.Minute
+		c.FailAbandonedBuilds()
+		switch {
+		case len(test.expected) == 0 && builds.updated != nil:
+			t.Errorf("%s: unexpected build update %#v", name, builds.updated)
+		case len(test.expected) > 0 && (builds.updated == nil || builds.updated.Status != test.expected):
+			t.Errorf("%s: expected the build to be updated to %s, got %#v", name, test.expected, builds.updated)
+		}
+	}
+}
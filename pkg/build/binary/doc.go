@@ -0,0 +1,3 @@
+// Package binary contains the handler that starts builds from content uploaded
+// by a client, streaming the upload into the builder pod once it is running.
+package binary
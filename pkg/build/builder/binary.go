@@ -0,0 +1,172 @@
+package builder
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/source-to-image/pkg/tar"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+const (
+	// BinaryInputDir is the directory inside the builder container where the
+	// content uploaded for a binary build is received.
+	BinaryInputDir = "/tmp/build-input"
+
+	// binaryInputFile is the name of the file holding the raw uploaded content.
+	binaryInputFile = "input"
+
+	// binaryInputCompleteFile is created once the uploaded content has been
+	// completely written to binaryInputFile.
+	binaryInputCompleteFile = "input.complete"
+
+	// binaryInputTimeout is how long a builder waits for the uploaded content
+	// before failing the build.
+	binaryInputTimeout = 10 * time.Minute
+)
+
+// ReceiveBinaryInput saves the content read from in to dir and marks it as
+// complete so that a builder waiting on the same directory can proceed.
+func ReceiveBinaryInput(dir string, in io.Reader) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, binaryInputFile))
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(file, in)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to receive build input: %v", err)
+	}
+	glog.V(2).Infof("Received %d bytes of build input", n)
+	return ioutil.WriteFile(filepath.Join(dir, binaryInputCompleteFile), []byte{}, 0600)
+}
+
+// waitForBinaryInput blocks until the content uploaded for a binary build has
+// been received in inputDir, or the timeout expires.
+func waitForBinaryInput(inputDir string, timeout time.Duration) (string, error) {
+	glog.V(2).Infof("Waiting for binary build input in %s", inputDir)
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := os.Stat(filepath.Join(inputDir, binaryInputCompleteFile)); err == nil {
+			return filepath.Join(inputDir, binaryInputFile), nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("no binary build input was received within %v", timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// extractBinaryInput places the received input file into dir. If asFile is set
+// the input is copied verbatim under that name, otherwise it is extracted as a
+// tar, gzipped tar or zip archive.
+func extractBinaryInput(t tar.Tar, input, dir string, source *api.BinaryBuildSource) error {
+	if source != nil && len(source.AsFile) > 0 {
+		glog.V(2).Infof("Saving build input as %s", source.AsFile)
+		return copyFile(input, filepath.Join(dir, source.AsFile))
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header, err := r.Peek(4)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		glog.V(2).Infof("Extracting zip build input into %s", dir)
+		return extractZip(input, dir)
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		glog.V(2).Infof("Extracting gzipped tar build input into %s", dir)
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return t.ExtractTarStream(dir, gz)
+	default:
+		glog.V(2).Infof("Extracting tar build input into %s", dir)
+		return t.ExtractTarStream(dir, r)
+	}
+}
+
+// extractZip extracts the zip archive at path into dir, refusing entries that
+// would be written outside of dir.
+func extractZip(path, dir string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		target := filepath.Join(dir, f.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode()|0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,159 @@
+package builder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stitar "github.com/openshift/source-to-image/pkg/tar"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func tarArchive(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	for name, content := range files {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gzipArchive(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(tarArchive(t, files)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReceiveAndExtractBinaryInput(t *testing.T) {
+	files := map[string]string{
+		"Dockerfile":     "FROM scratch\n",
+		"src/main/app.c": "int main() { return 0; }\n",
+	}
+	tests := map[string]struct {
+		input    []byte
+		source   *api.BinaryBuildSource
+		expected map[string]string
+	}{
+		"tar": {
+			input:    tarArchive(t, files),
+			expected: files,
+		},
+		"gzip": {
+			input:    gzipArchive(t, files),
+			expected: files,
+		},
+		"zip": {
+			input:    zipArchive(t, files),
+			expected: files,
+		},
+		"as file": {
+			input:    []byte("FROM scratch\n"),
+			source:   &api.BinaryBuildSource{AsFile: "Dockerfile"},
+			expected: map[string]string{"Dockerfile": "FROM scratch\n"},
+		},
+	}
+
+	for name, test := range tests {
+		inputDir, err := ioutil.TempDir("", "binary-input")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer os.RemoveAll(inputDir)
+		buildDir, err := ioutil.TempDir("", "binary-build")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer os.RemoveAll(buildDir)
+
+		if err := ReceiveBinaryInput(inputDir, bytes.NewReader(test.input)); err != nil {
+			t.Errorf("%s: unexpected error receiving input: %v", name, err)
+			continue
+		}
+		input, err := waitForBinaryInput(inputDir, time.Second)
+		if err != nil {
+			t.Errorf("%s: unexpected error waiting for input: %v", name, err)
+			continue
+		}
+		if err := extractBinaryInput(stitar.New(), input, buildDir, test.source); err != nil {
+			t.Errorf("%s: unexpected error extracting input: %v", name, err)
+			continue
+		}
+		for file, content := range test.expected {
+			data, err := ioutil.ReadFile(filepath.Join(buildDir, file))
+			if err != nil {
+				t.Errorf("%s: expected %s to be extracted: %v", name, file, err)
+				continue
+			}
+			if string(data) != content {
+				t.Errorf("%s: expected %s to contain %q, got %q", name, file, content, string(data))
+			}
+		}
+	}
+}
+
+func TestWaitForBinaryInputTimeout(t *testing.T) {
+	inputDir, err := ioutil.TempDir("", "binary-input")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(inputDir)
+
+	if _, err := waitForBinaryInput(inputDir, 0); err == nil {
+		t.Errorf("Expected an error when no input was received")
+	}
+}
+
+func TestExtractZipRejectsEscapingPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binary-build")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "input.zip")
+	if err := ioutil.WriteFile(archive, zipArchive(t, map[string]string{"../escape": "data"}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := extractZip(archive, filepath.Join(dir, "build")); err == nil {
+		t.Errorf("Expected an error extracting an entry outside of the build directory")
+	}
+}
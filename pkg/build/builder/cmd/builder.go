@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/fsouza/go-dockerclient"
 	"github.com/golang/glog"
 	"github.com/openshift/origin/pkg/api/latest"
@@ -53,10 +54,14 @@ func run(builderFactory factoryFunc) {
 		if err != nil {
 			glog.Fatalf("Build output does not have a valid Docker image reference: %v", err)
 		}
-		authcfg, authPresent = dockercfg.NewHelper().GetDockerAuth(ref.Registry)
+		authcfg, authPresent = pushAuth(ref.Registry)
 	}
 	b := builderFactory(client, endpoint, authcfg, authPresent, &build)
-	if err = b.Build(); err != nil {
+	err = b.Build()
+	if reportErr := bld.ReportRevision(kapi.TerminationMessagePathDefault, &build); reportErr != nil {
+		glog.Warningf("Unable to report the source revision of the build: %v", reportErr)
+	}
+	if err != nil {
 		glog.Fatalf("Build error: %v", err)
 	}
 	if !output {
@@ -65,16 +70,56 @@ func run(builderFactory factoryFunc) {
 
 }
 
+// pushAuth returns the credentials used to push to registry, read from the push secret of
+// the build when it has credentials for the registry and from the local dockercfg otherwise.
+func pushAuth(registry string) (docker.AuthConfiguration, bool) {
+	if path := os.Getenv("PUSH_DOCKERCFG_PATH"); len(path) > 0 {
+		if auth, ok := dockercfg.NewHelper().GetDockerAuthFromFile(path, registry); ok {
+			return auth, true
+		}
+		glog.Warningf("The push secret has no credentials for registry %q, using the local dockercfg", registry)
+	}
+	return dockercfg.NewHelper().GetDockerAuth(registry)
+}
+
 // RunDockerBuild creates a docker builder and runs its build
 func RunDockerBuild() {
 	run(func(client bld.DockerClient, sock string, auth docker.AuthConfiguration, present bool, build *api.Build) builder {
-		return bld.NewDockerBuilder(client, auth, present, build)
+		var pullAuth *docker.AuthConfigurations
+		if path := os.Getenv("PULL_DOCKERCFG_PATH"); len(path) > 0 {
+			auths, err := dockercfg.NewHelper().GetDockerAuthConfigurations(path)
+			if err != nil {
+				glog.Fatalf("Unable to read the pull secret: %v", err)
+			}
+			pullAuth = auths
+		}
+		return bld.NewDockerBuilder(client, auth, present, pullAuth, build)
 	})
 }
 
 // RunSTIBuild creates a STI builder and runs its build
 func RunSTIBuild() {
 	run(func(client bld.DockerClient, sock string, auth docker.AuthConfiguration, present bool, build *api.Build) builder {
-		return bld.NewSTIBuilder(client, sock, auth, present, build)
+		var pullAuth *docker.AuthConfiguration
+		if path := os.Getenv("PULL_DOCKERCFG_PATH"); len(path) > 0 {
+			ref, err := image.ParseDockerImageReference(build.Parameters.Strategy.STIStrategy.Image)
+			if err != nil {
+				glog.Fatalf("Builder image does not have a valid Docker image reference: %v", err)
+			}
+			if auth, ok := dockercfg.NewHelper().GetDockerAuthFromFile(path, ref.Registry); ok {
+				pullAuth = &auth
+			} else {
+				glog.Warningf("The pull secret has no credentials for registry %q of the builder image", ref.Registry)
+			}
+		}
+		return bld.NewSTIBuilder(client, sock, auth, present, pullAuth, build)
 	})
 }
+
+// RunReceiveBuildInput saves the build input streamed on standard input where a
+// binary build running in the same container is waiting for it
+func RunReceiveBuildInput() {
+	if err := bld.ReceiveBinaryInput(bld.BinaryInputDir, os.Stdin); err != nil {
+		glog.Fatalf("Unable to receive build input: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package dockercfg
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"os/user"
@@ -18,6 +19,9 @@ import (
 // Default docker registry server
 const defaultRegistryServer = "https://index.docker.io/v1/"
 
+// defaultRegistryHost is the host of the default docker registry server
+const defaultRegistryHost = "index.docker.io"
+
 // Helper contains all the valid config options for reading the local dockercfg file
 type Helper struct {
 }
@@ -35,32 +39,80 @@ func (h *Helper) InstallFlags(flags *pflag.FlagSet) {
 // GetDockerAuth returns a valid Docker AuthConfiguration entry, and whether it was read
 // from the local dockercfg file
 func (h *Helper) GetDockerAuth(registry string) (docker.AuthConfiguration, bool) {
+	return h.GetDockerAuthFromFile(getDockercfgFile(""), registry)
+}
+
+// GetDockerAuthFromFile returns the Docker AuthConfiguration entry of the dockercfg file at
+// path for the registry host, and whether one was found
+func (h *Helper) GetDockerAuthFromFile(path, registry string) (docker.AuthConfiguration, bool) {
 	var authCfg docker.AuthConfiguration
-	dockercfgPath := getDockercfgFile("")
-	if _, err := os.Stat(dockercfgPath); err != nil {
+	if _, err := os.Stat(path); err != nil {
 		return authCfg, false
 	}
-	cfg, err := readDockercfg(dockercfgPath)
+	cfg, err := readDockercfg(path)
 	if err != nil {
 		return authCfg, false
 	}
-	server := registry
-	if server == "" {
-		server = defaultRegistryServer
-	}
-	entry, ok := cfg[server]
+	server, entry, ok := cfg.lookup(registry)
 	if !ok {
 		return authCfg, false
 	}
-	uname, pass, err := getCredentials(entry.Auth)
+	authCfg, err = newAuthConfiguration(server, entry)
 	if err != nil {
 		return authCfg, false
 	}
-	authCfg.Username = uname
-	authCfg.Password = pass
 	return authCfg, true
 }
 
+// GetDockerAuthConfigurations returns all entries of the dockercfg file at path, keyed by
+// registry server as Docker expects them when building images
+func (h *Helper) GetDockerAuthConfigurations(path string) (*docker.AuthConfigurations, error) {
+	cfg, err := readDockercfg(path)
+	if err != nil {
+		return nil, err
+	}
+	auths := &docker.AuthConfigurations{Configs: map[string]docker.AuthConfiguration{}}
+	for server, entry := range cfg {
+		authCfg, err := newAuthConfiguration(server, entry)
+		if err != nil {
+			return nil, err
+		}
+		auths.Configs[server] = authCfg
+	}
+	return auths, nil
+}
+
+// newAuthConfiguration returns the AuthConfiguration of the entry for server
+func newAuthConfiguration(server string, entry authEntry) (docker.AuthConfiguration, error) {
+	uname, pass, err := getCredentials(entry.Auth)
+	if err != nil {
+		return docker.AuthConfiguration{}, err
+	}
+	return docker.AuthConfiguration{
+		Username:      uname,
+		Password:      pass,
+		Email:         entry.Email,
+		ServerAddress: server,
+	}, nil
+}
+
+// RegistryHost returns the host of a registry server given as a host or URL, where the
+// empty server is the default registry
+func RegistryHost(server string) string {
+	host := strings.ToLower(server)
+	if i := strings.Index(host, "://"); i != -1 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i != -1 {
+		host = host[:i]
+	}
+	switch host {
+	case "", "docker.io", "registry-1.docker.io":
+		return defaultRegistryHost
+	}
+	return host
+}
+
 // getDockercfgFile returns the path to the dockercfg file
 func getDockercfgFile(path string) string {
 	var cfgPath string
@@ -84,6 +136,25 @@ type authEntry struct {
 // dockercfg represents the contents of a .dockercfg file
 type dockercfg map[string]authEntry
 
+// lookup returns the server and entry for the registry, matching the servers of the file by
+// their host when none matches exactly
+func (cfg dockercfg) lookup(registry string) (string, authEntry, bool) {
+	server := registry
+	if server == "" {
+		server = defaultRegistryServer
+	}
+	if entry, ok := cfg[server]; ok {
+		return server, entry, true
+	}
+	host := RegistryHost(registry)
+	for server, entry := range cfg {
+		if RegistryHost(server) == host {
+			return server, entry, true
+		}
+	}
+	return "", authEntry{}, false
+}
+
 // readDockercfg reads the contents of a .dockercfg file into a map
 // with server name keys and AuthEntry values
 func readDockercfg(filePath string) (cfg dockercfg, err error) {
@@ -105,7 +176,10 @@ func getCredentials(auth string) (username, password string, err error) {
 	if err != nil {
 		return
 	}
-	unamepass := strings.Split(string(creds), ":")
+	unamepass := strings.SplitN(string(creds), ":", 2)
+	if len(unamepass) != 2 {
+		return "", "", errors.New("auth must be the base64 encoded username:password")
+	}
 	username = unamepass[0]
 	password = unamepass[1]
 	return
@@ -45,3 +45,47 @@ func TestGetCredentials(t *testing.T) {
 		t.Errorf("Unexpected username and password: %s,%s", uname, pass)
 	}
 }
+
+func TestGetDockerAuthFromFile(t *testing.T) {
+	content := `{
+		"https://index.docker.io/v1/": {"auth": "aHViOnNlY3JldA==", "email": "hub@example.com"},
+		"https://registry.example.com:5000/v1/": {"auth": "dXNlcjpwYXNzOndvcmQ=", "email": "user@example.com"}
+	}`
+	tempfile, err := ioutil.TempFile("", "cfgtest")
+	if err != nil {
+		t.Fatalf("Unable to create temp file: %v", err)
+	}
+	defer os.Remove(tempfile.Name())
+	tempfile.WriteString(content)
+	tempfile.Close()
+
+	tests := map[string]struct {
+		username string
+		password string
+		found    bool
+	}{
+		"":                          {"hub", "secret", true},
+		"docker.io":                 {"hub", "secret", true},
+		"registry.example.com:5000": {"user", "pass:word", true},
+		"registry.example.com":      {found: false},
+	}
+	h := NewHelper()
+	for registry, test := range tests {
+		auth, found := h.GetDockerAuthFromFile(tempfile.Name(), registry)
+		if found != test.found {
+			t.Errorf("%q: expected found %v, got %v", registry, test.found, found)
+			continue
+		}
+		if auth.Username != test.username || auth.Password != test.password {
+			t.Errorf("%q: unexpected credentials %s:%s", registry, auth.Username, auth.Password)
+		}
+	}
+
+	auths, err := h.GetDockerAuthConfigurations(tempfile.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths.Configs) != 2 || auths.Configs["https://registry.example.com:5000/v1/"].Email != "user@example.com" {
+		t.Errorf("Unexpected auth configurations %#v", auths)
+	}
+}
@@ -10,12 +10,14 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	dockercmd "github.com/docker/docker/builder/command"
 	"github.com/docker/docker/builder/parser"
 	"github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
 
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/source-to-image/pkg/git"
@@ -29,25 +31,30 @@ const urlCheckTimeout = 16 * time.Second
 
 // DockerBuilder builds Docker images given a git repository URL
 type DockerBuilder struct {
-	dockerClient DockerClient
-	authPresent  bool
-	auth         docker.AuthConfiguration
-	git          git.Git
-	tar          tar.Tar
-	build        *api.Build
-	urlTimeout   time.Duration
+	dockerClient   DockerClient
+	authPresent    bool
+	auth           docker.AuthConfiguration
+	pullAuth       *docker.AuthConfigurations
+	git            git.Git
+	tar            tar.Tar
+	build          *api.Build
+	urlTimeout     time.Duration
+	binaryInputDir string
 }
 
-// NewDockerBuilder creates a new instance of DockerBuilder
-func NewDockerBuilder(dockerClient DockerClient, authCfg docker.AuthConfiguration, authPresent bool, build *api.Build) *DockerBuilder {
+// NewDockerBuilder creates a new instance of DockerBuilder. authCfg is used to push the
+// output image, pullAuth, when set, to pull images from private registries.
+func NewDockerBuilder(dockerClient DockerClient, authCfg docker.AuthConfiguration, authPresent bool, pullAuth *docker.AuthConfigurations, build *api.Build) *DockerBuilder {
 	return &DockerBuilder{
-		dockerClient: dockerClient,
-		authPresent:  authPresent,
-		auth:         authCfg,
-		build:        build,
-		git:          git.New(),
-		tar:          tar.New(),
-		urlTimeout:   urlCheckTimeout,
+		dockerClient:   dockerClient,
+		authPresent:    authPresent,
+		auth:           authCfg,
+		pullAuth:       pullAuth,
+		build:          build,
+		git:            git.New(),
+		tar:            tar.New(),
+		urlTimeout:     urlCheckTimeout,
+		binaryInputDir: BinaryInputDir,
 	}
 }
 
@@ -60,14 +67,26 @@ func (d *DockerBuilder) Build() error {
 	if err = d.fetchSource(buildDir); err != nil {
 		return err
 	}
-	if err = d.addBuildParameters(buildDir); err != nil {
+	if err = extractImageSources(d.dockerClient, d.tar, d.build, buildDir); err != nil {
+		return err
+	}
+	if err = d.addInlineDockerfile(buildDir); err != nil {
 		return err
 	}
-	if err = d.dockerBuild(buildDir); err != nil {
+	if err = d.addBuildParameters(buildDir); err != nil {
 		return err
 	}
 	tag := d.build.Parameters.Output.DockerImageReference
+	if len(tag) == 0 && d.build.Parameters.PostCommit != nil {
+		tag = postCommitImageTag(d.build)
+	}
+	if err = d.dockerBuild(buildDir, tag); err != nil {
+		return err
+	}
 	defer removeImage(d.dockerClient, tag)
+	if err = runPostCommitHook(d.dockerClient, d.build, tag); err != nil {
+		return err
+	}
 	if len(d.build.Parameters.Output.DockerImageReference) != 0 {
 		return pushImage(d.dockerClient, tag, d.auth)
 	}
@@ -91,6 +110,12 @@ func (d *DockerBuilder) checkSourceURI() error {
 	if err != nil {
 		return err
 	}
+	// behind a proxy, only the proxy can be reached
+	if proxy := sourceProxy(d.build.Parameters.Source.Git, srcURL); len(proxy) > 0 {
+		if srcURL, err = url.Parse(proxy); err != nil {
+			return err
+		}
+	}
 	host := srcURL.Host
 	if strings.Index(host, ":") == -1 {
 		switch srcURL.Scheme {
@@ -112,35 +137,52 @@ func (d *DockerBuilder) checkSourceURI() error {
 // fetchSource retrieves the git source from the repository. If a commit ID
 // is included in the build revision, that commit ID is checked out. Otherwise
 // if a ref is included in the source definition, that ref is checked out.
+// The revision of the checked out commit is then recorded on the build.
+// Binary sources are instead received from the client through the build pod.
 func (d *DockerBuilder) fetchSource(dir string) error {
+	if d.build.Parameters.Source.Type == api.BuildSourceBinary {
+		input, err := waitForBinaryInput(d.binaryInputDir, binaryInputTimeout)
+		if err != nil {
+			return err
+		}
+		return extractBinaryInput(d.tar, input, dir, d.build.Parameters.Source.Binary)
+	}
 	if err := d.checkSourceURI(); err != nil {
 		return err
 	}
-	if err := d.git.Clone(d.build.Parameters.Source.Git.URI, dir); err != nil {
+	env, err := setupSourceSecret(d.build)
+	if err != nil {
 		return err
 	}
-	if d.build.Parameters.Source.Git.Ref == "" &&
-		(d.build.Parameters.Revision == nil ||
-			d.build.Parameters.Revision.Git == nil ||
-			d.build.Parameters.Revision.Git.Commit == "") {
+	return fetchGitSource(d.build, dir, env)
+}
+
+// dockerfilePath returns the path of the Dockerfile relative to the context directory.
+func (d *DockerBuilder) dockerfilePath() string {
+	if strategy := d.build.Parameters.Strategy.DockerStrategy; strategy != nil && len(strategy.DockerfilePath) > 0 {
+		return filepath.Clean(strategy.DockerfilePath)
+	}
+	return "Dockerfile"
+}
+
+// addInlineDockerfile writes the Dockerfile given in the build source to the Dockerfile
+// path, replacing the Dockerfile of the fetched source if there is one.
+func (d *DockerBuilder) addInlineDockerfile(dir string) error {
+	if len(d.build.Parameters.Source.Dockerfile) == 0 {
 		return nil
 	}
-	if d.build.Parameters.Revision != nil &&
-		d.build.Parameters.Revision.Git != nil &&
-		d.build.Parameters.Revision.Git.Commit != "" {
-		return d.git.Checkout(dir, d.build.Parameters.Revision.Git.Commit)
+	dockerfilePath := filepath.Join(dir, d.build.Parameters.Source.ContextDir, d.dockerfilePath())
+	if err := os.MkdirAll(filepath.Dir(dockerfilePath), 0755); err != nil {
+		return err
 	}
-	return d.git.Checkout(dir, d.build.Parameters.Source.Git.Ref)
+	return ioutil.WriteFile(dockerfilePath, []byte(d.build.Parameters.Source.Dockerfile), 0644)
 }
 
 // addBuildParameters checks if a Image is set to replace the default base image.
 // If that's the case then change the Dockerfile to make the build with the given image.
-// Also append the environment variables in the Dockerfile.
+// Also append the environment variables of the build and of the strategy in the Dockerfile.
 func (d *DockerBuilder) addBuildParameters(dir string) error {
-	dockerfilePath := filepath.Join(dir, "Dockerfile")
-	if d.build.Parameters.Strategy.DockerStrategy != nil && len(d.build.Parameters.Source.ContextDir) > 0 {
-		dockerfilePath = filepath.Join(dir, d.build.Parameters.Source.ContextDir, "Dockerfile")
-	}
+	dockerfilePath := filepath.Join(dir, d.build.Parameters.Source.ContextDir, d.dockerfilePath())
 
 	fileStat, err := os.Lstat(dockerfilePath)
 	if err != nil {
@@ -164,18 +206,62 @@ func (d *DockerBuilder) addBuildParameters(dir string) error {
 		newFileData = newFileData + string(fileData)
 	}
 
+	if len(newFileData) > 0 && !strings.HasSuffix(newFileData, "\n") {
+		newFileData += "\n"
+	}
 	envVars := getBuildEnvVars(d.build)
 	for k, v := range envVars {
-		newFileData = newFileData + fmt.Sprintf("ENV %s %s\n", k, v)
+		instruction, err := envInstruction(k, v)
+		if err != nil {
+			return err
+		}
+		newFileData += instruction
+	}
+	for _, env := range d.build.Parameters.Strategy.DockerStrategy.Env {
+		instruction, err := envInstruction(env.Name, env.Value)
+		if err != nil {
+			return err
+		}
+		newFileData += instruction
+	}
+	// Docker cannot pass the proxies to the build other than in the environment of the image
+	proxyEnv := proxyEnvVars(d.build)
+	proxyNames := []string{}
+	for name := range proxyEnv {
+		proxyNames = append(proxyNames, name)
+	}
+	sort.Strings(proxyNames)
+	for _, name := range proxyNames {
+		instruction, err := envInstruction(name, proxyEnv[name])
+		if err != nil {
+			return err
+		}
+		newFileData += instruction
+	}
+	if labels := imageLabels(d.build); len(labels) > 0 {
+		if supportsLabels(d.dockerClient) {
+			newFileData += labelInstruction(labels)
+		} else {
+			glog.V(2).Infof("The Docker daemon does not support labels, the source of the image is not recorded on it")
+		}
 	}
 
-	if ioutil.WriteFile(dockerfilePath, []byte(newFileData), filePerm); err != nil {
+	if err := ioutil.WriteFile(dockerfilePath, []byte(newFileData), filePerm); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// envInstruction returns the ENV instruction setting the environment variable name to value.
+// A line break would end the instruction and add the rest of value as another instruction.
+func envInstruction(name, value string) (string, error) {
+	if strings.ContainsAny(name+value, "\r\n") {
+		return "", fmt.Errorf("the environment variable %s may not contain line breaks", name)
+	}
+	return fmt.Sprintf("ENV %s %s\n", name, value), nil
+}
+
 // invalidCmdErr represents an error returned from replaceValidCmd
 // when an invalid Dockerfile command has been passed to
 // replaceValidCmd
@@ -307,13 +393,20 @@ func traverseAST(cmd string, node *parser.Node) int {
 }
 
 // dockerBuild performs a docker build on the source that has been retrieved
-func (d *DockerBuilder) dockerBuild(dir string) error {
-	var noCache bool
+func (d *DockerBuilder) dockerBuild(dir, tag string) error {
+	var (
+		noCache        bool
+		dockerfilePath string
+	)
 	if d.build.Parameters.Strategy.DockerStrategy != nil {
 		if d.build.Parameters.Source.ContextDir != "" {
 			dir = filepath.Join(dir, d.build.Parameters.Source.ContextDir)
 		}
 		noCache = d.build.Parameters.Strategy.DockerStrategy.NoCache
+		// only pass a non default Dockerfile, which older Docker daemons do not support
+		if len(d.build.Parameters.Strategy.DockerStrategy.DockerfilePath) > 0 {
+			dockerfilePath = d.dockerfilePath()
+		}
 	}
-	return buildImage(d.dockerClient, dir, noCache, d.build.Parameters.Output.DockerImageReference, d.tar)
+	return buildImage(d.dockerClient, dir, dockerfilePath, noCache, tag, d.tar, d.pullAuth)
 }
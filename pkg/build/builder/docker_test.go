@@ -2,11 +2,18 @@ package builder
 
 import (
 	"bytes"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	dockercmd "github.com/docker/docker/builder/command"
 	"github.com/docker/docker/builder/parser"
+
+	"github.com/openshift/origin/pkg/build/api"
 )
 
 func TestReplaceValidCmd(t *testing.T) {
@@ -240,3 +247,152 @@ scratch
 CMD ["executable","param1","param2"]
 `
 )
+
+func TestInlineDockerfileWithDockerfilePathAndEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &DockerBuilder{
+		build: &api.Build{
+			Parameters: api.BuildParameters{
+				Source: api.BuildSource{
+					ContextDir: "app",
+					Dockerfile: "FROM centos\nRUN make",
+				},
+				Strategy: api.BuildStrategy{
+					Type: api.DockerBuildStrategyType,
+					DockerStrategy: &api.DockerBuildStrategy{
+						DockerfilePath: "docker/Dockerfile.prod",
+						Env:            []kapi.EnvVar{{Name: "RACK_ENV", Value: "production"}},
+					},
+				},
+			},
+		},
+	}
+	if err := d.addInlineDockerfile(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.addBuildParameters(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app", "docker", "Dockerfile.prod"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "FROM centos\nRUN make\nENV ") {
+		t.Errorf("Expected the inline Dockerfile followed by ENV instructions, got %q", data)
+	}
+	if !strings.HasSuffix(string(data), "ENV RACK_ENV production\n") {
+		t.Errorf("Expected the strategy environment to be set last, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app", "Dockerfile")); !os.IsNotExist(err) {
+		t.Errorf("Expected no Dockerfile at the default path, got %v", err)
+	}
+}
+
+func TestAddBuildParametersProxies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &DockerBuilder{
+		dockerClient: &FakeDocker{},
+		build: &api.Build{
+			Parameters: api.BuildParameters{
+				Source: api.BuildSource{
+					Dockerfile: "FROM centos\nRUN make",
+					Git:        &api.GitBuildSource{URI: "https://github.com/my/repo", HTTPSProxy: "http://proxy.example.com:3128"},
+				},
+				Strategy: api.BuildStrategy{
+					Type:           api.DockerBuildStrategyType,
+					DockerStrategy: &api.DockerBuildStrategy{},
+				},
+			},
+		},
+	}
+	if err := d.addInlineDockerfile(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.addBuildParameters(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), "\nENV HTTPS_PROXY http://proxy.example.com:3128\nENV https_proxy http://proxy.example.com:3128\n") {
+		t.Errorf("Expected the proxies to be set in the environment of the build, got %q", data)
+	}
+}
+
+func TestAddBuildParametersLineBreak(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &DockerBuilder{
+		build: &api.Build{
+			Parameters: api.BuildParameters{
+				Source: api.BuildSource{Dockerfile: "FROM centos"},
+				Strategy: api.BuildStrategy{
+					Type: api.DockerBuildStrategyType,
+					DockerStrategy: &api.DockerBuildStrategy{
+						Env: []kapi.EnvVar{{Name: "VERSION", Value: "2.1\nRUN make"}},
+					},
+				},
+			},
+		},
+	}
+	if err := d.addInlineDockerfile(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.addBuildParameters(dir); err == nil {
+		t.Errorf("Expected an error for a value with a line break")
+	}
+}
+
+func TestAddBuildParametersLabels(t *testing.T) {
+	for version, expected := range map[string]bool{"1.17": false, "1.18": true} {
+		dir, err := ioutil.TempDir("", "docker-build")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		d := &DockerBuilder{
+			dockerClient: &FakeDocker{apiVersion: version},
+			build: &api.Build{
+				Parameters: api.BuildParameters{
+					Source: api.BuildSource{
+						Dockerfile: "FROM centos",
+						Git:        &api.GitBuildSource{URI: "https://github.com/my/repo"},
+					},
+					Strategy: api.BuildStrategy{
+						Type:           api.DockerBuildStrategyType,
+						DockerStrategy: &api.DockerBuildStrategy{},
+					},
+				},
+			},
+		}
+		if err := d.addInlineDockerfile(dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := d.addBuildParameters(dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, "Dockerfile"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if labelled := strings.Contains(string(data), "\nLABEL "); labelled != expected {
+			t.Errorf("%s: expected a LABEL instruction %t, got %q", version, expected, data)
+		}
+	}
+}
@@ -1,18 +1,50 @@
 package builder
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
 	"github.com/openshift/source-to-image/pkg/tar"
 )
 
+// labelAPIVersion is the remote API version of Docker 1.6, the first Docker daemon
+// supporting the LABEL instruction.
+var labelAPIVersion, _ = docker.NewAPIVersion("1.18")
+
 // DockerClient is an interface to the Docker client that contains
 // the methods used by the common builder
 type DockerClient interface {
 	BuildImage(opts docker.BuildImageOptions) error
 	PushImage(opts docker.PushImageOptions, auth docker.AuthConfiguration) error
+	PullImage(opts docker.PullImageOptions, auth docker.AuthConfiguration) error
 	RemoveImage(name string) error
+	CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error)
+	StartContainer(id string, hostConfig *docker.HostConfig) error
+	WaitContainer(id string) (int, error)
+	Logs(opts docker.LogsOptions) error
+	RemoveContainer(opts docker.RemoveContainerOptions) error
+	CopyFromContainer(opts docker.CopyFromContainerOptions) error
+	Version() (*docker.Env, error)
+}
+
+// supportsLabels returns true if the Docker daemon supports the LABEL instruction. Older
+// daemons fail the builds of Dockerfiles using it.
+func supportsLabels(client DockerClient) bool {
+	env, err := client.Version()
+	if err != nil {
+		glog.V(2).Infof("Unable to get the version of the Docker daemon: %v", err)
+		return false
+	}
+	version, err := docker.NewAPIVersion(env.Get("ApiVersion"))
+	if err != nil {
+		glog.V(2).Infof("Unable to parse the API version of the Docker daemon: %v", err)
+		return false
+	}
+	return !version.LessThan(labelAPIVersion)
 }
 
 // pushImage pushes a docker image to the registry specified in its tag
@@ -26,12 +58,25 @@ func pushImage(client DockerClient, name string, authConfig docker.AuthConfigura
 	return client.PushImage(opts, authConfig)
 }
 
+// pullImage pulls a docker image from the registry specified in its name
+func pullImage(client DockerClient, name string, authConfig docker.AuthConfiguration) error {
+	repository, tag := docker.ParseRepositoryTag(name)
+	opts := docker.PullImageOptions{
+		Repository:   repository,
+		Tag:          tag,
+		OutputStream: os.Stdout,
+	}
+	return client.PullImage(opts, authConfig)
+}
+
 func removeImage(client DockerClient, name string) error {
 	return client.RemoveImage(name)
 }
 
-// buildImage invokes a docker build on a particular directory
-func buildImage(client DockerClient, dir string, noCache bool, tag string, tar tar.Tar) error {
+// buildImage invokes a docker build on a particular directory, using the Dockerfile at
+// dockerfilePath within it or the default Dockerfile when dockerfilePath is empty. Images
+// are pulled with pullAuth when it is set.
+func buildImage(client DockerClient, dir, dockerfilePath string, noCache bool, tag string, tar tar.Tar, pullAuth *docker.AuthConfigurations) error {
 	tarFile, err := tar.CreateTarFile("", dir)
 	if err != nil {
 		return err
@@ -47,6 +92,24 @@ func buildImage(client DockerClient, dir string, noCache bool, tag string, tar t
 		OutputStream:   os.Stdout,
 		InputStream:    tarStream,
 		NoCache:        noCache,
+		Dockerfile:     dockerfilePath,
+	}
+	if pullAuth != nil {
+		opts.AuthConfigs = *pullAuth
 	}
 	return client.BuildImage(opts)
 }
+
+// addImageLabels replaces the image tagged tag by an image built from it with labels.
+func addImageLabels(client DockerClient, tag string, labels map[string]string) error {
+	dir, err := ioutil.TempDir("", "image-labels")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	dockerfile := fmt.Sprintf("FROM %s\n%s", tag, labelInstruction(labels))
+	if err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return err
+	}
+	return buildImage(client, dir, "", false, tag, tar.New(), nil)
+}
@@ -1,19 +1,31 @@
 package builder
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/fsouza/go-dockerclient"
+	"github.com/openshift/source-to-image/pkg/tar"
 )
 
 type FakeDocker struct {
 	pushImageFunc   func(opts docker.PushImageOptions, auth docker.AuthConfiguration) error
 	buildImageFunc  func(opts docker.BuildImageOptions) error
 	removeImageFunc func(name string) error
+	copyFunc        func(opts docker.CopyFromContainerOptions) error
+
+	// apiVersion is the remote API version of the daemon, 1.18 when empty
+	apiVersion string
+
+	containerConfig   *docker.Config
+	exitCode          int
+	removedContainers []string
+	pulledImages      []string
 }
 
 func (d *FakeDocker) BuildImage(opts docker.BuildImageOptions) error {
-	if d.pushImageFunc != nil {
+	if d.buildImageFunc != nil {
 		return d.buildImageFunc(opts)
 	}
 	return nil
@@ -26,6 +38,11 @@ func (d *FakeDocker) PushImage(opts docker.PushImageOptions, auth docker.AuthCon
 	return nil
 }
 
+func (d *FakeDocker) PullImage(opts docker.PullImageOptions, auth docker.AuthConfiguration) error {
+	d.pulledImages = append(d.pulledImages, opts.Repository+":"+opts.Tag)
+	return nil
+}
+
 func (d *FakeDocker) RemoveImage(name string) error {
 	if d.removeImageFunc != nil {
 		return d.removeImageFunc(name)
@@ -33,6 +50,51 @@ func (d *FakeDocker) RemoveImage(name string) error {
 	return nil
 }
 
+func (d *FakeDocker) CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error) {
+	d.containerConfig = opts.Config
+	return &docker.Container{ID: "container"}, nil
+}
+
+func (d *FakeDocker) StartContainer(id string, hostConfig *docker.HostConfig) error {
+	return nil
+}
+
+func (d *FakeDocker) WaitContainer(id string) (int, error) {
+	return d.exitCode, nil
+}
+
+func (d *FakeDocker) Logs(opts docker.LogsOptions) error {
+	return nil
+}
+
+func (d *FakeDocker) RemoveContainer(opts docker.RemoveContainerOptions) error {
+	d.removedContainers = append(d.removedContainers, opts.ID)
+	return nil
+}
+
+func (d *FakeDocker) CopyFromContainer(opts docker.CopyFromContainerOptions) error {
+	if d.copyFunc != nil {
+		return d.copyFunc(opts)
+	}
+	return nil
+}
+
+func (d *FakeDocker) Version() (*docker.Env, error) {
+	version := d.apiVersion
+	if len(version) == 0 {
+		version = "1.18"
+	}
+	return &docker.Env{"ApiVersion=" + version}, nil
+}
+
+func TestSupportsLabels(t *testing.T) {
+	for version, expected := range map[string]bool{"1.17": false, "1.18": true, "1.19": true, "unknown": false} {
+		if supported := supportsLabels(&FakeDocker{apiVersion: version}); supported != expected {
+			t.Errorf("%s: expected labels to be supported %t, got %t", version, expected, supported)
+		}
+	}
+}
+
 func TestDockerPush(t *testing.T) {
 	verifyFunc := func(opts docker.PushImageOptions, auth docker.AuthConfiguration) error {
 		if opts.Name != "test/image" {
@@ -43,3 +105,29 @@ func TestDockerPush(t *testing.T) {
 	fd := &FakeDocker{pushImageFunc: verifyFunc}
 	pushImage(fd, "test/image", docker.AuthConfiguration{})
 }
+
+func TestBuildImagePullAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pullAuth := &docker.AuthConfigurations{Configs: map[string]docker.AuthConfiguration{
+		"registry.example.com": {Username: "user", Password: "pass"},
+	}}
+	var actual docker.BuildImageOptions
+	fd := &FakeDocker{buildImageFunc: func(opts docker.BuildImageOptions) error {
+		actual = opts
+		return nil
+	}}
+	if err := buildImage(fd, dir, "docker/Dockerfile", false, "test/image", tar.New(), pullAuth); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.AuthConfigs.Configs["registry.example.com"].Username != "user" {
+		t.Errorf("Expected the pull credentials to be passed to the build, got %#v", actual.AuthConfigs)
+	}
+	if actual.Dockerfile != "docker/Dockerfile" {
+		t.Errorf("Expected the Dockerfile path to be passed to the build, got %q", actual.Dockerfile)
+	}
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/openshift/source-to-image/pkg/tar"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/builder/cmd/dockercfg"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// imageSourceAuth returns the credentials of the pull secret of the build for the registry of
+// image, or empty credentials when there are none.
+var imageSourceAuth = func(image string) docker.AuthConfiguration {
+	path := os.Getenv("PULL_DOCKERCFG_PATH")
+	if len(path) == 0 {
+		return docker.AuthConfiguration{}
+	}
+	ref, err := imageapi.ParseDockerImageReference(image)
+	if err != nil {
+		return docker.AuthConfiguration{}
+	}
+	auth, _ := dockercfg.NewHelper().GetDockerAuthFromFile(path, ref.Registry)
+	return auth
+}
+
+// extractImageSources copies the paths of the image sources of build into the context
+// directory of the source fetched into dir.
+func extractImageSources(client DockerClient, t tar.Tar, build *api.Build, dir string) error {
+	for _, source := range build.Parameters.Source.Images {
+		if len(source.DockerImageReference) == 0 {
+			return fmt.Errorf("the image of source %s was not resolved", source.From.Name)
+		}
+		if err := extractImageSource(client, t, source, filepath.Join(dir, build.Parameters.Source.ContextDir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractImageSource pulls the image of source and copies its paths from a container, which is
+// never started, into contextDir.
+func extractImageSource(client DockerClient, t tar.Tar, source api.ImageSource, contextDir string) error {
+	image := source.DockerImageReference
+	glog.V(2).Infof("Pulling image %s to copy files into the build", image)
+	if err := pullImage(client, image, imageSourceAuth(image)); err != nil {
+		return fmt.Errorf("unable to pull source image %s: %v", image, err)
+	}
+	container, err := client.CreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{Image: image, Cmd: []string{"/bin/true"}},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create a container of source image %s: %v", image, err)
+	}
+	defer func() {
+		if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: container.ID, Force: true}); err != nil {
+			glog.Warningf("Unable to remove the container of source image %s: %v", image, err)
+		}
+	}()
+
+	for _, path := range source.Paths {
+		destination := filepath.Join(contextDir, path.DestinationDir)
+		glog.V(2).Infof("Copying %s from image %s to %s", path.SourcePath, image, destination)
+		if err := os.MkdirAll(destination, 0755); err != nil {
+			return err
+		}
+		if err := copyFromContainer(client, t, container.ID, path.SourcePath, destination); err != nil {
+			return fmt.Errorf("unable to copy %s from source image %s: %v", path.SourcePath, image, err)
+		}
+	}
+	return nil
+}
+
+// copyFromContainer extracts the tar archive of resource in container, which Docker streams,
+// into dir.
+func copyFromContainer(client DockerClient, t tar.Tar, container, resource, dir string) error {
+	r, w := io.Pipe()
+	defer r.Close()
+	go func() {
+		w.CloseWithError(client.CopyFromContainer(docker.CopyFromContainerOptions{
+			Container:    container,
+			Resource:     resource,
+			OutputStream: w,
+		}))
+	}()
+	return t.ExtractTarStream(dir, r)
+}
@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/fsouza/go-dockerclient"
+	stitar "github.com/openshift/source-to-image/pkg/tar"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func TestExtractImageSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-source")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var copied []string
+	fd := &FakeDocker{copyFunc: func(opts docker.CopyFromContainerOptions) error {
+		copied = append(copied, opts.Resource)
+		// Docker archives the resource under its base name
+		w := tar.NewWriter(opts.OutputStream)
+		content := []byte("artifact")
+		name := filepath.Base(opts.Resource)
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+		return w.Close()
+	}}
+	build := &api.Build{
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				ContextDir: "app",
+				Images: []api.ImageSource{{
+					From:                 &kapi.ObjectReference{Name: "artifacts"},
+					DockerImageReference: "registry.example.com/builds/artifacts:1234",
+					Paths: []api.ImageSourcePath{
+						{SourcePath: "/artifacts/app.war", DestinationDir: "deployments"},
+						{SourcePath: "/artifacts/README"},
+					},
+				}},
+			},
+		},
+	}
+
+	if err := extractImageSources(fd, stitar.New(), build, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fd.pulledImages, []string{"registry.example.com/builds/artifacts:1234"}) {
+		t.Errorf("expected the source image to be pulled, got %v", fd.pulledImages)
+	}
+	if fd.containerConfig == nil || fd.containerConfig.Image != "registry.example.com/builds/artifacts:1234" {
+		t.Errorf("expected a container of the source image, got %#v", fd.containerConfig)
+	}
+	if !reflect.DeepEqual(copied, []string{"/artifacts/app.war", "/artifacts/README"}) {
+		t.Errorf("unexpected copied paths %v", copied)
+	}
+	for _, path := range []string{"app/deployments/app.war", "app/README"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("expected %s to be copied into the context directory: %v", path, err)
+		}
+	}
+	if !reflect.DeepEqual(fd.removedContainers, []string{"container"}) {
+		t.Errorf("expected the container to be removed, got %v", fd.removedContainers)
+	}
+}
+
+func TestExtractImageSourcesUnresolved(t *testing.T) {
+	build := &api.Build{
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Images: []api.ImageSource{{
+					From:  &kapi.ObjectReference{Name: "artifacts"},
+					Paths: []api.ImageSourcePath{{SourcePath: "/artifacts"}},
+				}},
+			},
+		},
+	}
+	fd := &FakeDocker{}
+	if err := extractImageSources(fd, stitar.New(), build, "/nonexistent"); err == nil {
+		t.Errorf("expected an error for an unresolved image")
+	}
+	if len(fd.pulledImages) != 0 {
+		t.Errorf("unexpected pulls %v", fd.pulledImages)
+	}
+}
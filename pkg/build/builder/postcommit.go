@@ -0,0 +1,78 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// postCommitImageTag returns the tag of the image of a build without output, so that its
+// post commit hook can run in it.
+func postCommitImageTag(build *api.Build) string {
+	return fmt.Sprintf("%s-%s:post-commit", build.Namespace, build.Name)
+}
+
+// postCommitConfig returns the container configuration running hook in image.
+func postCommitConfig(image string, hook *api.BuildPostCommitSpec) *docker.Config {
+	config := &docker.Config{
+		Image:        image,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	switch {
+	case len(hook.Script) > 0:
+		// the first argument after the script is $0, so Args become its positional parameters
+		config.Entrypoint = []string{"/bin/sh", "-c"}
+		config.Cmd = append([]string{hook.Script, "/bin/sh"}, hook.Args...)
+	case len(hook.Command) > 0:
+		config.Entrypoint = hook.Command
+		config.Cmd = hook.Args
+	default:
+		config.Cmd = hook.Args
+	}
+	return config
+}
+
+// runPostCommitHook runs the post commit hook of the build in a container of image, with its
+// output in the build log. The hook fails when it exits with a non-zero code.
+func runPostCommitHook(client DockerClient, build *api.Build, image string) error {
+	hook := build.Parameters.PostCommit
+	if hook == nil {
+		return nil
+	}
+	glog.V(2).Infof("Running the post commit hook of build %s in image %s", build.Name, image)
+	container, err := client.CreateContainer(docker.CreateContainerOptions{Config: postCommitConfig(image, hook)})
+	if err != nil {
+		return fmt.Errorf("unable to create a container for the post commit hook: %v", err)
+	}
+	defer func() {
+		if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: container.ID, Force: true}); err != nil {
+			glog.Warningf("Unable to remove the container of the post commit hook: %v", err)
+		}
+	}()
+
+	if err := client.StartContainer(container.ID, &docker.HostConfig{}); err != nil {
+		return fmt.Errorf("unable to start the post commit hook: %v", err)
+	}
+	code, err := client.WaitContainer(container.ID)
+	if err != nil {
+		return fmt.Errorf("unable to wait for the post commit hook: %v", err)
+	}
+	if err := client.Logs(docker.LogsOptions{
+		Container:    container.ID,
+		OutputStream: os.Stdout,
+		ErrorStream:  os.Stderr,
+		Stdout:       true,
+		Stderr:       true,
+	}); err != nil {
+		glog.Warningf("Unable to read the output of the post commit hook: %v", err)
+	}
+	if code != 0 {
+		return fmt.Errorf("post commit hook failed with exit code %d, the image was not pushed", code)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func TestPostCommitConfig(t *testing.T) {
+	tests := map[string]struct {
+		hook       api.BuildPostCommitSpec
+		entrypoint []string
+		cmd        []string
+	}{
+		"script": {
+			hook:       api.BuildPostCommitSpec{Script: "rake test $1", Args: []string{"--verbose"}},
+			entrypoint: []string{"/bin/sh", "-c"},
+			cmd:        []string{"rake test $1", "/bin/sh", "--verbose"},
+		},
+		"command": {
+			hook:       api.BuildPostCommitSpec{Command: []string{"rake", "test"}, Args: []string{"--verbose"}},
+			entrypoint: []string{"rake", "test"},
+			cmd:        []string{"--verbose"},
+		},
+		"args of the entrypoint": {
+			hook: api.BuildPostCommitSpec{Args: []string{"test"}},
+			cmd:  []string{"test"},
+		},
+	}
+	for name, test := range tests {
+		config := postCommitConfig("image", &test.hook)
+		if config.Image != "image" {
+			t.Errorf("%s: expected the hook to run in the built image, got %s", name, config.Image)
+		}
+		if !reflect.DeepEqual(config.Entrypoint, test.entrypoint) {
+			t.Errorf("%s: expected entrypoint %v, got %v", name, test.entrypoint, config.Entrypoint)
+		}
+		if !reflect.DeepEqual(config.Cmd, test.cmd) {
+			t.Errorf("%s: expected command %v, got %v", name, test.cmd, config.Cmd)
+		}
+	}
+}
+
+func TestRunPostCommitHook(t *testing.T) {
+	build := &api.Build{
+		Parameters: api.BuildParameters{
+			PostCommit: &api.BuildPostCommitSpec{Script: "make test"},
+		},
+	}
+
+	client := &FakeDocker{}
+	if err := runPostCommitHook(client, build, "built/image"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if client.containerConfig == nil || client.containerConfig.Image != "built/image" {
+		t.Errorf("Expected the hook to run in the built image, got %#v", client.containerConfig)
+	}
+	if len(client.removedContainers) != 1 {
+		t.Errorf("Expected the container of the hook to be removed, got %v", client.removedContainers)
+	}
+
+	client = &FakeDocker{exitCode: 1}
+	if err := runPostCommitHook(client, build, "built/image"); err == nil {
+		t.Errorf("Expected a failing hook to fail the build")
+	}
+
+	client = &FakeDocker{}
+	if err := runPostCommitHook(client, &api.Build{}, "built/image"); err != nil || client.containerConfig != nil {
+		t.Errorf("Expected no container without a hook, got %v", err)
+	}
+}
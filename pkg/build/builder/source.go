@@ -0,0 +1,226 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/glog"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// sshWrapperScript runs ssh with the private key of the source secret, verifying the host key
+// of the Git server with the given known hosts options.
+const sshWrapperScript = `#!/bin/sh
+exec ssh -i '%s' -o IdentitiesOnly=yes %s "$@"
+`
+
+// sshKnownHostsOptions verify the host key of the Git server against the known_hosts file of
+// the source secret.
+const sshKnownHostsOptions = "-o StrictHostKeyChecking=yes -o UserKnownHostsFile='%s'"
+
+// sshUnknownHostsOptions accept any host key, when the source secret has no known_hosts file.
+const sshUnknownHostsOptions = "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+
+// askPassScript answers the username and password prompts of git with the contents of the
+// source secret, so that credentials are neither part of the clone URL nor of the logs. The
+// token is used as the username when the secret has no username.
+const askPassScript = `#!/bin/sh
+case "$1" in
+Username*)
+	if [ -f '%[1]s' ]; then cat '%[1]s'; else cat '%[2]s'; fi ;;
+*)
+	cat '%[2]s' ;;
+esac
+`
+
+// setupSourceSecret returns the environment of the git commands fetching the source, which
+// makes them use the credentials of the source secret mounted in the builder container.
+func setupSourceSecret(build *api.Build) ([]string, error) {
+	if build.Parameters.Source.Git == nil || len(build.Parameters.Source.SourceSecretName) == 0 {
+		return nil, nil
+	}
+	secretDir := os.Getenv("SOURCE_SECRET_PATH")
+	if len(secretDir) == 0 {
+		secretDir = api.SourceSecretMountPath
+	}
+	scriptDir, err := ioutil.TempDir("", "source-secret")
+	if err != nil {
+		return nil, err
+	}
+	vars, err := sourceSecretEnv(secretDir, scriptDir, os.Getenv("HOME"))
+	if err != nil {
+		return nil, err
+	}
+	env := []string{}
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	glog.V(2).Infof("Using the credentials of source secret %s to fetch the source", build.Parameters.Source.SourceSecretName)
+	return env, nil
+}
+
+// sourceSecretEnv returns the git environment variables using the credentials found in
+// secretDir. Helper scripts are written to scriptDir, and the .gitconfig of the secret is
+// included from the .gitconfig in homeDir.
+func sourceSecretEnv(secretDir, scriptDir, homeDir string) (map[string]string, error) {
+	env := map[string]string{}
+	secretFile := func(key string) (string, bool) {
+		path := filepath.Join(secretDir, key)
+		_, err := os.Stat(path)
+		return path, err == nil
+	}
+
+	if key, ok := secretFile(api.SourceSecretSSHPrivateKey); ok {
+		// ssh refuses private keys readable by others, which files of secret volumes may be
+		data, err := ioutil.ReadFile(key)
+		if err != nil {
+			return nil, err
+		}
+		privateKey := filepath.Join(scriptDir, api.SourceSecretSSHPrivateKey)
+		if err := ioutil.WriteFile(privateKey, data, 0600); err != nil {
+			return nil, err
+		}
+		hostOptions := sshUnknownHostsOptions
+		if knownHosts, ok := secretFile(api.SourceSecretSSHKnownHosts); ok {
+			hostOptions = fmt.Sprintf(sshKnownHostsOptions, knownHosts)
+		} else {
+			glog.Warningf("The source secret has no %s file, the host key of the Git server is not verified", api.SourceSecretSSHKnownHosts)
+		}
+		script := filepath.Join(scriptDir, "ssh-wrapper.sh")
+		if err := ioutil.WriteFile(script, []byte(fmt.Sprintf(sshWrapperScript, privateKey, hostOptions)), 0700); err != nil {
+			return nil, err
+		}
+		env["GIT_SSH"] = script
+	}
+
+	if password, ok := secretFile(api.SourceSecretPassword); ok {
+		username, _ := secretFile(api.SourceSecretUsername)
+		script := filepath.Join(scriptDir, "askpass.sh")
+		if err := ioutil.WriteFile(script, []byte(fmt.Sprintf(askPassScript, username, password)), 0700); err != nil {
+			return nil, err
+		}
+		env["GIT_ASKPASS"] = script
+		env["GIT_TERMINAL_PROMPT"] = "0"
+	}
+
+	if caCert, ok := secretFile(api.SourceSecretCACert); ok {
+		env["GIT_SSL_CAINFO"] = caCert
+	}
+
+	if gitConfig, ok := secretFile(api.SourceSecretGitConfig); ok && len(homeDir) > 0 {
+		f, err := os.OpenFile(filepath.Join(homeDir, ".gitconfig"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if _, err := fmt.Fprintf(f, "[include]\n\tpath = %s\n", gitConfig); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// maxRevisionMessageLength bounds the commit message reported with the revision of a build,
+// as the termination message of a container is limited in size.
+const maxRevisionMessageLength = 1024
+
+// gitLogFormat prints the commit, author, committer and message of a commit on separate lines.
+const gitLogFormat = "--format=%H%n%an%n%ae%n%cn%n%ce%n%B"
+
+// runGit runs git with args in dir, adding env to the environment of the builder, and returns
+// its output.
+var runGit = func(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// fetchGitSource clones the Git source of build into dir and checks out the commit of its
+// revision, or else its ref. The revision that was checked out is then set on build. The git
+// commands are run with env added to their environment.
+func fetchGitSource(build *api.Build, dir string, env []string) error {
+	source := build.Parameters.Source.Git
+	if _, err := runGit("", env, "clone", "--recursive", "--", source.URI, dir); err != nil {
+		return err
+	}
+	ref := source.Ref
+	if revision := build.Parameters.Revision; revision != nil && revision.Git != nil && len(revision.Git.Commit) > 0 {
+		ref = revision.Git.Commit
+	}
+	if len(ref) > 0 {
+		if _, err := runGit(dir, env, "checkout", ref); err != nil {
+			return err
+		}
+	}
+
+	revision, err := gitRevision(dir)
+	if err != nil {
+		// the build does not depend on it, the source is only not recorded
+		glog.Warningf("Unable to resolve the checked out source revision: %v", err)
+		return nil
+	}
+	glog.V(2).Infof("Building commit %s of %s", revision.Git.Commit, source.URI)
+	build.Parameters.Revision = revision
+	return nil
+}
+
+// gitRevision returns the revision of the commit checked out in dir.
+func gitRevision(dir string) (*api.SourceRevision, error) {
+	out, err := runGit(dir, nil, "log", "-1", gitLogFormat)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitN(out, "\n", 6)
+	if len(lines) < 5 || len(lines[0]) == 0 {
+		return nil, fmt.Errorf("unexpected output of git log: %q", out)
+	}
+	revision := &api.GitSourceRevision{
+		Commit:    lines[0],
+		Author:    api.SourceControlUser{Name: lines[1], Email: lines[2]},
+		Committer: api.SourceControlUser{Name: lines[3], Email: lines[4]},
+	}
+	if len(lines) == 6 {
+		revision.Message = strings.TrimSpace(lines[5])
+	}
+	return &api.SourceRevision{Type: api.BuildSourceGit, Git: revision}, nil
+}
+
+// ReportRevision writes the source revision of build to path, the termination message of the
+// builder container, from which the build controller records it on the build.
+func ReportRevision(path string, build *api.Build) error {
+	revision := build.Parameters.Revision
+	if revision == nil || revision.Git == nil || len(revision.Git.Commit) == 0 {
+		return nil
+	}
+	reported := *revision.Git
+	if len(reported.Message) > maxRevisionMessageLength {
+		// cut before the rune crossing the limit, the message must remain valid UTF-8
+		end := maxRevisionMessageLength
+		for end > 0 && !utf8.RuneStart(reported.Message[end]) {
+			end--
+		}
+		reported.Message = reported.Message[:end]
+	}
+	data, err := json.Marshal(&api.SourceRevision{Type: revision.Type, Git: &reported})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,233 @@
+package builder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func writeSecret(t *testing.T, data map[string]string) string {
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, value := range data {
+		if err := ioutil.WriteFile(filepath.Join(dir, key), []byte(value), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestSourceSecretEnvSSHKey(t *testing.T) {
+	secretDir := writeSecret(t, map[string]string{api.SourceSecretSSHPrivateKey: "private key"})
+	defer os.RemoveAll(secretDir)
+	scriptDir, _ := ioutil.TempDir("", "scripts")
+	defer os.RemoveAll(scriptDir)
+
+	env, err := sourceSecretEnv(secretDir, scriptDir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(env) != 1 || len(env["GIT_SSH"]) == 0 {
+		t.Fatalf("Expected only GIT_SSH to be set, got %v", env)
+	}
+	key := filepath.Join(scriptDir, api.SourceSecretSSHPrivateKey)
+	info, err := os.Stat(key)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the private key to be copied readable by its owner only, got %v, %v", info, err)
+	}
+	script, _ := ioutil.ReadFile(env["GIT_SSH"])
+	if !strings.Contains(string(script), "-i '"+key+"'") || strings.Contains(string(script), "private key") {
+		t.Errorf("Expected the ssh wrapper to reference the key file, got %s", script)
+	}
+}
+
+func TestSourceSecretEnvSSHKnownHosts(t *testing.T) {
+	secretDir := writeSecret(t, map[string]string{
+		api.SourceSecretSSHPrivateKey: "private key",
+		api.SourceSecretSSHKnownHosts: "github.com ssh-rsa AAAA",
+	})
+	defer os.RemoveAll(secretDir)
+	scriptDir, _ := ioutil.TempDir("", "scripts")
+	defer os.RemoveAll(scriptDir)
+
+	env, err := sourceSecretEnv(secretDir, scriptDir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script, _ := ioutil.ReadFile(env["GIT_SSH"])
+	knownHosts := filepath.Join(secretDir, api.SourceSecretSSHKnownHosts)
+	if !strings.Contains(string(script), "StrictHostKeyChecking=yes") || !strings.Contains(string(script), "UserKnownHostsFile='"+knownHosts+"'") {
+		t.Errorf("Expected the ssh wrapper to verify host keys with the known hosts of the secret, got %s", script)
+	}
+}
+
+func TestSourceSecretEnvHTTPS(t *testing.T) {
+	secretDir := writeSecret(t, map[string]string{
+		api.SourceSecretUsername: "user",
+		api.SourceSecretPassword: "token",
+		api.SourceSecretCACert:   "ca",
+	})
+	defer os.RemoveAll(secretDir)
+	scriptDir, _ := ioutil.TempDir("", "scripts")
+	defer os.RemoveAll(scriptDir)
+
+	env, err := sourceSecretEnv(secretDir, scriptDir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["GIT_SSL_CAINFO"] != filepath.Join(secretDir, api.SourceSecretCACert) {
+		t.Errorf("Expected the CA certificate of the secret to be used, got %v", env)
+	}
+	script := env["GIT_ASKPASS"]
+	if len(script) == 0 {
+		t.Fatalf("Expected GIT_ASKPASS to be set, got %v", env)
+	}
+	if data, _ := ioutil.ReadFile(script); strings.Contains(string(data), "token") {
+		t.Errorf("Expected the askpass script not to contain the token, got %s", data)
+	}
+	for prompt, expected := range map[string]string{
+		"Username for 'https://github.com': ": "user",
+		"Password for 'https://github.com': ": "token",
+	} {
+		out, err := exec.Command(script, prompt).Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(out) != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, prompt, out)
+		}
+	}
+}
+
+func TestSourceSecretEnvGitConfig(t *testing.T) {
+	secretDir := writeSecret(t, map[string]string{api.SourceSecretGitConfig: "[http]\n"})
+	defer os.RemoveAll(secretDir)
+	homeDir, _ := ioutil.TempDir("", "home")
+	defer os.RemoveAll(homeDir)
+
+	env, err := sourceSecretEnv(secretDir, homeDir, homeDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(env) != 0 {
+		t.Errorf("Expected no environment variables, got %v", env)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(homeDir, ".gitconfig"))
+	expected := "[include]\n\tpath = " + filepath.Join(secretDir, api.SourceSecretGitConfig) + "\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
+
+func TestFetchGitSourceRecordsRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	repo, err := ioutil.TempDir("", "repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(repo)
+	commands := [][]string{
+		{"init"},
+		{"-c", "user.name=John Doe", "-c", "user.email=jdoe@example.com", "commit", "--allow-empty", "-m", "First commit"},
+		{"branch", "stable"},
+		{"-c", "user.name=Jane Roe", "-c", "user.email=jroe@example.com", "commit", "--allow-empty", "-m", "Second commit\n\nWith a body"},
+	}
+	for _, args := range commands {
+		if _, err := runGit(repo, nil, args...); err != nil {
+			t.Fatalf("git %v: unexpected error: %v", args, err)
+		}
+	}
+
+	for ref, message := range map[string]string{"": "Second commit\n\nWith a body", "stable": "First commit"} {
+		dir, err := ioutil.TempDir("", "source")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		build := &api.Build{
+			Parameters: api.BuildParameters{
+				Source: api.BuildSource{Type: api.BuildSourceGit, Git: &api.GitBuildSource{URI: repo, Ref: ref}},
+			},
+		}
+		if err := fetchGitSource(build, dir, nil); err != nil {
+			t.Fatalf("%q: unexpected error: %v", ref, err)
+		}
+		revision := build.Parameters.Revision
+		if revision == nil || revision.Type != api.BuildSourceGit || revision.Git == nil || len(revision.Git.Commit) != 40 {
+			t.Fatalf("%q: unexpected revision %#v", ref, revision)
+		}
+		if revision.Git.Message != message {
+			t.Errorf("%q: expected message %q, got %q", ref, message, revision.Git.Message)
+		}
+		if ref == "stable" && (revision.Git.Author.Name != "John Doe" || revision.Git.Committer.Email != "jdoe@example.com") {
+			t.Errorf("%q: unexpected author %v or committer %v", ref, revision.Git.Author, revision.Git.Committer)
+		}
+	}
+}
+
+func TestReportRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "termination")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "termination-log")
+
+	if err := ReportRevision(path, &api.Build{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be reported for a build without revision, got %v", err)
+	}
+
+	build := &api.Build{
+		Parameters: api.BuildParameters{
+			Revision: &api.SourceRevision{
+				Type: api.BuildSourceGit,
+				Git:  &api.GitSourceRevision{Commit: "abcdef", Message: strings.Repeat("x", 2*maxRevisionMessageLength)},
+			},
+		},
+	}
+	if err := ReportRevision(path, build); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"type":"Git","git":{"commit":"abcdef","author":{},"committer":{},"message":"` + strings.Repeat("x", maxRevisionMessageLength) + `"}}`
+	if string(data) != expected {
+		t.Errorf("Unexpected report %s", data)
+	}
+	if len(build.Parameters.Revision.Git.Message) != 2*maxRevisionMessageLength {
+		t.Errorf("Expected the revision of the build to be left unchanged")
+	}
+
+	// a multibyte rune crossing the limit is left out entirely
+	build.Parameters.Revision.Git.Message = "x" + strings.Repeat("é", maxRevisionMessageLength)
+	if err := ReportRevision(path, build); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	revision := &api.SourceRevision{}
+	if data, err = ioutil.ReadFile(path); err == nil {
+		err = json.Unmarshal(data, revision)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	message := revision.Git.Message
+	if expected := "x" + strings.Repeat("é", (maxRevisionMessageLength-1)/2); message != expected || !utf8.ValidString(message) {
+		t.Errorf("Expected the message to be cut on a rune boundary, got %d bytes", len(message))
+	}
+}
@@ -1,10 +1,14 @@
 package builder
 
 import (
+	"io/ioutil"
+	"os"
+
 	"github.com/fsouza/go-dockerclient"
 	"github.com/golang/glog"
 	stiapi "github.com/openshift/source-to-image/pkg/api"
 	sti "github.com/openshift/source-to-image/pkg/build/strategies"
+	"github.com/openshift/source-to-image/pkg/tar"
 
 	"github.com/openshift/origin/pkg/build/api"
 )
@@ -15,16 +19,19 @@ type STIBuilder struct {
 	dockerSocket string
 	authPresent  bool
 	auth         docker.AuthConfiguration
+	pullAuth     *docker.AuthConfiguration
 	build        *api.Build
 }
 
-// NewSTIBuilder creates a new STIBuilder instance
-func NewSTIBuilder(client DockerClient, dockerSocket string, authCfg docker.AuthConfiguration, authPresent bool, build *api.Build) *STIBuilder {
+// NewSTIBuilder creates a new STIBuilder instance. authCfg is used to push the output image,
+// pullAuth, when set, to pull the builder image from a private registry.
+func NewSTIBuilder(client DockerClient, dockerSocket string, authCfg docker.AuthConfiguration, authPresent bool, pullAuth *docker.AuthConfiguration, build *api.Build) *STIBuilder {
 	return &STIBuilder{
 		dockerClient: client,
 		dockerSocket: dockerSocket,
 		authPresent:  authPresent,
 		auth:         authCfg,
+		pullAuth:     pullAuth,
 		build:        build,
 	}
 }
@@ -35,19 +42,44 @@ func (s *STIBuilder) Build() error {
 	request := &stiapi.Request{
 		BaseImage:    s.build.Parameters.Strategy.STIStrategy.Image,
 		DockerSocket: s.dockerSocket,
-		Source:       s.build.Parameters.Source.Git.URI,
 		ContextDir:   s.build.Parameters.Source.ContextDir,
 		Tag:          tag,
 		ScriptsURL:   s.build.Parameters.Strategy.STIStrategy.Scripts,
-		Environment:  getBuildEnvVars(s.build),
 		Incremental:  s.build.Parameters.Strategy.STIStrategy.Incremental,
 	}
 
-	if s.build.Parameters.Revision != nil && s.build.Parameters.Revision.Git != nil &&
-		s.build.Parameters.Revision.Git.Commit != "" {
-		request.Ref = s.build.Parameters.Revision.Git.Commit
-	} else if s.build.Parameters.Source.Git.Ref != "" {
-		request.Ref = s.build.Parameters.Source.Git.Ref
+	if s.build.Parameters.Source.Type == api.BuildSourceBinary {
+		// STI copies sources from a local directory that is not a clone spec
+		dir, err := s.receiveBinarySource()
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		request.Source = dir
+	} else {
+		// the source is cloned here rather than by STI so that the commit it resolves
+		// the ref to is known
+		dir, err := s.fetchSource()
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		request.Source = dir
+	}
+	if err := extractImageSources(s.dockerClient, tar.New(), s.build, request.Source); err != nil {
+		return err
+	}
+	// the environment includes the commit, which is known once the source was fetched
+	request.Environment = getBuildEnvVars(s.build)
+	for name, value := range proxyEnvVars(s.build) {
+		request.Environment[name] = value
+	}
+	if s.pullAuth != nil {
+		// STI pulls images without credentials, but uses the builder image when it is present
+		glog.V(2).Infof("Pulling builder image %s with the credentials of the pull secret", request.BaseImage)
+		if err := pullImage(s.dockerClient, request.BaseImage, *s.pullAuth); err != nil {
+			return err
+		}
 	}
 	glog.V(2).Infof("Creating a new STI builder with build request: %#v\n", request)
 	builder, err := sti.GetStrategy(request)
@@ -55,7 +87,22 @@ func (s *STIBuilder) Build() error {
 		return err
 	}
 	defer removeImage(s.dockerClient, tag)
-	if _, err = builder.Build(request); err != nil {
+	result, err := builder.Build(request)
+	if err != nil {
+		return err
+	}
+	image := result.ImageID
+	if labels := imageLabels(s.build); len(tag) > 0 && len(labels) > 0 && supportsLabels(s.dockerClient) {
+		// STI cannot label the images it builds
+		if err := addImageLabels(s.dockerClient, tag, labels); err != nil {
+			return err
+		}
+		image = tag
+	}
+	if len(image) == 0 {
+		image = tag
+	}
+	if err = runPostCommitHook(s.dockerClient, s.build, image); err != nil {
 		return err
 	}
 	if len(s.build.Parameters.Output.DockerImageReference) != 0 {
@@ -63,3 +110,39 @@ func (s *STIBuilder) Build() error {
 	}
 	return nil
 }
+
+// fetchSource clones the Git source of the build into a new temporary directory, which is
+// returned.
+func (s *STIBuilder) fetchSource() (string, error) {
+	env, err := setupSourceSecret(s.build)
+	if err != nil {
+		return "", err
+	}
+	dir, err := ioutil.TempDir("", "sti-build")
+	if err != nil {
+		return "", err
+	}
+	if err := fetchGitSource(s.build, dir, env); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// receiveBinarySource waits for the uploaded build input and extracts it into
+// a new temporary directory, which is returned.
+func (s *STIBuilder) receiveBinarySource() (string, error) {
+	input, err := waitForBinaryInput(BinaryInputDir, binaryInputTimeout)
+	if err != nil {
+		return "", err
+	}
+	dir, err := ioutil.TempDir("", "sti-build")
+	if err != nil {
+		return "", err
+	}
+	if err := extractBinaryInput(tar.New(), input, dir, s.build.Parameters.Source.Binary); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
@@ -1,6 +1,13 @@
 package builder
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
 	buildapi "github.com/openshift/origin/pkg/build/api"
 )
 
@@ -14,10 +21,12 @@ func getBuildEnvVars(build *buildapi.Build) map[string]string {
 	envVars := map[string]string{
 		"OPENSHIFT_BUILD_NAME":      build.Name,
 		"OPENSHIFT_BUILD_NAMESPACE": build.Namespace,
-		"OPENSHIFT_BUILD_SOURCE":    build.Parameters.Source.Git.URI,
 	}
-	if build.Parameters.Source.Git.Ref != "" {
-		envVars["OPENSHIFT_BUILD_REFERENCE"] = build.Parameters.Source.Git.Ref
+	if git := build.Parameters.Source.Git; git != nil {
+		envVars["OPENSHIFT_BUILD_SOURCE"] = git.URI
+		if git.Ref != "" {
+			envVars["OPENSHIFT_BUILD_REFERENCE"] = git.Ref
+		}
 	}
 	if build.Parameters.Revision != nil &&
 		build.Parameters.Revision.Git != nil &&
@@ -26,3 +35,88 @@ func getBuildEnvVars(build *buildapi.Build) map[string]string {
 	}
 	return envVars
 }
+
+// proxyEnvVars returns the environment variables setting the proxies of the Git source of
+// build, for the commands of the build that fetch dependencies.
+func proxyEnvVars(build *buildapi.Build) map[string]string {
+	envVars := map[string]string{}
+	git := build.Parameters.Source.Git
+	if git == nil {
+		return envVars
+	}
+	if len(git.HTTPProxy) > 0 {
+		envVars["HTTP_PROXY"] = git.HTTPProxy
+		envVars["http_proxy"] = git.HTTPProxy
+	}
+	if len(git.HTTPSProxy) > 0 {
+		envVars["HTTPS_PROXY"] = git.HTTPSProxy
+		envVars["https_proxy"] = git.HTTPSProxy
+	}
+	if len(git.NoProxy) > 0 {
+		envVars["NO_PROXY"] = git.NoProxy
+		envVars["no_proxy"] = git.NoProxy
+	}
+	return envVars
+}
+
+// sourceProxy returns the proxy of git used to reach u, or an empty string when u is reached
+// directly.
+func sourceProxy(git *buildapi.GitBuildSource, u *url.URL) string {
+	var proxy string
+	switch u.Scheme {
+	case "http":
+		proxy = git.HTTPProxy
+	case "https":
+		proxy = git.HTTPSProxy
+	}
+	if len(proxy) == 0 {
+		return ""
+	}
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, entry := range strings.Split(git.NoProxy, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		if entry == "*" || host == strings.TrimPrefix(entry, ".") || strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")) {
+			return ""
+		}
+	}
+	return proxy
+}
+
+// imageLabels returns the labels recording the source of build on the image it builds.
+func imageLabels(build *buildapi.Build) map[string]string {
+	labels := map[string]string{}
+	if git := build.Parameters.Source.Git; git != nil {
+		labels[buildapi.ImageSourceLocationLabel] = git.URI
+		if len(git.Ref) > 0 {
+			labels[buildapi.ImageCommitRefLabel] = git.Ref
+		}
+	}
+	if revision := build.Parameters.Revision; revision != nil && revision.Git != nil && len(revision.Git.Commit) > 0 {
+		labels[buildapi.ImageCommitIDLabel] = revision.Git.Commit
+	}
+	return labels
+}
+
+// labelInstruction returns the Dockerfile LABEL instruction setting labels, or an empty
+// string when there are none.
+func labelInstruction(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := []string{}
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", strconv.Quote(key), strconv.Quote(labels[key])))
+	}
+	return "LABEL " + strings.Join(pairs, " ") + "\n"
+}
@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"net/url"
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
@@ -76,3 +77,65 @@ func TestGetBuildEnvVars(t *testing.T) {
 		}
 	}
 }
+
+func TestLabelInstruction(t *testing.T) {
+	b := &api.Build{
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Git: &api.GitBuildSource{URI: "https://github.com/openshift/ruby-hello-world", Ref: "beta"},
+			},
+			Revision: &api.SourceRevision{
+				Type: api.BuildSourceGit,
+				Git:  &api.GitSourceRevision{Commit: "56789"},
+			},
+		},
+	}
+	expected := `LABEL "io.openshift.build.commit.id"="56789" "io.openshift.build.commit.ref"="beta" "io.openshift.build.source-location"="https://github.com/openshift/ruby-hello-world"` + "\n"
+	if instruction := labelInstruction(imageLabels(b)); instruction != expected {
+		t.Errorf("Expected %q, got %q", expected, instruction)
+	}
+	if instruction := labelInstruction(imageLabels(&api.Build{})); len(instruction) != 0 {
+		t.Errorf("Expected no instruction for a build without source, got %q", instruction)
+	}
+}
+
+func TestSourceProxy(t *testing.T) {
+	git := &api.GitBuildSource{
+		HTTPProxy:  "http://proxy.example.com:3128",
+		HTTPSProxy: "http://secure-proxy.example.com:3128",
+		NoProxy:    "localhost, .internal.example.com,git.example.org",
+	}
+	tests := map[string]string{
+		"http://github.com/my/repo":                    "http://proxy.example.com:3128",
+		"https://github.com/my/repo":                   "http://secure-proxy.example.com:3128",
+		"https://localhost:8443/my/repo":               "",
+		"https://git.internal.example.com/my/repo":     "",
+		"https://internal.example.com/my/repo":         "",
+		"https://git.example.org/my/repo":              "",
+		"https://mirror.git.example.org/my/repo":       "",
+		"https://git.example.org.attacker.com/my/repo": "http://secure-proxy.example.com:3128",
+	}
+	for rawurl, expected := range tests {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", rawurl, err)
+		}
+		if proxy := sourceProxy(git, u); proxy != expected {
+			t.Errorf("%s: expected proxy %q, got %q", rawurl, expected, proxy)
+		}
+	}
+}
+
+func TestProxyEnvVars(t *testing.T) {
+	build := &api.Build{Parameters: api.BuildParameters{Source: api.BuildSource{Git: &api.GitBuildSource{
+		HTTPProxy:  "http://proxy.example.com:3128",
+		HTTPSProxy: "http://proxy.example.com:3128",
+	}}}}
+	env := proxyEnvVars(build)
+	if len(env) != 4 || env["http_proxy"] != "http://proxy.example.com:3128" || env["HTTPS_PROXY"] != "http://proxy.example.com:3128" {
+		t.Errorf("unexpected proxy environment %v", env)
+	}
+	if env := proxyEnvVars(&api.Build{}); len(env) != 0 {
+		t.Errorf("unexpected proxy environment without a Git source %v", env)
+	}
+}
@@ -1,6 +1,11 @@
 package client
 
 import (
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+
 	buildapi "github.com/openshift/origin/pkg/build/api"
 	osclient "github.com/openshift/origin/pkg/client"
 )
@@ -46,6 +51,16 @@ type BuildUpdater interface {
 	Update(namespace string, build *buildapi.Build) error
 }
 
+// BuildGetter provides methods for getting existing Builds.
+type BuildGetter interface {
+	Get(namespace, name string) (*buildapi.Build, error)
+}
+
+// BuildLister provides methods for listing the Builds in a namespace.
+type BuildLister interface {
+	List(namespace string, label labels.Selector) (*buildapi.BuildList, error)
+}
+
 // OSClientBuildClient deletes build create and update operations to the OpenShift client interface
 type OSClientBuildClient struct {
 	Client osclient.Interface
@@ -67,3 +82,34 @@ func (c OSClientBuildClient) Update(namespace string, build *buildapi.Build) err
 	_, e := c.Client.Builds(namespace).Update(build)
 	return e
 }
+
+// Get returns a build using the OpenShift client.
+func (c OSClientBuildClient) Get(namespace, name string) (*buildapi.Build, error) {
+	return c.Client.Builds(namespace).Get(name)
+}
+
+// List lists the builds in a namespace matching label using the OpenShift client.
+func (c OSClientBuildClient) List(namespace string, label labels.Selector) (*buildapi.BuildList, error) {
+	return c.Client.Builds(namespace).List(label, fields.Everything())
+}
+
+// SecretGetter provides methods for getting the Secrets builds refer to.
+type SecretGetter interface {
+	Get(namespace, name string) (*kapi.Secret, error)
+}
+
+// KubeClientSecretGetter delegates get operations to the Kubernetes client interface
+type KubeClientSecretGetter struct {
+	Client kclient.Interface
+}
+
+// NewKubeClientSecretGetter creates a new secret getter that uses a Kubernetes client to get
+// Secrets
+func NewKubeClientSecretGetter(client kclient.Interface) *KubeClientSecretGetter {
+	return &KubeClientSecretGetter{Client: client}
+}
+
+// Get returns a Secret using the Kubernetes client.
+func (c KubeClientSecretGetter) Get(namespace, name string) (*kapi.Secret, error) {
+	return c.Client.Secrets(namespace).Get(name)
+}
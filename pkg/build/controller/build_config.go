@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"fmt"
+
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildclient "github.com/openshift/origin/pkg/build/client"
+)
+
+// maxBuildConfigUpdateAttempts is the number of times the state of the triggers of a BuildConfig
+// is recorded before giving up when the BuildConfig keeps changing.
+const maxBuildConfigUpdateAttempts = 3
+
+// recordTriggerState updates the BuildConfig config after one of its triggers started a build.
+// Creating the build advanced the LastVersion of the stored BuildConfig, so the current version
+// is read again, record sets the state of the triggers on it and it is updated, starting over
+// when the BuildConfig changed meanwhile.
+func recordTriggerState(getter buildclient.BuildConfigGetter, updater buildclient.BuildConfigUpdater, config *buildapi.BuildConfig, record func(*buildapi.BuildConfig)) error {
+	var err error
+	for i := 0; i < maxBuildConfigUpdateAttempts; i++ {
+		var current *buildapi.BuildConfig
+		if current, err = getter.Get(config.Namespace, config.Name); err != nil {
+			return fmt.Errorf("unable to get buildConfig %s/%s: %v", config.Namespace, config.Name, err)
+		}
+		record(current)
+		if err = updater.Update(current); err == nil || !kerrors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
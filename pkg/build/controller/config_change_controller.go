@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/golang/glog"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildclient "github.com/openshift/origin/pkg/build/client"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// ConfigChangeControllerFatalError is returned when the build was started but the change of
+// the config could not be recorded, in which case handling the config must not be retried.
+type ConfigChangeControllerFatalError struct {
+	Reason string
+	Err    error
+}
+
+func (e ConfigChangeControllerFatalError) Error() string {
+	return fmt.Sprintf("fatal error handling BuildConfig change: %s: %v", e.Reason, e.Err)
+}
+
+// ConfigChangeController starts a build of BuildConfigs with a ConfigChange trigger when they
+// are created and whenever their Parameters change.
+type ConfigChangeController struct {
+	BuildCreator       buildclient.BuildCreator
+	BuildConfigGetter  buildclient.BuildConfigGetter
+	BuildConfigUpdater buildclient.BuildConfigUpdater
+}
+
+// HandleBuildConfig starts a build of config if its Parameters differ from the ones the last
+// build of its ConfigChange trigger was created from.
+func (c *ConfigChangeController) HandleBuildConfig(config *buildapi.BuildConfig) error {
+	var trigger *buildapi.BuildTriggerPolicy
+	for i := range config.Triggers {
+		if config.Triggers[i].Type == buildapi.ConfigChangeBuildTriggerType {
+			trigger = &config.Triggers[i]
+			break
+		}
+	}
+	if trigger == nil {
+		return nil
+	}
+
+	hash := parametersHash(&config.Parameters)
+	if trigger.ConfigChange != nil && trigger.ConfigChange.LastTriggeredParametersHash == hash {
+		glog.V(5).Infof("Ignoring buildConfig %s/%s; its parameters did not change", config.Namespace, config.Name)
+		return nil
+	}
+
+	glog.V(4).Infof("Running build for changed buildConfig %s/%s", config.Namespace, config.Name)
+	build := buildutil.GenerateBuildFromConfig(config, nil, nil)
+	build.Causes = []buildapi.BuildCause{{Type: buildapi.ConfigChangeBuildTriggerType}}
+	if err := c.BuildCreator.Create(config.Namespace, build); err != nil {
+		return fmt.Errorf("error starting build for buildConfig %s/%s: %v", config.Namespace, config.Name, err)
+	}
+
+	err := recordTriggerState(c.BuildConfigGetter, c.BuildConfigUpdater, config, func(current *buildapi.BuildConfig) {
+		for i := range current.Triggers {
+			if current.Triggers[i].Type == buildapi.ConfigChangeBuildTriggerType {
+				current.Triggers[i].ConfigChange = &buildapi.ConfigChangeTrigger{LastTriggeredParametersHash: hash}
+			}
+		}
+	})
+	if err != nil {
+		// As for image changes, building the same parameters again is better than retrying
+		// and starting several builds for a single change.
+		return ConfigChangeControllerFatalError{Reason: fmt.Sprintf("error updating buildConfig %s/%s with the parameters of its last build", config.Namespace, config.Name), Err: err}
+	}
+	return nil
+}
+
+// parametersHash returns a hash of the content of parameters.
+func parametersHash(parameters *buildapi.BuildParameters) string {
+	hasher := fnv.New64a()
+	util.DeepHashObject(hasher, *parameters)
+	return fmt.Sprintf("%x", hasher.Sum64())
+}
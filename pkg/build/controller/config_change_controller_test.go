@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+)
+
+func mockConfigChangeBuildConfig() *buildapi.BuildConfig {
+	return &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "testBuildCfg", Namespace: "default"},
+		Parameters: buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type: buildapi.BuildSourceGit,
+				Git:  &buildapi.GitBuildSource{URI: "git://example.com/app.git"},
+			},
+			Strategy: buildapi.BuildStrategy{
+				Type:           buildapi.DockerBuildStrategyType,
+				DockerStrategy: &buildapi.DockerBuildStrategy{},
+			},
+		},
+		Triggers: []buildapi.BuildTriggerPolicy{{Type: buildapi.ConfigChangeBuildTriggerType}},
+	}
+}
+
+func TestConfigChangeBuildsChangedParameters(t *testing.T) {
+	creator := &mockBuildCreator{}
+	updater := &mockBuildConfigUpdater{}
+	config := mockConfigChangeBuildConfig()
+	getter := &mockBuildConfigGetter{buildcfg: config}
+	c := &ConfigChangeController{BuildCreator: creator, BuildConfigGetter: getter, BuildConfigUpdater: updater}
+
+	// a new config is built
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creator.build == nil {
+		t.Fatalf("Expected a build of the new config")
+	}
+	if len(creator.build.Causes) != 1 || creator.build.Causes[0].Type != buildapi.ConfigChangeBuildTriggerType {
+		t.Errorf("Expected the config change to be recorded as the cause, got %#v", creator.build.Causes)
+	}
+	if updater.buildcfg == nil || updater.buildcfg.Triggers[0].ConfigChange == nil || len(updater.buildcfg.Triggers[0].ConfigChange.LastTriggeredParametersHash) == 0 {
+		t.Fatalf("Expected the hash of the built parameters to be recorded, got %#v", updater.buildcfg)
+	}
+
+	// updates of other fields are ignored
+	config = updater.buildcfg
+	getter.buildcfg = config
+	creator.build, updater.buildcfg = nil, nil
+	config.Labels = map[string]string{"updated": "true"}
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creator.build != nil || updater.buildcfg != nil {
+		t.Errorf("Unexpected build %#v or config update %#v", creator.build, updater.buildcfg)
+	}
+
+	// a change of the parameters is built
+	config.Parameters.Source.Git.Ref = "stable"
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creator.build == nil || creator.build.Parameters.Source.Git.Ref != "stable" {
+		t.Errorf("Expected a build of the changed parameters, got %#v", creator.build)
+	}
+}
+
+func TestConfigChangeWithoutTrigger(t *testing.T) {
+	creator := &mockBuildCreator{}
+	c := &ConfigChangeController{BuildCreator: creator, BuildConfigUpdater: &mockBuildConfigUpdater{}}
+	config := mockConfigChangeBuildConfig()
+	config.Triggers = nil
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creator.build != nil {
+		t.Errorf("Unexpected build %#v", creator.build)
+	}
+}
+
+func TestConfigChangeErrors(t *testing.T) {
+	updater := &mockBuildConfigUpdater{}
+	c := &ConfigChangeController{BuildCreator: &mockBuildCreator{err: errors.New("denied")}, BuildConfigUpdater: updater}
+	err := c.HandleBuildConfig(mockConfigChangeBuildConfig())
+	if _, fatal := err.(ConfigChangeControllerFatalError); err == nil || fatal {
+		t.Errorf("Expected a retryable error, got %v", err)
+	}
+	if updater.buildcfg != nil {
+		t.Errorf("Expected the config not to be updated without a build")
+	}
+
+	config := mockConfigChangeBuildConfig()
+	c = &ConfigChangeController{BuildCreator: &mockBuildCreator{}, BuildConfigGetter: &mockBuildConfigGetter{buildcfg: config}, BuildConfigUpdater: &mockBuildConfigUpdater{err: errors.New("conflict")}}
+	err = c.HandleBuildConfig(config)
+	if _, fatal := err.(ConfigChangeControllerFatalError); !fatal {
+		t.Errorf("Expected a fatal error once the build was started, got %v", err)
+	}
+}
+
+func TestConfigChangeUpdatesCurrentBuildConfig(t *testing.T) {
+	config := mockConfigChangeBuildConfig()
+	config.ResourceVersion = "1"
+	current := mockConfigChangeBuildConfig()
+	current.ResourceVersion, current.LastVersion = "2", 1
+	updater := &mockBuildConfigUpdater{resourceVersion: "2"}
+	c := &ConfigChangeController{BuildCreator: &mockBuildCreator{}, BuildConfigGetter: &mockBuildConfigGetter{buildcfg: current}, BuildConfigUpdater: updater}
+
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updater.buildcfg == nil || updater.buildcfg.LastVersion != 1 || updater.buildcfg.Triggers[0].ConfigChange == nil {
+		t.Errorf("Expected the parameters hash to be recorded on the current config, got %#v", updater.buildcfg)
+	}
+}
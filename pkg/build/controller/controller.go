@@ -1,25 +1,33 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/golang/glog"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	errors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/cache"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 
 	buildapi "github.com/openshift/origin/pkg/build/api"
 	buildclient "github.com/openshift/origin/pkg/build/client"
+	"github.com/openshift/origin/pkg/build/prune"
 	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
 // BuildController watches build resources and manages their state
 type BuildController struct {
-	BuildUpdater  buildclient.BuildUpdater
-	PodManager    podManager
-	BuildStrategy BuildStrategy
+	BuildUpdater      buildclient.BuildUpdater
+	BuildLister       buildclient.BuildLister
+	BuildConfigGetter buildclient.BuildConfigGetter
+	BuildDeleter      prune.BuildDeleter
+	PodManager        podManager
+	BuildStrategy     BuildStrategy
 
 	ImageRepositoryClient imageRepositoryClient
 }
@@ -34,6 +42,17 @@ type podManager interface {
 	DeletePod(namespace string, pod *kapi.Pod) error
 }
 
+// BuildLogSaver stores the log of a build so that it outlives the build pod.
+type BuildLogSaver interface {
+	SaveBuildLog(build *buildapi.Build, pod *kapi.Pod) error
+}
+
+// BuildNotifier tells the receivers configured on the BuildConfig of a build that it finished.
+// Notify only queues the notifications, they are delivered in the background.
+type BuildNotifier interface {
+	Notify(build *buildapi.Build)
+}
+
 type imageRepositoryClient interface {
 	GetImageRepository(namespace, name string) (*imageapi.ImageRepository, error)
 }
@@ -41,11 +60,25 @@ type imageRepositoryClient interface {
 func (bc *BuildController) HandleBuild(build *buildapi.Build) error {
 	glog.V(4).Infof("Handling build %s", build.Name)
 
-	// We only deal with new builds here
+	// A finished build may allow the next build of its config to start or older builds
+	// to be pruned, otherwise we only deal with new builds here
 	if build.Status != buildapi.BuildStatusNew {
+		if prune.IsFinished(build) {
+			return bc.handleFinishedBuild(build)
+		}
 		return nil
 	}
 
+	if !build.Cancelled {
+		runnable, err := bc.runPolicyAllowsStart(build)
+		if err != nil {
+			return fmt.Errorf("Failed to check the run policy of build %s/%s: %v", build.Namespace, build.Name, err)
+		}
+		if !runnable {
+			return nil
+		}
+	}
+
 	if err := bc.nextBuildStatus(build); err != nil {
 		// TODO: all build errors should be retried, and build error should not be a permanent status change.
 		// Instead, we should requeue this build request using the same backoff logic as the scheduler.
@@ -64,6 +97,174 @@ func (bc *BuildController) HandleBuild(build *buildapi.Build) error {
 	return nil
 }
 
+// buildConfig returns the BuildConfig the build was created from, or nil if the build has
+// no config or the config no longer exists.
+func (bc *BuildController) buildConfig(build *buildapi.Build) (*buildapi.BuildConfig, error) {
+	configName := build.Labels[buildapi.BuildConfigLabel]
+	if len(configName) == 0 {
+		return nil, nil
+	}
+	config, err := bc.BuildConfigGetter.Get(build.Namespace, configName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+// runPolicy returns the run policy of a BuildConfig. Builds without a config run in parallel.
+func runPolicy(config *buildapi.BuildConfig) buildapi.BuildRunPolicy {
+	if config == nil || len(config.RunPolicy) == 0 {
+		return buildapi.BuildRunPolicyParallel
+	}
+	return config.RunPolicy
+}
+
+// configBuilds returns the other builds created from the same BuildConfig as build.
+func (bc *BuildController) configBuilds(build *buildapi.Build) ([]*buildapi.Build, error) {
+	selector := labels.SelectorFromSet(labels.Set{buildapi.BuildConfigLabel: build.Labels[buildapi.BuildConfigLabel]})
+	list, err := bc.BuildLister.List(build.Namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	builds := []*buildapi.Build{}
+	for i := range list.Items {
+		if list.Items[i].Name != build.Name {
+			builds = append(builds, &list.Items[i])
+		}
+	}
+	return builds, nil
+}
+
+// runPolicyAllowsStart returns true if the run policy of the build's config allows the new build
+// to start now. Serial builds wait for any running build and for older queued builds of the config.
+// With SerialLatestOnly, queued builds superseded by a newer build are cancelled instead.
+func (bc *BuildController) runPolicyAllowsStart(build *buildapi.Build) (bool, error) {
+	config, err := bc.buildConfig(build)
+	if err != nil {
+		return false, err
+	}
+	policy := runPolicy(config)
+	if policy == buildapi.BuildRunPolicyParallel {
+		return true, nil
+	}
+
+	builds, err := bc.configBuilds(build)
+	if err != nil {
+		return false, err
+	}
+	runnable := true
+	for _, other := range builds {
+		switch {
+		case other.Status == buildapi.BuildStatusPending || other.Status == buildapi.BuildStatusRunning:
+			glog.V(4).Infof("Build %s/%s is waiting for build %s to finish", build.Namespace, build.Name, other.Name)
+			runnable = false
+		case other.Status != buildapi.BuildStatusNew || other.Cancelled:
+			continue
+		case policy == buildapi.BuildRunPolicySerialLatestOnly && isBuildOlder(build, other):
+			glog.V(2).Infof("Cancelling build %s/%s, superseded by build %s", build.Namespace, build.Name, other.Name)
+			return false, bc.cancelSupersededBuild(build, other)
+		case policy == buildapi.BuildRunPolicySerialLatestOnly:
+			glog.V(2).Infof("Cancelling build %s/%s, superseded by build %s", other.Namespace, other.Name, build.Name)
+			if err := bc.cancelSupersededBuild(other, build); err != nil {
+				return false, err
+			}
+		case isBuildOlder(other, build):
+			glog.V(4).Infof("Build %s/%s is queued behind build %s", build.Namespace, build.Name, other.Name)
+			runnable = false
+		}
+	}
+	return runnable, nil
+}
+
+// handleFinishedBuild starts the next queued build of a serially run config and deletes the
+// builds beyond the history limits of the config once a build has finished.
+func (bc *BuildController) handleFinishedBuild(finished *buildapi.Build) error {
+	config, err := bc.buildConfig(finished)
+	if err != nil || config == nil {
+		return err
+	}
+	serial := runPolicy(config) != buildapi.BuildRunPolicyParallel
+	limited := config.SuccessfulBuildsHistoryLimit != nil || config.FailedBuildsHistoryLimit != nil
+	if !serial && !limited {
+		return nil
+	}
+
+	builds, err := bc.configBuilds(finished)
+	if err != nil {
+		return err
+	}
+	if serial {
+		if err := bc.startNextSerialBuild(finished, builds); err != nil {
+			return err
+		}
+	}
+	if limited {
+		return bc.pruneBuildHistory(config, append(builds, finished))
+	}
+	return nil
+}
+
+// HandleDeletedBuild starts the next queued build of a serially run config when a build that
+// had not finished is deleted, since no completion will ever start it.
+func (bc *BuildController) HandleDeletedBuild(deleted *buildapi.Build) error {
+	if prune.IsFinished(deleted) || deleted.Cancelled {
+		return nil
+	}
+	config, err := bc.buildConfig(deleted)
+	if err != nil || config == nil || runPolicy(config) == buildapi.BuildRunPolicyParallel {
+		return err
+	}
+	builds, err := bc.configBuilds(deleted)
+	if err != nil {
+		return err
+	}
+	return bc.startNextSerialBuild(deleted, builds)
+}
+
+// pruneBuildHistory deletes the builds of a config beyond its history limits, oldest first.
+func (bc *BuildController) pruneBuildHistory(config *buildapi.BuildConfig, builds []*buildapi.Build) error {
+	for _, build := range prune.BuildsOverLimit(builds, config.SuccessfulBuildsHistoryLimit, config.FailedBuildsHistoryLimit) {
+		glog.V(2).Infof("Deleting build %s/%s beyond the history limits of %s", build.Namespace, build.Name, config.Name)
+		if err := bc.BuildDeleter.DeleteBuild(build); err != nil {
+			return fmt.Errorf("Failed to delete build %s/%s: %v", build.Namespace, build.Name, err)
+		}
+	}
+	return nil
+}
+
+// startNextSerialBuild starts the oldest queued build of a serially run config once the build
+// that was running has finished or was deleted.
+func (bc *BuildController) startNextSerialBuild(finished *buildapi.Build, builds []*buildapi.Build) error {
+	var next *buildapi.Build
+	for _, other := range builds {
+		if other.Status != buildapi.BuildStatusNew || other.Cancelled {
+			continue
+		}
+		if next == nil || isBuildOlder(other, next) {
+			next = other
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	glog.V(4).Infof("Build %s/%s is gone, handling the next build %s", finished.Namespace, finished.Name, next.Name)
+	return bc.HandleBuild(next)
+}
+
+// cancelSupersededBuild cancels a build that has not started because a newer build of its
+// config was queued.
+func (bc *BuildController) cancelSupersededBuild(build, newer *buildapi.Build) error {
+	build.Cancelled = true
+	build.Status = buildapi.BuildStatusCancelled
+	build.Message = fmt.Sprintf("Superseded by build %s", newer.Name)
+	now := util.Now()
+	build.CompletionTimestamp = &now
+	return bc.BuildUpdater.Update(build.Namespace, build)
+}
+
 // nextBuildStatus updates build with any appropriate changes, or returns an error if
 // the change cannot occur. When returning nil, be sure to set build.Status and optionally
 // build.Message.
@@ -98,6 +299,30 @@ func (bc *BuildController) nextBuildStatus(build *buildapi.Build) error {
 		}
 	}
 
+	// resolve the images of image sources not resolved by the image change trigger of the build
+	for i := range build.Parameters.Source.Images {
+		source := &build.Parameters.Source.Images[i]
+		if source.From == nil || len(source.DockerImageReference) > 0 {
+			continue
+		}
+		namespace := source.From.Namespace
+		if len(namespace) == 0 {
+			namespace = build.Namespace
+		}
+		repo, err := bc.ImageRepositoryClient.GetImageRepository(namespace, source.From.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Errorf("the referenced source image repository %s/%s does not exist", namespace, source.From.Name)
+			}
+			return fmt.Errorf("the referenced source repo %s/%s could not be found by %s/%s: %v", namespace, source.From.Name, build.Namespace, build.Name, err)
+		}
+		latest, err := imageapi.LatestTaggedImage(repo, source.Tag)
+		if err != nil {
+			return fmt.Errorf("the referenced source image repository %s/%s has no image: %v", namespace, source.From.Name, err)
+		}
+		source.DockerImageReference = latest.DockerImageReference
+	}
+
 	// set the expected build parameters, which will be saved if no error occurs
 	build.Status = buildapi.BuildStatusPending
 	build.PodName = fmt.Sprintf("build-%s", build.Name)
@@ -134,6 +359,8 @@ type BuildPodController struct {
 	BuildStore   cache.Store
 	BuildUpdater buildclient.BuildUpdater
 	PodManager   podManager
+	LogSaver     BuildLogSaver
+	Notifier     BuildNotifier
 }
 
 func (bc *BuildPodController) HandlePod(pod *kapi.Pod) error {
@@ -161,6 +388,17 @@ func (bc *BuildPodController) HandlePod(pod *kapi.Pod) error {
 		return nil
 	}
 
+	// Kill builds that did not finish within their completion deadline.
+	// TODO: once pods carry ActiveDeadlineSeconds, the kubelet kills them and this becomes a
+	// mapping of a DeadlineExceeded pod to a Failed build.
+	if deadline, exceeded := completionDeadlineExceeded(pod); exceeded && (build.Status == buildapi.BuildStatusPending || build.Status == buildapi.BuildStatusRunning) {
+		glog.V(2).Infof("Build %s timed out after %v, deleting pod %s", build.Name, deadline, pod.Name)
+		if err := bc.timeOutBuild(build, pod, deadline); err != nil {
+			return fmt.Errorf("Failed to time out build %s: %#v, will retry", build.Name, err)
+		}
+		return nil
+	}
+
 	nextStatus := build.Status
 
 	switch pod.Status.Phase {
@@ -182,6 +420,10 @@ func (bc *BuildPodController) HandlePod(pod *kapi.Pod) error {
 		glog.V(4).Infof("Updating build %s status %s -> %s", build.Name, build.Status, nextStatus)
 		build.Status = nextStatus
 		if build.Status == buildapi.BuildStatusComplete || build.Status == buildapi.BuildStatusFailed || build.Status == buildapi.BuildStatusCancelled {
+			if revision := reportedRevision(pod); revision != nil {
+				build.Parameters.Revision = revision
+			}
+			bc.saveBuildLog(build, pod)
 			dummy := util.Now()
 			build.CompletionTimestamp = &dummy
 		}
@@ -192,10 +434,87 @@ func (bc *BuildPodController) HandlePod(pod *kapi.Pod) error {
 		if err := bc.BuildUpdater.Update(build.Namespace, build); err != nil {
 			return fmt.Errorf("Failed to update build %s: %#v", build.Name, err)
 		}
+		bc.notify(build)
 	}
 	return nil
 }
 
+// timeOutBuild deletes the pod of a build that exceeded its completion deadline and fails the build.
+func (bc *BuildPodController) timeOutBuild(build *buildapi.Build, pod *kapi.Pod, deadline time.Duration) error {
+	bc.saveBuildLog(build, pod)
+	err := bc.PodManager.DeletePod(build.Namespace, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	build.Status = buildapi.BuildStatusFailed
+	build.Reason = buildapi.BuildStatusReasonTimedOut
+	build.Message = fmt.Sprintf("Build timed out: it did not complete within %v", deadline)
+	now := util.Now()
+	build.CompletionTimestamp = &now
+	if err := bc.BuildUpdater.Update(build.Namespace, build); err != nil {
+		return err
+	}
+	bc.notify(build)
+	return nil
+}
+
+// saveBuildLog stores the log of a build before its pod goes away. Failures are only logged,
+// the log is then served from the pod for as long as the pod exists.
+func (bc *BuildPodController) saveBuildLog(build *buildapi.Build, pod *kapi.Pod) {
+	if bc.LogSaver == nil || pod.Status.Phase == kapi.PodPending || pod.Status.Phase == kapi.PodUnknown {
+		return
+	}
+	if err := bc.LogSaver.SaveBuildLog(build, pod); err != nil {
+		glog.V(2).Infof("Unable to save the log of build %s: %v", build.Name, err)
+	}
+}
+
+// notify queues the completion notifications of a build that just finished.
+func (bc *BuildPodController) notify(build *buildapi.Build) {
+	if bc.Notifier != nil && prune.IsFinished(build) {
+		bc.Notifier.Notify(build)
+	}
+}
+
+// reportedRevision returns the source revision the builder resolved and reported in the
+// termination message of its container, or nil if there is none.
+func reportedRevision(pod *kapi.Pod) *buildapi.SourceRevision {
+	for _, info := range pod.Status.Info {
+		if info.State.Termination == nil || len(info.State.Termination.Message) == 0 {
+			continue
+		}
+		revision := &buildapi.SourceRevision{}
+		if err := json.Unmarshal([]byte(info.State.Termination.Message), revision); err != nil {
+			glog.V(4).Infof("Ignoring termination message of pod %s that is not a source revision: %v", pod.Name, err)
+			continue
+		}
+		if revision.Type == buildapi.BuildSourceGit && revision.Git != nil && len(revision.Git.Commit) > 0 {
+			return revision
+		}
+	}
+	return nil
+}
+
+// completionDeadlineExceeded returns the completion deadline recorded on a pod that has not
+// finished, and whether the pod has existed for longer than it.
+func completionDeadlineExceeded(pod *kapi.Pod) (time.Duration, bool) {
+	if pod.Status.Phase == kapi.PodSucceeded || pod.Status.Phase == kapi.PodFailed || pod.CreationTimestamp.IsZero() {
+		return 0, false
+	}
+	value, ok := pod.Annotations[buildapi.BuildCompletionDeadlineAnnotation]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		glog.V(2).Infof("Ignoring invalid completion deadline %q of pod %s: %v", value, pod.Name, err)
+		return 0, false
+	}
+	deadline := time.Duration(seconds) * time.Second
+	return deadline, time.Since(pod.CreationTimestamp.Time) > deadline
+}
+
 // CancelBuild updates a build status to Cancelled, after its associated pod is deleted.
 func (bc *BuildPodController) CancelBuild(build *buildapi.Build, pod *kapi.Pod) error {
 	if !isBuildCancellable(build) {
@@ -203,6 +522,7 @@ func (bc *BuildPodController) CancelBuild(build *buildapi.Build, pod *kapi.Pod)
 		return nil
 	}
 
+	bc.saveBuildLog(build, pod)
 	err := bc.PodManager.DeletePod(build.Namespace, pod)
 	if err != nil && !errors.IsNotFound(err) {
 		return err
@@ -214,11 +534,24 @@ func (bc *BuildPodController) CancelBuild(build *buildapi.Build, pod *kapi.Pod)
 	if err := bc.BuildUpdater.Update(build.Namespace, build); err != nil {
 		return err
 	}
+	bc.notify(build)
 
 	glog.V(2).Infof("Build %s was successfully cancelled.", build.Name)
 	return nil
 }
 
+// isBuildOlder returns true if a was created before b from the same BuildConfig, using the build
+// numbers when both are set.
+func isBuildOlder(a, b *buildapi.Build) bool {
+	if a.Number != 0 && b.Number != 0 {
+		return a.Number < b.Number
+	}
+	if !a.CreationTimestamp.Equal(b.CreationTimestamp.Time) {
+		return a.CreationTimestamp.Before(b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
 // isBuildCancellable checks for build status and returns true if the condition is checked.
 func isBuildCancellable(build *buildapi.Build) bool {
 	return build.Status == buildapi.BuildStatusNew || build.Status == buildapi.BuildStatusPending || build.Status == buildapi.BuildStatusRunning
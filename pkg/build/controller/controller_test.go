@@ -4,9 +4,11 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 
 	buildapi "github.com/openshift/origin/pkg/build/api"
@@ -344,6 +346,288 @@ func TestHandleBuild(t *testing.T) {
 	}
 }
 
+type taggedImageRepositoryClient struct {
+	namespace string
+}
+
+func (c *taggedImageRepositoryClient) GetImageRepository(namespace, name string) (*imageapi.ImageRepository, error) {
+	c.namespace = namespace
+	return &imageapi.ImageRepository{
+		ObjectMeta: kapi.ObjectMeta{Name: name, Namespace: namespace},
+		Status: imageapi.ImageRepositoryStatus{
+			DockerImageRepository: "registry/" + name,
+			Tags: map[string]imageapi.TagEventList{
+				"latest": {Items: []imageapi.TagEvent{{DockerImageReference: "registry/" + name + "@sha256:1234"}}},
+			},
+		},
+	}, nil
+}
+
+func TestHandleBuildResolvesImageSources(t *testing.T) {
+	build := mockBuild(buildapi.BuildStatusNew, buildapi.BuildOutput{DockerImageReference: "repository/dataBuild"})
+	build.Parameters.Source.Images = []buildapi.ImageSource{
+		{From: &kapi.ObjectReference{Name: "artifacts"}, Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts"}}},
+		// set by the image change trigger that created the build
+		{From: &kapi.ObjectReference{Name: "triggered"}, DockerImageReference: "registry/triggered@sha256:5678", Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts"}}},
+	}
+	ctrl := mockBuildController()
+	client := &taggedImageRepositoryClient{}
+	ctrl.ImageRepositoryClient = client
+
+	if err := ctrl.HandleBuild(build); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if client.namespace != build.Namespace {
+		t.Errorf("Expected the repository to be looked up in the namespace of the build, got %q", client.namespace)
+	}
+	images := ctrl.BuildStrategy.(*okStrategy).build.Parameters.Source.Images
+	if images[0].DockerImageReference != "registry/artifacts@sha256:1234" {
+		t.Errorf("Expected the latest image of the repository, got %q", images[0].DockerImageReference)
+	}
+	if images[1].DockerImageReference != "registry/triggered@sha256:5678" {
+		t.Errorf("Expected the triggering image to be kept, got %q", images[1].DockerImageReference)
+	}
+
+	build = mockBuild(buildapi.BuildStatusNew, buildapi.BuildOutput{DockerImageReference: "repository/dataBuild"})
+	build.Parameters.Source.Images = []buildapi.ImageSource{{From: &kapi.ObjectReference{Name: "artifacts"}, Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts"}}}}
+	ctrl = mockBuildController()
+	ctrl.ImageRepositoryClient = &errNotFoundImageRepositoryClient{}
+	if err := ctrl.HandleBuild(build); err == nil {
+		t.Errorf("Expected an error for a missing source image repository")
+	}
+}
+
+type recordingBuildUpdater struct {
+	updated map[string]*buildapi.Build
+}
+
+func (r *recordingBuildUpdater) Update(namespace string, build *buildapi.Build) error {
+	r.updated[build.Name] = build
+	return nil
+}
+
+type fakeBuildLister struct {
+	builds []buildapi.Build
+}
+
+func (l *fakeBuildLister) List(namespace string, label labels.Selector) (*buildapi.BuildList, error) {
+	return &buildapi.BuildList{Items: l.builds}, nil
+}
+
+type fakeBuildConfigGetter struct {
+	config *buildapi.BuildConfig
+}
+
+func (g *fakeBuildConfigGetter) Get(namespace, name string) (*buildapi.BuildConfig, error) {
+	return g.config, nil
+}
+
+func mockConfigBuild(name string, number int, status buildapi.BuildStatus) buildapi.Build {
+	build := mockBuild(status, buildapi.BuildOutput{DockerImageReference: "repository/dataBuild"})
+	build.Name = name
+	build.Labels = map[string]string{buildapi.BuildConfigLabel: "config"}
+	build.Number = number
+	return *build
+}
+
+func TestHandleBuildRunPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy    buildapi.BuildRunPolicy
+		build     buildapi.Build
+		others    []buildapi.Build
+		started   string
+		cancelled []string
+	}{
+		"parallel starts beside a running build": {
+			policy:  buildapi.BuildRunPolicyParallel,
+			build:   mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			others:  []buildapi.Build{mockConfigBuild("build-1", 1, buildapi.BuildStatusRunning)},
+			started: "build-2",
+		},
+		"serial waits for a running build": {
+			policy: buildapi.BuildRunPolicySerial,
+			build:  mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			others: []buildapi.Build{mockConfigBuild("build-1", 1, buildapi.BuildStatusRunning)},
+		},
+		"serial waits for an older queued build": {
+			policy: buildapi.BuildRunPolicySerial,
+			build:  mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			others: []buildapi.Build{mockConfigBuild("build-1", 1, buildapi.BuildStatusNew)},
+		},
+		"serial starts the oldest queued build": {
+			policy: buildapi.BuildRunPolicySerial,
+			build:  mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			others: []buildapi.Build{
+				mockConfigBuild("build-1", 1, buildapi.BuildStatusComplete),
+				mockConfigBuild("build-3", 3, buildapi.BuildStatusNew),
+			},
+			started: "build-2",
+		},
+		"serial latest only cancels older queued builds": {
+			policy: buildapi.BuildRunPolicySerialLatestOnly,
+			build:  mockConfigBuild("build-3", 3, buildapi.BuildStatusNew),
+			others: []buildapi.Build{
+				mockConfigBuild("build-1", 1, buildapi.BuildStatusNew),
+				mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			},
+			started:   "build-3",
+			cancelled: []string{"build-1", "build-2"},
+		},
+		"serial latest only cancels a superseded build": {
+			policy:    buildapi.BuildRunPolicySerialLatestOnly,
+			build:     mockConfigBuild("build-1", 1, buildapi.BuildStatusNew),
+			others:    []buildapi.Build{mockConfigBuild("build-2", 2, buildapi.BuildStatusNew)},
+			cancelled: []string{"build-1"},
+		},
+		"serial latest only waits for a running build": {
+			policy: buildapi.BuildRunPolicySerialLatestOnly,
+			build:  mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			others: []buildapi.Build{mockConfigBuild("build-1", 1, buildapi.BuildStatusRunning)},
+		},
+		"finished build starts the next queued build": {
+			policy: buildapi.BuildRunPolicySerial,
+			build:  mockConfigBuild("build-1", 1, buildapi.BuildStatusComplete),
+			others: []buildapi.Build{
+				mockConfigBuild("build-3", 3, buildapi.BuildStatusNew),
+				mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+			},
+			started: "build-2",
+		},
+	}
+
+	for name, test := range tests {
+		updater := &recordingBuildUpdater{updated: make(map[string]*buildapi.Build)}
+		ctrl := mockBuildController()
+		ctrl.BuildUpdater = updater
+		ctrl.BuildLister = &fakeBuildLister{append([]buildapi.Build{test.build}, test.others...)}
+		ctrl.BuildConfigGetter = &fakeBuildConfigGetter{&buildapi.BuildConfig{
+			ObjectMeta: kapi.ObjectMeta{Name: "config", Namespace: "namespace"},
+			RunPolicy:  test.policy,
+		}}
+
+		build := test.build
+		if err := ctrl.HandleBuild(&build); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+
+		expected := len(test.cancelled)
+		if len(test.started) > 0 {
+			expected++
+			if b, ok := updater.updated[test.started]; !ok || b.Status != buildapi.BuildStatusPending {
+				t.Errorf("%s: expected build %s to be started, got %#v", name, test.started, b)
+			}
+		}
+		for _, cancelled := range test.cancelled {
+			if b, ok := updater.updated[cancelled]; !ok || b.Status != buildapi.BuildStatusCancelled || !b.Cancelled {
+				t.Errorf("%s: expected build %s to be cancelled, got %#v", name, cancelled, b)
+			}
+		}
+		if len(updater.updated) != expected {
+			t.Errorf("%s: expected %d updated builds, got %v", name, expected, updater.updated)
+		}
+	}
+}
+
+func TestHandleDeletedBuild(t *testing.T) {
+	tests := map[string]struct {
+		policy  buildapi.BuildRunPolicy
+		deleted buildapi.Build
+		started string
+	}{
+		"deleted running build starts the next queued build": {
+			policy:  buildapi.BuildRunPolicySerial,
+			deleted: mockConfigBuild("build-1", 1, buildapi.BuildStatusRunning),
+			started: "build-2",
+		},
+		"deleted queued build starts the next queued build": {
+			policy:  buildapi.BuildRunPolicySerial,
+			deleted: mockConfigBuild("build-1", 1, buildapi.BuildStatusNew),
+			started: "build-2",
+		},
+		"deleted finished build": {
+			policy:  buildapi.BuildRunPolicySerial,
+			deleted: mockConfigBuild("build-1", 1, buildapi.BuildStatusComplete),
+		},
+		"parallel": {
+			policy:  buildapi.BuildRunPolicyParallel,
+			deleted: mockConfigBuild("build-1", 1, buildapi.BuildStatusRunning),
+		},
+	}
+
+	for name, test := range tests {
+		updater := &recordingBuildUpdater{updated: make(map[string]*buildapi.Build)}
+		ctrl := mockBuildController()
+		ctrl.BuildUpdater = updater
+		ctrl.BuildLister = &fakeBuildLister{[]buildapi.Build{
+			mockConfigBuild("build-3", 3, buildapi.BuildStatusNew),
+			mockConfigBuild("build-2", 2, buildapi.BuildStatusNew),
+		}}
+		ctrl.BuildConfigGetter = &fakeBuildConfigGetter{&buildapi.BuildConfig{
+			ObjectMeta: kapi.ObjectMeta{Name: "config", Namespace: "namespace"},
+			RunPolicy:  test.policy,
+		}}
+
+		if err := ctrl.HandleDeletedBuild(&test.deleted); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if len(test.started) == 0 {
+			if len(updater.updated) != 0 {
+				t.Errorf("%s: expected no builds to be updated, got %v", name, updater.updated)
+			}
+			continue
+		}
+		if b, ok := updater.updated[test.started]; !ok || b.Status != buildapi.BuildStatusPending || len(updater.updated) != 1 {
+			t.Errorf("%s: expected only build %s to be started, got %v", name, test.started, updater.updated)
+		}
+	}
+}
+
+type recordingBuildDeleter struct {
+	deleted []string
+}
+
+func (d *recordingBuildDeleter) DeleteBuild(build *buildapi.Build) error {
+	d.deleted = append(d.deleted, build.Name)
+	return nil
+}
+
+func TestHandleBuildHistoryLimits(t *testing.T) {
+	keepComplete, keepFailed := 1, 0
+	finished := mockConfigBuild("build-4", 4, buildapi.BuildStatusComplete)
+	builds := []buildapi.Build{
+		finished,
+		mockConfigBuild("build-1", 1, buildapi.BuildStatusComplete),
+		mockConfigBuild("build-2", 2, buildapi.BuildStatusFailed),
+		mockConfigBuild("build-3", 3, buildapi.BuildStatusComplete),
+		mockConfigBuild("build-5", 5, buildapi.BuildStatusRunning),
+	}
+	for i := range builds {
+		completed := util.Unix(int64(builds[i].Number), 0)
+		builds[i].CompletionTimestamp = &completed
+	}
+	finished.CompletionTimestamp = builds[0].CompletionTimestamp
+
+	deleter := &recordingBuildDeleter{}
+	ctrl := mockBuildController()
+	ctrl.BuildDeleter = deleter
+	ctrl.BuildLister = &fakeBuildLister{builds}
+	ctrl.BuildConfigGetter = &fakeBuildConfigGetter{&buildapi.BuildConfig{
+		ObjectMeta:                   kapi.ObjectMeta{Name: "config", Namespace: "namespace"},
+		SuccessfulBuildsHistoryLimit: &keepComplete,
+		FailedBuildsHistoryLimit:     &keepFailed,
+	}}
+
+	if err := ctrl.HandleBuild(&finished); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"build-3", "build-1", "build-2"}
+	if !reflect.DeepEqual(deleter.deleted, expected) {
+		t.Errorf("Expected builds %v to be deleted, got %v", expected, deleter.deleted)
+	}
+}
+
 func TestHandlePod(t *testing.T) {
 	type handlePodTest struct {
 		matchID             bool
@@ -463,6 +747,83 @@ func TestHandlePod(t *testing.T) {
 	}
 }
 
+type recordingPodManager struct {
+	okPodManager
+	deleted []string
+}
+
+func (m *recordingPodManager) DeletePod(namespace string, pod *kapi.Pod) error {
+	m.deleted = append(m.deleted, pod.Name)
+	return nil
+}
+
+func TestHandlePodCompletionDeadline(t *testing.T) {
+	tests := map[string]struct {
+		phase     kapi.PodPhase
+		deadline  string
+		outStatus buildapi.BuildStatus
+		outReason buildapi.BuildStatusReason
+		deleted   bool
+	}{
+		"running past the deadline": {
+			phase:     kapi.PodRunning,
+			deadline:  "60",
+			outStatus: buildapi.BuildStatusFailed,
+			outReason: buildapi.BuildStatusReasonTimedOut,
+			deleted:   true,
+		},
+		"pending past the deadline": {
+			phase:     kapi.PodPending,
+			deadline:  "60",
+			outStatus: buildapi.BuildStatusFailed,
+			outReason: buildapi.BuildStatusReasonTimedOut,
+			deleted:   true,
+		},
+		"running within the deadline": {
+			phase:     kapi.PodRunning,
+			deadline:  "600",
+			outStatus: buildapi.BuildStatusRunning,
+		},
+		"finished past the deadline": {
+			phase:     kapi.PodSucceeded,
+			deadline:  "60",
+			outStatus: buildapi.BuildStatusComplete,
+		},
+		"without a deadline": {
+			phase:     kapi.PodRunning,
+			outStatus: buildapi.BuildStatusRunning,
+		},
+	}
+
+	for name, test := range tests {
+		build := mockBuild(buildapi.BuildStatusRunning, buildapi.BuildOutput{})
+		ctrl := mockBuildPodController(build)
+		podManager := &recordingPodManager{}
+		ctrl.PodManager = podManager
+
+		pod := mockPod(test.phase, 0)
+		pod.Name = build.PodName
+		pod.CreationTimestamp = util.NewTime(time.Now().Add(-2 * time.Minute))
+		if len(test.deadline) > 0 {
+			pod.Annotations = map[string]string{buildapi.BuildCompletionDeadlineAnnotation: test.deadline}
+		}
+
+		if err := ctrl.HandlePod(pod); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if build.Status != test.outStatus || build.Reason != test.outReason {
+			t.Errorf("%s: expected status %s with reason %q, got %s with %q", name, test.outStatus, test.outReason, build.Status, build.Reason)
+		}
+		if deleted := len(podManager.deleted) > 0; deleted != test.deleted {
+			t.Errorf("%s: expected pod deleted to be %v, got %v", name, test.deleted, podManager.deleted)
+		}
+		if test.deleted && build.CompletionTimestamp == nil {
+			t.Errorf("%s: expected the completion timestamp to be set", name)
+		}
+	}
+}
+
 func TestCancelBuild(t *testing.T) {
 	type handleCancelBuildTest struct {
 		inStatus            buildapi.BuildStatus
@@ -589,3 +950,164 @@ func TestCancelBuild(t *testing.T) {
 		}
 	}
 }
+
+type recordingLogSaver struct {
+	saved []string
+}
+
+func (s *recordingLogSaver) SaveBuildLog(build *buildapi.Build, pod *kapi.Pod) error {
+	s.saved = append(s.saved, build.Name)
+	return nil
+}
+
+func TestHandlePodSavesBuildLog(t *testing.T) {
+	tests := map[string]struct {
+		inStatus  buildapi.BuildStatus
+		phase     kapi.PodPhase
+		exitCode  int
+		cancelled bool
+		saved     bool
+	}{
+		"running": {
+			inStatus: buildapi.BuildStatusRunning,
+			phase:    kapi.PodRunning,
+		},
+		"complete": {
+			inStatus: buildapi.BuildStatusRunning,
+			phase:    kapi.PodSucceeded,
+			saved:    true,
+		},
+		"failed": {
+			inStatus: buildapi.BuildStatusRunning,
+			phase:    kapi.PodFailed,
+			exitCode: 1,
+			saved:    true,
+		},
+		"already complete": {
+			inStatus: buildapi.BuildStatusComplete,
+			phase:    kapi.PodSucceeded,
+		},
+		"cancelled while running": {
+			inStatus:  buildapi.BuildStatusRunning,
+			phase:     kapi.PodRunning,
+			cancelled: true,
+			saved:     true,
+		},
+		"cancelled while pending": {
+			inStatus:  buildapi.BuildStatusPending,
+			phase:     kapi.PodPending,
+			cancelled: true,
+		},
+	}
+
+	for name, test := range tests {
+		build := mockBuild(test.inStatus, buildapi.BuildOutput{})
+		build.Cancelled = test.cancelled
+		ctrl := mockBuildPodController(build)
+		saver := &recordingLogSaver{}
+		ctrl.LogSaver = saver
+
+		pod := mockPod(test.phase, test.exitCode)
+		pod.Name = build.PodName
+		if err := ctrl.HandlePod(pod); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if saved := len(saver.saved) == 1; saved != test.saved {
+			t.Errorf("%s: expected the log to be saved: %v, got %v", name, test.saved, saver.saved)
+		}
+	}
+}
+
+type recordingNotifier struct {
+	notified []buildapi.BuildStatus
+}
+
+func (n *recordingNotifier) Notify(build *buildapi.Build) {
+	n.notified = append(n.notified, build.Status)
+}
+
+func TestHandlePodNotifiesFinishedBuilds(t *testing.T) {
+	tests := map[string]struct {
+		inStatus  buildapi.BuildStatus
+		phase     kapi.PodPhase
+		exitCode  int
+		cancelled bool
+		notified  buildapi.BuildStatus
+	}{
+		"running": {
+			inStatus: buildapi.BuildStatusPending,
+			phase:    kapi.PodRunning,
+		},
+		"complete": {
+			inStatus: buildapi.BuildStatusRunning,
+			phase:    kapi.PodSucceeded,
+			notified: buildapi.BuildStatusComplete,
+		},
+		"failed": {
+			inStatus: buildapi.BuildStatusRunning,
+			phase:    kapi.PodFailed,
+			exitCode: 1,
+			notified: buildapi.BuildStatusFailed,
+		},
+		"already complete": {
+			inStatus: buildapi.BuildStatusComplete,
+			phase:    kapi.PodSucceeded,
+		},
+		"cancelled": {
+			inStatus:  buildapi.BuildStatusRunning,
+			phase:     kapi.PodRunning,
+			cancelled: true,
+			notified:  buildapi.BuildStatusCancelled,
+		},
+	}
+
+	for name, test := range tests {
+		build := mockBuild(test.inStatus, buildapi.BuildOutput{})
+		build.Cancelled = test.cancelled
+		ctrl := mockBuildPodController(build)
+		notifier := &recordingNotifier{}
+		ctrl.Notifier = notifier
+
+		pod := mockPod(test.phase, test.exitCode)
+		pod.Name = build.PodName
+		if err := ctrl.HandlePod(pod); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if len(test.notified) == 0 {
+			if len(notifier.notified) != 0 {
+				t.Errorf("%s: unexpected notifications %v", name, notifier.notified)
+			}
+			continue
+		}
+		if len(notifier.notified) != 1 || notifier.notified[0] != test.notified {
+			t.Errorf("%s: expected a notification of status %s, got %v", name, test.notified, notifier.notified)
+		}
+	}
+}
+
+func TestHandlePodRecordsReportedRevision(t *testing.T) {
+	build := mockBuild(buildapi.BuildStatusRunning, buildapi.BuildOutput{})
+	ctrl := mockBuildPodController(build)
+	pod := mockPod(kapi.PodSucceeded, 0)
+	pod.Name = build.PodName
+	info := pod.Status.Info["container1"]
+	info.State.Termination.Message = `{"type":"Git","git":{"commit":"abcdef","author":{"name":"John Doe","email":"jdoe@example.com"},"message":"Fix the build"}}`
+	pod.Status.Info["container1"] = info
+
+	if err := ctrl.HandlePod(pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	revision := build.Parameters.Revision
+	if revision == nil || revision.Git == nil || revision.Git.Commit != "abcdef" || revision.Git.Author.Name != "John Doe" || revision.Git.Message != "Fix the build" {
+		t.Errorf("Unexpected revision %#v", revision)
+	}
+
+	for _, message := range []string{"", "build failed", `{"type":"Git"}`} {
+		pod.Status.Info["container1"] = kapi.ContainerStatus{State: kapi.ContainerState{Termination: &kapi.ContainerStateTerminated{Message: message}}}
+		if revision := reportedRevision(pod); revision != nil {
+			t.Errorf("%q: unexpected revision %#v", message, revision)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package factory
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang/glog"
@@ -19,8 +20,10 @@ import (
 	buildclient "github.com/openshift/origin/pkg/build/client"
 	buildcontroller "github.com/openshift/origin/pkg/build/controller"
 	strategy "github.com/openshift/origin/pkg/build/controller/strategy"
+	"github.com/openshift/origin/pkg/build/prune"
 	osclient "github.com/openshift/origin/pkg/client"
 	controller "github.com/openshift/origin/pkg/controller"
+	"github.com/openshift/origin/pkg/generate/git"
 	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
@@ -38,27 +41,43 @@ type BuildControllerFactory struct {
 	DockerBuildStrategy *strategy.DockerBuildStrategy
 	STIBuildStrategy    *strategy.STIBuildStrategy
 	CustomBuildStrategy *strategy.CustomBuildStrategy
+	// BuildDefaults, if set, are applied to the build pods created by the strategies.
+	BuildDefaults *strategy.BuildDefaults
 	// Stop may be set to allow controllers created by this factory to be terminated.
 	Stop <-chan struct{}
 }
 
 // Create constructs a BuildController
 func (factory *BuildControllerFactory) Create() controller.RunnableController {
-	queue := cache.NewFIFO(cache.MetaNamespaceKeyFunc)
-	cache.NewReflector(&buildLW{client: factory.OSClient}, &buildapi.Build{}, queue, 2*time.Minute).Run()
-
 	client := ControllerClient{factory.KubeClient, factory.OSClient}
 	buildController := &buildcontroller.BuildController{
 		BuildUpdater:          factory.BuildUpdater,
+		BuildLister:           buildclient.NewOSClientBuildClient(factory.OSClient),
+		BuildConfigGetter:     buildclient.NewOSClientBuildConfigClient(factory.OSClient),
+		BuildDeleter:          prune.NewBuildDeleter(factory.OSClient, factory.KubeClient),
 		ImageRepositoryClient: client,
 		PodManager:            client,
 		BuildStrategy: &typeBasedFactoryStrategy{
 			DockerBuildStrategy: factory.DockerBuildStrategy,
 			STIBuildStrategy:    factory.STIBuildStrategy,
 			CustomBuildStrategy: factory.CustomBuildStrategy,
+			BuildDefaults:       factory.BuildDefaults,
 		},
 	}
 
+	// The resync replaces the queue contents, which hands the builds queued by a serial run
+	// policy to the controller again. Deleted builds are handed to the controller as well so
+	// that the builds queued behind them can start.
+	queue := &buildQueue{
+		FIFO: cache.NewFIFO(cache.MetaNamespaceKeyFunc),
+		deleted: func(build *buildapi.Build) {
+			if err := buildController.HandleDeletedBuild(build); err != nil {
+				kutil.HandleError(fmt.Errorf("unable to handle the deletion of build %s/%s: %v", build.Namespace, build.Name, err))
+			}
+		},
+	}
+	cache.NewReflector(&buildLW{client: factory.OSClient}, &buildapi.Build{}, queue, 2*time.Minute).Run()
+
 	return &controller.RetryController{
 		Queue:        queue,
 		RetryManager: controller.NewQueueRetryManager(queue, cache.MetaNamespaceKeyFunc, logAndRetry),
@@ -69,11 +88,32 @@ func (factory *BuildControllerFactory) Create() controller.RunnableController {
 	}
 }
 
+// buildQueue is a FIFO of builds that reports the builds deleted from it.
+type buildQueue struct {
+	*cache.FIFO
+	deleted func(*buildapi.Build)
+}
+
+// Delete removes the build from the queue and reports its deletion.
+func (q *buildQueue) Delete(obj interface{}) error {
+	if err := q.FIFO.Delete(obj); err != nil {
+		return err
+	}
+	if build, ok := obj.(*buildapi.Build); ok {
+		q.deleted(build)
+	}
+	return nil
+}
+
 // BuildPodControllerFactory construct BuildPodController objects
 type BuildPodControllerFactory struct {
 	OSClient     osclient.Interface
 	KubeClient   kclient.Interface
 	BuildUpdater buildclient.BuildUpdater
+	// LogSaver, if set, stores the logs of builds before their pods go away.
+	LogSaver buildcontroller.BuildLogSaver
+	// Notifier, if set, sends the completion notifications of finished builds.
+	Notifier buildcontroller.BuildNotifier
 	// Stop may be set to allow controllers created by this factory to be terminated.
 	Stop <-chan struct{}
 
@@ -101,6 +141,8 @@ func (factory *BuildPodControllerFactory) Create() controller.RunnableController
 		BuildStore:   factory.buildStore,
 		BuildUpdater: factory.BuildUpdater,
 		PodManager:   client,
+		LogSaver:     factory.LogSaver,
+		Notifier:     factory.Notifier,
 	}
 
 	return &controller.RetryController{
@@ -118,6 +160,7 @@ func (factory *BuildPodControllerFactory) Create() controller.RunnableController
 type ImageChangeControllerFactory struct {
 	Client             osclient.Interface
 	BuildCreator       buildclient.BuildCreator
+	BuildConfigGetter  buildclient.BuildConfigGetter
 	BuildConfigUpdater buildclient.BuildConfigUpdater
 	// Stop may be set to allow controllers created by this factory to be terminated.
 	Stop <-chan struct{}
@@ -134,6 +177,7 @@ func (factory *ImageChangeControllerFactory) Create() controller.RunnableControl
 
 	imageChangeController := &buildcontroller.ImageChangeController{
 		BuildConfigStore:   store,
+		BuildConfigGetter:  factory.BuildConfigGetter,
 		BuildConfigUpdater: factory.BuildConfigUpdater,
 		BuildCreator:       factory.BuildCreator,
 		Stop:               factory.Stop,
@@ -159,6 +203,133 @@ func (factory *ImageChangeControllerFactory) Create() controller.RunnableControl
 	}
 }
 
+// ConfigChangeControllerFactory can create a ConfigChangeController which obtains BuildConfigs
+// from a queue populated from a watch of all BuildConfigs.
+type ConfigChangeControllerFactory struct {
+	Client             osclient.Interface
+	BuildCreator       buildclient.BuildCreator
+	BuildConfigGetter  buildclient.BuildConfigGetter
+	BuildConfigUpdater buildclient.BuildConfigUpdater
+	// Stop may be set to allow controllers created by this factory to be terminated.
+	Stop <-chan struct{}
+}
+
+// Create creates a new ConfigChangeController which is used to trigger builds when a
+// BuildConfig is created or its parameters change.
+func (factory *ConfigChangeControllerFactory) Create() controller.RunnableController {
+	queue := cache.NewFIFO(cache.MetaNamespaceKeyFunc)
+	cache.NewReflector(&buildConfigLW{client: factory.Client}, &buildapi.BuildConfig{}, queue, 2*time.Minute).Run()
+
+	configChangeController := &buildcontroller.ConfigChangeController{
+		BuildCreator:       factory.BuildCreator,
+		BuildConfigGetter:  factory.BuildConfigGetter,
+		BuildConfigUpdater: factory.BuildConfigUpdater,
+	}
+
+	return &controller.RetryController{
+		Queue: queue,
+		RetryManager: controller.NewQueueRetryManager(
+			queue,
+			cache.MetaNamespaceKeyFunc,
+			func(obj interface{}, err error, count int) bool {
+				kutil.HandleError(err)
+				if _, isFatal := err.(buildcontroller.ConfigChangeControllerFatalError); isFatal {
+					return false
+				}
+				return count < 3
+			},
+		),
+		Handle: func(obj interface{}) error {
+			config := obj.(*buildapi.BuildConfig)
+			return configChangeController.HandleBuildConfig(config)
+		},
+	}
+}
+
+// SCMPollControllerFactory can create an SCMPollController which checks the source repositories
+// of the BuildConfigs with an SCMPoll trigger at a fixed interval.
+type SCMPollControllerFactory struct {
+	Client             osclient.Interface
+	BuildCreator       buildclient.BuildCreator
+	BuildConfigGetter  buildclient.BuildConfigGetter
+	BuildConfigUpdater buildclient.BuildConfigUpdater
+	// BuildDefaults, if set, provide the proxies of the sources that do not set them.
+	BuildDefaults *strategy.BuildDefaults
+	// Interval is how often the repositories are polled.
+	Interval time.Duration
+	// Stop may be set to allow controllers created by this factory to be terminated.
+	Stop <-chan struct{}
+}
+
+// Create creates a new SCMPollController which is used to trigger builds when the source ref
+// of a BuildConfig points to a new commit.
+func (factory *SCMPollControllerFactory) Create() controller.RunnableController {
+	queue := cache.NewFIFO(cache.MetaNamespaceKeyFunc)
+	cache.NewPoller(factory.pollBuildConfigs, factory.Interval, queue).RunUntil(factory.Stop)
+
+	scmPollController := &buildcontroller.SCMPollController{
+		Git:                git.NewRepository(),
+		BuildDefaults:      factory.BuildDefaults,
+		BuildCreator:       factory.BuildCreator,
+		BuildConfigGetter:  factory.BuildConfigGetter,
+		BuildConfigUpdater: factory.BuildConfigUpdater,
+	}
+
+	return &controller.RetryController{
+		Queue: queue,
+		RetryManager: controller.NewQueueRetryManager(
+			queue,
+			cache.MetaNamespaceKeyFunc,
+			func(obj interface{}, err error, _ int) bool {
+				// the repository is polled again on the next interval
+				kutil.HandleError(err)
+				return false
+			},
+		),
+		Handle: func(obj interface{}) error {
+			config := obj.(*buildapi.BuildConfig)
+			return scmPollController.HandleBuildConfig(config)
+		},
+	}
+}
+
+// pollBuildConfigs lists the BuildConfigs with an SCMPoll trigger and returns an enumerator for
+// cache.Poller.
+func (factory *SCMPollControllerFactory) pollBuildConfigs() (cache.Enumerator, error) {
+	configs, err := factory.Client.BuildConfigs(kapi.NamespaceAll).List(labels.Everything(), fields.Everything())
+	if err != nil {
+		return nil, err
+	}
+	list := &buildapi.BuildConfigList{}
+	for _, config := range configs.Items {
+		for _, trigger := range config.Triggers {
+			if trigger.Type == buildapi.SCMPollBuildTriggerType {
+				list.Items = append(list.Items, config)
+				break
+			}
+		}
+	}
+	return &buildConfigEnumerator{list}, nil
+}
+
+// buildConfigEnumerator allows a cache.Poller to enumerate items in a BuildConfigList
+type buildConfigEnumerator struct {
+	*buildapi.BuildConfigList
+}
+
+// Len returns the number of items in the build config list.
+func (e *buildConfigEnumerator) Len() int {
+	if e.BuildConfigList == nil {
+		return 0
+	}
+	return len(e.Items)
+}
+
+// Get returns the item (and ID) with the particular index.
+func (e *buildConfigEnumerator) Get(index int) interface{} {
+	return &e.Items[index]
+}
+
 // pollPods lists pods for all builds in the buildStore which are pending or running and
 // returns an enumerator for cache.Poller. The poll scope is narrowed for efficiency.
 func (factory *BuildPodControllerFactory) pollPods() (cache.Enumerator, error) {
@@ -203,19 +374,34 @@ type typeBasedFactoryStrategy struct {
 	DockerBuildStrategy *strategy.DockerBuildStrategy
 	STIBuildStrategy    *strategy.STIBuildStrategy
 	CustomBuildStrategy *strategy.CustomBuildStrategy
+	BuildDefaults       *strategy.BuildDefaults
 }
 
 func (f *typeBasedFactoryStrategy) CreateBuildPod(build *buildapi.Build) (*kapi.Pod, error) {
+	var (
+		pod *kapi.Pod
+		err error
+	)
+	if f.BuildDefaults != nil {
+		f.BuildDefaults.ApplyToBuild(build)
+	}
 	switch build.Parameters.Strategy.Type {
 	case buildapi.DockerBuildStrategyType:
-		return f.DockerBuildStrategy.CreateBuildPod(build)
+		pod, err = f.DockerBuildStrategy.CreateBuildPod(build)
 	case buildapi.STIBuildStrategyType:
-		return f.STIBuildStrategy.CreateBuildPod(build)
+		pod, err = f.STIBuildStrategy.CreateBuildPod(build)
 	case buildapi.CustomBuildStrategyType:
-		return f.CustomBuildStrategy.CreateBuildPod(build)
+		pod, err = f.CustomBuildStrategy.CreateBuildPod(build)
 	default:
 		return nil, errors.New("No strategy defined for type")
 	}
+	if err != nil {
+		return nil, err
+	}
+	if f.BuildDefaults != nil {
+		f.BuildDefaults.ApplyTo(pod)
+	}
+	return pod, nil
 }
 
 // panicIfStopped panics with the provided object if the channel is closed
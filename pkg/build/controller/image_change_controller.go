@@ -5,6 +5,7 @@ import (
 
 	"github.com/golang/glog"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/cache"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 
@@ -29,6 +30,7 @@ func (e ImageChangeControllerFatalError) Error() string {
 type ImageChangeController struct {
 	BuildConfigStore   cache.Store
 	BuildCreator       buildclient.BuildCreator
+	BuildConfigGetter  buildclient.BuildConfigGetter
 	BuildConfigUpdater buildclient.BuildConfigUpdater
 	// Stop is an optional channel that controls when the controller exits
 	Stop <-chan struct{}
@@ -44,6 +46,8 @@ func (c *ImageChangeController) HandleImageRepo(repo *imageapi.ImageRepository)
 		config := bc.(*buildapi.BuildConfig)
 
 		shouldBuild := false
+		causes := []buildapi.BuildCause{}
+		inputs := []imageSourceChange{}
 		// For every ImageChange trigger find the latest tagged image from the image repository and replace that value
 		// throughout the build strategies. A new build is triggered only if the latest tagged image id or pull spec
 		// differs from the last triggered build recorded on the build config.
@@ -73,19 +77,35 @@ func (c *ImageChangeController) HandleImageRepo(repo *imageapi.ImageRepository)
 				next = latest.DockerImageReference
 			}
 			if len(last) == 0 || next != last {
-				subs[change.Image] = latest.DockerImageReference
+				// triggers of image source inputs do not replace the image of the strategy
+				if len(change.Image) > 0 {
+					subs[change.Image] = latest.DockerImageReference
+				}
 				change.LastTriggeredImageID = next
 				shouldBuild = true
+				from := change.From
+				if len(from.Namespace) == 0 {
+					from.Namespace = repo.Namespace
+				}
+				inputs = append(inputs, imageSourceChange{from: from, tag: change.Tag, image: latest.DockerImageReference})
+				causes = append(causes, buildapi.BuildCause{
+					Type:         buildapi.ImageChangeBuildTriggerType,
+					ImageTrigger: &buildapi.BuildCauseImageTrigger{ImageID: next, From: from, Tag: change.Tag},
+				})
 			}
 		}
 
 		if shouldBuild {
 			glog.V(4).Infof("Running build for buildConfig %s in namespace %s", config.Name, config.Namespace)
 			b := buildutil.GenerateBuildFromConfig(config, nil, subs)
+			b.Causes = causes
+			for _, input := range inputs {
+				input.substitute(b)
+			}
 			if err := c.BuildCreator.Create(config.Namespace, b); err != nil {
 				return fmt.Errorf("error starting build for buildConfig %s: %v", config.Name, err)
 			}
-			if err := c.BuildConfigUpdater.Update(config); err != nil {
+			if err := recordTriggerState(c.BuildConfigGetter, c.BuildConfigUpdater, config, recordLastTriggeredImages(config)); err != nil {
 				// This is not a retryable error because the build has been created.  The worst case
 				// outcome of not updating the buildconfig is that we might rerun a build for the
 				// same "new" imageid change in the future, which is better than running the build
@@ -96,3 +116,56 @@ func (c *ImageChangeController) HandleImageRepo(repo *imageapi.ImageRepository)
 	}
 	return nil
 }
+
+// recordLastTriggeredImages returns a function setting the image last triggered by the
+// ImageChange triggers of config on the matching triggers of another version of config.
+func recordLastTriggeredImages(config *buildapi.BuildConfig) func(*buildapi.BuildConfig) {
+	return func(current *buildapi.BuildConfig) {
+		for _, trigger := range config.Triggers {
+			if trigger.Type != buildapi.ImageChangeBuildTriggerType {
+				continue
+			}
+			for i := range current.Triggers {
+				change := current.Triggers[i].ImageChange
+				if current.Triggers[i].Type != buildapi.ImageChangeBuildTriggerType || change.From != trigger.ImageChange.From || change.Tag != trigger.ImageChange.Tag {
+					continue
+				}
+				change.LastTriggeredImageID = trigger.ImageChange.LastTriggeredImageID
+			}
+		}
+	}
+}
+
+// imageSourceChange is a change of an image triggering a build, which is used by the image
+// sources of the build that refer to the same tag.
+type imageSourceChange struct {
+	from  kapi.ObjectReference
+	tag   string
+	image string
+}
+
+// substitute sets the image of the image sources of build referring to the changed tag, so
+// that the build uses the image that triggered it.
+func (c imageSourceChange) substitute(build *buildapi.Build) {
+	for i := range build.Parameters.Source.Images {
+		source := &build.Parameters.Source.Images[i]
+		if source.From == nil || source.From.Name != c.from.Name {
+			continue
+		}
+		if len(source.From.Namespace) > 0 && source.From.Namespace != c.from.Namespace {
+			continue
+		}
+		if defaultTag(source.Tag) != defaultTag(c.tag) {
+			continue
+		}
+		source.DockerImageReference = c.image
+	}
+}
+
+// defaultTag returns tag, or latest if it is empty.
+func defaultTag(tag string) string {
+	if len(tag) == 0 {
+		return "latest"
+	}
+	return tag
+}
@@ -5,18 +5,40 @@ import (
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 
 	buildapi "github.com/openshift/origin/pkg/build/api"
 	buildtest "github.com/openshift/origin/pkg/build/controller/test"
 	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
+type mockBuildConfigGetter struct {
+	buildcfg *buildapi.BuildConfig
+	err      error
+}
+
+func (m *mockBuildConfigGetter) Get(namespace, name string) (*buildapi.BuildConfig, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	copy, err := kapi.Scheme.Copy(m.buildcfg)
+	if err != nil {
+		return nil, err
+	}
+	return copy.(*buildapi.BuildConfig), nil
+}
+
 type mockBuildConfigUpdater struct {
 	buildcfg *buildapi.BuildConfig
 	err      error
+	// resourceVersion, when set, is the only resource version accepted by updates
+	resourceVersion string
 }
 
 func (m *mockBuildConfigUpdater) Update(buildcfg *buildapi.BuildConfig) error {
+	if len(m.resourceVersion) > 0 && buildcfg.ResourceVersion != m.resourceVersion {
+		return kerrors.NewConflict("buildConfig", buildcfg.Name, fmt.Errorf("resource version %s is stale", buildcfg.ResourceVersion))
+	}
 	m.buildcfg = buildcfg
 	return m.err
 }
@@ -101,6 +123,7 @@ func mockImageChangeController(buildcfg *buildapi.BuildConfig) *ImageChangeContr
 	return &ImageChangeController{
 		BuildConfigStore:   buildtest.NewFakeBuildConfigStore(buildcfg),
 		BuildCreator:       &mockBuildCreator{},
+		BuildConfigGetter:  &mockBuildConfigGetter{buildcfg: buildcfg},
 		BuildConfigUpdater: &mockBuildConfigUpdater{},
 	}
 }
@@ -129,6 +152,63 @@ func TestNewImageID(t *testing.T) {
 	if buildConfigUpdater.buildcfg.Triggers[0].ImageChange.LastTriggeredImageID != "newImageID123" {
 		t.Errorf("Expected imageID newImageID123, got %s", buildConfigUpdater.buildcfg.Triggers[0].ImageChange.LastTriggeredImageID)
 	}
+	causes := buildCreator.build.Causes
+	if len(causes) != 1 || causes[0].Type != buildapi.ImageChangeBuildTriggerType || causes[0].ImageTrigger == nil {
+		t.Fatalf("Expected the image change to be recorded as the cause, got %#v", causes)
+	}
+	if trigger := causes[0].ImageTrigger; trigger.ImageID != "newImageID123" || trigger.From.Name != "testImageRepo" || trigger.Tag != "testTag" {
+		t.Errorf("Unexpected image trigger cause %#v", trigger)
+	}
+}
+
+func TestNewImageIDUpdatesCurrentBuildConfig(t *testing.T) {
+	// creating the build advanced the stored config, which must be updated instead of the cached one
+	buildcfg := mockBuildConfig("registry.com/namespace/imagename", "registry.com/namespace/imagename", "testImageRepo", "testTag")
+	buildcfg.ResourceVersion = "1"
+	current := mockBuildConfig("registry.com/namespace/imagename", "registry.com/namespace/imagename", "testImageRepo", "testTag")
+	current.ResourceVersion, current.LastVersion = "2", 1
+	imagerepo := mockImageRepo("testImageRepo", "registry.com/namespace/imagename", map[string]string{"testTag": "newImageID123"})
+	controller := mockImageChangeController(buildcfg)
+	controller.BuildConfigGetter = &mockBuildConfigGetter{buildcfg: current}
+	buildConfigUpdater := controller.BuildConfigUpdater.(*mockBuildConfigUpdater)
+	buildConfigUpdater.resourceVersion = "2"
+
+	if err := controller.HandleImageRepo(imagerepo); err != nil {
+		t.Fatalf("Unexpected error %v from HandleImageRepo", err)
+	}
+	if buildConfigUpdater.buildcfg == nil {
+		t.Fatal("Expected buildConfig update when new image was created!")
+	}
+	if buildConfigUpdater.buildcfg.LastVersion != 1 {
+		t.Errorf("Expected the current buildConfig to be updated, got %#v", buildConfigUpdater.buildcfg)
+	}
+	if id := buildConfigUpdater.buildcfg.Triggers[0].ImageChange.LastTriggeredImageID; id != "newImageID123" {
+		t.Errorf("Expected imageID newImageID123, got %s", id)
+	}
+}
+
+func TestNewImageIDOfImageSource(t *testing.T) {
+	// the trigger only watches an input of the build, the builder image is not replaced
+	buildcfg := mockBuildConfig("registry.com/namespace/builder", "", "artifacts", "")
+	buildcfg.Parameters.Source.Images = []buildapi.ImageSource{
+		{From: &kapi.ObjectReference{Name: "artifacts"}, Tag: "latest", Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts"}}},
+		{From: &kapi.ObjectReference{Name: "artifacts"}, Tag: "stable", Paths: []buildapi.ImageSourcePath{{SourcePath: "/artifacts"}}},
+	}
+	imagerepo := mockImageRepo("artifacts", "registry.com/namespace/artifacts", map[string]string{"latest": "newImageID123"})
+	controller := mockImageChangeController(buildcfg)
+	if err := controller.HandleImageRepo(imagerepo); err != nil {
+		t.Fatalf("Unexpected error %v from HandleImageRepo", err)
+	}
+	build := controller.BuildCreator.(*mockBuildCreator).build
+	if build == nil {
+		t.Fatalf("Expected new build when the image of an input changes")
+	}
+	if build.Parameters.Strategy.DockerStrategy.Image != "registry.com/namespace/builder" {
+		t.Errorf("Expected the builder image to be kept, got %s", build.Parameters.Strategy.DockerStrategy.Image)
+	}
+	if images := build.Parameters.Source.Images; images[0].DockerImageReference != "registry.com/namespace/artifacts:newImageID123" || len(images[1].DockerImageReference) != 0 {
+		t.Errorf("Expected only the input of the changed tag to use the new image, got %#v", images)
+	}
 }
 
 func TestNewImageIDDefaultTag(t *testing.T) {
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildclient "github.com/openshift/origin/pkg/build/client"
+	buildstrategy "github.com/openshift/origin/pkg/build/controller/strategy"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// remoteCommitGetter returns the commit a ref of a remote Git repository points to.
+type remoteCommitGetter interface {
+	GetRemoteCommit(url string, ref string, env []string) (string, error)
+}
+
+// SCMPollController checks the Git repository of BuildConfigs with an SCMPoll trigger and
+// starts a build of the new commit when their source ref moved. Errors are not retried, the
+// repository is checked again on the next poll.
+type SCMPollController struct {
+	Git remoteCommitGetter
+	// BuildDefaults, if set, provide the proxies used to reach the repositories of sources that
+	// do not set them, like the builds do.
+	BuildDefaults      *buildstrategy.BuildDefaults
+	BuildCreator       buildclient.BuildCreator
+	BuildConfigGetter  buildclient.BuildConfigGetter
+	BuildConfigUpdater buildclient.BuildConfigUpdater
+}
+
+// HandleBuildConfig polls the source repository of config.
+func (c *SCMPollController) HandleBuildConfig(config *buildapi.BuildConfig) error {
+	var trigger *buildapi.BuildTriggerPolicy
+	for i := range config.Triggers {
+		if config.Triggers[i].Type == buildapi.SCMPollBuildTriggerType {
+			trigger = &config.Triggers[i]
+			break
+		}
+	}
+	if trigger == nil || config.Parameters.Source.Git == nil {
+		return nil
+	}
+	source := *config.Parameters.Source.Git
+	if c.BuildDefaults != nil {
+		c.BuildDefaults.ApplyToGitSource(&source)
+	}
+
+	commit, err := c.Git.GetRemoteCommit(source.URI, source.Ref, proxyEnv(&source))
+	if err != nil {
+		return fmt.Errorf("unable to poll the source of buildConfig %s/%s: %v", config.Namespace, config.Name, err)
+	}
+	if trigger.SCMPoll != nil && trigger.SCMPoll.LastBuiltCommit == commit {
+		return nil
+	}
+
+	glog.V(4).Infof("Running build for buildConfig %s/%s at commit %s", config.Namespace, config.Name, commit)
+	revision := &buildapi.SourceRevision{
+		Type: buildapi.BuildSourceGit,
+		Git:  &buildapi.GitSourceRevision{Commit: commit},
+	}
+	build := buildutil.GenerateBuildFromConfig(config, revision, nil)
+	build.Causes = []buildapi.BuildCause{{Type: buildapi.SCMPollBuildTriggerType}}
+	if err := c.BuildCreator.Create(config.Namespace, build); err != nil {
+		return fmt.Errorf("error starting build for buildConfig %s/%s: %v", config.Namespace, config.Name, err)
+	}
+
+	// The build is started again on the next poll when the config cannot be updated, which is
+	// better than not building a new commit at all.
+	err = recordTriggerState(c.BuildConfigGetter, c.BuildConfigUpdater, config, func(current *buildapi.BuildConfig) {
+		for i := range current.Triggers {
+			if current.Triggers[i].Type == buildapi.SCMPollBuildTriggerType {
+				current.Triggers[i].SCMPoll = &buildapi.SCMPollTrigger{LastBuiltCommit: commit}
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error updating buildConfig %s/%s with the last built commit %s: %v", config.Namespace, config.Name, commit, err)
+	}
+	return nil
+}
+
+// proxyEnv returns the environment variables setting the proxies of source for git.
+func proxyEnv(source *buildapi.GitBuildSource) []string {
+	env := []string{}
+	for _, proxy := range []struct{ name, value string }{
+		{"http_proxy", source.HTTPProxy},
+		{"https_proxy", source.HTTPSProxy},
+		{"no_proxy", source.NoProxy},
+	} {
+		if len(proxy.value) > 0 {
+			env = append(env, proxy.name+"="+proxy.value, strings.ToUpper(proxy.name)+"="+proxy.value)
+		}
+	}
+	return env
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildstrategy "github.com/openshift/origin/pkg/build/controller/strategy"
+)
+
+type fakeRemoteCommitGetter struct {
+	commit string
+	err    error
+	url    string
+	ref    string
+	env    []string
+}
+
+func (g *fakeRemoteCommitGetter) GetRemoteCommit(url string, ref string, env []string) (string, error) {
+	g.url, g.ref, g.env = url, ref, env
+	return g.commit, g.err
+}
+
+func mockSCMPollBuildConfig(lastBuiltCommit string) *buildapi.BuildConfig {
+	trigger := buildapi.BuildTriggerPolicy{Type: buildapi.SCMPollBuildTriggerType}
+	if len(lastBuiltCommit) > 0 {
+		trigger.SCMPoll = &buildapi.SCMPollTrigger{LastBuiltCommit: lastBuiltCommit}
+	}
+	return &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "testBuildCfg", Namespace: "default"},
+		Parameters: buildapi.BuildParameters{
+			Source: buildapi.BuildSource{
+				Type: buildapi.BuildSourceGit,
+				Git:  &buildapi.GitBuildSource{URI: "git://example.com/app.git", Ref: "stable"},
+			},
+			Strategy: buildapi.BuildStrategy{
+				Type:           buildapi.DockerBuildStrategyType,
+				DockerStrategy: &buildapi.DockerBuildStrategy{},
+			},
+		},
+		Triggers: []buildapi.BuildTriggerPolicy{trigger},
+	}
+}
+
+func TestSCMPollNewCommit(t *testing.T) {
+	for _, last := range []string{"", "1234"} {
+		git := &fakeRemoteCommitGetter{commit: "abcd"}
+		creator := &mockBuildCreator{}
+		updater := &mockBuildConfigUpdater{}
+		config := mockSCMPollBuildConfig(last)
+		c := &SCMPollController{Git: git, BuildCreator: creator, BuildConfigGetter: &mockBuildConfigGetter{buildcfg: config}, BuildConfigUpdater: updater}
+
+		if err := c.HandleBuildConfig(config); err != nil {
+			t.Fatalf("%q: unexpected error: %v", last, err)
+		}
+		if git.url != "git://example.com/app.git" || git.ref != "stable" {
+			t.Errorf("%q: unexpected poll of %s %s", last, git.url, git.ref)
+		}
+		if creator.build == nil {
+			t.Fatalf("%q: expected a build to be created", last)
+		}
+		if revision := creator.build.Parameters.Revision; revision == nil || revision.Git == nil || revision.Git.Commit != "abcd" {
+			t.Errorf("%q: unexpected revision %#v", last, revision)
+		}
+		if causes := creator.build.Causes; len(causes) != 1 || causes[0].Type != buildapi.SCMPollBuildTriggerType {
+			t.Errorf("%q: unexpected causes %#v", last, causes)
+		}
+		if updater.buildcfg == nil || updater.buildcfg.Triggers[0].SCMPoll == nil || updater.buildcfg.Triggers[0].SCMPoll.LastBuiltCommit != "abcd" {
+			t.Errorf("%q: expected the last built commit to be recorded, got %#v", last, updater.buildcfg)
+		}
+	}
+}
+
+func TestSCMPollProxies(t *testing.T) {
+	git := &fakeRemoteCommitGetter{commit: "abcd"}
+	config := mockSCMPollBuildConfig("abcd")
+	config.Parameters.Source.Git.HTTPSProxy = "http://source-proxy:3128"
+	c := &SCMPollController{
+		Git:           git,
+		BuildDefaults: &buildstrategy.BuildDefaults{GitHTTPProxy: "http://proxy:3128", GitHTTPSProxy: "http://proxy:3128", GitNoProxy: "example.com"},
+	}
+
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"http_proxy=http://proxy:3128", "HTTP_PROXY=http://proxy:3128",
+		"https_proxy=http://source-proxy:3128", "HTTPS_PROXY=http://source-proxy:3128",
+		"no_proxy=example.com", "NO_PROXY=example.com",
+	}
+	if !reflect.DeepEqual(git.env, expected) {
+		t.Errorf("Expected the proxies %v, got %v", expected, git.env)
+	}
+	if len(config.Parameters.Source.Git.HTTPProxy) != 0 {
+		t.Errorf("Expected the default proxies not to be set on the config")
+	}
+}
+
+func TestSCMPollSameCommit(t *testing.T) {
+	creator := &mockBuildCreator{}
+	updater := &mockBuildConfigUpdater{}
+	c := &SCMPollController{Git: &fakeRemoteCommitGetter{commit: "abcd"}, BuildCreator: creator, BuildConfigUpdater: updater}
+
+	if err := c.HandleBuildConfig(mockSCMPollBuildConfig("abcd")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creator.build != nil || updater.buildcfg != nil {
+		t.Errorf("Unexpected build %#v or config update %#v", creator.build, updater.buildcfg)
+	}
+}
+
+func TestSCMPollErrors(t *testing.T) {
+	creator := &mockBuildCreator{}
+	c := &SCMPollController{Git: &fakeRemoteCommitGetter{err: errors.New("unreachable")}, BuildCreator: creator, BuildConfigUpdater: &mockBuildConfigUpdater{}}
+	if err := c.HandleBuildConfig(mockSCMPollBuildConfig("")); err == nil || creator.build != nil {
+		t.Errorf("Expected an error and no build, got %v and %#v", err, creator.build)
+	}
+
+	config := mockSCMPollBuildConfig("")
+	c = &SCMPollController{Git: &fakeRemoteCommitGetter{commit: "abcd"}, BuildCreator: &mockBuildCreator{}, BuildConfigGetter: &mockBuildConfigGetter{buildcfg: config}, BuildConfigUpdater: &mockBuildConfigUpdater{err: errors.New("conflict")}}
+	if err := c.HandleBuildConfig(config); err == nil {
+		t.Errorf("Expected an error when the config cannot be updated")
+	}
+
+	c = &SCMPollController{Git: &fakeRemoteCommitGetter{commit: "abcd"}, BuildCreator: &mockBuildCreator{}, BuildConfigGetter: &mockBuildConfigGetter{err: errors.New("not found")}, BuildConfigUpdater: &mockBuildConfigUpdater{}}
+	if err := c.HandleBuildConfig(config); err == nil {
+		t.Errorf("Expected an error when the config cannot be read again")
+	}
+}
+
+func TestSCMPollUpdatesCurrentBuildConfig(t *testing.T) {
+	config := mockSCMPollBuildConfig("1234")
+	config.ResourceVersion = "1"
+	current := mockSCMPollBuildConfig("1234")
+	current.ResourceVersion, current.LastVersion = "2", 1
+	updater := &mockBuildConfigUpdater{resourceVersion: "2"}
+	c := &SCMPollController{Git: &fakeRemoteCommitGetter{commit: "abcd"}, BuildCreator: &mockBuildCreator{}, BuildConfigGetter: &mockBuildConfigGetter{buildcfg: current}, BuildConfigUpdater: updater}
+
+	if err := c.HandleBuildConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updater.buildcfg == nil || updater.buildcfg.LastVersion != 1 || updater.buildcfg.Triggers[0].SCMPoll.LastBuiltCommit != "abcd" {
+		t.Errorf("Expected the last built commit to be recorded on the current config, got %#v", updater.buildcfg)
+	}
+}
@@ -71,5 +71,10 @@ func (bs *CustomBuildStrategy) CreateBuildPod(build *buildapi.Build) (*kapi.Pod,
 		setupDockerSocket(pod)
 		setupDockerConfig(pod)
 	}
+	setupResources(build, pod)
+	setupSourceSecret(build, pod)
+	setupProxy(build, pod)
+	setupRegistrySecrets(build, pod, strategy.PullSecretName)
+	setupCompletionDeadline(build, pod)
 	return pod, nil
 }
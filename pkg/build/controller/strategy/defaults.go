@@ -0,0 +1,63 @@
+package strategy
+
+import (
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+)
+
+// BuildDefaults are the cluster wide compute resource limits and node selector of build pods,
+// and the proxies of Git sources, used for the builds that do not set them.
+type BuildDefaults struct {
+	Resources    kapi.ResourceRequirements
+	NodeSelector map[string]string
+
+	GitHTTPProxy  string
+	GitHTTPSProxy string
+	GitNoProxy    string
+}
+
+// ApplyToBuild sets the default proxies the Git source of build does not set, before the
+// build pod is created from it.
+func (d *BuildDefaults) ApplyToBuild(build *buildapi.Build) {
+	if git := build.Parameters.Source.Git; git != nil {
+		d.ApplyToGitSource(git)
+	}
+}
+
+// ApplyToGitSource sets the default proxies git does not set.
+func (d *BuildDefaults) ApplyToGitSource(git *buildapi.GitBuildSource) {
+	if len(git.HTTPProxy) == 0 {
+		git.HTTPProxy = d.GitHTTPProxy
+	}
+	if len(git.HTTPSProxy) == 0 {
+		git.HTTPSProxy = d.GitHTTPSProxy
+	}
+	if len(git.NoProxy) == 0 {
+		git.NoProxy = d.GitNoProxy
+	}
+}
+
+// ApplyTo sets the default limits the build container of pod does not have, and the default
+// node selector when pod has none.
+// TODO: default the resource requests the same way once ResourceRequirements has them.
+func (d *BuildDefaults) ApplyTo(pod *kapi.Pod) {
+	if len(d.Resources.Limits) > 0 && len(pod.Spec.Containers) > 0 {
+		container := &pod.Spec.Containers[0]
+		for name, quantity := range d.Resources.Limits {
+			if _, ok := container.Resources.Limits[name]; ok {
+				continue
+			}
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = kapi.ResourceList{}
+			}
+			container.Resources.Limits[name] = quantity
+		}
+	}
+	if len(pod.Spec.NodeSelector) == 0 && len(d.NodeSelector) > 0 {
+		pod.Spec.NodeSelector = map[string]string{}
+		for key, value := range d.NodeSelector {
+			pod.Spec.NodeSelector[key] = value
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"testing"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
+
+	"github.com/openshift/origin/pkg/api/v1beta1"
+)
+
+func TestBuildResourcesAndDefaults(t *testing.T) {
+	build := mockDockerBuild()
+	build.Parameters.Resources.Limits = kapi.ResourceList{kapi.ResourceMemory: resource.MustParse("2Gi")}
+
+	strategy := DockerBuildStrategy{Image: "docker-test-image", Codec: v1beta1.Codec}
+	pod, err := strategy.CreateBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defaults := &BuildDefaults{
+		Resources: kapi.ResourceRequirements{
+			Limits: kapi.ResourceList{
+				kapi.ResourceCPU:    resource.MustParse("500m"),
+				kapi.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		},
+		NodeSelector: map[string]string{"region": "builds"},
+	}
+	defaults.ApplyTo(pod)
+
+	limits := pod.Spec.Containers[0].Resources.Limits
+	if memory := limits[kapi.ResourceMemory]; memory.String() != "2Gi" {
+		t.Errorf("Expected the memory limit of the build to be kept, got %s", memory.String())
+	}
+	if cpu := limits[kapi.ResourceCPU]; cpu.String() != "500m" {
+		t.Errorf("Expected the default CPU limit, got %s", cpu.String())
+	}
+	if pod.Spec.NodeSelector["region"] != "builds" {
+		t.Errorf("Expected the default node selector, got %v", pod.Spec.NodeSelector)
+	}
+
+	build.Parameters.NodeSelector = map[string]string{"region": "large-builds"}
+	pod, err = strategy.CreateBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defaults.ApplyTo(pod)
+	if len(pod.Spec.NodeSelector) != 1 || pod.Spec.NodeSelector["region"] != "large-builds" {
+		t.Errorf("Expected the node selector of the build, got %v", pod.Spec.NodeSelector)
+	}
+}
+
+func TestBuildProxyDefaults(t *testing.T) {
+	defaults := &BuildDefaults{
+		GitHTTPProxy:  "http://proxy.example.com:3128",
+		GitHTTPSProxy: "http://proxy.example.com:3128",
+		GitNoProxy:    ".example.com",
+	}
+	build := mockDockerBuild()
+	defaults.ApplyToBuild(build)
+
+	strategy := DockerBuildStrategy{Image: "docker-test-image", Codec: v1beta1.Codec}
+	pod, err := strategy.CreateBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	env := map[string]string{}
+	for _, v := range pod.Spec.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+		if env[name] != "http://proxy.example.com:3128" {
+			t.Errorf("Expected the default proxy in %s, got %q", name, env[name])
+		}
+	}
+	if env["NO_PROXY"] != ".example.com" || env["no_proxy"] != ".example.com" {
+		t.Errorf("Expected the default hosts without proxy, got %q and %q", env["NO_PROXY"], env["no_proxy"])
+	}
+
+	// the proxies of the build replace the defaults
+	build = mockDockerBuild()
+	build.Parameters.Source.Git.HTTPSProxy = "https://secure-proxy.example.com"
+	defaults.ApplyToBuild(build)
+	if git := build.Parameters.Source.Git; git.HTTPSProxy != "https://secure-proxy.example.com" || git.HTTPProxy != "http://proxy.example.com:3128" {
+		t.Errorf("Expected only the proxies the build does not set to be defaulted, got %#v", git)
+	}
+}
@@ -48,5 +48,14 @@ func (bs *DockerBuildStrategy) CreateBuildPod(build *buildapi.Build) (*kapi.Pod,
 
 	setupDockerSocket(pod)
 	setupDockerConfig(pod)
+	setupResources(build, pod)
+	setupSourceSecret(build, pod)
+	setupProxy(build, pod)
+	var pullSecretName string
+	if strategy := build.Parameters.Strategy.DockerStrategy; strategy != nil {
+		pullSecretName = strategy.PullSecretName
+	}
+	setupRegistrySecrets(build, pod, pullSecretName)
+	setupCompletionDeadline(build, pod)
 	return pod, nil
 }
@@ -79,3 +79,79 @@ func mockDockerBuild() *buildapi.Build {
 		PodName: "-the-pod-id",
 	}
 }
+
+func TestCreateBuildPodCompletionDeadline(t *testing.T) {
+	deadline := int64(300)
+	build := mockDockerBuild()
+	build.Parameters.CompletionDeadlineSeconds = &deadline
+
+	strategy := DockerBuildStrategy{Image: "docker-test-image", Codec: v1beta1.Codec}
+	pod, err := strategy.CreateBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value := pod.Annotations[buildapi.BuildCompletionDeadlineAnnotation]; value != "300" {
+		t.Errorf("Expected the pod to carry a completion deadline of 300 seconds, got %q", value)
+	}
+}
+
+func TestCreateBuildPodSourceSecret(t *testing.T) {
+	build := mockDockerBuild()
+	build.Namespace = "my-project"
+	build.Parameters.Source.SourceSecretName = "repo-key"
+
+	strategy := DockerBuildStrategy{Image: "docker-test-image", Codec: v1beta1.Codec}
+	pod, err := strategy.CreateBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var secret *kapi.SecretVolumeSource
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == "source-secret" {
+			secret = volume.Secret
+		}
+	}
+	if secret == nil || secret.Target.Name != "repo-key" || secret.Target.Namespace != "my-project" {
+		t.Fatalf("Expected the source secret to be a volume of the pod, got %#v", pod.Spec.Volumes)
+	}
+	mounted := false
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		if mount.Name == "source-secret" && mount.MountPath == buildapi.SourceSecretMountPath && mount.ReadOnly {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("Expected the source secret to be mounted read only, got %#v", pod.Spec.Containers[0].VolumeMounts)
+	}
+}
+
+func TestCreateBuildPodRegistrySecrets(t *testing.T) {
+	build := mockDockerBuild()
+	build.Parameters.Strategy.DockerStrategy.PullSecretName = "pull-secret"
+	build.Parameters.Output.PushSecretName = "push-secret"
+
+	strategy := DockerBuildStrategy{Image: "docker-test-image", Codec: v1beta1.Codec}
+	pod, err := strategy.CreateBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	secrets := map[string]string{}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil {
+			secrets[volume.Name] = volume.Secret.Target.Name
+		}
+	}
+	if secrets["pull-secret"] != "pull-secret" || secrets["push-secret"] != "push-secret" {
+		t.Errorf("Expected the pull and push secrets to be volumes of the pod, got %v", secrets)
+	}
+	env := map[string]string{}
+	for _, v := range pod.Spec.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	if env["PULL_DOCKERCFG_PATH"] != "/var/run/secrets/openshift.io/pull/dockercfg" {
+		t.Errorf("Unexpected PULL_DOCKERCFG_PATH %q", env["PULL_DOCKERCFG_PATH"])
+	}
+	if env["PUSH_DOCKERCFG_PATH"] != "/var/run/secrets/openshift.io/push/dockercfg" {
+		t.Errorf("Unexpected PUSH_DOCKERCFG_PATH %q", env["PUSH_DOCKERCFG_PATH"])
+	}
+}
@@ -41,7 +41,9 @@ func (bs *STIBuildStrategy) CreateBuildPod(build *buildapi.Build) (*kapi.Pod, er
 
 	containerEnv := []kapi.EnvVar{
 		{Name: "BUILD", Value: string(data)},
-		{Name: "SOURCE_REPOSITORY", Value: build.Parameters.Source.Git.URI},
+	}
+	if git := build.Parameters.Source.Git; git != nil {
+		containerEnv = append(containerEnv, kapi.EnvVar{Name: "SOURCE_REPOSITORY", Value: git.URI})
 	}
 
 	if strategy := build.Parameters.Strategy.STIStrategy; len(strategy.Env) > 0 {
@@ -70,5 +72,10 @@ func (bs *STIBuildStrategy) CreateBuildPod(build *buildapi.Build) (*kapi.Pod, er
 
 	setupDockerSocket(pod)
 	setupDockerConfig(pod)
+	setupResources(build, pod)
+	setupSourceSecret(build, pod)
+	setupProxy(build, pod)
+	setupRegistrySecrets(build, pod, build.Parameters.Strategy.STIStrategy.PullSecretName)
+	setupCompletionDeadline(build, pod)
 	return pod, nil
 }
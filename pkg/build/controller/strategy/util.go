@@ -3,6 +3,7 @@ package strategy
 import (
 	"os"
 	"path"
+	"strconv"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	buildapi "github.com/openshift/origin/pkg/build/api"
@@ -90,3 +91,119 @@ func setupBuildEnv(build *buildapi.Build, pod *kapi.Pod) error {
 	}
 	return nil
 }
+
+// setupCompletionDeadline records the completion deadline of the build on the pod, where it
+// is enforced by the build pod controller.
+// TODO: set pod.Spec.ActiveDeadlineSeconds instead once the Kubernetes rebase brings the
+// field, and let the build pod controller fail builds whose pod exceeded its deadline.
+func setupCompletionDeadline(build *buildapi.Build, pod *kapi.Pod) {
+	if build.Parameters.CompletionDeadlineSeconds == nil {
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[buildapi.BuildCompletionDeadlineAnnotation] = strconv.FormatInt(*build.Parameters.CompletionDeadlineSeconds, 10)
+}
+
+// setupSourceSecret mounts the secret holding the credentials used to fetch the source of the
+// build into the builder container, and points the builder to it with SOURCE_SECRET_PATH.
+func setupSourceSecret(build *buildapi.Build, pod *kapi.Pod) {
+	name := build.Parameters.Source.SourceSecretName
+	if len(name) == 0 {
+		return
+	}
+	mountSecretVolume(pod, "source-secret", build.Namespace, name, buildapi.SourceSecretMountPath)
+	addEnv(pod, kapi.EnvVar{Name: "SOURCE_SECRET_PATH", Value: buildapi.SourceSecretMountPath})
+}
+
+// setupRegistrySecrets mounts the secrets holding the .dockercfg files used to pull images
+// during the build and to push its output, whose paths are given to the builder with
+// PULL_DOCKERCFG_PATH and PUSH_DOCKERCFG_PATH.
+func setupRegistrySecrets(build *buildapi.Build, pod *kapi.Pod, pullSecretName string) {
+	if len(pullSecretName) != 0 {
+		mountSecretVolume(pod, "pull-secret", build.Namespace, pullSecretName, buildapi.PullSecretMountPath)
+		addEnv(pod, kapi.EnvVar{Name: "PULL_DOCKERCFG_PATH", Value: path.Join(buildapi.PullSecretMountPath, buildapi.DockerConfigKey)})
+	}
+	if name := build.Parameters.Output.PushSecretName; len(name) != 0 {
+		mountSecretVolume(pod, "push-secret", build.Namespace, name, buildapi.PushSecretMountPath)
+		addEnv(pod, kapi.EnvVar{Name: "PUSH_DOCKERCFG_PATH", Value: path.Join(buildapi.PushSecretMountPath, buildapi.DockerConfigKey)})
+	}
+}
+
+// mountSecretVolume mounts the named secret read only at mountPath of the builder container.
+func mountSecretVolume(pod *kapi.Pod, volumeName, namespace, secretName, mountPath string) {
+	if len(pod.Spec.Containers) == 0 {
+		return
+	}
+	secretVolume := kapi.Volume{
+		Name: volumeName,
+		VolumeSource: kapi.VolumeSource{
+			Secret: &kapi.SecretVolumeSource{
+				Target: kapi.ObjectReference{
+					Kind:      "Secret",
+					Namespace: namespace,
+					Name:      secretName,
+				},
+			},
+		},
+	}
+
+	secretVolumeMount := kapi.VolumeMount{
+		Name:      volumeName,
+		ReadOnly:  true,
+		MountPath: mountPath,
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, secretVolume)
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, secretVolumeMount)
+}
+
+// setupProxy sets the proxies of the Git source of build in the environment of its pod, where
+// git and the builder honor them.
+func setupProxy(build *buildapi.Build, pod *kapi.Pod) {
+	git := build.Parameters.Source.Git
+	if git == nil {
+		return
+	}
+	// curl, which fetches http sources for git, only reads the lower case http_proxy
+	addProxyEnv(pod, git.HTTPProxy, "HTTP_PROXY", "http_proxy")
+	addProxyEnv(pod, git.HTTPSProxy, "HTTPS_PROXY", "https_proxy")
+	addProxyEnv(pod, git.NoProxy, "NO_PROXY", "no_proxy")
+}
+
+// addProxyEnv sets the environment variables names to proxy, if it is set.
+func addProxyEnv(pod *kapi.Pod, proxy string, names ...string) {
+	if len(proxy) == 0 {
+		return
+	}
+	for _, name := range names {
+		addEnv(pod, kapi.EnvVar{Name: name, Value: proxy})
+	}
+}
+
+// addEnv adds environment variables to the builder container.
+func addEnv(pod *kapi.Pod, vars ...kapi.EnvVar) {
+	if len(pod.Spec.Containers) > 0 {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, vars...)
+	}
+}
+
+// setupResources sets the compute resource limits and the node selector of the build on its
+// pod.
+// TODO: copy the resource requests as well once Kubernetes is rebased and ResourceRequirements
+// has them, until then the scheduler only considers the limits.
+func setupResources(build *buildapi.Build, pod *kapi.Pod) {
+	if limits := build.Parameters.Resources.Limits; len(limits) > 0 && len(pod.Spec.Containers) > 0 {
+		pod.Spec.Containers[0].Resources.Limits = kapi.ResourceList{}
+		for name, quantity := range limits {
+			pod.Spec.Containers[0].Resources.Limits[name] = quantity
+		}
+	}
+	if len(build.Parameters.NodeSelector) > 0 {
+		pod.Spec.NodeSelector = map[string]string{}
+		for key, value := range build.Parameters.NodeSelector {
+			pod.Spec.NodeSelector[key] = value
+		}
+	}
+}
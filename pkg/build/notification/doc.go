@@ -0,0 +1,3 @@
+// Package notification sends the completion notifications of builds to the URLs
+// configured on their BuildConfig.
+package notification
@@ -0,0 +1,327 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/cache"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildclient "github.com/openshift/origin/pkg/build/client"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the payload keyed with the notification
+	// secret, as "sha256=<hex>".
+	SignatureHeader = "X-OpenShift-Signature"
+	// DeliveryHeader carries the UID of the build, which is the same for every attempt.
+	DeliveryHeader = "X-OpenShift-Delivery"
+
+	defaultAttempts = 5
+	defaultBackoff  = 2 * time.Second
+	defaultTimeout  = 10 * time.Second
+	// defaultWorkers is the number of builds whose notifications are delivered at a time.
+	defaultWorkers = 5
+	// statusUpdateAttempts is how many times recording the delivery status is retried when
+	// the build was modified concurrently.
+	statusUpdateAttempts = 3
+)
+
+// Payload is the JSON body of a build completion notification.
+type Payload struct {
+	Namespace   string                     `json:"namespace"`
+	Name        string                     `json:"name"`
+	BuildConfig string                     `json:"buildConfig,omitempty"`
+	Status      buildapi.BuildStatus       `json:"status"`
+	Reason      buildapi.BuildStatusReason `json:"reason,omitempty"`
+	Message     string                     `json:"message,omitempty"`
+	Revision    string                     `json:"revision,omitempty"`
+	OutputImage string                     `json:"outputImage,omitempty"`
+	// Duration is the number of seconds the build ran, zero if it never started.
+	Duration            int64      `json:"duration"`
+	StartTimestamp      *util.Time `json:"startTimestamp,omitempty"`
+	CompletionTimestamp *util.Time `json:"completionTimestamp,omitempty"`
+}
+
+// NewPayload returns the notification payload describing build.
+func NewPayload(build *buildapi.Build) *Payload {
+	payload := &Payload{
+		Namespace:           build.Namespace,
+		Name:                build.Name,
+		BuildConfig:         build.Labels[buildapi.BuildConfigLabel],
+		Status:              build.Status,
+		Reason:              build.Reason,
+		Message:             build.Message,
+		OutputImage:         build.Parameters.Output.DockerImageReference,
+		StartTimestamp:      build.StartTimestamp,
+		CompletionTimestamp: build.CompletionTimestamp,
+	}
+	if revision := build.Parameters.Revision; revision != nil && revision.Git != nil {
+		payload.Revision = revision.Git.Commit
+	} else if git := build.Parameters.Source.Git; git != nil {
+		payload.Revision = git.Ref
+	}
+	if build.StartTimestamp != nil && build.CompletionTimestamp != nil {
+		payload.Duration = int64(build.CompletionTimestamp.Sub(build.StartTimestamp.Time) / time.Second)
+	}
+	return payload
+}
+
+// Sign returns the signature of body keyed with key, as sent in SignatureHeader.
+func Sign(body, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// buildStatusClient gets builds and records their delivery status.
+type buildStatusClient interface {
+	buildclient.BuildGetter
+	buildclient.BuildUpdater
+}
+
+// Notifier sends the completion notification of a finished build to each URL of its
+// BuildConfig. Failed deliveries are retried with an exponential backoff, and the outcome
+// is recorded on the build. Finished builds are queued, and their notifications are delivered
+// by a bounded number of workers.
+type Notifier struct {
+	ConfigGetter buildclient.BuildConfigGetter
+	Secrets      buildclient.SecretGetter
+	Builds       buildStatusClient
+	// Client sends the notifications. The default client refuses to connect to cluster
+	// internal addresses.
+	Client *http.Client
+	// Attempts is the maximum number of times a notification is sent to a URL.
+	Attempts int
+	// Backoff is the delay before the first retry, doubled for each following retry.
+	Backoff time.Duration
+	// Workers is the number of builds whose notifications are delivered at a time.
+	Workers int
+
+	queue *cache.FIFO
+	sleep func(time.Duration)
+}
+
+// NewNotifier returns a Notifier using the default number of attempts, backoff and workers.
+func NewNotifier(configs buildclient.BuildConfigGetter, secrets buildclient.SecretGetter, builds buildStatusClient) *Notifier {
+	return &Notifier{
+		ConfigGetter: configs,
+		Secrets:      secrets,
+		Builds:       builds,
+		Client: &http.Client{
+			Transport: &http.Transport{Dial: dialExternal},
+			Timeout:   defaultTimeout,
+		},
+		Attempts: defaultAttempts,
+		Backoff:  defaultBackoff,
+		Workers:  defaultWorkers,
+		queue:    cache.NewFIFO(cache.MetaNamespaceKeyFunc),
+		sleep:    time.Sleep,
+	}
+}
+
+// Notify queues the notifications of build. They are delivered by the workers started by Run,
+// so that neither looking up the BuildConfig nor slow receivers delay the handling of builds.
+func (n *Notifier) Notify(build *buildapi.Build) {
+	if len(build.Labels[buildapi.BuildConfigLabel]) == 0 {
+		return
+	}
+	if err := n.queue.Add(build); err != nil {
+		glog.Errorf("Unable to queue the notifications of build %s/%s: %v", build.Namespace, build.Name, err)
+	}
+}
+
+// Run starts the workers delivering the queued notifications.
+func (n *Notifier) Run() {
+	for i := 0; i < n.Workers; i++ {
+		go util.Forever(n.handleNext, 0)
+	}
+}
+
+// handleNext delivers the notifications of the next queued build, waiting for one if there is
+// none.
+func (n *Notifier) handleNext() {
+	build := n.queue.Pop().(*buildapi.Build)
+	configName := build.Labels[buildapi.BuildConfigLabel]
+	config, err := n.ConfigGetter.Get(build.Namespace, configName)
+	if err != nil {
+		glog.V(2).Infof("Unable to get BuildConfig %s/%s to notify the completion of build %s: %v", build.Namespace, configName, build.Name, err)
+		return
+	}
+	if len(config.Notifications) == 0 {
+		return
+	}
+	n.notify(build, config.Notifications)
+}
+
+// notify delivers the notifications of build and records their status.
+func (n *Notifier) notify(build *buildapi.Build, notifications []buildapi.BuildNotification) {
+	body, err := json.Marshal(NewPayload(build))
+	if err != nil {
+		glog.Errorf("Unable to encode the notification of build %s/%s: %v", build.Namespace, build.Name, err)
+		return
+	}
+	statuses := make([]buildapi.BuildNotificationStatus, 0, len(notifications))
+	for i := range notifications {
+		key, err := n.signatureKey(build.Namespace, notifications[i].SecretName)
+		if err != nil {
+			glog.V(2).Infof("Unable to notify %s of build %s/%s: %v", notifications[i].URL, build.Namespace, build.Name, err)
+			statuses = append(statuses, buildapi.BuildNotificationStatus{URL: notifications[i].URL, Message: err.Error()})
+			continue
+		}
+		statuses = append(statuses, n.deliver(build, notifications[i].URL, key, body))
+	}
+	if err := n.recordStatus(build, statuses); err != nil {
+		glog.Errorf("Unable to record the notification status of build %s/%s: %v", build.Namespace, build.Name, err)
+	}
+}
+
+// signatureKey returns the key of the payload signature stored in the secret name.
+func (n *Notifier) signatureKey(namespace, name string) ([]byte, error) {
+	secret, err := n.Secrets.Get(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the notification secret %s", name)
+	}
+	key, ok := secret.Data[buildapi.NotificationSecretKey]
+	if !ok || len(key) == 0 {
+		return nil, fmt.Errorf("the notification secret %s has no %q entry", name, buildapi.NotificationSecretKey)
+	}
+	return key, nil
+}
+
+// deliver POSTs body, signed with key, to url until it is accepted or the attempts are
+// exhausted.
+func (n *Notifier) deliver(build *buildapi.Build, url string, key, body []byte) buildapi.BuildNotificationStatus {
+	status := buildapi.BuildNotificationStatus{URL: url}
+	backoff := n.Backoff
+	for status.Attempts < n.Attempts {
+		if status.Attempts > 0 {
+			n.sleep(backoff)
+			backoff *= 2
+		}
+		status.Attempts++
+		now := util.Now()
+		status.Timestamp = &now
+
+		err := n.post(build, url, key, body)
+		if err == nil {
+			status.Delivered = true
+			status.Message = ""
+			break
+		}
+		status.Message = err.Error()
+		glog.V(2).Infof("Attempt %d to notify %s of build %s/%s failed: %v", status.Attempts, url, build.Namespace, build.Name, err)
+	}
+	return status
+}
+
+func (n *Notifier) post(build *buildapi.Build, url string, key, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(body, key))
+	req.Header.Set(DeliveryHeader, string(build.UID))
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// the status text is chosen by the receiver, only its code is recorded on the build
+		return fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// internalNetworks are the networks notifications are not sent to. They reach the cluster,
+// its nodes and their metadata services rather than external receivers.
+var internalNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isExternal returns true if notifications may be sent to ip.
+func isExternal(ip net.IP) bool {
+	if ip.IsMulticast() {
+		return false
+	}
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialExternal connects to addr if its host only resolves to external addresses. The
+// address is checked when connecting, so redirects and changing DNS records are covered.
+func dialExternal(network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if !isExternal(ip) {
+			return nil, fmt.Errorf("notifications may not be sent to the cluster internal address %s", ip)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address found for %s", host)
+	}
+	return net.DialTimeout(network, net.JoinHostPort(ips[0].String(), port), defaultTimeout)
+}
+
+// recordStatus stores statuses on the latest version of build.
+func (n *Notifier) recordStatus(build *buildapi.Build, statuses []buildapi.BuildNotificationStatus) error {
+	var err error
+	for i := 0; i < statusUpdateAttempts; i++ {
+		var latest *buildapi.Build
+		latest, err = n.Builds.Get(build.Namespace, build.Name)
+		if err != nil {
+			return err
+		}
+		latest.Notifications = statuses
+		if err = n.Builds.Update(latest.Namespace, latest); err == nil || !kerrors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
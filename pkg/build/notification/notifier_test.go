@@ -0,0 +1,262 @@
+package notification
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+)
+
+type fakeConfigGetter struct {
+	config *buildapi.BuildConfig
+}
+
+func (g *fakeConfigGetter) Get(namespace, name string) (*buildapi.BuildConfig, error) {
+	if g.config == nil || g.config.Name != name {
+		return nil, kerrors.NewNotFound("buildConfig", name)
+	}
+	return g.config, nil
+}
+
+type fakeSecretGetter struct{}
+
+func (fakeSecretGetter) Get(namespace, name string) (*kapi.Secret, error) {
+	if name != "notification-secret" {
+		return nil, kerrors.NewNotFound("secret", name)
+	}
+	return &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{buildapi.NotificationSecretKey: []byte("secret")},
+	}, nil
+}
+
+type fakeBuildClient struct {
+	build     *buildapi.Build
+	conflicts int
+	updated   *buildapi.Build
+}
+
+func (c *fakeBuildClient) Get(namespace, name string) (*buildapi.Build, error) {
+	build := *c.build
+	return &build, nil
+}
+
+func (c *fakeBuildClient) Update(namespace string, build *buildapi.Build) error {
+	if c.conflicts > 0 {
+		c.conflicts--
+		return kerrors.NewConflict("build", build.Name, nil)
+	}
+	c.updated = build
+	return nil
+}
+
+func testBuild() *buildapi.Build {
+	start := util.Date(2015, 4, 20, 10, 0, 0, 0, time.UTC)
+	completion := util.Date(2015, 4, 20, 10, 1, 30, 0, time.UTC)
+	return &buildapi.Build{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:      "app-1",
+			Namespace: "default",
+			UID:       "1234",
+			Labels:    map[string]string{buildapi.BuildConfigLabel: "app"},
+		},
+		Parameters: buildapi.BuildParameters{
+			Revision: &buildapi.SourceRevision{
+				Type: buildapi.BuildSourceGit,
+				Git:  &buildapi.GitSourceRevision{Commit: "abcdef"},
+			},
+			Output: buildapi.BuildOutput{DockerImageReference: "registry/default/app:latest"},
+		},
+		Status:              buildapi.BuildStatusFailed,
+		Message:             "Build timed out",
+		StartTimestamp:      &start,
+		CompletionTimestamp: &completion,
+	}
+}
+
+func TestNewPayload(t *testing.T) {
+	payload := NewPayload(testBuild())
+	if payload.Name != "app-1" || payload.BuildConfig != "app" || payload.Status != buildapi.BuildStatusFailed {
+		t.Errorf("Unexpected payload %#v", payload)
+	}
+	if payload.Revision != "abcdef" || payload.OutputImage != "registry/default/app:latest" || payload.Message != "Build timed out" {
+		t.Errorf("Unexpected payload %#v", payload)
+	}
+	if payload.Duration != 90 {
+		t.Errorf("Expected a duration of 90 seconds, got %d", payload.Duration)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	requests := 0
+	var signature string
+	var payload Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		signature = req.Header.Get(SignatureHeader)
+		if signature != Sign(body, []byte("secret")) {
+			t.Errorf("Unexpected signature %s", signature)
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Unexpected payload %s: %v", body, err)
+		}
+		if req.Header.Get(DeliveryHeader) != "1234" {
+			t.Errorf("Unexpected delivery ID %s", req.Header.Get(DeliveryHeader))
+		}
+	}))
+	defer server.Close()
+
+	build := testBuild()
+	builds := &fakeBuildClient{build: build, conflicts: 1}
+	n := NewNotifier(&fakeConfigGetter{}, fakeSecretGetter{}, builds)
+	// the test server listens on a loopback address
+	n.Client = &http.Client{}
+	n.Attempts = 3
+	backoffs := []time.Duration{}
+	n.sleep = func(d time.Duration) { backoffs = append(backoffs, d) }
+
+	n.notify(build, []buildapi.BuildNotification{
+		{URL: server.URL, SecretName: "notification-secret"},
+		{URL: "http://127.0.0.1:0/unreachable", SecretName: "notification-secret"},
+		{URL: server.URL, SecretName: "missing"},
+	})
+
+	if payload.Name != "app-1" || payload.Revision != "abcdef" {
+		t.Errorf("Unexpected payload %#v", payload)
+	}
+	if len(backoffs) != 4 || backoffs[0] != defaultBackoff || backoffs[1] != 2*defaultBackoff {
+		t.Errorf("Unexpected backoffs %v", backoffs)
+	}
+	if builds.updated == nil || len(builds.updated.Notifications) != 3 {
+		t.Fatalf("Expected the notification status to be recorded, got %#v", builds.updated)
+	}
+	delivered, failed, unsigned := builds.updated.Notifications[0], builds.updated.Notifications[1], builds.updated.Notifications[2]
+	if !delivered.Delivered || delivered.Attempts != 3 || len(delivered.Message) != 0 || delivered.Timestamp == nil {
+		t.Errorf("Unexpected status of the delivered notification %#v", delivered)
+	}
+	if failed.Delivered || failed.Attempts != 3 || len(failed.Message) == 0 {
+		t.Errorf("Unexpected status of the failed notification %#v", failed)
+	}
+	if unsigned.Delivered || unsigned.Attempts != 0 || unsigned.Message != "unable to get the notification secret missing" {
+		t.Errorf("Unexpected status of the notification without secret %#v", unsigned)
+	}
+}
+
+func TestNotifyRecordsOnlyTheResponseCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("receiver chosen text"))
+	}))
+	defer server.Close()
+
+	build := testBuild()
+	builds := &fakeBuildClient{build: build}
+	n := NewNotifier(&fakeConfigGetter{}, fakeSecretGetter{}, builds)
+	n.Client = &http.Client{}
+	n.Attempts = 1
+	n.notify(build, []buildapi.BuildNotification{{URL: server.URL, SecretName: "notification-secret"}})
+
+	if builds.updated == nil || builds.updated.Notifications[0].Message != "unexpected response code 418" {
+		t.Errorf("Expected only the response code to be recorded, got %#v", builds.updated)
+	}
+}
+
+func TestNotifyInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("Unexpected notification of a loopback address")
+	}))
+	defer server.Close()
+
+	build := testBuild()
+	builds := &fakeBuildClient{build: build}
+	n := NewNotifier(&fakeConfigGetter{}, fakeSecretGetter{}, builds)
+	n.Attempts = 1
+	n.notify(build, []buildapi.BuildNotification{{URL: server.URL, SecretName: "notification-secret"}})
+	if builds.updated == nil || builds.updated.Notifications[0].Delivered {
+		t.Errorf("Expected the notification not to be delivered, got %#v", builds.updated)
+	}
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.17.0.1", "192.168.1.1", "169.254.169.254", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		if isExternal(net.ParseIP(ip)) {
+			t.Errorf("Expected %s to be internal", ip)
+		}
+	}
+	for _, ip := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		if !isExternal(net.ParseIP(ip)) {
+			t.Errorf("Expected %s to be external", ip)
+		}
+	}
+}
+
+func TestNotifyQueuesBuilds(t *testing.T) {
+	delivered := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		delivered <- req.Header.Get(DeliveryHeader)
+	}))
+	defer server.Close()
+
+	config := &buildapi.BuildConfig{
+		ObjectMeta:    kapi.ObjectMeta{Name: "app"},
+		Notifications: []buildapi.BuildNotification{{URL: server.URL, SecretName: "notification-secret"}},
+	}
+	configs := &countingConfigGetter{fakeConfigGetter: fakeConfigGetter{config}}
+	builds := &fakeBuildClient{build: testBuild()}
+	n := NewNotifier(configs, fakeSecretGetter{}, builds)
+	n.Client = &http.Client{}
+
+	n.Notify(testBuild())
+	if configs.gets != 0 {
+		t.Fatalf("Expected the BuildConfig to be read by the worker, not by Notify")
+	}
+	n.handleNext()
+	select {
+	case id := <-delivered:
+		if id != "1234" {
+			t.Errorf("Unexpected delivery ID %s", id)
+		}
+	default:
+		t.Fatalf("Expected the queued notification to be delivered")
+	}
+	if builds.updated == nil || len(builds.updated.Notifications) != 1 || !builds.updated.Notifications[0].Delivered {
+		t.Errorf("Expected the notification to be recorded as delivered, got %#v", builds.updated)
+	}
+}
+
+type countingConfigGetter struct {
+	fakeConfigGetter
+	gets int
+}
+
+func (g *countingConfigGetter) Get(namespace, name string) (*buildapi.BuildConfig, error) {
+	g.gets++
+	return g.fakeConfigGetter.Get(namespace, name)
+}
+
+func TestNotifyWithoutNotifications(t *testing.T) {
+	builds := &fakeBuildClient{build: testBuild()}
+	n := NewNotifier(&fakeConfigGetter{&buildapi.BuildConfig{ObjectMeta: kapi.ObjectMeta{Name: "app"}}}, fakeSecretGetter{}, builds)
+	n.Notify(&buildapi.Build{ObjectMeta: kapi.ObjectMeta{Name: "orphan"}})
+	n.Notify(testBuild())
+	if items := n.queue.List(); len(items) != 1 {
+		t.Fatalf("Expected only the build of a BuildConfig to be queued, got %v", items)
+	}
+	n.handleNext()
+	if builds.updated != nil {
+		t.Errorf("Unexpected update of build %#v", builds.updated)
+	}
+}
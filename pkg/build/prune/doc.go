@@ -0,0 +1,3 @@
+// Package prune selects finished builds that are no longer needed and deletes
+// them together with the pods that ran them.
+package prune
@@ -0,0 +1,163 @@
+package prune
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+// Options controls which finished builds are selected for pruning.
+type Options struct {
+	// KeepYoungerThan keeps every build that finished less than this long ago.
+	KeepYoungerThan time.Duration
+	// KeepComplete is the number of most recent complete builds kept for each BuildConfig.
+	KeepComplete int
+	// KeepFailed is the number of most recent failed, errored or cancelled builds kept for
+	// each BuildConfig.
+	KeepFailed int
+	// Orphans selects the finished builds whose BuildConfig does not exist.
+	Orphans bool
+}
+
+// SelectBuilds returns the finished builds that should be removed. Builds of an existing
+// BuildConfig are selected once there are more recent builds to keep, builds without a
+// BuildConfig are selected only when Orphans is set. Builds that finished within
+// KeepYoungerThan of now are never selected.
+func SelectBuilds(configs []buildapi.BuildConfig, builds []buildapi.Build, options Options, now time.Time) []*buildapi.Build {
+	exists := map[string]bool{}
+	for _, config := range configs {
+		exists[config.Namespace+"/"+config.Name] = true
+	}
+
+	byConfig := map[string][]*buildapi.Build{}
+	orphans := []*buildapi.Build{}
+	for i := range builds {
+		build := &builds[i]
+		configName := build.Labels[buildapi.BuildConfigLabel]
+		if len(configName) == 0 || !exists[build.Namespace+"/"+configName] {
+			if IsFinished(build) {
+				orphans = append(orphans, build)
+			}
+			continue
+		}
+		key := build.Namespace + "/" + configName
+		byConfig[key] = append(byConfig[key], build)
+	}
+
+	candidates := []*buildapi.Build{}
+	for _, configBuilds := range byConfig {
+		candidates = append(candidates, BuildsOverLimit(configBuilds, &options.KeepComplete, &options.KeepFailed)...)
+	}
+	if options.Orphans {
+		candidates = append(candidates, orphans...)
+	}
+
+	selected := []*buildapi.Build{}
+	for _, build := range candidates {
+		if now.Sub(finishedAt(build)) < options.KeepYoungerThan {
+			continue
+		}
+		selected = append(selected, build)
+	}
+	sort.Sort(sort.Reverse(byFinished(selected)))
+	return selected
+}
+
+// BuildsOverLimit returns the finished builds beyond the most recent keepComplete complete
+// builds and keepFailed failed, errored or cancelled builds. The builds are expected to belong
+// to the same BuildConfig. A nil limit keeps all builds of that kind.
+func BuildsOverLimit(builds []*buildapi.Build, keepComplete, keepFailed *int) []*buildapi.Build {
+	complete, failed := []*buildapi.Build{}, []*buildapi.Build{}
+	for _, build := range builds {
+		switch {
+		case build.Status == buildapi.BuildStatusComplete:
+			complete = append(complete, build)
+		case IsFinished(build):
+			failed = append(failed, build)
+		}
+	}
+
+	over := []*buildapi.Build{}
+	for _, group := range []struct {
+		builds []*buildapi.Build
+		keep   *int
+	}{{complete, keepComplete}, {failed, keepFailed}} {
+		if group.keep == nil || len(group.builds) <= *group.keep {
+			continue
+		}
+		sort.Sort(sort.Reverse(byFinished(group.builds)))
+		over = append(over, group.builds[*group.keep:]...)
+	}
+	return over
+}
+
+// IsFinished returns true if the build will not run any more.
+func IsFinished(build *buildapi.Build) bool {
+	switch build.Status {
+	case buildapi.BuildStatusComplete, buildapi.BuildStatusFailed, buildapi.BuildStatusError, buildapi.BuildStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// finishedAt returns the time a build finished, falling back to its creation for builds
+// that never ran.
+func finishedAt(build *buildapi.Build) time.Time {
+	if build.CompletionTimestamp != nil {
+		return build.CompletionTimestamp.Time
+	}
+	return build.CreationTimestamp.Time
+}
+
+// byFinished sorts builds by the time they finished, oldest first.
+type byFinished []*buildapi.Build
+
+func (b byFinished) Len() int      { return len(b) }
+func (b byFinished) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byFinished) Less(i, j int) bool {
+	ti, tj := finishedAt(b[i]), finishedAt(b[j])
+	if !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	if b[i].Number != b[j].Number {
+		return b[i].Number < b[j].Number
+	}
+	return b[i].Name < b[j].Name
+}
+
+// BuildDeleter deletes builds.
+type BuildDeleter interface {
+	DeleteBuild(build *buildapi.Build) error
+}
+
+// NewBuildDeleter returns a BuildDeleter that deletes a build and the pod that ran it.
+func NewBuildDeleter(osClient osclient.Interface, kubeClient kclient.Interface) BuildDeleter {
+	return &buildDeleter{osClient: osClient, kubeClient: kubeClient}
+}
+
+type buildDeleter struct {
+	osClient   osclient.Interface
+	kubeClient kclient.Interface
+}
+
+// DeleteBuild deletes the pod of the build, if any, and then the build itself.
+func (d *buildDeleter) DeleteBuild(build *buildapi.Build) error {
+	if len(build.PodName) > 0 {
+		glog.V(4).Infof("Deleting pod %s/%s of build %s", build.Namespace, build.PodName, build.Name)
+		if err := d.kubeClient.Pods(build.Namespace).Delete(build.PodName); err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+	}
+	glog.V(4).Infof("Deleting build %s/%s", build.Namespace, build.Name)
+	if err := d.osClient.Builds(build.Namespace).Delete(build.Name); err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
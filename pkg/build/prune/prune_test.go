@@ -0,0 +1,129 @@
+package prune
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
+)
+
+var now = time.Date(2015, 4, 1, 12, 0, 0, 0, time.UTC)
+
+func mockBuild(name, config string, status buildapi.BuildStatus, finishedAgo time.Duration) buildapi.Build {
+	build := buildapi.Build{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:              name,
+			Namespace:         "test",
+			CreationTimestamp: util.NewTime(now.Add(-finishedAgo - time.Minute)),
+		},
+		Status: status,
+	}
+	if len(config) > 0 {
+		build.Labels = map[string]string{buildapi.BuildConfigLabel: config}
+	}
+	if status != buildapi.BuildStatusNew && status != buildapi.BuildStatusPending && status != buildapi.BuildStatusRunning {
+		finished := util.NewTime(now.Add(-finishedAgo))
+		build.CompletionTimestamp = &finished
+	}
+	return build
+}
+
+func names(builds []*buildapi.Build) []string {
+	result := []string{}
+	for _, build := range builds {
+		result = append(result, build.Name)
+	}
+	return result
+}
+
+func TestSelectBuilds(t *testing.T) {
+	configs := []buildapi.BuildConfig{
+		{ObjectMeta: kapi.ObjectMeta{Name: "app", Namespace: "test"}},
+	}
+	builds := []buildapi.Build{
+		mockBuild("app-1", "app", buildapi.BuildStatusComplete, 5*time.Hour),
+		mockBuild("app-2", "app", buildapi.BuildStatusFailed, 4*time.Hour),
+		mockBuild("app-3", "app", buildapi.BuildStatusComplete, 3*time.Hour),
+		mockBuild("app-4", "app", buildapi.BuildStatusCancelled, 2*time.Hour),
+		mockBuild("app-5", "app", buildapi.BuildStatusComplete, 10*time.Minute),
+		mockBuild("app-6", "app", buildapi.BuildStatusRunning, 0),
+		mockBuild("gone-1", "gone", buildapi.BuildStatusComplete, 3*time.Hour),
+		mockBuild("gone-2", "gone", buildapi.BuildStatusNew, 0),
+		mockBuild("manual", "", buildapi.BuildStatusFailed, 3*time.Hour),
+	}
+
+	tests := map[string]struct {
+		options  Options
+		expected []string
+	}{
+		"keep all by count": {
+			options:  Options{KeepComplete: 5, KeepFailed: 5},
+			expected: []string{},
+		},
+		"keep most recent": {
+			options:  Options{KeepComplete: 1, KeepFailed: 1},
+			expected: []string{"app-3", "app-2", "app-1"},
+		},
+		"keep none": {
+			options:  Options{},
+			expected: []string{"app-5", "app-4", "app-3", "app-2", "app-1"},
+		},
+		"keep younger builds": {
+			options:  Options{KeepYoungerThan: time.Hour},
+			expected: []string{"app-4", "app-3", "app-2", "app-1"},
+		},
+		"orphans": {
+			options:  Options{KeepComplete: 5, KeepFailed: 5, Orphans: true},
+			expected: []string{"manual", "gone-1"},
+		},
+	}
+
+	for name, test := range tests {
+		selected := names(SelectBuilds(configs, builds, test.options, now))
+		if !reflect.DeepEqual(selected, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, selected)
+		}
+	}
+}
+
+func TestBuildsOverLimit(t *testing.T) {
+	list := []buildapi.Build{
+		mockBuild("app-1", "app", buildapi.BuildStatusComplete, 3*time.Hour),
+		mockBuild("app-2", "app", buildapi.BuildStatusError, 2*time.Hour),
+		mockBuild("app-3", "app", buildapi.BuildStatusComplete, time.Hour),
+		mockBuild("app-4", "app", buildapi.BuildStatusPending, 0),
+	}
+	builds := []*buildapi.Build{}
+	for i := range list {
+		builds = append(builds, &list[i])
+	}
+
+	zero, one := 0, 1
+	tests := map[string]struct {
+		keepComplete, keepFailed *int
+		expected                 []string
+	}{
+		"no limits": {
+			expected: []string{},
+		},
+		"complete limit": {
+			keepComplete: &one,
+			expected:     []string{"app-1"},
+		},
+		"failed limit": {
+			keepFailed: &zero,
+			expected:   []string{"app-2"},
+		},
+	}
+
+	for name, test := range tests {
+		over := names(BuildsOverLimit(builds, test.keepComplete, test.keepFailed))
+		if !reflect.DeepEqual(over, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, over)
+		}
+	}
+}
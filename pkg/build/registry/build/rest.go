@@ -14,6 +14,7 @@ import (
 
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/build/api/validation"
+	"github.com/openshift/origin/pkg/cmd/server/bootstrappolicy"
 )
 
 // REST implements the RESTStorage interface in terms of an Registry.
@@ -80,6 +81,9 @@ func (r *REST) Create(ctx kapi.Context, obj runtime.Object) (runtime.Object, err
 	if len(build.Status) == 0 {
 		build.Status = api.BuildStatusNew
 	}
+	if err := setCauses(ctx, build); err != nil {
+		return nil, err
+	}
 	kapi.FillObjectMetaSystemFields(ctx, &build.ObjectMeta)
 	if errs := validation.ValidateBuild(build); len(errs) > 0 {
 		return nil, errors.NewInvalid("build", build.Name, errs)
@@ -91,6 +95,31 @@ func (r *REST) Create(ctx kapi.Context, obj runtime.Object) (runtime.Object, err
 	return build, nil
 }
 
+// setCauses records why build was created. The triggers and the binary build endpoint create
+// builds as the internal component user and record the causes themselves, builds created by
+// anyone else are recorded as started manually by the requesting user.
+func setCauses(ctx kapi.Context, build *api.Build) error {
+	userName := ""
+	if user, ok := kapi.UserFrom(ctx); ok {
+		userName = user.GetName()
+	}
+	if userName == bootstrappolicy.InternalComponentUsername {
+		return nil
+	}
+
+	manual := &api.BuildCauseManual{User: userName}
+	for _, cause := range build.Causes {
+		if cause.Type != api.ManualBuildTriggerType {
+			return errors.NewForbidden("build", build.Name, fmt.Errorf("builds may not be recorded as started by a %s trigger", cause.Type))
+		}
+		if cause.Manual != nil && len(cause.Manual.FromBuild) != 0 {
+			manual.FromBuild = cause.Manual.FromBuild
+		}
+	}
+	build.Causes = []api.BuildCause{{Type: api.ManualBuildTriggerType, Manual: manual}}
+	return nil
+}
+
 // Update replaces a given Build instance with an existing instance in r.registry.
 func (r *REST) Update(ctx kapi.Context, obj runtime.Object) (runtime.Object, bool, error) {
 	build, ok := obj.(*api.Build)
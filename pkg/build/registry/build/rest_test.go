@@ -12,6 +12,7 @@ import (
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/auth/user"
 	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
@@ -19,6 +20,7 @@ import (
 	"github.com/openshift/origin/pkg/api/latest"
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/build/registry/test"
+	"github.com/openshift/origin/pkg/cmd/server/bootstrappolicy"
 )
 
 func TestNewBuild(t *testing.T) {
@@ -239,6 +241,60 @@ func TestCreateBuild(t *testing.T) {
 	}
 }
 
+func TestCreateBuildRecordsUser(t *testing.T) {
+	storage := REST{&test.BuildRegistry{}}
+	build := mockBuild()
+	build.Causes = []api.BuildCause{
+		{Type: api.ManualBuildTriggerType, Manual: &api.BuildCauseManual{FromBuild: "data-build-1"}},
+		{Type: api.ManualBuildTriggerType, Manual: &api.BuildCauseManual{User: "other-user"}},
+	}
+	ctx := kapi.WithUser(kapi.NewDefaultContext(), &user.DefaultInfo{Name: "jdoe"})
+	obj, err := storage.Create(ctx, build)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	causes := obj.(*api.Build).Causes
+	expected := []api.BuildCause{{Type: api.ManualBuildTriggerType, Manual: &api.BuildCauseManual{User: "jdoe", FromBuild: "data-build-1"}}}
+	if !reflect.DeepEqual(causes, expected) {
+		t.Errorf("Expected the requesting user to be recorded, got %#v", causes)
+	}
+
+	build = mockBuild()
+	if obj, err = storage.Create(ctx, build); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	causes = obj.(*api.Build).Causes
+	if len(causes) != 1 || causes[0].Manual == nil || causes[0].Manual.User != "jdoe" {
+		t.Errorf("Expected a manual cause to be recorded, got %#v", causes)
+	}
+}
+
+func TestCreateBuildTriggerCauses(t *testing.T) {
+	storage := REST{&test.BuildRegistry{}}
+	causes := []api.BuildCause{
+		{Type: api.ManualBuildTriggerType, Manual: &api.BuildCauseManual{User: "binary-user"}},
+		{Type: api.GenericWebHookBuildTriggerType, WebHook: &api.BuildCauseWebHook{}},
+	}
+
+	build := mockBuild()
+	build.Causes = causes
+	ctx := kapi.WithUser(kapi.NewDefaultContext(), &user.DefaultInfo{Name: "jdoe"})
+	if _, err := storage.Create(ctx, build); !errors.IsForbidden(err) {
+		t.Errorf("Expected users not to record trigger causes, got %v", err)
+	}
+
+	build = mockBuild()
+	build.Causes = causes
+	ctx = kapi.WithUser(kapi.NewDefaultContext(), &user.DefaultInfo{Name: bootstrappolicy.InternalComponentUsername})
+	obj, err := storage.Create(ctx, build)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(obj.(*api.Build).Causes, causes) {
+		t.Errorf("Expected the causes recorded by the triggers to be kept, got %#v", obj.(*api.Build).Causes)
+	}
+}
+
 func TestUpdateBuild(t *testing.T) {
 	mockRegistry := test.BuildRegistry{}
 	storage := REST{&mockRegistry}
@@ -0,0 +1,181 @@
+package buildlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	restful "github.com/emicklei/go-restful"
+	"github.com/golang/glog"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/registry/build"
+)
+
+// Handler serves the logs of builds. The logs of finished builds are read from the LogStore,
+// the logs of running builds are read from their pod.
+type Handler struct {
+	BuildRegistry build.Registry
+	LogStore      LogStore
+	PodControl    PodControlInterface
+	Logs          ContainerLogGetter
+	Codec         runtime.Codec
+}
+
+// NewHandler creates a Handler serving logs stored in store, or read from build pods with
+// the provided client.
+func NewHandler(registry build.Registry, store LogStore, client *kclient.Client, codec runtime.Codec) *Handler {
+	return &Handler{
+		BuildRegistry: registry,
+		LogStore:      store,
+		PodControl:    RealPodControl{client},
+		Logs:          NewContainerLogGetter(client),
+		Codec:         codec,
+	}
+}
+
+// logSource opens the log of a build. Logs are only followed while the build is running.
+type logSource func(follow bool) (io.ReadCloser, error)
+
+// ServeRequest handles a GET to buildLogs/{name}. The follow, tailLines and sinceTime query
+// parameters select the part of the log that is returned.
+func (h *Handler) ServeRequest(req *restful.Request, resp *restful.Response) {
+	namespace := req.Request.URL.Query().Get("namespace")
+	if len(namespace) == 0 {
+		namespace = kapi.NamespaceDefault
+	}
+	name := req.PathParameter("name")
+	options, err := ParseLogOptions(req.Request.URL.Query())
+	if err != nil {
+		h.writeError(resp.ResponseWriter, kerrors.NewBadRequest(err.Error()))
+		return
+	}
+
+	ctx := kapi.WithNamespace(kapi.NewContext(), namespace)
+	if err := h.serveLog(ctx, resp.ResponseWriter, name, options); err != nil {
+		glog.V(4).Infof("Failed to serve the log of build %s/%s: %v", namespace, name, err)
+		h.writeError(resp.ResponseWriter, err)
+	}
+}
+
+// serveLog writes the selected part of the log of the named build to w. Errors returned
+// before anything was written can still be reported to the client.
+func (h *Handler) serveLog(ctx kapi.Context, w http.ResponseWriter, name string, options api.BuildLogOptions) error {
+	open, err := h.logSource(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	skip := int64(0)
+	if options.TailLines != nil && options.Follow {
+		// the end of a followed log is unknown, so count what was logged so far to find
+		// where the tail starts
+		in, err := open(false)
+		if err != nil {
+			return err
+		}
+		lines, err := countLines(in, options.SinceTime)
+		in.Close()
+		if err != nil {
+			return err
+		}
+		if skip = lines - *options.TailLines; skip < 0 {
+			skip = 0
+		}
+	}
+
+	in, err := open(options.Follow)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	if options.TailLines != nil && !options.Follow {
+		err = copyTail(w, in, options.SinceTime, *options.TailLines)
+	} else {
+		err = copyLines(w, in, options.SinceTime, skip, options.Follow)
+	}
+	if err != nil {
+		glog.V(4).Infof("Stopped streaming the log of build %s: %v", name, err)
+	}
+	return nil
+}
+
+// logSource returns the source of the log of the named build.
+func (h *Handler) logSource(ctx kapi.Context, name string) (logSource, error) {
+	build, err := h.BuildRegistry.GetBuild(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch build.Status {
+	case api.BuildStatusNew, api.BuildStatusPending:
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("build %s is %s, its log is available once it is running", name, strings.ToLower(string(build.Status))))
+	case api.BuildStatusRunning:
+		return h.podLogSource(build)
+	}
+
+	log, err := h.LogStore.GetBuildLog(ctx, name)
+	if err == nil {
+		return func(bool) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(log)), nil
+		}, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	// the log was not stored, serve it from the pod as long as the pod exists
+	source, err := h.podLogSource(build)
+	if err != nil {
+		glog.V(4).Infof("No log is available for build %s: %v", name, err)
+		return nil, kerrors.NewNotFound("buildLog", name)
+	}
+	return func(bool) (io.ReadCloser, error) {
+		return source(false)
+	}, nil
+}
+
+// podLogSource returns the source of the log of the container running the build.
+func (h *Handler) podLogSource(build *api.Build) (logSource, error) {
+	if len(build.PodName) == 0 {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("build %s has no pod", build.Name))
+	}
+	pod, err := h.PodControl.getPod(build.Namespace, build.PodName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("pod %s of build %s has no containers", pod.Name, build.Name))
+	}
+	// Build will take place only in one container
+	container := pod.Spec.Containers[0].Name
+	return func(follow bool) (io.ReadCloser, error) {
+		return h.Logs.ContainerLog(pod, container, follow)
+	}, nil
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	statusErr, ok := err.(*kerrors.StatusError)
+	if !ok {
+		statusErr = kerrors.NewInternalError(err).(*kerrors.StatusError)
+	}
+	status := statusErr.Status()
+	data, err := h.Codec.Encode(&status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status.Code)
+	w.Write(data)
+}
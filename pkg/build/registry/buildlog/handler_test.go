@@ -0,0 +1,238 @@
+package buildlog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/registry/test"
+)
+
+const testLog = "2015-04-20T10:00:00.000000000Z line 1\n" +
+	"2015-04-20T10:01:00.000000000Z line 2\n" +
+	"2015-04-20T10:02:00.000000000Z line 3\n" +
+	"2015-04-20T10:03:00.000000000Z line 4\n"
+
+type fakeLogStore struct {
+	logs map[string]string
+}
+
+func (s *fakeLogStore) GetBuildLog(ctx kapi.Context, name string) ([]byte, error) {
+	log, ok := s.logs[name]
+	if !ok {
+		return nil, kerrors.NewNotFound("buildLog", name)
+	}
+	return []byte(log), nil
+}
+
+func (s *fakeLogStore) SetBuildLog(ctx kapi.Context, name string, log []byte) error {
+	if s.logs == nil {
+		s.logs = map[string]string{}
+	}
+	s.logs[name] = string(log)
+	return nil
+}
+
+type fakeLogGetter struct {
+	log    string
+	follow []bool
+}
+
+func (g *fakeLogGetter) ContainerLog(pod *kapi.Pod, container string, follow bool) (io.ReadCloser, error) {
+	g.follow = append(g.follow, follow)
+	return ioutil.NopCloser(strings.NewReader(g.log)), nil
+}
+
+func testHandler(status api.BuildStatus, store *fakeLogStore, logs *fakeLogGetter) *Handler {
+	return &Handler{
+		BuildRegistry: &test.BuildRegistry{Build: mockBuild(status, "runningPod")},
+		LogStore:      store,
+		PodControl:    &podControl{},
+		Logs:          logs,
+	}
+}
+
+func tailLines(lines int64) *int64 {
+	return &lines
+}
+
+func TestServeLog(t *testing.T) {
+	tests := map[string]struct {
+		status   api.BuildStatus
+		stored   bool
+		options  api.BuildLogOptions
+		expected string
+		follow   []bool
+	}{
+		"stored log of a finished build": {
+			status:   api.BuildStatusComplete,
+			stored:   true,
+			expected: testLog,
+		},
+		"pod log of a finished build that was not stored": {
+			status:   api.BuildStatusFailed,
+			expected: testLog,
+			follow:   []bool{false},
+		},
+		"followed running build": {
+			status:   api.BuildStatusRunning,
+			options:  api.BuildLogOptions{Follow: true},
+			expected: testLog,
+			follow:   []bool{true},
+		},
+		"tail": {
+			status:   api.BuildStatusComplete,
+			stored:   true,
+			options:  api.BuildLogOptions{TailLines: tailLines(2)},
+			expected: "2015-04-20T10:02:00.000000000Z line 3\n2015-04-20T10:03:00.000000000Z line 4\n",
+		},
+		"tail of a followed running build": {
+			status:   api.BuildStatusRunning,
+			options:  api.BuildLogOptions{Follow: true, TailLines: tailLines(1)},
+			expected: "2015-04-20T10:03:00.000000000Z line 4\n",
+			follow:   []bool{false, true},
+		},
+		"since time": {
+			status:   api.BuildStatusRunning,
+			options:  api.BuildLogOptions{SinceTime: &util.Time{Time: time.Date(2015, 4, 20, 10, 2, 0, 0, time.UTC)}},
+			expected: "2015-04-20T10:02:00.000000000Z line 3\n2015-04-20T10:03:00.000000000Z line 4\n",
+			follow:   []bool{false},
+		},
+		"since time and tail": {
+			status: api.BuildStatusComplete,
+			stored: true,
+			options: api.BuildLogOptions{
+				SinceTime: &util.Time{Time: time.Date(2015, 4, 20, 10, 1, 0, 0, time.UTC)},
+				TailLines: tailLines(5),
+			},
+			expected: "2015-04-20T10:01:00.000000000Z line 2\n2015-04-20T10:02:00.000000000Z line 3\n2015-04-20T10:03:00.000000000Z line 4\n",
+		},
+	}
+
+	for name, test := range tests {
+		store := &fakeLogStore{}
+		if test.stored {
+			store.logs = map[string]string{"foo-build": testLog}
+		}
+		logs := &fakeLogGetter{log: testLog}
+		h := testHandler(test.status, store, logs)
+
+		w := httptest.NewRecorder()
+		if err := h.serveLog(kapi.NewDefaultContext(), w, "foo-build", test.options); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if w.Body.String() != test.expected {
+			t.Errorf("%s: expected log %q, got %q", name, test.expected, w.Body.String())
+		}
+		if len(logs.follow) != len(test.follow) {
+			t.Errorf("%s: expected pod log reads %v, got %v", name, test.follow, logs.follow)
+			continue
+		}
+		for i := range test.follow {
+			if logs.follow[i] != test.follow[i] {
+				t.Errorf("%s: expected pod log reads %v, got %v", name, test.follow, logs.follow)
+			}
+		}
+	}
+}
+
+func TestServeLogPendingBuild(t *testing.T) {
+	for _, status := range []api.BuildStatus{api.BuildStatusNew, api.BuildStatusPending} {
+		h := testHandler(status, &fakeLogStore{}, &fakeLogGetter{log: testLog})
+		err := h.serveLog(kapi.NewDefaultContext(), httptest.NewRecorder(), "foo-build", api.BuildLogOptions{Follow: true})
+		if !kerrors.IsBadRequest(err) {
+			t.Errorf("%s: expected a bad request error, got %v", status, err)
+		}
+	}
+}
+
+func TestParseLogOptions(t *testing.T) {
+	options, err := ParseLogOptions(url.Values{
+		"follow":    {"true"},
+		"tailLines": {"10"},
+		"sinceTime": {"2015-04-20T10:00:00Z"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !options.Follow || options.TailLines == nil || *options.TailLines != 10 {
+		t.Errorf("Unexpected options %#v", options)
+	}
+	if options.SinceTime == nil || !options.SinceTime.Equal(time.Date(2015, 4, 20, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected since time %v", options.SinceTime)
+	}
+
+	for _, query := range []url.Values{{"follow": {"sure"}}, {"tailLines": {"-1"}}, {"sinceTime": {"yesterday"}}} {
+		if _, err := ParseLogOptions(query); err == nil {
+			t.Errorf("Expected an error parsing %v", query)
+		}
+	}
+}
+
+func TestSaveBuildLog(t *testing.T) {
+	store := &fakeLogStore{}
+	saver := &LogSaver{Logs: &fakeLogGetter{log: testLog}, Store: store, MaxBytes: len(testLog)}
+	if err := saver.SaveBuildLog(mockBuild(api.BuildStatusComplete, "runningPod"), mockPod(kapi.PodSucceeded)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.logs["foo-build"] != testLog {
+		t.Errorf("Expected the whole log to be stored, got %q", store.logs["foo-build"])
+	}
+}
+
+func TestSaveBuildLogTimestampsLines(t *testing.T) {
+	store := &fakeLogStore{}
+	saver := &LogSaver{Logs: &fakeLogGetter{log: testLog}, Store: store, MaxBytes: 60}
+	if err := saver.SaveBuildLog(mockBuild(api.BuildStatusComplete, "runningPod"), mockPod(kapi.PodSucceeded)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "2015-04-20T10:03:00Z ... 114 bytes of the build log were truncated ...\n2015-04-20T10:03:00.000000000Z line 4\n"
+	if store.logs["foo-build"] != expected {
+		t.Errorf("Expected %q, got %q", expected, store.logs["foo-build"])
+	}
+}
+
+func TestTimestampLines(t *testing.T) {
+	now := time.Date(2015, 4, 20, 11, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"":                "",
+		"no time\nat all": "2015-04-20T11:00:00Z no time\n2015-04-20T11:00:00Z at all",
+		"2015-04-20T10:00:00.000000000Z line 1\ncontinued\n2015-04-20T10:01:00.000000000Z line 2\n": "2015-04-20T10:00:00.000000000Z line 1\n2015-04-20T10:00:00Z continued\n2015-04-20T10:01:00.000000000Z line 2\n",
+	}
+	for log, expected := range tests {
+		if actual := string(timestampLines([]byte(log), now)); actual != expected {
+			t.Errorf("%q: expected %q, got %q", log, expected, actual)
+		}
+	}
+}
+
+func TestReadTailTruncatesLongLogs(t *testing.T) {
+	log, err := readTail(strings.NewReader(testLog), 60)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "... 114 bytes of the build log were truncated ...\n2015-04-20T10:03:00.000000000Z line 4\n"
+	if string(log) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(log))
+	}
+
+	big := bytes.Repeat([]byte("0123456789\n"), 100000)
+	log, err = readTail(bytes.NewReader(big), 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(string(log), "0123456789\n") || len(log) > 1100 {
+		t.Errorf("Unexpected truncated log of %d bytes", len(log))
+	}
+}
@@ -0,0 +1,194 @@
+package buildlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// LogStore stores the logs of finished builds.
+type LogStore interface {
+	GetBuildLog(ctx kapi.Context, name string) ([]byte, error)
+	SetBuildLog(ctx kapi.Context, name string, log []byte) error
+}
+
+// ContainerLogGetter reads the log of a container in a pod.
+type ContainerLogGetter interface {
+	ContainerLog(pod *kapi.Pod, container string, follow bool) (io.ReadCloser, error)
+}
+
+// NewContainerLogGetter returns a ContainerLogGetter that reads container logs from the
+// node running the pod through the node proxy of the API server.
+func NewContainerLogGetter(client *kclient.Client) ContainerLogGetter {
+	return proxyLogGetter{client}
+}
+
+// proxyLogGetter reads container logs through the node proxy of the API server.
+type proxyLogGetter struct {
+	client *kclient.Client
+}
+
+func (g proxyLogGetter) ContainerLog(pod *kapi.Pod, container string, follow bool) (io.ReadCloser, error) {
+	req := g.client.RESTClient.Get().
+		Prefix("proxy").
+		Resource("minions").
+		Name(pod.Status.Host).
+		Suffix("containerLogs", pod.Namespace, pod.Name, container)
+	if follow {
+		req = req.Param("follow", "1")
+	}
+	return req.Stream()
+}
+
+// ParseLogOptions reads the follow, tailLines and sinceTime query parameters.
+func ParseLogOptions(query url.Values) (api.BuildLogOptions, error) {
+	options := api.BuildLogOptions{}
+	if value := query.Get("follow"); len(value) > 0 {
+		follow, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("follow must be a boolean: %v", err)
+		}
+		options.Follow = follow
+	}
+	if value := query.Get("tailLines"); len(value) > 0 {
+		lines, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || lines < 0 {
+			return options, fmt.Errorf("tailLines must be a non-negative number of lines")
+		}
+		options.TailLines = &lines
+	}
+	if value := query.Get("sinceTime"); len(value) > 0 {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return options, fmt.Errorf("sinceTime must be a RFC3339 time: %v", err)
+		}
+		options.SinceTime = &util.Time{Time: since}
+	}
+	return options, nil
+}
+
+// lineTime returns the time prefixed to a log line. The node returns container logs with
+// timestamps and stored logs are timestamped when they are saved, so every line starts with
+// the time it was logged at.
+func lineTime(line string) (time.Time, bool) {
+	i := strings.Index(line, " ")
+	if i <= 0 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, line[:i])
+	return t, err == nil
+}
+
+// sinceFilter selects the lines logged at or after a point in time. Lines without a
+// timestamp are selected like the line before them.
+type sinceFilter struct {
+	since    *util.Time
+	selected bool
+}
+
+func (f *sinceFilter) selects(line string) bool {
+	if f.since == nil {
+		return true
+	}
+	if t, ok := lineTime(line); ok {
+		f.selected = !t.Before(f.since.Time)
+	}
+	return f.selected
+}
+
+// readLines calls fn with every line read from in until in is exhausted or fn fails.
+func readLines(in io.Reader, fn func(line string) error) error {
+	r := bufio.NewReader(in)
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// countLines returns the number of lines read from in that were logged since the given time.
+func countLines(in io.Reader, since *util.Time) (int64, error) {
+	filter := &sinceFilter{since: since}
+	count := int64(0)
+	err := readLines(in, func(line string) error {
+		if filter.selects(line) {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// copyLines writes the lines read from in that were logged since the given time to out,
+// leaving out the first skip of them. Every line is flushed to the client when flush is set.
+func copyLines(out io.Writer, in io.Reader, since *util.Time, skip int64, flush bool) error {
+	filter := &sinceFilter{since: since}
+	flusher, _ := out.(http.Flusher)
+	return readLines(in, func(line string) error {
+		if !filter.selects(line) {
+			return nil
+		}
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		if _, err := io.WriteString(out, line); err != nil {
+			return err
+		}
+		if flush && flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// copyTail writes the last tail lines read from in that were logged since the given time to out.
+func copyTail(out io.Writer, in io.Reader, since *util.Time, tail int64) error {
+	if tail == 0 {
+		return nil
+	}
+	filter := &sinceFilter{since: since}
+	lines := []string{}
+	next := 0
+	err := readLines(in, func(line string) error {
+		if !filter.selects(line) {
+			return nil
+		}
+		if int64(len(lines)) < tail {
+			lines = append(lines, line)
+			return nil
+		}
+		lines[next] = line
+		next = (next + 1) % len(lines)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range lines {
+		if _, err := io.WriteString(out, lines[(next+i)%len(lines)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
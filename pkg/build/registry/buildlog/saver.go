@@ -0,0 +1,121 @@
+package buildlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// DefaultMaxLogBytes is the size stored build logs are limited to. Longer logs are
+// truncated, keeping their end where build failures are reported.
+const DefaultMaxLogBytes = 1024 * 1024
+
+// LogSaver copies the log of a finished build from its pod to the LogStore, so that it
+// stays available once the pod is deleted.
+type LogSaver struct {
+	Logs  ContainerLogGetter
+	Store LogStore
+	// MaxBytes is the maximum size of a stored log.
+	MaxBytes int
+}
+
+// NewLogSaver creates a LogSaver reading build logs with the provided client.
+func NewLogSaver(client *kclient.Client, store LogStore) *LogSaver {
+	return &LogSaver{
+		Logs:     NewContainerLogGetter(client),
+		Store:    store,
+		MaxBytes: DefaultMaxLogBytes,
+	}
+}
+
+// SaveBuildLog stores the log of the container that ran the build in pod.
+func (s *LogSaver) SaveBuildLog(build *api.Build, pod *kapi.Pod) error {
+	if len(pod.Spec.Containers) == 0 {
+		return fmt.Errorf("pod %s of build %s has no containers", pod.Name, build.Name)
+	}
+	in, err := s.Logs.ContainerLog(pod, pod.Spec.Containers[0].Name, false)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	log, err := readTail(in, s.MaxBytes)
+	if err != nil {
+		return err
+	}
+	log = timestampLines(log, time.Now())
+	ctx := kapi.WithNamespace(kapi.NewContext(), build.Namespace)
+	return s.Store.SetBuildLog(ctx, build.Name, log)
+}
+
+// readTail reads in and returns at most max bytes from its end. A truncated log starts at
+// a line boundary and is preceded by a line saying how much was left out.
+func readTail(in io.Reader, max int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	dropped := 0
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := in.Read(chunk)
+		buf.Write(chunk[:n])
+		// keep the buffer from growing far beyond the limit while reading
+		if excess := buf.Len() - max; excess > max {
+			buf.Next(excess)
+			dropped += excess
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if excess := buf.Len() - max; excess > 0 {
+		buf.Next(excess)
+		dropped += excess
+	}
+	if dropped == 0 {
+		return buf.Bytes(), nil
+	}
+	log := buf.Bytes()
+	if i := bytes.IndexByte(log, '\n'); i >= 0 {
+		dropped += i + 1
+		log = log[i+1:]
+	}
+	header := fmt.Sprintf("... %d bytes of the build log were truncated ...\n", dropped)
+	return append([]byte(header), log...), nil
+}
+
+// timestampLines prefixes the lines of log without a timestamp, like the truncation header,
+// with the time of the line before them, so that every stored line can be selected by the
+// time it was logged at. Leading lines get the time of the first timestamped line, or now
+// when no line has a timestamp.
+func timestampLines(log []byte, now time.Time) []byte {
+	lines := strings.SplitAfter(string(log), "\n")
+	last := now.UTC().Format(time.RFC3339Nano)
+	for _, line := range lines {
+		if t, ok := lineTime(line); ok {
+			last = t.Format(time.RFC3339Nano)
+			break
+		}
+	}
+	buf := &bytes.Buffer{}
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		if t, ok := lineTime(line); ok {
+			last = t.Format(time.RFC3339Nano)
+		} else {
+			buf.WriteString(last + " ")
+		}
+		buf.WriteString(line)
+	}
+	return buf.Bytes()
+}
@@ -1,8 +1,11 @@
 package etcd
 
 import (
+	"fmt"
+
 	"github.com/golang/glog"
 
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	etcderr "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors/etcd"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
@@ -20,6 +23,8 @@ const (
 	BuildPath string = "/builds"
 	// BuildConfigPath is the path to buildConfig resources in etcd
 	BuildConfigPath string = "/buildConfigs"
+	// BuildLogPath is the path to the stored logs of finished builds in etcd
+	BuildLogPath string = "/buildLogs"
 )
 
 // Etcd implements build.Registry and buildconfig.Registry backed by etcd.
@@ -101,10 +106,38 @@ func (r *Etcd) CreateBuild(ctx kapi.Context, build *api.Build) error {
 	if err != nil {
 		return err
 	}
+	if err := r.assignBuildNumber(ctx, build); err != nil {
+		return err
+	}
 	err = r.CreateObj(key, build, nil, 0)
 	return etcderr.InterpretCreateError(err, "build", build.Name)
 }
 
+// assignBuildNumber increments the LastVersion of the BuildConfig the build was created from
+// and records the new value as the build number, so builds of a config can be ordered.
+// TODO: move this to the REST layer once it has access to BuildConfigs
+func (r *Etcd) assignBuildNumber(ctx kapi.Context, build *api.Build) error {
+	configName := build.Labels[api.BuildConfigLabel]
+	if len(configName) == 0 || build.Number != 0 {
+		return nil
+	}
+	key, err := makeBuildConfigKey(ctx, configName)
+	if err != nil {
+		return err
+	}
+	err = r.AtomicUpdate(key, &api.BuildConfig{}, false, func(in runtime.Object) (runtime.Object, uint64, error) {
+		config := in.(*api.BuildConfig)
+		config.LastVersion++
+		build.Number = config.LastVersion
+		return config, 0, nil
+	})
+	if tools.IsEtcdNotFound(err) {
+		glog.V(4).Infof("BuildConfig %s for build %s does not exist, the build is not numbered", configName, build.Name)
+		return nil
+	}
+	return err
+}
+
 // UpdateBuild replaces an existing Build.
 func (r *Etcd) UpdateBuild(ctx kapi.Context, build *api.Build) error {
 	key, err := makeBuildKey(ctx, build.Name)
@@ -122,7 +155,44 @@ func (r *Etcd) DeleteBuild(ctx kapi.Context, id string) error {
 		return err
 	}
 	err = r.Delete(key, true)
-	return etcderr.InterpretDeleteError(err, "build", id)
+	if err != nil {
+		return etcderr.InterpretDeleteError(err, "build", id)
+	}
+	logKey, err := makeBuildLogKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Client.Delete(logKey, false); err != nil && !tools.IsEtcdNotFound(err) {
+		glog.V(2).Infof("Unable to delete the stored log of build %s: %v", id, err)
+	}
+	return nil
+}
+
+func makeBuildLogKey(ctx kapi.Context, id string) (string, error) {
+	return kubeetcd.MakeEtcdItemKey(ctx, BuildLogPath, id)
+}
+
+// GetBuildLog returns the stored log of a finished Build.
+func (r *Etcd) GetBuildLog(ctx kapi.Context, id string) ([]byte, error) {
+	key, err := makeBuildLogKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.Get(key, false, false)
+	if err != nil {
+		return nil, etcderr.InterpretGetError(err, "buildLog", id)
+	}
+	return []byte(resp.Node.Value), nil
+}
+
+// SetBuildLog stores the log of a finished Build, replacing any log stored before.
+func (r *Etcd) SetBuildLog(ctx kapi.Context, id string, log []byte) error {
+	key, err := makeBuildLogKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.Client.Set(key, string(log), 0)
+	return etcderr.InterpretUpdateError(err, "buildLog", id)
 }
 
 func makeBuildConfigListKey(ctx kapi.Context) string {
@@ -174,13 +244,24 @@ func (r *Etcd) CreateBuildConfig(ctx kapi.Context, config *api.BuildConfig) erro
 	return etcderr.InterpretCreateError(err, "buildConfig", config.Name)
 }
 
-// UpdateBuildConfig replaces an existing BuildConfig.
+// UpdateBuildConfig replaces an existing BuildConfig. The LastVersion of the stored
+// BuildConfig is kept, since it is only advanced when builds are created.
 func (r *Etcd) UpdateBuildConfig(ctx kapi.Context, config *api.BuildConfig) error {
 	key, err := makeBuildConfigKey(ctx, config.Name)
 	if err != nil {
 		return err
 	}
-	err = r.SetObj(key, config, nil, 0)
+	err = r.AtomicUpdate(key, &api.BuildConfig{}, false, func(in runtime.Object) (runtime.Object, uint64, error) {
+		existing := in.(*api.BuildConfig)
+		if len(config.ResourceVersion) != 0 && existing.ResourceVersion != config.ResourceVersion {
+			return nil, 0, kerrors.NewConflict("buildConfig", config.Name, fmt.Errorf("the resource version %q does not match the stored version %q", config.ResourceVersion, existing.ResourceVersion))
+		}
+		config.LastVersion = existing.LastVersion
+		return config, 0, nil
+	})
+	if tools.IsEtcdNotFound(err) {
+		return kerrors.NewNotFound("buildConfig", config.Name)
+	}
 	return etcderr.InterpretUpdateError(err, "buildConfig", config.Name)
 }
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
@@ -139,6 +140,89 @@ func TestEtcdCreateBuild(t *testing.T) {
 	}
 }
 
+func TestEtcdCreateBuildAssignsNumber(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.Set(makeTestDefaultBuildConfigKey("config"), runtime.EncodeOrDie(latest.Codec, &api.BuildConfig{ObjectMeta: kapi.ObjectMeta{Name: "config"}, LastVersion: 2}), 0)
+	registry := NewTestEtcd(fakeClient)
+	build := &api.Build{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   "foo",
+			Labels: map[string]string{api.BuildConfigLabel: "config"},
+		},
+	}
+	if err := registry.CreateBuild(kapi.NewDefaultContext(), build); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if build.Number != 3 {
+		t.Errorf("Expected build number 3, got %d", build.Number)
+	}
+
+	config, err := registry.GetBuildConfig(kapi.NewDefaultContext(), "config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.LastVersion != 3 {
+		t.Errorf("Expected config LastVersion 3, got %d", config.LastVersion)
+	}
+}
+
+func TestEtcdUpdateBuildConfigKeepsLastVersion(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.Set(makeTestDefaultBuildConfigKey("config"), runtime.EncodeOrDie(latest.Codec, &api.BuildConfig{ObjectMeta: kapi.ObjectMeta{Name: "config"}, LastVersion: 2}), 0)
+	registry := NewTestEtcd(fakeClient)
+
+	config, err := registry.GetBuildConfig(kapi.NewDefaultContext(), "config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.LastVersion = 1
+	config.Labels = map[string]string{"updated": "true"}
+	if err := registry.UpdateBuildConfig(kapi.NewDefaultContext(), config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, err = registry.GetBuildConfig(kapi.NewDefaultContext(), "config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.LastVersion != 2 {
+		t.Errorf("Expected config LastVersion 2, got %d", config.LastVersion)
+	}
+	if config.Labels["updated"] != "true" {
+		t.Errorf("Expected the config to be updated, got %#v", config)
+	}
+}
+
+func TestEtcdUpdateBuildConfigConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.Set(makeTestDefaultBuildConfigKey("config"), runtime.EncodeOrDie(latest.Codec, &api.BuildConfig{ObjectMeta: kapi.ObjectMeta{Name: "config"}}), 0)
+	registry := NewTestEtcd(fakeClient)
+
+	config, err := registry.GetBuildConfig(kapi.NewDefaultContext(), "config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a build of the config advances the stored version
+	build := &api.Build{ObjectMeta: kapi.ObjectMeta{Name: "foo", Labels: map[string]string{api.BuildConfigLabel: "config"}}}
+	if err := registry.CreateBuild(kapi.NewDefaultContext(), build); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = registry.UpdateBuildConfig(kapi.NewDefaultContext(), config)
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict updating a stale config, got %v", err)
+	}
+
+	// an update without a resource version is unconditional
+	config.ResourceVersion = ""
+	if err := registry.UpdateBuildConfig(kapi.NewDefaultContext(), config); err != nil {
+		t.Errorf("Unexpected error updating a config without a resource version: %v", err)
+	}
+}
+
 func TestEtcdCreateBuildAlreadyExisting(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.Data[makeTestDefaultBuildKey("foo")] = tools.EtcdResponseWithError{
@@ -181,6 +265,38 @@ func TestEtcdDeleteBuild(t *testing.T) {
 	}
 }
 
+func TestEtcdBuildLog(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	registry := NewTestEtcd(fakeClient)
+	ctx := kapi.NewDefaultContext()
+
+	fakeClient.ExpectNotFoundGet("/buildLogs/default/foo")
+	if _, err := registry.GetBuildLog(ctx, "foo"); !errors.IsNotFound(err) {
+		t.Errorf("Expected a not found error for a missing log, got %v", err)
+	}
+	if err := registry.SetBuildLog(ctx, "foo", []byte("line 1\nline 2\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log, err := registry.GetBuildLog(ctx, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(log) != "line 1\nline 2\n" {
+		t.Errorf("Unexpected log %q", string(log))
+	}
+
+	fakeClient.Set(makeTestDefaultBuildKey("foo"), runtime.EncodeOrDie(latest.Codec, &api.Build{
+		ObjectMeta: kapi.ObjectMeta{Name: "foo"},
+	}), 0)
+	if err := registry.DeleteBuild(ctx, "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := registry.GetBuildLog(ctx, "foo"); !errors.IsNotFound(err) {
+		t.Errorf("Expected the log to be deleted with its build, got %v", err)
+	}
+}
+
 func TestEtcdEmptyListBuilds(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	key := makeTestDefaultBuildListKey()
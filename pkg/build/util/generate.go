@@ -20,16 +20,12 @@ func GenerateBuildFromConfig(bc *buildapi.BuildConfig, r *buildapi.SourceRevisio
 	bcCopy := obj.(*buildapi.BuildConfig)
 
 	b := &buildapi.Build{
-		Parameters: buildapi.BuildParameters{
-			Source:   bcCopy.Parameters.Source,
-			Strategy: bcCopy.Parameters.Strategy,
-			Output:   bcCopy.Parameters.Output,
-			Revision: r,
-		},
+		Parameters: bcCopy.Parameters,
 		ObjectMeta: kapi.ObjectMeta{
 			Labels: bcCopy.Labels,
 		},
 	}
+	b.Parameters.Revision = r
 	if b.Labels == nil {
 		b.Labels = make(map[string]string)
 	}
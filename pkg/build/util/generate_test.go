@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
 	buildapi "github.com/openshift/origin/pkg/build/api"
 	imageapi "github.com/openshift/origin/pkg/image/api"
 )
@@ -28,6 +29,7 @@ func TestGenerateBuildFromConfig(t *testing.T) {
 	source := mockSource()
 	strategy := mockDockerStrategy()
 	output := mockOutput()
+	deadline := int64(600)
 
 	bc := &buildapi.BuildConfig{
 		ObjectMeta: kapi.ObjectMeta{
@@ -42,8 +44,14 @@ func TestGenerateBuildFromConfig(t *testing.T) {
 					Commit: "1234",
 				},
 			},
-			Strategy: strategy,
-			Output:   output,
+			Strategy:                  strategy,
+			Output:                    output,
+			CompletionDeadlineSeconds: &deadline,
+			PostCommit:                &buildapi.BuildPostCommitSpec{Script: "make test"},
+			Resources: kapi.ResourceRequirements{
+				Limits: kapi.ResourceList{kapi.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			NodeSelector: map[string]string{"region": "builds"},
 		},
 	}
 	revision := &buildapi.SourceRevision{
@@ -65,6 +73,18 @@ func TestGenerateBuildFromConfig(t *testing.T) {
 	if !reflect.DeepEqual(revision, build.Parameters.Revision) {
 		t.Errorf("Build revision does not match passed in revision")
 	}
+	if !reflect.DeepEqual(bc.Parameters.CompletionDeadlineSeconds, build.Parameters.CompletionDeadlineSeconds) {
+		t.Errorf("Build completion deadline does not match BuildConfig completion deadline")
+	}
+	if !reflect.DeepEqual(bc.Parameters.PostCommit, build.Parameters.PostCommit) {
+		t.Errorf("Build post commit hook does not match BuildConfig post commit hook")
+	}
+	if !reflect.DeepEqual(bc.Parameters.Resources, build.Parameters.Resources) {
+		t.Errorf("Build resources do not match BuildConfig resources")
+	}
+	if !reflect.DeepEqual(bc.Parameters.NodeSelector, build.Parameters.NodeSelector) {
+		t.Errorf("Build node selector does not match BuildConfig node selector")
+	}
 	if build.Labels["testlabel"] != bc.Labels["testlabel"] {
 		t.Errorf("Build does not contain labels from BuildConfig")
 	}
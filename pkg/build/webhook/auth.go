@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// AuthError is returned for webhook requests that are not authenticated or not allowed to
+// trigger a build. The controller responds to it with Code instead of a generic failure.
+type AuthError struct {
+	Code    int
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
+
+// NewUnauthorizedError returns an error for requests without valid credentials.
+func NewUnauthorizedError(format string, args ...interface{}) error {
+	return &AuthError{Code: http.StatusUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewForbiddenError returns an error for requests whose credentials do not match the
+// trigger, or that were already processed.
+func NewForbiddenError(format string, args ...interface{}) error {
+	return &AuthError{Code: http.StatusForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// VerifySignature checks that signature, given as "sha1=<hex>" or "sha256=<hex>", is the
+// HMAC of body keyed with secret.
+func VerifySignature(signature string, body []byte, secret string) error {
+	parts := strings.SplitN(signature, "=", 2)
+	if len(parts) != 2 {
+		return NewUnauthorizedError("Malformed signature %q", signature)
+	}
+	var newHash func() hash.Hash
+	switch parts[0] {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	default:
+		return NewUnauthorizedError("Unsupported signature algorithm %s", parts[0])
+	}
+	actual, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return NewUnauthorizedError("Malformed signature %q", signature)
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(actual, mac.Sum(nil)) {
+		return NewForbiddenError("Signature does not match the request body")
+	}
+	return nil
+}
+
+// Authenticator verifies webhook requests. Signed requests are verified with the HMAC of
+// their body keyed with the trigger secret, unsigned requests by the secret in the URL unless
+// the trigger requires a signature. Signed requests must carry a delivery ID, which names the
+// build they start so that a replayed request cannot start another one.
+type Authenticator struct {
+	// SignatureHeaders are the headers that may carry the signature, in order of preference.
+	SignatureHeaders []string
+	// DeliveryHeader is the header carrying the unique ID of a delivery.
+	DeliveryHeader string
+}
+
+// NewAuthenticator returns an Authenticator reading signatures from signatureHeaders.
+func NewAuthenticator(deliveryHeader string, signatureHeaders ...string) *Authenticator {
+	return &Authenticator{
+		SignatureHeaders: signatureHeaders,
+		DeliveryHeader:   deliveryHeader,
+	}
+}
+
+// Authenticate verifies that req, whose body was already read, may trigger a build of config
+// through trigger. secret is the secret given in the URL.
+func (a *Authenticator) Authenticate(config *api.BuildConfig, trigger *api.WebHookTrigger, secret string, req *http.Request, body []byte) error {
+	signed, err := a.verify(config, trigger, secret, req, body)
+	if err != nil {
+		return err
+	}
+	if signed && len(a.DeliveryHeader) > 0 && len(a.DeliveryID(req)) == 0 {
+		return NewUnauthorizedError("Signed requests for BuildConfig %s must carry the %s header", config.Name, a.DeliveryHeader)
+	}
+	return nil
+}
+
+// DeliveryID returns the unique ID of the delivery of req, or an empty string.
+func (a *Authenticator) DeliveryID(req *http.Request) string {
+	if len(a.DeliveryHeader) == 0 {
+		return ""
+	}
+	return req.Header.Get(a.DeliveryHeader)
+}
+
+// verify authenticates req and returns whether it was signed.
+func (a *Authenticator) verify(config *api.BuildConfig, trigger *api.WebHookTrigger, secret string, req *http.Request, body []byte) (bool, error) {
+	for _, header := range a.SignatureHeaders {
+		if signature := req.Header.Get(header); len(signature) > 0 {
+			return true, VerifySignature(signature, body, trigger.Secret)
+		}
+	}
+	if trigger.RequireSignature {
+		return false, NewUnauthorizedError("BuildConfig %s requires requests signed with the %s header", config.Name, a.SignatureHeaders[0])
+	}
+	if len(secret) == 0 || trigger.Secret != secret {
+		return false, NewForbiddenError("Secret does not match for BuildConfig %s", config.Name)
+	}
+	return false, nil
+}
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"testing"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func sign(newHash func() hash.Hash, prefix, secret string, body []byte) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return prefix + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/master"}`)
+	tests := map[string]struct {
+		signature string
+		code      int
+	}{
+		"sha1":               {signature: sign(sha1.New, "sha1", "secret101", body)},
+		"sha256":             {signature: sign(sha256.New, "sha256", "secret101", body)},
+		"wrong secret":       {signature: sign(sha1.New, "sha1", "other", body), code: http.StatusForbidden},
+		"wrong algorithm":    {signature: sign(sha256.New, "sha1", "secret101", body), code: http.StatusForbidden},
+		"unknown algorithm":  {signature: sign(sha256.New, "md5", "secret101", body), code: http.StatusUnauthorized},
+		"malformed":          {signature: "sha1", code: http.StatusUnauthorized},
+		"malformed hex":      {signature: "sha1=zz", code: http.StatusUnauthorized},
+		"signature of other": {signature: sign(sha1.New, "sha1", "secret101", []byte("{}")), code: http.StatusForbidden},
+	}
+	for name, test := range tests {
+		err := VerifySignature(test.signature, body, "secret101")
+		if test.code == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+			}
+			continue
+		}
+		authErr, ok := err.(*AuthError)
+		if !ok || authErr.Code != test.code {
+			t.Errorf("%s: expected an error with code %d, got %v", name, test.code, err)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/master"}`)
+	tests := map[string]struct {
+		requireSignature bool
+		urlSecret        string
+		signature        string
+		withoutDelivery  bool
+		code             int
+	}{
+		"url secret": {
+			urlSecret: "secret101",
+		},
+		"wrong url secret": {
+			urlSecret: "wrong",
+			code:      http.StatusForbidden,
+		},
+		"empty url secret": {
+			code: http.StatusForbidden,
+		},
+		"signed with placeholder url secret": {
+			urlSecret: "-",
+			signature: sign(sha256.New, "sha256", "secret101", body),
+		},
+		"signed without url secret": {
+			signature: sign(sha256.New, "sha256", "secret101", body),
+		},
+		"signed without delivery": {
+			signature:       sign(sha256.New, "sha256", "secret101", body),
+			withoutDelivery: true,
+			code:            http.StatusUnauthorized,
+		},
+		"wrong signature with url secret": {
+			urlSecret: "secret101",
+			signature: sign(sha256.New, "sha256", "wrong", body),
+			code:      http.StatusForbidden,
+		},
+		"required signature": {
+			requireSignature: true,
+			urlSecret:        "-",
+			signature:        sign(sha1.New, "sha1", "secret101", body),
+		},
+		"required signature missing": {
+			requireSignature: true,
+			urlSecret:        "secret101",
+			code:             http.StatusUnauthorized,
+		},
+	}
+	for name, test := range tests {
+		auth := NewAuthenticator("X-Delivery", "X-Signature")
+		config := &api.BuildConfig{ObjectMeta: kapi.ObjectMeta{Name: "build100", Namespace: "test"}}
+		trigger := &api.WebHookTrigger{Secret: "secret101", RequireSignature: test.requireSignature}
+		req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(body))
+		if len(test.signature) > 0 {
+			req.Header.Set("X-Signature", test.signature)
+		}
+		if !test.withoutDelivery {
+			req.Header.Set("X-Delivery", "1")
+		}
+
+		err := auth.Authenticate(config, trigger, test.urlSecret, req, body)
+		if test.code == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+			}
+			continue
+		}
+		authErr, ok := err.(*AuthError)
+		if !ok || authErr.Code != test.code {
+			t.Errorf("%s: expected an error with code %d, got %v", name, test.code, err)
+		}
+	}
+}
+
+func TestAuthenticateDeliveryID(t *testing.T) {
+	auth := NewAuthenticator("X-Delivery", "X-Signature")
+	req, _ := http.NewRequest("POST", "http://origin.com", nil)
+	if id := auth.DeliveryID(req); id != "" {
+		t.Errorf("Expected no delivery ID, got %q", id)
+	}
+	req.Header.Set("X-Delivery", "1")
+	if id := auth.DeliveryID(req); id != "1" {
+		t.Errorf("Expected delivery ID 1, got %q", id)
+	}
+}
@@ -0,0 +1,201 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
+)
+
+// WebHook used for processing bitbucket webhook requests.
+type WebHook struct{}
+
+// New returns bitbucket webhook plugin.
+func New() *WebHook {
+	return &WebHook{}
+}
+
+const (
+	// cloudPushEventType is sent by Bitbucket Cloud for pushes of branches and tags
+	cloudPushEventType = "repo:push"
+	// serverPushEventType is sent by Bitbucket Server for pushes of branches and tags
+	serverPushEventType = "repo:refs_changed"
+	// serverPingEventType is sent by Bitbucket Server to test a webhook
+	serverPingEventType = "diagnostics:ping"
+)
+
+type cloudCommit struct {
+	Hash    string `json:"hash,omitempty"`
+	Message string `json:"message,omitempty"`
+	Author  struct {
+		Raw string `json:"raw,omitempty"`
+	} `json:"author,omitempty"`
+}
+
+type cloudRef struct {
+	Type   string      `json:"type,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	Target cloudCommit `json:"target,omitempty"`
+}
+
+type cloudPushEvent struct {
+	Push struct {
+		Changes []struct {
+			New *cloudRef `json:"new,omitempty"`
+		} `json:"changes,omitempty"`
+	} `json:"push,omitempty"`
+}
+
+type serverPushEvent struct {
+	Actor struct {
+		Name         string `json:"name,omitempty"`
+		DisplayName  string `json:"displayName,omitempty"`
+		EmailAddress string `json:"emailAddress,omitempty"`
+	} `json:"actor,omitempty"`
+	Changes []struct {
+		RefID  string `json:"refId,omitempty"`
+		ToHash string `json:"toHash,omitempty"`
+		Type   string `json:"type,omitempty"`
+	} `json:"changes,omitempty"`
+}
+
+// Extract services webhooks from Bitbucket Cloud and Bitbucket Server
+func (p *WebHook) Extract(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (revision *api.SourceRevision, proceed bool, err error) {
+	trigger, ok := webhook.FindTriggerPolicy(api.BitbucketWebHookBuildTriggerType, buildCfg)
+	if !ok {
+		err = fmt.Errorf("BuildConfig %s does not support the Bitbucket webhook trigger type", buildCfg.Name)
+		return
+	}
+	if trigger.BitbucketWebHook.Secret != secret {
+		err = webhook.NewForbiddenError("Secret does not match for BuildConfig %s", buildCfg.Name)
+		return
+	}
+	if buildCfg.Parameters.Source.Git == nil {
+		err = fmt.Errorf("BuildConfig %s does not have a Git source to build", buildCfg.Name)
+		return
+	}
+	if err = verifyRequest(req); err != nil {
+		return
+	}
+	method := req.Header.Get("X-Event-Key")
+	if method == serverPingEventType {
+		return
+	}
+	if method != cloudPushEventType && method != serverPushEventType {
+		err = fmt.Errorf("Unknown X-Event-Key %s", method)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return
+	}
+
+	configRef := buildCfg.Parameters.Source.Git.Ref
+	if method == cloudPushEventType {
+		revision, err = cloudRevision(body, configRef)
+	} else {
+		revision, err = serverRevision(body, configRef)
+	}
+	if err != nil {
+		return
+	}
+	proceed = revision != nil
+	if !proceed {
+		glog.V(2).Infof("Skipping build for '%s'.  No pushed reference matches configuration", buildCfg.Name)
+	}
+	return
+}
+
+// cloudRevision returns the revision a Bitbucket Cloud push event set the configured ref to,
+// or nil if the ref was not pushed.
+func cloudRevision(body []byte, configRef string) (*api.SourceRevision, error) {
+	var event cloudPushEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	for _, change := range event.Push.Changes {
+		// deleted refs have no new state
+		if change.New == nil {
+			continue
+		}
+		ref := change.New.Name
+		switch change.New.Type {
+		case "branch":
+			ref = "refs/heads/" + ref
+		case "tag":
+			ref = "refs/tags/" + ref
+		default:
+			continue
+		}
+		if !webhook.GitRefMatches(ref, configRef) {
+			continue
+		}
+		commit := change.New.Target
+		return &api.SourceRevision{
+			Type: api.BuildSourceGit,
+			Git: &api.GitSourceRevision{
+				Commit:  commit.Hash,
+				Author:  parseUser(commit.Author.Raw),
+				Message: commit.Message,
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// serverRevision returns the revision a Bitbucket Server push event set the configured ref
+// to, or nil if the ref was not pushed. The event only names the commit and the pushing user.
+func serverRevision(body []byte, configRef string) (*api.SourceRevision, error) {
+	var event serverPushEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	for _, change := range event.Changes {
+		if change.Type == "DELETE" || webhook.IsDeletedCommit(change.ToHash) {
+			continue
+		}
+		if !webhook.GitRefMatches(change.RefID, configRef) {
+			continue
+		}
+		name := event.Actor.DisplayName
+		if len(name) == 0 {
+			name = event.Actor.Name
+		}
+		return &api.SourceRevision{
+			Type: api.BuildSourceGit,
+			Git: &api.GitSourceRevision{
+				Commit:    change.ToHash,
+				Committer: api.SourceControlUser{Name: name, Email: event.Actor.EmailAddress},
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// parseUser reads a user given as "Name <email>".
+func parseUser(raw string) api.SourceControlUser {
+	if address, err := mail.ParseAddress(raw); err == nil {
+		return api.SourceControlUser{Name: address.Name, Email: address.Address}
+	}
+	return api.SourceControlUser{Name: strings.TrimSpace(raw)}
+}
+
+func verifyRequest(req *http.Request) error {
+	if method := req.Method; method != "POST" {
+		return fmt.Errorf("Unsupported HTTP method %s", method)
+	}
+	if contentType := req.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		return fmt.Errorf("Unsupported Content-Type %s", contentType)
+	}
+	if req.Header.Get("X-Event-Key") == "" {
+		return errors.New("Missing X-Event-Key")
+	}
+	return nil
+}
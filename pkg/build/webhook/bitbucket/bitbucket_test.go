@@ -0,0 +1,162 @@
+package bitbucket
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openshift/origin/pkg/build/api"
+	webhooktest "github.com/openshift/origin/pkg/build/webhook/test"
+)
+
+func testBuildConfig() *api.BuildConfig {
+	return &api.BuildConfig{
+		Triggers: []api.BuildTriggerPolicy{
+			{
+				Type: api.BitbucketWebHookBuildTriggerType,
+				BitbucketWebHook: &api.WebHookTrigger{
+					Secret: "secret101",
+				},
+			},
+		},
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Type: api.BuildSourceGit,
+				Git: &api.GitBuildSource{
+					URI: "git://example.com/my/repo.git",
+				},
+			},
+		},
+	}
+}
+
+func newServer() *httptest.Server {
+	return webhooktest.NewServer("bitbucket", New(), testBuildConfig())
+}
+
+func TestRequestHeaders(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	webhooktest.RunHeaderTests(t, server.URL+"/build100/secret101/bitbucket", map[string]webhooktest.HeaderTest{
+		"wrong method": {
+			Method:   "GET",
+			Expected: "method",
+		},
+		"wrong content type": {
+			Headers:  map[string]string{"Content-Type": "application/text", "X-Event-Key": cloudPushEventType},
+			Expected: "Content-Type",
+		},
+		"missing event": {
+			Headers:  map[string]string{"Content-Type": "application/json"},
+			Expected: "X-Event-Key",
+		},
+		"wrong event": {
+			Headers:  map[string]string{"Content-Type": "application/json", "X-Event-Key": "issue:created"},
+			Expected: "Unknown",
+		},
+	})
+}
+
+func extract(t *testing.T, config *api.BuildConfig, filename, eventType string) (*api.SourceRevision, bool, error) {
+	event := []byte{}
+	if len(filename) > 0 {
+		var err error
+		if event, err = ioutil.ReadFile("fixtures/" + filename); err != nil {
+			t.Fatalf("Failed to open %s: %v", filename, err)
+		}
+	}
+	req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("X-Event-Key", eventType)
+	return New().Extract(config, "secret101", "", req)
+}
+
+func TestExtract(t *testing.T) {
+	tests := map[string]struct {
+		file      string
+		event     string
+		ref       string
+		proceed   bool
+		commit    string
+		author    api.SourceControlUser
+		committer api.SourceControlUser
+	}{
+		"cloud push": {
+			file:    "pushevent.json",
+			event:   cloudPushEventType,
+			proceed: true,
+			commit:  "709d658dc5b6d6afcd46049c2f332ee3f515a67d",
+			author:  api.SourceControlUser{Name: "Emma", Email: "emma@example.com"},
+		},
+		"cloud push of another branch": {
+			file:  "pushevent.json",
+			event: cloudPushEventType,
+			ref:   "other",
+		},
+		"cloud tag push": {
+			file:    "tagpushevent.json",
+			event:   cloudPushEventType,
+			ref:     "v1.0.0",
+			proceed: true,
+			commit:  "709d658dc5b6d6afcd46049c2f332ee3f515a67d",
+			author:  api.SourceControlUser{Name: "Emma", Email: "emma@example.com"},
+		},
+		"cloud tag push for a branch": {
+			file:  "tagpushevent.json",
+			event: cloudPushEventType,
+		},
+		"server push": {
+			file:      "server-pushevent.json",
+			event:     serverPushEventType,
+			proceed:   true,
+			commit:    "178864a7d521b6f5e720b386b2c2b0ef8563e0dc",
+			committer: api.SourceControlUser{Name: "Administrator", Email: "admin@example.com"},
+		},
+		"server push deleting the branch": {
+			file:  "server-pushevent.json",
+			event: serverPushEventType,
+			ref:   "old",
+		},
+		"server ping": {
+			event: serverPingEventType,
+		},
+	}
+
+	for name, test := range tests {
+		config := testBuildConfig()
+		config.Parameters.Source.Git.Ref = test.ref
+		revision, proceed, err := extract(t, config, test.file, test.event)
+		if err != nil {
+			t.Errorf("%s: error while extracting build info: %v", name, err)
+			continue
+		}
+		if proceed != test.proceed {
+			t.Errorf("%s: expected proceed %v, got %v", name, test.proceed, proceed)
+		}
+		if !test.proceed {
+			continue
+		}
+		if revision == nil || revision.Git == nil {
+			t.Errorf("%s: expecting the revision to not be nil", name)
+			continue
+		}
+		if revision.Git.Commit != test.commit {
+			t.Errorf("%s: expected commit %s, got %s", name, test.commit, revision.Git.Commit)
+		}
+		if revision.Git.Author != test.author || revision.Git.Committer != test.committer {
+			t.Errorf("%s: unexpected users in revision %#v", name, revision.Git)
+		}
+	}
+}
+
+func TestExtractWrongSecret(t *testing.T) {
+	event, _ := ioutil.ReadFile("fixtures/pushevent.json")
+	req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Event-Key", cloudPushEventType)
+	if _, proceed, err := New().Extract(testBuildConfig(), "wrongsecret", "", req); err == nil || proceed {
+		t.Errorf("Expecting an error for a wrong secret")
+	}
+}
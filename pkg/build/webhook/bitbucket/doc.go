@@ -0,0 +1,4 @@
+// Package bitbucket contains webhook.Plugin implementation of Bitbucket webhooks
+// according to https://confluence.atlassian.com/bitbucket/event-payloads-740262817.html
+// for Bitbucket Cloud and the repo:refs_changed event of Bitbucket Server.
+package bitbucket
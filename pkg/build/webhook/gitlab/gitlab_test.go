@@ -0,0 +1,188 @@
+package gitlab
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
+	webhooktest "github.com/openshift/origin/pkg/build/webhook/test"
+)
+
+func testBuildConfig() *api.BuildConfig {
+	return &api.BuildConfig{
+		Triggers: []api.BuildTriggerPolicy{
+			{
+				Type: api.GitLabWebHookBuildTriggerType,
+				GitLabWebHook: &api.WebHookTrigger{
+					Secret: "secret101",
+				},
+			},
+		},
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Type: api.BuildSourceGit,
+				Git: &api.GitBuildSource{
+					URI: "git://example.com/my/repo.git",
+				},
+			},
+		},
+	}
+}
+
+func newServer() *httptest.Server {
+	return webhooktest.NewServer("gitlab", New(), testBuildConfig())
+}
+
+func TestRequestHeaders(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	webhooktest.RunHeaderTests(t, server.URL+"/build100/secret101/gitlab", map[string]webhooktest.HeaderTest{
+		"wrong method": {
+			Method:   "GET",
+			Expected: "method",
+		},
+		"wrong content type": {
+			Headers:  map[string]string{"Content-Type": "application/text", "X-Gitlab-Event": pushEventType},
+			Expected: "Content-Type",
+		},
+		"missing event": {
+			Headers:  map[string]string{"Content-Type": "application/json"},
+			Expected: "X-Gitlab-Event",
+		},
+		"wrong event": {
+			Headers:  map[string]string{"Content-Type": "application/json", "X-Gitlab-Event": "Issue Hook"},
+			Expected: "Unknown",
+		},
+	})
+}
+
+func TestJsonPushEvent(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+
+	data, err := ioutil.ReadFile("fixtures/pushevent.json")
+	if err != nil {
+		t.Fatalf("Failed to open pushevent.json: %v", err)
+	}
+	req, _ := http.NewRequest("POST", server.URL+"/build100/secret101/gitlab", bytes.NewReader(data))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Gitlab-Event", pushEventType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed posting webhook: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Wrong response code, expecting 200, got %s: %s!", resp.Status, string(body))
+	}
+}
+
+func extract(t *testing.T, config *api.BuildConfig, filename, eventType string) (*api.SourceRevision, bool, error) {
+	event, err := ioutil.ReadFile("fixtures/" + filename)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", filename, err)
+	}
+	req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Gitlab-Event", eventType)
+	return New().Extract(config, "secret101", "", req)
+}
+
+func TestExtractProvidesValidBuildForAPushEvent(t *testing.T) {
+	revision, proceed, err := extract(t, testBuildConfig(), "pushevent.json", pushEventType)
+	if err != nil {
+		t.Fatalf("Error while extracting build info: %v", err)
+	}
+	if !proceed {
+		t.Errorf("The 'proceed' return value should equal 'true'")
+	}
+	if revision == nil || revision.Git == nil {
+		t.Fatalf("Expecting the revision to not be nil")
+	}
+	if revision.Git.Commit != "da1560886d4f094c3e6c9ef40349f7d38b5d27d7" {
+		t.Errorf("Expecting the revision to contain the head commit id, got %s", revision.Git.Commit)
+	}
+	if revision.Git.Message != "fixed readme" || revision.Git.Author.Name != "GitLab dev user" {
+		t.Errorf("Expecting the revision to contain the head commit details, got %#v", revision.Git)
+	}
+}
+
+func TestExtractForATagPushEvent(t *testing.T) {
+	config := testBuildConfig()
+	config.Parameters.Source.Git.Ref = "v1.0.0"
+	revision, proceed, err := extract(t, config, "tagpushevent.json", tagPushEventType)
+	if err != nil {
+		t.Fatalf("Error while extracting build info: %v", err)
+	}
+	if !proceed {
+		t.Errorf("The 'proceed' return value should equal 'true'")
+	}
+	if revision == nil || revision.Git.Commit != "82b3d5ae55f7080f1e6022629cdb57bfae7cccc7" {
+		t.Errorf("Expecting the revision to contain the tagged commit, got %#v", revision)
+	}
+}
+
+func TestExtractSkipsBuildForUnmatchedRefs(t *testing.T) {
+	config := testBuildConfig()
+	config.Parameters.Source.Git.Ref = "other"
+	_, proceed, err := extract(t, config, "pushevent.json", pushEventType)
+	if err != nil {
+		t.Fatalf("Error while extracting build info: %v", err)
+	}
+	if proceed {
+		t.Errorf("Expecting to not continue from this event because the branch is not for this buildConfig")
+	}
+
+	_, proceed, _ = extract(t, testBuildConfig(), "tagpushevent.json", tagPushEventType)
+	if proceed {
+		t.Errorf("Expecting to not continue from a tag push when the buildConfig builds a branch")
+	}
+}
+
+func TestExtractWrongSecret(t *testing.T) {
+	event, _ := ioutil.ReadFile("fixtures/pushevent.json")
+	req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Gitlab-Event", pushEventType)
+	if _, proceed, err := New().Extract(testBuildConfig(), "wrongsecret", "", req); err == nil || proceed {
+		t.Errorf("Expecting an error for a wrong secret")
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	tests := map[string]struct {
+		token            string
+		requireSignature bool
+		ok               bool
+	}{
+		"matching token":       {token: "secret101", ok: true},
+		"wrong token":          {token: "wrongsecret"},
+		"no token":             {ok: true},
+		"required token":       {token: "secret101", requireSignature: true, ok: true},
+		"missing token":        {requireSignature: true},
+		"wrong required token": {token: "wrongsecret", requireSignature: true},
+	}
+
+	event, _ := ioutil.ReadFile("fixtures/pushevent.json")
+	for name, test := range tests {
+		config := testBuildConfig()
+		config.Triggers[0].GitLabWebHook.RequireSignature = test.requireSignature
+		req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Gitlab-Event", pushEventType)
+		if len(test.token) > 0 {
+			req.Header.Add(tokenHeader, test.token)
+		}
+		_, proceed, err := New().Extract(config, "secret101", "", req)
+		if test.ok && (err != nil || !proceed) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if _, isAuthErr := err.(*webhook.AuthError); !test.ok && !isAuthErr {
+			t.Errorf("%s: expected an authentication error, got %v", name, err)
+		}
+	}
+}
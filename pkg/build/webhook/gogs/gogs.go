@@ -0,0 +1,132 @@
+package gogs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
+)
+
+// WebHook used for processing gogs webhook requests.
+type WebHook struct{}
+
+// New returns gogs webhook plugin.
+func New() *WebHook {
+	return &WebHook{}
+}
+
+// signatureHeader carries the hex encoded HMAC-SHA256 of the request body keyed with the
+// secret configured on the Gogs webhook.
+const signatureHeader = "X-Gogs-Signature"
+
+type commit struct {
+	ID        string                `json:"id,omitempty"`
+	Author    api.SourceControlUser `json:"author,omitempty"`
+	Committer api.SourceControlUser `json:"committer,omitempty"`
+	Message   string                `json:"message,omitempty"`
+}
+
+type pushEvent struct {
+	Ref     string   `json:"ref,omitempty"`
+	After   string   `json:"after,omitempty"`
+	Commits []commit `json:"commits,omitempty"`
+}
+
+// Extract services webhooks from Gogs
+func (p *WebHook) Extract(buildCfg *api.BuildConfig, secret, path string, req *http.Request) (revision *api.SourceRevision, proceed bool, err error) {
+	trigger, ok := webhook.FindTriggerPolicy(api.GogsWebHookBuildTriggerType, buildCfg)
+	if !ok {
+		err = fmt.Errorf("BuildConfig %s does not support the Gogs webhook trigger type", buildCfg.Name)
+		return
+	}
+	if trigger.GogsWebHook.Secret != secret {
+		err = webhook.NewForbiddenError("Secret does not match for BuildConfig %s", buildCfg.Name)
+		return
+	}
+	if buildCfg.Parameters.Source.Git == nil {
+		err = fmt.Errorf("BuildConfig %s does not have a Git source to build", buildCfg.Name)
+		return
+	}
+	if err = verifyRequest(req); err != nil {
+		return
+	}
+	method := req.Header.Get("X-Gogs-Event")
+	if method != "push" {
+		err = fmt.Errorf("Unknown X-Gogs-Event %s", method)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return
+	}
+	if err = verifySignature(buildCfg, trigger.GogsWebHook, req, body); err != nil {
+		return
+	}
+	var event pushEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		return
+	}
+	if webhook.IsDeletedCommit(event.After) {
+		glog.V(2).Infof("Skipping build for '%s'.  Reference '%s' was deleted", buildCfg.Name, event.Ref)
+		return
+	}
+	proceed = webhook.GitRefMatches(event.Ref, buildCfg.Parameters.Source.Git.Ref)
+	if !proceed {
+		glog.V(2).Infof("Skipping build for '%s'.  Reference '%s' does not match configuration", buildCfg.Name, event.Ref)
+	}
+
+	head := headCommit(event)
+	revision = &api.SourceRevision{
+		Type: api.BuildSourceGit,
+		Git: &api.GitSourceRevision{
+			Commit:    head.ID,
+			Author:    head.Author,
+			Committer: head.Committer,
+			Message:   head.Message,
+		},
+	}
+
+	return
+}
+
+// headCommit returns the commit the pushed ref points to.
+func headCommit(event pushEvent) commit {
+	for _, c := range event.Commits {
+		if c.ID == event.After {
+			return c
+		}
+	}
+	return commit{ID: event.After}
+}
+
+// verifySignature checks the signature Gogs sends in the X-Gogs-Signature header, which is
+// required when the trigger requires signed requests.
+func verifySignature(buildCfg *api.BuildConfig, trigger *api.WebHookTrigger, req *http.Request, body []byte) error {
+	signature := req.Header.Get(signatureHeader)
+	if len(signature) == 0 {
+		if trigger.RequireSignature {
+			return webhook.NewUnauthorizedError("BuildConfig %s requires requests signed with the %s header", buildCfg.Name, signatureHeader)
+		}
+		return nil
+	}
+	return webhook.VerifySignature("sha256="+signature, body, trigger.Secret)
+}
+
+func verifyRequest(req *http.Request) error {
+	if method := req.Method; method != "POST" {
+		return fmt.Errorf("Unsupported HTTP method %s", method)
+	}
+	if contentType := req.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		return fmt.Errorf("Unsupported Content-Type %s", contentType)
+	}
+	if req.Header.Get("X-Gogs-Event") == "" {
+		return errors.New("Missing X-Gogs-Event")
+	}
+	return nil
+}
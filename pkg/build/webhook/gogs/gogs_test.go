@@ -0,0 +1,174 @@
+package gogs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
+	webhooktest "github.com/openshift/origin/pkg/build/webhook/test"
+)
+
+func testBuildConfig() *api.BuildConfig {
+	return &api.BuildConfig{
+		Triggers: []api.BuildTriggerPolicy{
+			{
+				Type: api.GogsWebHookBuildTriggerType,
+				GogsWebHook: &api.WebHookTrigger{
+					Secret: "secret101",
+				},
+			},
+		},
+		Parameters: api.BuildParameters{
+			Source: api.BuildSource{
+				Type: api.BuildSourceGit,
+				Git: &api.GitBuildSource{
+					URI: "git://example.com/my/repo.git",
+				},
+			},
+		},
+	}
+}
+
+func newServer() *httptest.Server {
+	return webhooktest.NewServer("gogs", New(), testBuildConfig())
+}
+
+func TestRequestHeaders(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	webhooktest.RunHeaderTests(t, server.URL+"/build100/secret101/gogs", map[string]webhooktest.HeaderTest{
+		"wrong method": {
+			Method:   "GET",
+			Expected: "method",
+		},
+		"wrong content type": {
+			Headers:  map[string]string{"Content-Type": "application/text", "X-Gogs-Event": "push"},
+			Expected: "Content-Type",
+		},
+		"missing event": {
+			Headers:  map[string]string{"Content-Type": "application/json"},
+			Expected: "X-Gogs-Event",
+		},
+		"wrong event": {
+			Headers:  map[string]string{"Content-Type": "application/json", "X-Gogs-Event": "issues"},
+			Expected: "Unknown",
+		},
+	})
+}
+
+func extract(t *testing.T, config *api.BuildConfig, filename string) (*api.SourceRevision, bool, error) {
+	event, err := ioutil.ReadFile("fixtures/" + filename)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", filename, err)
+	}
+	req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Gogs-Event", "push")
+	return New().Extract(config, "secret101", "", req)
+}
+
+func TestExtract(t *testing.T) {
+	tests := map[string]struct {
+		file    string
+		ref     string
+		proceed bool
+		message string
+	}{
+		"push": {
+			file:    "pushevent.json",
+			proceed: true,
+			message: "Update README\n",
+		},
+		"push of another branch": {
+			file:    "pushevent.json",
+			ref:     "other",
+			message: "Update README\n",
+		},
+		"tag push": {
+			file:    "tagpushevent.json",
+			ref:     "v1.0.0",
+			proceed: true,
+		},
+		"tag push for a branch": {
+			file: "tagpushevent.json",
+		},
+	}
+
+	for name, test := range tests {
+		config := testBuildConfig()
+		config.Parameters.Source.Git.Ref = test.ref
+		revision, proceed, err := extract(t, config, test.file)
+		if err != nil {
+			t.Errorf("%s: error while extracting build info: %v", name, err)
+			continue
+		}
+		if proceed != test.proceed {
+			t.Errorf("%s: expected proceed %v, got %v", name, test.proceed, proceed)
+		}
+		if revision == nil || revision.Git == nil {
+			t.Errorf("%s: expecting the revision to not be nil", name)
+			continue
+		}
+		if revision.Git.Commit != "bffeb74224043ba2feb48d137756c8a9331c449a" {
+			t.Errorf("%s: expecting the revision to contain the pushed commit, got %s", name, revision.Git.Commit)
+		}
+		if revision.Git.Message != test.message {
+			t.Errorf("%s: expected message %q, got %q", name, test.message, revision.Git.Message)
+		}
+	}
+}
+
+func TestExtractWrongSecret(t *testing.T) {
+	event, _ := ioutil.ReadFile("fixtures/pushevent.json")
+	req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Gogs-Event", "push")
+	if _, proceed, err := New().Extract(testBuildConfig(), "wrongsecret", "", req); err == nil || proceed {
+		t.Errorf("Expecting an error for a wrong secret")
+	}
+}
+
+func TestExtractSignature(t *testing.T) {
+	event, _ := ioutil.ReadFile("fixtures/pushevent.json")
+	mac := hmac.New(sha256.New, []byte("secret101"))
+	mac.Write(event)
+	valid := hex.EncodeToString(mac.Sum(nil))
+
+	tests := map[string]struct {
+		signature        string
+		requireSignature bool
+		ok               bool
+	}{
+		"matching signature":       {signature: valid, ok: true},
+		"wrong signature":          {signature: hex.EncodeToString([]byte("wrong"))},
+		"no signature":             {ok: true},
+		"required signature":       {signature: valid, requireSignature: true, ok: true},
+		"missing signature":        {requireSignature: true},
+		"wrong required signature": {signature: "zz", requireSignature: true},
+	}
+
+	for name, test := range tests {
+		config := testBuildConfig()
+		config.Triggers[0].GogsWebHook.RequireSignature = test.requireSignature
+		req, _ := http.NewRequest("POST", "http://origin.com", bytes.NewReader(event))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Gogs-Event", "push")
+		if len(test.signature) > 0 {
+			req.Header.Add(signatureHeader, test.signature)
+		}
+		_, proceed, err := New().Extract(config, "secret101", "", req)
+		if test.ok && (err != nil || !proceed) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if _, isAuthErr := err.(*webhook.AuthError); !test.ok && !isAuthErr {
+			t.Errorf("%s: expected an authentication error, got %v", name, err)
+		}
+	}
+}
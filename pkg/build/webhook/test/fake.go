@@ -0,0 +1,74 @@
+package test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/webhook"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// OKImageRepositoryNamespaceGetter finds no image repositories.
+type OKImageRepositoryNamespaceGetter struct{}
+
+func (OKImageRepositoryNamespaceGetter) GetByNamespace(namespace, name string) (*imageapi.ImageRepository, error) {
+	return nil, nil
+}
+
+// OKBuildConfigGetter returns Config for every build config.
+type OKBuildConfigGetter struct {
+	Config *api.BuildConfig
+}
+
+func (g OKBuildConfigGetter) Get(namespace, name string) (*api.BuildConfig, error) {
+	return g.Config, nil
+}
+
+// OKBuildCreator accepts every build.
+type OKBuildCreator struct{}
+
+func (OKBuildCreator) Create(namespace string, build *api.Build) error {
+	return nil
+}
+
+// NewServer returns a server handling webhook requests for config with plugin, registered as
+// name.
+func NewServer(name string, plugin webhook.Plugin, config *api.BuildConfig) *httptest.Server {
+	return httptest.NewServer(webhook.NewController(OKBuildConfigGetter{config}, OKBuildCreator{}, OKImageRepositoryNamespaceGetter{}, map[string]webhook.Plugin{name: plugin}))
+}
+
+// HeaderTest is a request to a webhook plugin that must be rejected as a bad request with a
+// message containing Expected.
+type HeaderTest struct {
+	Method   string
+	Headers  map[string]string
+	Expected string
+}
+
+// RunHeaderTests sends the requests of tests to url and checks that they are rejected.
+func RunHeaderTests(t *testing.T, url string, tests map[string]HeaderTest) {
+	for name, test := range tests {
+		method := test.Method
+		if len(method) == 0 {
+			method = "POST"
+		}
+		req, _ := http.NewRequest(method, url, nil)
+		for key, value := range test.Headers {
+			req.Header.Add(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), test.Expected) {
+			t.Errorf("%s: expected BadRequest, got %s: %s!", name, resp.Status, string(body))
+		}
+	}
+}
@@ -5,17 +5,35 @@ import (
 	"strings"
 )
 
-// GitRefMatches determines if the ref from a webhook event matches a build configuration
+// GitRefMatches determines if the ref from a webhook event matches a build configuration.
+// Branches (refs/heads/) and tags (refs/tags/) are matched by their short names.
 func GitRefMatches(eventRef, configRef string) bool {
-	const RefPrefix = "refs/heads/"
-	eventRef = strings.TrimPrefix(eventRef, RefPrefix)
-	configRef = strings.TrimPrefix(configRef, RefPrefix)
+	eventRef = shortRef(eventRef)
+	configRef = shortRef(configRef)
 	if configRef == "" {
 		configRef = "master"
 	}
 	return configRef == eventRef
 }
 
+// shortRef strips the branch or tag prefix from a git reference.
+func shortRef(ref string) string {
+	const (
+		BranchPrefix = "refs/heads/"
+		TagPrefix    = "refs/tags/"
+	)
+	if strings.HasPrefix(ref, TagPrefix) {
+		return strings.TrimPrefix(ref, TagPrefix)
+	}
+	return strings.TrimPrefix(ref, BranchPrefix)
+}
+
+// IsDeletedCommit determines if the commit a ref points to after a push denotes that the
+// ref was deleted. Git hosting services report deletions with an all zero commit ID.
+func IsDeletedCommit(commit string) bool {
+	return len(commit) > 0 && strings.Trim(commit, "0") == ""
+}
+
 // FindTrigger retrieves the BuildTrigger of a given type from a build configuration
 func FindTriggerPolicy(triggerType api.BuildTriggerType, config *api.BuildConfig) (*api.BuildTriggerPolicy, bool) {
 	for _, p := range config.Triggers {
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"testing"
+)
+
+func TestGitRefMatches(t *testing.T) {
+	tests := []struct {
+		eventRef, configRef string
+		matches             bool
+	}{
+		{"refs/heads/master", "", true},
+		{"refs/heads/master", "master", true},
+		{"refs/heads/master", "refs/heads/master", true},
+		{"refs/heads/other", "", false},
+		{"refs/heads/other", "master", false},
+		{"refs/tags/v1.0", "v1.0", true},
+		{"refs/tags/v1.0", "refs/tags/v1.0", true},
+		{"refs/tags/v1.0", "", false},
+		{"refs/tags/v1.0", "v1.1", false},
+	}
+	for _, test := range tests {
+		if matches := GitRefMatches(test.eventRef, test.configRef); matches != test.matches {
+			t.Errorf("Expected ref %q matching %q to be %v", test.eventRef, test.configRef, test.matches)
+		}
+	}
+}
+
+func TestIsDeletedCommit(t *testing.T) {
+	if !IsDeletedCommit("0000000000000000000000000000000000000000") {
+		t.Errorf("Expected an all zero commit to denote a deleted ref")
+	}
+	for _, commit := range []string{"", "9bdc3a26ff933b32f3e558636b58aea86a69f051"} {
+		if IsDeletedCommit(commit) {
+			t.Errorf("Expected commit %q not to denote a deleted ref", commit)
+		}
+	}
+}
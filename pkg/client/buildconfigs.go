@@ -1,6 +1,8 @@
 package client
 
 import (
+	"io"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
@@ -21,6 +23,7 @@ type BuildConfigInterface interface {
 	Update(config *buildapi.BuildConfig) (*buildapi.BuildConfig, error)
 	Delete(name string) error
 	Watch(label labels.Selector, field fields.Selector, resourceVersion string) (watch.Interface, error)
+	InstantiateBinary(name, asFile string, r io.Reader) (*buildapi.Build, error)
 }
 
 // buildConfigs implements BuildConfigsNamespacer interface
@@ -87,3 +90,16 @@ func (c *buildConfigs) Watch(label labels.Selector, field fields.Selector, resou
 		FieldsSelectorParam("fields", field).
 		Watch()
 }
+
+// InstantiateBinary starts a new build from the buildconfig using the content read from r as
+// its source. If asFile is set, the content is saved as a single file with that name rather
+// than being extracted as an archive.
+func (c *buildConfigs) InstantiateBinary(name, asFile string, r io.Reader) (result *buildapi.Build, err error) {
+	result = &buildapi.Build{}
+	req := c.r.Post().Namespace(c.ns).Resource("buildConfigs").Name(name).Suffix("instantiatebinary")
+	if len(asFile) != 0 {
+		req.Param("asFile", asFile)
+	}
+	err = req.Body(r).Do().Into(result)
+	return
+}
@@ -1,7 +1,12 @@
 package client
 
 import (
+	"strconv"
+	"time"
+
 	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
 )
 
 // BuildLogsNamespacer has methods to work with BuildLogs resources in a namespace
@@ -12,6 +17,7 @@ type BuildLogsNamespacer interface {
 // BuildLogsInterface exposes methods on BuildLogs resources.
 type BuildLogInterface interface {
 	Redirect(name string) *kclient.Request
+	Get(name string, options buildapi.BuildLogOptions) *kclient.Request
 }
 
 // buildLogs implements BuildLogsNamespacer interface
@@ -32,3 +38,18 @@ func newBuildLogs(c *Client, namespace string) *buildLogs {
 func (c *buildLogs) Redirect(name string) *kclient.Request {
 	return c.r.Get().Namespace(c.ns).Prefix("redirect").Resource("buildLogs").Name(name)
 }
+
+// Get builds and returns a request for the part of a build log selected by options
+func (c *buildLogs) Get(name string, options buildapi.BuildLogOptions) *kclient.Request {
+	req := c.r.Get().Namespace(c.ns).Resource("buildLogs").Name(name)
+	if options.Follow {
+		req = req.Param("follow", "true")
+	}
+	if options.TailLines != nil {
+		req = req.Param("tailLines", strconv.FormatInt(*options.TailLines, 10))
+	}
+	if options.SinceTime != nil {
+		req = req.Param("sinceTime", options.SinceTime.UTC().Format(time.RFC3339))
+	}
+	return req
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"io"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
@@ -44,3 +46,8 @@ func (c *FakeBuildConfigs) Watch(label labels.Selector, field fields.Selector, r
 	c.Fake.Actions = append(c.Fake.Actions, FakeAction{Action: "watch-buildconfigs"})
 	return nil, nil
 }
+
+func (c *FakeBuildConfigs) InstantiateBinary(name, asFile string, r io.Reader) (*buildapi.Build, error) {
+	c.Fake.Actions = append(c.Fake.Actions, FakeAction{Action: "instantiate-binary-buildconfig", Value: name})
+	return &buildapi.Build{}, nil
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+
+	buildapi "github.com/openshift/origin/pkg/build/api"
 )
 
 // FakeBuildLogs implements BuildLogInterface. Meant to be embedded into a struct to get a default
@@ -16,3 +18,9 @@ func (c *FakeBuildLogs) Redirect(name string) *kclient.Request {
 	c.Fake.Actions = append(c.Fake.Actions, FakeAction{Action: "redirect"})
 	return &kclient.Request{}
 }
+
+// Get builds and returns a buildLog request
+func (c *FakeBuildLogs) Get(name string, options buildapi.BuildLogOptions) *kclient.Request {
+	c.Fake.Actions = append(c.Fake.Actions, FakeAction{Action: "get-buildlogs", Value: name})
+	return &kclient.Request{}
+}
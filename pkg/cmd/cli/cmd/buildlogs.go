@@ -3,22 +3,47 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"os"
+	"time"
 
+	cmdutil "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/cmd/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/spf13/cobra"
 
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	osclient "github.com/openshift/origin/pkg/client"
 	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
 )
 
 const buildLogsLongDesc = `Retrieve logs from the containers where the build occured
 
+The logs of finished builds are kept after their build containers are removed. The logs
+of running builds are streamed until the build finishes, unless --follow=false is given.
+
 NOTE: This command may be moved in the future.
 
 Examples:
 
 	# Stream logs from container to stdout
 	$ %[1]s build-logs 566bed879d2d
+
+	# Show the last 20 lines of the log without waiting for the build to finish
+	$ %[1]s build-logs 566bed879d2d --follow=false --tail=20
+
+	# Show what was logged since 10:00 UTC
+	$ %[1]s build-logs 566bed879d2d --since-time=2015-04-20T10:00:00Z
+
+	# Wait up to an hour for a queued build to start
+	$ %[1]s build-logs 566bed879d2d --timeout=1h
 `
 
+const (
+	// buildStartPollInterval is how often a build is checked while waiting for it to start.
+	buildStartPollInterval = time.Second
+	// defaultBuildStartTimeout is how long to wait for a build to start by default.
+	defaultBuildStartTimeout = 10 * time.Minute
+)
+
 func NewCmdBuildLogs(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "build-logs <build>",
@@ -29,15 +54,31 @@ func NewCmdBuildLogs(fullName string, f *clientcmd.Factory, out io.Writer) *cobr
 				usageError(cmd, "<build> is a required argument")
 			}
 
+			options := buildapi.BuildLogOptions{Follow: cmdutil.GetFlagBool(cmd, "follow")}
+			if tail := cmdutil.GetFlagInt(cmd, "tail"); tail >= 0 {
+				lines := int64(tail)
+				options.TailLines = &lines
+			}
+			if value := cmdutil.GetFlagString(cmd, "since-time"); len(value) > 0 {
+				since, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					usageError(cmd, "--since-time must be a RFC3339 time, e.g. 2015-04-20T10:00:00Z")
+				}
+				options.SinceTime = &util.Time{Time: since}
+			}
+
 			namespace, err := f.DefaultNamespace()
 			checkErr(err)
 
 			c, _, err := f.Clients()
 			checkErr(err)
 
-			request := c.BuildLogs(namespace).Redirect(args[0])
+			if options.Follow {
+				_, err := waitForBuildToStart(c.Builds(namespace), args[0], cmdutil.GetFlagDuration(cmd, "timeout"), os.Stderr)
+				checkErr(err)
+			}
 
-			readCloser, err := request.Stream()
+			readCloser, err := c.BuildLogs(namespace).Get(args[0], options).Stream()
 			checkErr(err)
 			defer readCloser.Close()
 
@@ -45,5 +86,40 @@ func NewCmdBuildLogs(fullName string, f *clientcmd.Factory, out io.Writer) *cobr
 			checkErr(err)
 		},
 	}
+	cmd.Flags().BoolP("follow", "f", true, "Stream the log of a running build until the build finishes")
+	cmd.Flags().Int("tail", -1, "Number of lines to show from the end of the log, all lines when negative")
+	cmd.Flags().String("since-time", "", "Only show lines logged after this RFC3339 time")
+	cmd.Flags().Duration("timeout", defaultBuildStartTimeout, "How long to wait for the build to start when following its log")
 	return cmd
 }
+
+// waitForBuildToStart waits until the named build is no longer New or Pending, when its log
+// becomes available, and returns it. A build still waiting after timeout is an error. The
+// status of a build that has to wait is reported once to status.
+func waitForBuildToStart(c osclient.BuildInterface, name string, timeout time.Duration, status io.Writer) (*buildapi.Build, error) {
+	deadline := time.Now().Add(timeout)
+	var reported buildapi.BuildStatus
+	for {
+		build, err := c.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		switch build.Status {
+		case buildapi.BuildStatusNew, buildapi.BuildStatusPending:
+		default:
+			return build, nil
+		}
+		if build.Status != reported {
+			reported = build.Status
+			if build.Status == buildapi.BuildStatusNew {
+				fmt.Fprintf(status, "Build %s is queued, waiting for it to start\n", name)
+			} else {
+				fmt.Fprintf(status, "Build %s is pending, waiting for its pod to start\n", name)
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("build %s did not start within %v, its status is %s", name, timeout, build.Status)
+		}
+		time.Sleep(buildStartPollInterval)
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	cmdutil "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/cmd/util"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+const deployLongDesc = `
+View, cancel or retry the latest deployment of a deployment configuration.
+
+Without options, the status of the latest deployment is shown. A deployment which
+is new, pending or running can be cancelled: its deployer is stopped, the previous
+deployment is scaled back up and the deployment is marked cancelled. A failed
+deployment can be retried: the deployment is run again, without creating a new
+version of the deployment configuration.
+
+Examples:
+
+	# Display the status of the latest deployment of the 'database' config
+	$ %[1]s deploy database
+
+	# Cancel the deployment of the 'database' config in progress
+	$ %[1]s deploy database --cancel
+
+	# Run the failed latest deployment of the 'database' config again
+	$ %[1]s deploy database --retry
+`
+
+// NewCmdDeploy views, cancels or retries the latest deployment of a deployment config.
+func NewCmdDeploy(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy <deploymentConfig>",
+		Short: "View, cancel or retry the latest deployment of a deployment configuration.",
+		Long:  fmt.Sprintf(deployLongDesc, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 || len(args[0]) == 0 {
+				usageError(cmd, "A deployment configuration name is required.")
+			}
+
+			cancel := cmdutil.GetFlagBool(cmd, "cancel")
+			retry := cmdutil.GetFlagBool(cmd, "retry")
+			if cancel && retry {
+				usageError(cmd, "Only one of --cancel or --retry may be specified.")
+			}
+
+			osClient, kClient, err := f.Clients()
+			checkErr(err)
+
+			namespace, err := f.DefaultNamespace()
+			checkErr(err)
+
+			config, err := osClient.DeploymentConfigs(namespace).Get(args[0])
+			checkErr(err)
+			if config.LatestVersion == 0 {
+				fmt.Fprintf(out, "Deployment config %s has not been deployed yet\n", config.Name)
+				return
+			}
+
+			deploymentName := deployutil.LatestDeploymentNameForConfig(config)
+			deployment, err := kClient.ReplicationControllers(namespace).Get(deploymentName)
+			checkErr(err)
+
+			switch {
+			case cancel:
+				checkErr(cancelDeployment(kClient, deployment, out))
+			case retry:
+				checkErr(retryDeployment(kClient, deployment, out))
+			default:
+				fmt.Fprintf(out, "Deployment %s is %s\n", deployment.Name, deployment.Annotations[deployapi.DeploymentStatusAnnotation])
+				if reason := deployment.Annotations[deployapi.DeploymentStatusReasonAnnotation]; len(reason) > 0 {
+					fmt.Fprintf(out, "Reason: %s\n", reason)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().Bool("cancel", false, "Cancel the deployment in progress and restore the previous deployment.")
+	cmd.Flags().Bool("retry", false, "Run the failed latest deployment again.")
+	return cmd
+}
+
+// cancelDeployment requests the cancellation of deployment, if it is in progress.
+func cancelDeployment(kClient kclient.Interface, deployment *kapi.ReplicationController, out io.Writer) error {
+	switch status := deployapi.DeploymentStatus(deployment.Annotations[deployapi.DeploymentStatusAnnotation]); status {
+	case deployapi.DeploymentStatusNew, deployapi.DeploymentStatusPending, deployapi.DeploymentStatusRunning:
+	default:
+		fmt.Fprintf(out, "Deployment %s is %s and can't be cancelled\n", deployment.Name, status)
+		return nil
+	}
+	if deployutil.IsDeploymentCancelled(deployment) {
+		fmt.Fprintf(out, "Deployment %s is already being cancelled\n", deployment.Name)
+		return nil
+	}
+
+	err := updateDeployment(kClient, deployment, func(deployment *kapi.ReplicationController) {
+		deployment.Annotations[deployapi.DeploymentCancelledAnnotation] = deployapi.DeploymentCancelledAnnotationValue
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Cancelling deployment %s\n", deployment.Name)
+	return nil
+}
+
+// retryDeployment requests a retry of deployment, if it failed. The deployment controller
+// deletes the pods of the failed attempt and runs the deployment again.
+func retryDeployment(kClient kclient.Interface, deployment *kapi.ReplicationController, out io.Writer) error {
+	if status := deployapi.DeploymentStatus(deployment.Annotations[deployapi.DeploymentStatusAnnotation]); status != deployapi.DeploymentStatusFailed {
+		fmt.Fprintf(out, "Deployment %s is %s; only failed deployments can be retried\n", deployment.Name, status)
+		return nil
+	}
+	if deployutil.IsDeploymentRetried(deployment) {
+		fmt.Fprintf(out, "Deployment %s is already being retried\n", deployment.Name)
+		return nil
+	}
+
+	err := updateDeployment(kClient, deployment, func(deployment *kapi.ReplicationController) {
+		deployment.Annotations[deployapi.DeploymentRetryAnnotation] = deployapi.DeploymentRetryAnnotationValue
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Retrying deployment %s\n", deployment.Name)
+	return nil
+}
+
+// maxConflictRetries is the number of times an update of a deployment is attempted when it
+// conflicts with another update.
+const maxConflictRetries = 3
+
+// updateDeployment applies mutate to deployment and updates it, getting the deployment again
+// and reapplying mutate on conflicts, up to maxConflictRetries times.
+func updateDeployment(kClient kclient.Interface, deployment *kapi.ReplicationController, mutate func(*kapi.ReplicationController)) error {
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		if i > 0 {
+			if deployment, err = kClient.ReplicationControllers(deployment.Namespace).Get(deployment.Name); err != nil {
+				return err
+			}
+		}
+		mutate(deployment)
+		if _, err = kClient.ReplicationControllers(deployment.Namespace).Update(deployment); err == nil || !kerrors.IsConflict(err) {
+			break
+		}
+	}
+	return err
+}
@@ -3,11 +3,13 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	cmdutil "github.com/GoogleCloudPlatform/kubernetes/pkg/kubectl/cmd/util"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/openshift/source-to-image/pkg/tar"
 	"github.com/spf13/cobra"
 
 	buildapi "github.com/openshift/origin/pkg/build/api"
@@ -31,6 +33,12 @@ Examples:
 
 	# Starts build from build configuration matching the name "3bd2ug53b" and watches the logs until the build completes or fails
 	$ %[1]s start-build 3bd2ug53b --follow
+
+	# Starts build from build configuration matching the name "3bd2ug53b" using the contents of the current directory
+	$ %[1]s start-build 3bd2ug53b --from-dir=.
+
+	# Starts build from build configuration matching the name "3bd2ug53b" using a local Dockerfile
+	$ %[1]s start-build 3bd2ug53b --from-file=./Dockerfile
 `
 
 func NewCmdStartBuild(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
@@ -41,9 +49,19 @@ func NewCmdStartBuild(fullName string, f *clientcmd.Factory, out io.Writer) *cob
 		Run: func(cmd *cobra.Command, args []string) {
 			buildName := cmdutil.GetFlagString(cmd, "from-build")
 			follow := cmdutil.GetFlagBool(cmd, "follow")
+			fromFile := cmdutil.GetFlagString(cmd, "from-file")
+			fromDir := cmdutil.GetFlagString(cmd, "from-dir")
 			if len(args) != 1 && len(buildName) == 0 {
 				usageError(cmd, "Must pass a name of buildConfig or specify build name with '--from-build' flag")
 			}
+			if len(fromFile) != 0 || len(fromDir) != 0 {
+				if len(args) != 1 || len(buildName) != 0 {
+					usageError(cmd, "'--from-file' and '--from-dir' require the name of a buildConfig and cannot be used with '--from-build'")
+				}
+				if len(fromFile) != 0 && len(fromDir) != 0 {
+					usageError(cmd, "Only one of '--from-file' or '--from-dir' may be specified")
+				}
+			}
 
 			client, _, err := f.Clients()
 			checkErr(err)
@@ -52,59 +70,44 @@ func NewCmdStartBuild(fullName string, f *clientcmd.Factory, out io.Writer) *cob
 			checkErr(err)
 
 			var newBuild *buildapi.Build
-			if len(buildName) == 0 {
+			switch {
+			case len(fromFile) != 0 || len(fromDir) != 0:
+				// the server creates the build while the content is uploaded
+				newBuild, err = instantiateBinaryBuild(client.BuildConfigs(namespace), args[0], fromFile, fromDir)
+				checkErr(err)
+			case len(buildName) == 0:
 				// from build config
 				config, err := client.BuildConfigs(namespace).Get(args[0])
 				checkErr(err)
 
 				newBuild, err = buildutil.GenerateBuildWithImageTag(config, nil, client.ImageRepositories(kapi.NamespaceAll).(osclient.ImageRepositoryNamespaceGetter))
 				checkErr(err)
-			} else {
+
+				// Start a build, the server records the user starting it
+				newBuild, err = client.Builds(namespace).Create(newBuild)
+				checkErr(err)
+			default:
 				build, err := client.Builds(namespace).Get(buildName)
 				checkErr(err)
 
+				// Start a build
 				newBuild = buildutil.GenerateBuildFromBuild(build)
+				// the server records the user starting the build along with the build run again
+				newBuild.Causes = []buildapi.BuildCause{{Type: buildapi.ManualBuildTriggerType, Manual: &buildapi.BuildCauseManual{FromBuild: build.Name}}}
+				newBuild, err = client.Builds(namespace).Create(newBuild)
+				checkErr(err)
 			}
 
-			// Start a build
-			newBuild, err = client.Builds(namespace).Create(newBuild)
-			checkErr(err)
-
 			if follow {
-				set := labels.Set(newBuild.Labels)
-				selector := labels.SelectorFromSet(set)
+				_, err := waitForBuildToStart(client.Builds(namespace), newBuild.Name, cmdutil.GetFlagDuration(cmd, "timeout"), os.Stderr)
+				checkErr(err)
 
-				// Add a watcher for the build about to start
-				watcher, err := client.Builds(namespace).Watch(selector, fields.Everything(), newBuild.ResourceVersion)
+				rd, err := client.BuildLogs(namespace).Get(newBuild.Name, buildapi.BuildLogOptions{Follow: true}).Stream()
+				checkErr(err)
+				defer rd.Close()
+
+				_, err = io.Copy(out, rd)
 				checkErr(err)
-				defer watcher.Stop()
-
-				for event := range watcher.ResultChan() {
-					build, ok := event.Object.(*buildapi.Build)
-					if !ok {
-						checkErr(fmt.Errorf("cannot convert input to Build"))
-					}
-
-					// Iterate over watcher's results and search for
-					// the build we just started. Also make sure that
-					// the build is running, complete, or has failed
-					if build.Name == newBuild.Name {
-						switch build.Status {
-						case buildapi.BuildStatusRunning, buildapi.BuildStatusComplete, buildapi.BuildStatusFailed:
-							rd, err := client.BuildLogs(namespace).Redirect(newBuild.Name).Stream()
-							checkErr(err)
-							defer rd.Close()
-
-							_, err = io.Copy(out, rd)
-							checkErr(err)
-							break
-						}
-
-						if build.Status == buildapi.BuildStatusComplete || build.Status == buildapi.BuildStatusFailed {
-							break
-						}
-					}
-				}
 			}
 
 			fmt.Fprintf(out, "%s\n", newBuild.Name)
@@ -112,5 +115,32 @@ func NewCmdStartBuild(fullName string, f *clientcmd.Factory, out io.Writer) *cob
 	}
 	cmd.Flags().String("from-build", "", "Specify the name of a build which should be re-run")
 	cmd.Flags().Bool("follow", false, "Start a build and watch its logs until it completes or fails")
+	cmd.Flags().Duration("timeout", defaultBuildStartTimeout, "How long to wait for the build to start when following its log")
+	cmd.Flags().String("from-file", "", "Upload a local file as the source of the build, saved under its own name")
+	cmd.Flags().String("from-dir", "", "Upload the contents of a local directory as the source of the build")
 	return cmd
 }
+
+// instantiateBinaryBuild starts a build from the named buildConfig, uploading either the
+// file or an archive of the directory as the source of the build.
+func instantiateBinaryBuild(client osclient.BuildConfigInterface, name, fromFile, fromDir string) (*buildapi.Build, error) {
+	path, asFile := fromFile, filepath.Base(fromFile)
+	if len(fromDir) != 0 {
+		tmpDir, err := ioutil.TempDir("", "start-build")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+		if path, err = tar.New().CreateTarFile(tmpDir, fromDir); err != nil {
+			return nil, fmt.Errorf("unable to archive %s: %v", fromDir, err)
+		}
+		asFile = ""
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return client.InstantiateBinary(name, asFile, f)
+}
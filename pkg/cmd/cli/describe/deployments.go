@@ -12,9 +12,11 @@ import (
 	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 
+	buildapi "github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/client"
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
+	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
 // DeploymentConfigDescriber generates information about a DeploymentConfig
@@ -26,12 +28,16 @@ type deploymentDescriberClient interface {
 	getDeploymentConfig(namespace, name string) (*deployapi.DeploymentConfig, error)
 	getDeployment(namespace, name string) (*kapi.ReplicationController, error)
 	listPods(namespace string, selector labels.Selector) (*kapi.PodList, error)
+	getImageRepository(namespace, name string) (*imageapi.ImageRepository, error)
+	getImage(name string) (*imageapi.Image, error)
 }
 
 type genericDeploymentDescriberClient struct {
 	getDeploymentConfigFunc func(namespace, name string) (*deployapi.DeploymentConfig, error)
 	getDeploymentFunc       func(namespace, name string) (*kapi.ReplicationController, error)
 	listPodsFunc            func(namespace string, selector labels.Selector) (*kapi.PodList, error)
+	getImageRepositoryFunc  func(namespace, name string) (*imageapi.ImageRepository, error)
+	getImageFunc            func(name string) (*imageapi.Image, error)
 }
 
 func (c *genericDeploymentDescriberClient) getDeploymentConfig(namespace, name string) (*deployapi.DeploymentConfig, error) {
@@ -46,6 +52,14 @@ func (c *genericDeploymentDescriberClient) listPods(namespace string, selector l
 	return c.listPodsFunc(namespace, selector)
 }
 
+func (c *genericDeploymentDescriberClient) getImageRepository(namespace, name string) (*imageapi.ImageRepository, error) {
+	return c.getImageRepositoryFunc(namespace, name)
+}
+
+func (c *genericDeploymentDescriberClient) getImage(name string) (*imageapi.Image, error) {
+	return c.getImageFunc(name)
+}
+
 func NewDeploymentConfigDescriberForConfig(config *deployapi.DeploymentConfig) *DeploymentConfigDescriber {
 	return &DeploymentConfigDescriber{
 		client: &genericDeploymentDescriberClient{
@@ -58,6 +72,12 @@ func NewDeploymentConfigDescriberForConfig(config *deployapi.DeploymentConfig) *
 			listPodsFunc: func(namespace string, selector labels.Selector) (*kapi.PodList, error) {
 				return nil, kerrors.NewNotFound("PodList", fmt.Sprintf("%v", selector))
 			},
+			getImageRepositoryFunc: func(namespace, name string) (*imageapi.ImageRepository, error) {
+				return nil, kerrors.NewNotFound("ImageRepository", name)
+			},
+			getImageFunc: func(name string) (*imageapi.Image, error) {
+				return nil, kerrors.NewNotFound("Image", name)
+			},
 		},
 	}
 }
@@ -74,6 +94,12 @@ func NewDeploymentConfigDescriber(client client.Interface, kclient kclient.Inter
 			listPodsFunc: func(namespace string, selector labels.Selector) (*kapi.PodList, error) {
 				return kclient.Pods(namespace).List(selector)
 			},
+			getImageRepositoryFunc: func(namespace, name string) (*imageapi.ImageRepository, error) {
+				return client.ImageRepositories(namespace).Get(name)
+			},
+			getImageFunc: func(name string) (*imageapi.Image, error) {
+				return client.Images().Get(name)
+			},
 		},
 	}
 }
@@ -94,8 +120,12 @@ func (d *DeploymentConfigDescriber) Describe(namespace, name string) (string, er
 		}
 
 		printStrategy(deploymentConfig.Template.Strategy, out)
+		if deploymentConfig.RollbackPolicy != nil && deploymentConfig.RollbackPolicy.Automatic {
+			formatString(out, "Rollback Policy", "Automatic")
+		}
 		printTriggers(deploymentConfig.Triggers, out)
 		printReplicationControllerSpec(deploymentConfig.Template.ControllerTemplate, out)
+		printImageSources(deploymentConfig, d.client, out)
 
 		deploymentName := deployutil.LatestDeploymentNameForConfig(deploymentConfig)
 		deployment, err := d.client.getDeployment(namespace, deploymentName)
@@ -117,6 +147,9 @@ func printStrategy(strategy deployapi.DeploymentStrategy, w io.Writer) {
 	fmt.Fprintf(w, "Strategy:\t%s\n", strategy.Type)
 	switch strategy.Type {
 	case deployapi.DeploymentStrategyTypeRecreate:
+		if params := strategy.RecreateParams; params != nil && params.TimeoutSeconds != nil {
+			fmt.Fprintf(w, "\t- Timeout:\t%ds\n", *params.TimeoutSeconds)
+		}
 	case deployapi.DeploymentStrategyTypeCustom:
 		fmt.Fprintf(w, "\t- Image:\t%s\n", strategy.CustomParams.Image)
 
@@ -127,7 +160,28 @@ func printStrategy(strategy deployapi.DeploymentStrategy, w io.Writer) {
 		if len(strategy.CustomParams.Command) > 0 {
 			fmt.Fprintf(w, "\t- Command:\t%v\n", strings.Join(strategy.CustomParams.Command, " "))
 		}
+	case deployapi.DeploymentStrategyTypeRolling:
+		if params := strategy.RollingParams; params != nil {
+			if params.IntervalSeconds != nil {
+				fmt.Fprintf(w, "\t- Interval:\t%ds\n", *params.IntervalSeconds)
+			}
+			if params.TimeoutSeconds != nil {
+				fmt.Fprintf(w, "\t- Timeout:\t%ds\n", *params.TimeoutSeconds)
+			}
+			fmt.Fprintf(w, "\t- Max Surge:\t%d\n", params.MaxSurge)
+			fmt.Fprintf(w, "\t- Max Unavailable:\t%d\n", params.MaxUnavailable)
+		}
+	}
+
+	printHook("Pre", strategy.Pre, w)
+	printHook("Post", strategy.Post, w)
+}
+
+func printHook(prefix string, hook *deployapi.LifecycleHook, w io.Writer) {
+	if hook == nil || hook.ExecNewPod == nil {
+		return
 	}
+	fmt.Fprintf(w, "\t- %s Hook:\t%s in container %s (failure policy: %s)\n", prefix, strings.Join(hook.ExecNewPod.Command, " "), hook.ExecNewPod.ContainerName, hook.FailurePolicy)
 }
 
 func printTriggers(triggers []deployapi.DeploymentTriggerPolicy, w io.Writer) {
@@ -179,6 +233,45 @@ func printReplicationControllerSpec(spec kapi.ReplicationControllerSpec, w io.Wr
 	return nil
 }
 
+// printImageSources prints the source the images of the image change triggers of config were
+// built from. Images that cannot be resolved or were not built by OpenShift are skipped.
+func printImageSources(config *deployapi.DeploymentConfig, client deploymentDescriberClient, w *tabwriter.Writer) {
+	header := false
+	for _, t := range config.Triggers {
+		if t.Type != deployapi.DeploymentTriggerOnImageChange || t.ImageChangeParams == nil || len(t.ImageChangeParams.From.Name) == 0 {
+			continue
+		}
+		params := t.ImageChangeParams
+		namespace := params.From.Namespace
+		if len(namespace) == 0 {
+			namespace = config.Namespace
+		}
+		repo, err := client.getImageRepository(namespace, params.From.Name)
+		if err != nil {
+			continue
+		}
+		tag := params.Tag
+		if len(tag) == 0 {
+			tag = "latest"
+		}
+		event, err := imageapi.LatestTaggedImage(repo, tag)
+		if err != nil || len(event.Image) == 0 {
+			continue
+		}
+		image, err := client.getImage(event.Image)
+		if err != nil || len(image.DockerImageMetadata.Config.Labels[buildapi.ImageCommitIDLabel]) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprint(w, "Image Sources:\n")
+			header = true
+		}
+		fmt.Fprintf(w, "\t- %s:%s\n", params.From.Name, tag)
+		fmt.Fprintf(w, "\t\tImage:\t%s\n", event.DockerImageReference)
+		formatImageSource(w, image.DockerImageMetadata.Config.Labels, "\t\t")
+	}
+}
+
 func printDeploymentRc(deployment *kapi.ReplicationController, client deploymentDescriberClient, w io.Writer) error {
 	running, waiting, succeeded, failed, err := getPodStatusForDeployment(deployment, client)
 	if err != nil {
@@ -188,6 +281,9 @@ func printDeploymentRc(deployment *kapi.ReplicationController, client deployment
 	fmt.Fprint(w, "Latest Deployment:\n")
 	fmt.Fprintf(w, "\tName:\t%s\n", deployment.Name)
 	fmt.Fprintf(w, "\tStatus:\t%s\n", deployment.Annotations[deployapi.DeploymentStatusAnnotation])
+	if reason, ok := deployment.Annotations[deployapi.DeploymentStatusReasonAnnotation]; ok {
+		fmt.Fprintf(w, "\tReason:\t%s\n", reason)
+	}
 	fmt.Fprintf(w, "\tSelector:\t%s\n", formatLabels(deployment.Spec.Selector))
 	fmt.Fprintf(w, "\tLabels:\t%s\n", formatLabels(deployment.Labels))
 	fmt.Fprintf(w, "\tReplicas:\t%d current / %d desired\n", deployment.Status.Replicas, deployment.Spec.Replicas)
@@ -3,6 +3,8 @@ package describe
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
@@ -81,12 +83,24 @@ func (d *BuildDescriber) DescribeParameters(p buildapi.BuildParameters, out *tab
 		}
 		if p.Strategy.DockerStrategy != nil {
 			formatString(out, "Image", p.Strategy.DockerStrategy.Image)
+			if len(p.Strategy.DockerStrategy.DockerfilePath) != 0 {
+				formatString(out, "Dockerfile Path", p.Strategy.DockerStrategy.DockerfilePath)
+			}
+			if len(p.Strategy.DockerStrategy.Env) != 0 {
+				formatString(out, "Environment", formatLabels(convertEnv(p.Strategy.DockerStrategy.Env)))
+			}
+			if len(p.Strategy.DockerStrategy.PullSecretName) != 0 {
+				formatString(out, "Pull Secret", p.Strategy.DockerStrategy.PullSecretName)
+			}
 		}
 	case buildapi.STIBuildStrategyType:
 		formatString(out, "Image", p.Strategy.STIStrategy.Image)
 		if p.Strategy.STIStrategy.Incremental {
 			formatString(out, "Incremental Build", "yes")
 		}
+		if len(p.Strategy.STIStrategy.PullSecretName) != 0 {
+			formatString(out, "Pull Secret", p.Strategy.STIStrategy.PullSecretName)
+		}
 	case buildapi.CustomBuildStrategyType:
 		formatString(out, "Image", p.Strategy.CustomStrategy.Image)
 		if p.Strategy.CustomStrategy.ExposeDockerSocket {
@@ -95,6 +109,9 @@ func (d *BuildDescriber) DescribeParameters(p buildapi.BuildParameters, out *tab
 		if len(p.Strategy.CustomStrategy.Env) != 0 {
 			formatString(out, "Environment", formatLabels(convertEnv(p.Strategy.CustomStrategy.Env)))
 		}
+		if len(p.Strategy.CustomStrategy.PullSecretName) != 0 {
+			formatString(out, "Pull Secret", p.Strategy.CustomStrategy.PullSecretName)
+		}
 	}
 	formatString(out, "Source Type", p.Source.Type)
 	if p.Source.Git != nil {
@@ -105,6 +122,36 @@ func (d *BuildDescriber) DescribeParameters(p buildapi.BuildParameters, out *tab
 		if len(p.Source.ContextDir) > 0 {
 			formatString(out, "ContextDir", p.Source.ContextDir)
 		}
+		if len(p.Source.SourceSecretName) > 0 {
+			formatString(out, "Source Secret", p.Source.SourceSecretName)
+		}
+	}
+	if len(p.Source.Dockerfile) > 0 {
+		formatString(out, "Dockerfile", "inline")
+	}
+	if p.Source.Binary != nil && len(p.Source.Binary.AsFile) > 0 {
+		formatString(out, "Binary As File", p.Source.Binary.AsFile)
+	}
+	for _, image := range p.Source.Images {
+		name := image.DockerImageReference
+		if image.From != nil {
+			name = image.From.Name
+			if len(image.From.Namespace) > 0 {
+				name = image.From.Namespace + "/" + name
+			}
+			if len(image.Tag) > 0 {
+				name = name + ":" + image.Tag
+			}
+		}
+		paths := []string{}
+		for _, path := range image.Paths {
+			destination := path.DestinationDir
+			if len(destination) == 0 {
+				destination = "."
+			}
+			paths = append(paths, fmt.Sprintf("%s->%s", path.SourcePath, destination))
+		}
+		formatString(out, "Image Source", fmt.Sprintf("%s %s", name, strings.Join(paths, ", ")))
 	}
 	if p.Output.To != nil {
 		if p.Output.To.Namespace != "" {
@@ -115,6 +162,35 @@ func (d *BuildDescriber) DescribeParameters(p buildapi.BuildParameters, out *tab
 	}
 
 	formatString(out, "Output Spec", p.Output.DockerImageReference)
+	if len(p.Output.PushSecretName) != 0 {
+		formatString(out, "Push Secret", p.Output.PushSecretName)
+	}
+	if p.CompletionDeadlineSeconds != nil {
+		formatString(out, "Completion Deadline", fmt.Sprintf("%ds", *p.CompletionDeadlineSeconds))
+	}
+	if limits := p.Resources.Limits; len(limits) > 0 {
+		names := []string{}
+		for name := range limits {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		resources := []string{}
+		for _, name := range names {
+			quantity := limits[kapi.ResourceName(name)]
+			resources = append(resources, fmt.Sprintf("%s=%s", name, quantity.String()))
+		}
+		formatString(out, "Resource Limits", strings.Join(resources, ", "))
+	}
+	if len(p.NodeSelector) > 0 {
+		formatString(out, "Node Selector", formatLabels(p.NodeSelector))
+	}
+	if hook := p.PostCommit; hook != nil {
+		command := hook.Command
+		if len(hook.Script) > 0 {
+			command = []string{"/bin/sh", "-c", hook.Script}
+		}
+		formatString(out, "Post Commit Hook", strings.Join(append(append([]string{}, command...), hook.Args...), " "))
+	}
 	if p.Revision != nil && p.Revision.Type == buildapi.BuildSourceGit && p.Revision.Git != nil {
 		formatString(out, "Git Commit", p.Revision.Git.Commit)
 		d.DescribeUser(out, "Revision Author", p.Revision.Git.Author)
@@ -125,6 +201,39 @@ func (d *BuildDescriber) DescribeParameters(p buildapi.BuildParameters, out *tab
 	}
 }
 
+// DescribeCauses generates information about the reasons a build was started
+func (d *BuildDescriber) DescribeCauses(causes []buildapi.BuildCause, out *tabwriter.Writer) {
+	for _, cause := range causes {
+		description := string(cause.Type)
+		switch {
+		case cause.WebHook != nil:
+			if revision := cause.WebHook.Revision; revision != nil && revision.Git != nil {
+				description = fmt.Sprintf("%s webhook for commit %s", cause.Type, revision.Git.Commit)
+			} else {
+				description = fmt.Sprintf("%s webhook", cause.Type)
+			}
+		case cause.ImageTrigger != nil:
+			from := cause.ImageTrigger.From.Name
+			if len(cause.ImageTrigger.From.Namespace) > 0 {
+				from = cause.ImageTrigger.From.Namespace + "/" + from
+			}
+			if len(cause.ImageTrigger.Tag) > 0 {
+				from += ":" + cause.ImageTrigger.Tag
+			}
+			description = fmt.Sprintf("image change of %s to %s", from, cause.ImageTrigger.ImageID)
+		case cause.Manual != nil:
+			description = "started manually"
+			if len(cause.Manual.User) > 0 {
+				description += " by " + cause.Manual.User
+			}
+			if len(cause.Manual.FromBuild) > 0 {
+				description += " from build " + cause.Manual.FromBuild
+			}
+		}
+		formatString(out, "Cause", description)
+	}
+}
+
 func (d *BuildDescriber) Describe(namespace, name string) (string, error) {
 	c := d.Builds(namespace)
 	build, err := c.Get(name)
@@ -134,7 +243,16 @@ func (d *BuildDescriber) Describe(namespace, name string) (string, error) {
 
 	return tabbedString(func(out *tabwriter.Writer) error {
 		formatMeta(out, build.ObjectMeta)
+		if build.Number > 0 {
+			formatString(out, "Build Number", strconv.Itoa(build.Number))
+		}
 		formatString(out, "Status", bold(build.Status))
+		if len(build.Reason) > 0 {
+			formatString(out, "Reason", build.Reason)
+		}
+		if len(build.Message) > 0 {
+			formatString(out, "Message", build.Message)
+		}
 		if build.StartTimestamp != nil {
 			formatString(out, "Started", build.StartTimestamp.Time)
 		}
@@ -159,7 +277,15 @@ func (d *BuildDescriber) Describe(namespace, name string) (string, error) {
 		}
 
 		formatString(out, "Build Pod", build.PodName)
+		d.DescribeCauses(build.Causes, out)
 		d.DescribeParameters(build.Parameters, out)
+		for _, status := range build.Notifications {
+			result := "delivered"
+			if !status.Delivered {
+				result = "not delivered: " + status.Message
+			}
+			formatString(out, "Notification", fmt.Sprintf("%s (%s after %d attempt(s))", status.URL, result, status.Attempts))
+		}
 		return nil
 	})
 }
@@ -178,6 +304,18 @@ func (d *BuildConfigDescriber) DescribeTriggers(bc *buildapi.BuildConfig, host s
 		formatString(out, "Webhook "+t, whURL)
 	}
 	for _, trigger := range bc.Triggers {
+		if trigger.Type == buildapi.SCMPollBuildTriggerType {
+			lastBuilt := "<none>"
+			if trigger.SCMPoll != nil && len(trigger.SCMPoll.LastBuiltCommit) > 0 {
+				lastBuilt = trigger.SCMPoll.LastBuiltCommit
+			}
+			formatString(out, "SCM Poll Trigger", fmt.Sprintf("last built commit %s", lastBuilt))
+			continue
+		}
+		if trigger.Type == buildapi.ConfigChangeBuildTriggerType {
+			formatString(out, "Config Change Trigger", "builds when the parameters change")
+			continue
+		}
 		if trigger.Type != buildapi.ImageChangeBuildTriggerType {
 			continue
 		}
@@ -203,8 +341,23 @@ func (d *BuildConfigDescriber) Describe(namespace, name string) (string, error)
 
 	return tabbedString(func(out *tabwriter.Writer) error {
 		formatMeta(out, buildConfig.ObjectMeta)
+		if len(buildConfig.RunPolicy) > 0 {
+			formatString(out, "Run Policy", buildConfig.RunPolicy)
+		}
+		if buildConfig.LastVersion > 0 {
+			formatString(out, "Latest Build", strconv.Itoa(buildConfig.LastVersion))
+		}
+		if limit := buildConfig.SuccessfulBuildsHistoryLimit; limit != nil {
+			formatString(out, "Successful Builds History Limit", strconv.Itoa(*limit))
+		}
+		if limit := buildConfig.FailedBuildsHistoryLimit; limit != nil {
+			formatString(out, "Failed Builds History Limit", strconv.Itoa(*limit))
+		}
 		buildDescriber.DescribeParameters(buildConfig.Parameters, out)
 		d.DescribeTriggers(buildConfig, d.host, out)
+		for _, notification := range buildConfig.Notifications {
+			formatString(out, "Notification URL", notification.URL)
+		}
 		return nil
 	})
 }
@@ -233,10 +386,24 @@ func (d *ImageDescriber) Describe(namespace, name string) (string, error) {
 	return tabbedString(func(out *tabwriter.Writer) error {
 		formatMeta(out, image.ObjectMeta)
 		formatString(out, "Docker Image", image.DockerImageReference)
+		formatImageSource(out, image.DockerImageMetadata.Config.Labels, "")
 		return nil
 	})
 }
 
+// formatImageSource prints the source an image was built from, as labelled by the builder.
+func formatImageSource(out *tabwriter.Writer, labels map[string]string, indent string) {
+	for _, label := range []struct{ name, key string }{
+		{"Source Location", buildapi.ImageSourceLocationLabel},
+		{"Source Ref", buildapi.ImageCommitRefLabel},
+		{"Source Commit", buildapi.ImageCommitIDLabel},
+	} {
+		if value, ok := labels[label.key]; ok {
+			formatString(out, indent+label.name, value)
+		}
+	}
+}
+
 // ImageRepositoryDescriber generates information about a ImageRepository
 type ImageRepositoryDescriber struct {
 	client.Interface
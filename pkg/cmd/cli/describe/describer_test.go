@@ -10,9 +10,11 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/openshift/origin/pkg/client"
 
+	buildapi "github.com/openshift/origin/pkg/build/api"
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deployapitest "github.com/openshift/origin/pkg/deploy/api/test"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
+	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
 type describeClient struct {
@@ -81,11 +83,31 @@ func TestDeploymentConfigDescriber(t *testing.T) {
 			listPodsFunc: func(namespace string, selector labels.Selector) (*kapi.PodList, error) {
 				return podList, nil
 			},
+			getImageRepositoryFunc: func(namespace, name string) (*imageapi.ImageRepository, error) {
+				return &imageapi.ImageRepository{
+					ObjectMeta: kapi.ObjectMeta{Name: name, Namespace: namespace},
+					Status: imageapi.ImageRepositoryStatus{
+						Tags: map[string]imageapi.TagEventList{
+							"latest": {Items: []imageapi.TagEvent{{DockerImageReference: "registry/test/imageRepo@abcdef", Image: "abcdef"}}},
+						},
+					},
+				}, nil
+			},
+			getImageFunc: func(name string) (*imageapi.Image, error) {
+				image := &imageapi.Image{ObjectMeta: kapi.ObjectMeta{Name: name}}
+				image.DockerImageMetadata.Config.Labels = map[string]string{
+					buildapi.ImageSourceLocationLabel: "https://github.com/openshift/ruby-hello-world",
+					buildapi.ImageCommitIDLabel:       "56789",
+				}
+				return image, nil
+			},
 		},
 	}
 
+	var output string
 	describe := func() {
-		if output, err := d.Describe("test", "deployment"); err != nil {
+		var err error
+		if output, err = d.Describe("test", "deployment"); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		} else {
 			t.Logf("describer output:\n%s\n", output)
@@ -103,7 +125,11 @@ func TestDeploymentConfigDescriber(t *testing.T) {
 
 	config.Triggers[0].ImageChangeParams.RepositoryName = ""
 	config.Triggers[0].ImageChangeParams.From = kapi.ObjectReference{Name: "imageRepo"}
+	config.Triggers[0].ImageChangeParams.Tag = "latest"
 	describe()
+	if !strings.Contains(output, "imageRepo:latest") || !strings.Contains(output, "Source Commit:\t\t56789") {
+		t.Errorf("Expected the source of the image to be described, got:\n%s", output)
+	}
 }
 
 func mkPod(status kapi.PodPhase, exitCode int) *kapi.Pod {
@@ -121,3 +147,20 @@ func mkPod(status kapi.PodPhase, exitCode int) *kapi.Pod {
 		},
 	}
 }
+
+func TestWebhookURL(t *testing.T) {
+	config := &buildapi.BuildConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: "app"},
+		Triggers: []buildapi.BuildTriggerPolicy{
+			{Type: buildapi.GithubWebHookBuildTriggerType, GithubWebHook: &buildapi.WebHookTrigger{Secret: "secret101", RequireSignature: true}},
+			{Type: buildapi.GenericWebHookBuildTriggerType, GenericWebHook: &buildapi.WebHookTrigger{Secret: "secret202"}},
+		},
+	}
+	urls := webhookURL(config, "https://master")
+	if url := urls["github"]; strings.Contains(url, "secret101") || !strings.HasSuffix(url, "/buildConfigHooks/app/github") {
+		t.Errorf("Expected the secret of a trigger requiring signatures to be left out, got %s", url)
+	}
+	if url := urls["generic"]; !strings.HasSuffix(url, "/buildConfigHooks/app/secret202/generic") {
+		t.Errorf("Expected the secret in the URL, got %s", url)
+	}
+}
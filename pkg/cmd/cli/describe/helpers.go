@@ -90,18 +90,25 @@ func formatMeta(out *tabwriter.Writer, m api.ObjectMeta) {
 	formatAnnotations(out, m, "")
 }
 
-// webhookURL assembles map with of webhook type as key and webhook url and value
+// webhookURL assembles map with of webhook type as key and webhook url and value. The secret
+// is left out of the URL of GitHub and generic triggers requiring signed requests.
 func webhookURL(c *buildapi.BuildConfig, configHost string) map[string]string {
 	result := map[string]string{}
 	for i, trigger := range c.Triggers {
-		whTrigger := ""
+		var whTrigger *buildapi.WebHookTrigger
 		switch trigger.Type {
 		case "github":
-			whTrigger = trigger.GithubWebHook.Secret
+			whTrigger = trigger.GithubWebHook
 		case "generic":
-			whTrigger = trigger.GenericWebHook.Secret
+			whTrigger = trigger.GenericWebHook
+		case "gitlab":
+			whTrigger = trigger.GitLabWebHook
+		case "bitbucket":
+			whTrigger = trigger.BitbucketWebHook
+		case "gogs":
+			whTrigger = trigger.GogsWebHook
 		}
-		if len(whTrigger) == 0 {
+		if whTrigger == nil || len(whTrigger.Secret) == 0 {
 			continue
 		}
 		apiVersion := latest.Version
@@ -109,11 +116,15 @@ func webhookURL(c *buildapi.BuildConfig, configHost string) map[string]string {
 		if len(configHost) > 0 {
 			host = configHost
 		}
-		url := fmt.Sprintf("%s/osapi/%s/buildConfigHooks/%s/%s/%s",
+		secret := whTrigger.Secret + "/"
+		if whTrigger.RequireSignature && (trigger.Type == "github" || trigger.Type == "generic") {
+			secret = ""
+		}
+		url := fmt.Sprintf("%s/osapi/%s/buildConfigHooks/%s/%s%s",
 			host,
 			apiVersion,
 			c.Name,
-			whTrigger,
+			secret,
 			c.Triggers[i].Type,
 		)
 		result[string(trigger.Type)] = url
@@ -169,7 +169,11 @@ func printBuildConfig(bc *buildapi.BuildConfig, w io.Writer) error {
 		_, err := fmt.Fprintf(w, "%s\t%v\t%s\n", bc.Name, bc.Parameters.Strategy.Type, bc.Parameters.Strategy.CustomStrategy.Image)
 		return err
 	}
-	_, err := fmt.Fprintf(w, "%s\t%v\t%s\n", bc.Name, bc.Parameters.Strategy.Type, bc.Parameters.Source.Git.URI)
+	source := string(bc.Parameters.Source.Type)
+	if bc.Parameters.Source.Git != nil {
+		source = bc.Parameters.Source.Git.URI
+	}
+	_, err := fmt.Fprintf(w, "%s\t%v\t%s\n", bc.Name, bc.Parameters.Strategy.Type, source)
 	return err
 }
 
@@ -689,6 +689,9 @@ func expectedBuildTriggers() []buildapi.BuildTriggerPolicy {
 				Secret: "asecret",
 			},
 		},
+		{
+			Type: buildapi.ConfigChangeBuildTriggerType,
+		},
 	}
 }
 
@@ -0,0 +1,129 @@
+package prune
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+
+	"github.com/openshift/origin/pkg/build/prune"
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+const buildsLongDesc = `
+Prune old builds
+
+Finished builds, and the pods that ran them, are kept on the server until they are deleted.
+This command deletes the finished builds of each build config beyond the most recent ones
+to keep, and optionally the builds whose build config no longer exists. Builds that finished
+recently are always kept.
+
+By default the command only lists the builds it would delete. Pass --confirm to delete them.
+
+Examples:
+
+	# List the builds that would be deleted in the current namespace
+	$ %[1]s
+
+	# Delete all but the 2 most recent complete builds of each build config
+	$ %[1]s --keep-complete=2 --confirm
+
+	# Also delete the builds of build configs that no longer exist
+	$ %[1]s --orphans --confirm
+`
+
+// PruneBuildsOptions holds the settings of the prune builds command.
+type PruneBuildsOptions struct {
+	Namespace string
+	Confirm   bool
+	Options   prune.Options
+
+	Client  client.Interface
+	Deleter prune.BuildDeleter
+	Out     io.Writer
+}
+
+// NewCmdPruneBuilds creates the command that prunes old builds.
+func NewCmdPruneBuilds(f *clientcmd.Factory, parentName, name string, out io.Writer) *cobra.Command {
+	options := &PruneBuildsOptions{
+		Options: prune.Options{
+			KeepYoungerThan: 60 * time.Minute,
+			KeepComplete:    5,
+			KeepFailed:      1,
+		},
+		Out: out,
+	}
+
+	cmd := &cobra.Command{
+		Use:   name,
+		Short: "Remove old completed and failed builds",
+		Long:  fmt.Sprintf(buildsLongDesc, parentName+" "+name),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				cmd.Help()
+				return
+			}
+			osClient, kubeClient, err := f.Clients()
+			if err != nil {
+				glog.Fatalf("Error getting client: %v", err)
+			}
+			if options.Namespace, err = f.DefaultNamespace(); err != nil {
+				glog.Fatalf("Error getting namespace: %v", err)
+			}
+			options.Client = osClient
+			options.Deleter = prune.NewBuildDeleter(osClient, kubeClient)
+			if err := options.Run(); err != nil {
+				glog.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Confirm, "confirm", options.Confirm, "Delete the builds instead of only listing them.")
+	cmd.Flags().BoolVar(&options.Options.Orphans, "orphans", options.Options.Orphans, "Prune builds whose build config no longer exists.")
+	cmd.Flags().DurationVar(&options.Options.KeepYoungerThan, "keep-younger-than", options.Options.KeepYoungerThan, "Keep builds that finished less than this long ago.")
+	cmd.Flags().IntVar(&options.Options.KeepComplete, "keep-complete", options.Options.KeepComplete, "The number of most recent complete builds to keep for each build config.")
+	cmd.Flags().IntVar(&options.Options.KeepFailed, "keep-failed", options.Options.KeepFailed, "The number of most recent failed, errored or cancelled builds to keep for each build config.")
+
+	return cmd
+}
+
+// Run selects the builds to prune and deletes them, or lists them unless Confirm is set.
+func (o *PruneBuildsOptions) Run() error {
+	if o.Options.KeepComplete < 0 || o.Options.KeepFailed < 0 {
+		return fmt.Errorf("the number of builds to keep cannot be negative")
+	}
+
+	configs, err := o.Client.BuildConfigs(o.Namespace).List(labels.Everything(), fields.Everything())
+	if err != nil {
+		return err
+	}
+	builds, err := o.Client.Builds(o.Namespace).List(labels.Everything(), fields.Everything())
+	if err != nil {
+		return err
+	}
+	selected := prune.SelectBuilds(configs.Items, builds.Items, o.Options, time.Now())
+
+	if !o.Confirm {
+		fmt.Fprintln(o.Out, "Dry run enabled - no modifications will be made. Add --confirm to remove builds")
+	}
+	w := tabwriter.NewWriter(o.Out, 10, 4, 3, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSTATUS")
+	for _, build := range selected {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", build.Namespace, build.Name, build.Status)
+		if !o.Confirm {
+			continue
+		}
+		if err := o.Deleter.DeleteBuild(build); err != nil {
+			return fmt.Errorf("unable to delete build %s/%s: %v", build.Namespace, build.Name, err)
+		}
+	}
+	return nil
+}
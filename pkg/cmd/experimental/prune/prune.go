@@ -0,0 +1,33 @@
+package prune
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+const longDesc = `
+Remove older versions of resources from the server
+
+The commands here allow administrators to manage the older versions of resources on
+the system by removing them.
+`
+
+// NewCmdPrune creates the command that groups the prune commands.
+func NewCmdPrune(f *clientcmd.Factory, parentName, name string, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name,
+		Short: "Remove older versions of resources from the server",
+		Long:  longDesc,
+		Run: func(c *cobra.Command, args []string) {
+			c.SetOutput(out)
+			c.Help()
+		},
+	}
+
+	cmd.AddCommand(NewCmdPruneBuilds(f, fmt.Sprintf("%s %s", parentName, name), "builds", out))
+	return cmd
+}
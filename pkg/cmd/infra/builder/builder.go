@@ -54,3 +54,26 @@ func NewCommandDockerBuilder(name string) *cobra.Command {
 	cmd.AddCommand(version.NewVersionCommand(name))
 	return cmd
 }
+
+const longCommandReceiveBuildInputDesc = `
+Receive Binary Build Input
+
+This command reads the content uploaded for a binary build from standard input
+and hands it to the build running in the same container.
+It expects to be run inside of a builder container.
+`
+
+// NewCommandReceiveBuildInput provides a CLI handler for receiving binary build input
+func NewCommandReceiveBuildInput(name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name,
+		Short: "Receive the input of an OpenShift binary build",
+		Long:  longCommandReceiveBuildInputDesc,
+		Run: func(c *cobra.Command, args []string) {
+			cmd.RunReceiveBuildInput()
+		},
+	}
+
+	templates.UseMainTemplates(cmd)
+	return cmd
+}
@@ -15,7 +15,8 @@ import (
 	"github.com/openshift/origin/pkg/cmd/util"
 	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
-	strategy "github.com/openshift/origin/pkg/deploy/strategy/recreate"
+	"github.com/openshift/origin/pkg/deploy/strategy/recreate"
+	"github.com/openshift/origin/pkg/deploy/strategy/rolling"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
 	"github.com/openshift/origin/pkg/version"
 )
@@ -32,11 +33,21 @@ type config struct {
 	Namespace      string
 }
 
+// strategy performs a deployment of a deployment config.
+type strategy interface {
+	Deploy(deployment *kapi.ReplicationController, oldDeployments []kapi.ObjectReference) error
+}
+
 type replicationControllerGetter interface {
 	Get(namespace, name string) (*kapi.ReplicationController, error)
 	List(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error)
 }
 
+type replicationControllerUpdater interface {
+	Get(namespace, name string) (*kapi.ReplicationController, error)
+	Update(namespace string, controller *kapi.ReplicationController) (*kapi.ReplicationController, error)
+}
+
 // NewCommandDeployer provides a CLI handler for deploy.
 func NewCommandDeployer(name string) *cobra.Command {
 	cfg := &config{
@@ -87,9 +98,49 @@ func deploy(kClient kclient.Interface, namespace, deploymentName string) error {
 		return err
 	}
 
-	// TODO: Choose a strategy based on some input
-	strategy := strategy.NewRecreateDeploymentStrategy(kClient, latest.Codec)
-	return strategy.Deploy(newDeployment, oldDeployments)
+	strategy, err := strategyFor(kClient, newDeployment)
+	if err != nil {
+		return err
+	}
+	if err := strategy.Deploy(newDeployment, oldDeployments); err != nil {
+		// The deployment is marked failed once the deployer exits, record why.
+		if recordErr := recordFailureReason(&realReplicationControllerUpdater{kClient}, newDeployment, err); recordErr != nil {
+			glog.Errorf("Couldn't record the failure of deployment %s: %v", newDeployment.Name, recordErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// recordFailureReason annotates deployment with the error which made it fail.
+func recordFailureReason(updater replicationControllerUpdater, deployment *kapi.ReplicationController, reason error) error {
+	current, err := updater.Get(deployment.Namespace, deployment.Name)
+	if err != nil {
+		return err
+	}
+	if current.Annotations == nil {
+		current.Annotations = map[string]string{}
+	}
+	current.Annotations[deployapi.DeploymentStatusReasonAnnotation] = reason.Error()
+	_, err = updater.Update(deployment.Namespace, current)
+	return err
+}
+
+// strategyFor returns the strategy of the config of deployment. Other strategy types, like
+// Custom, are not run by this deployer.
+func strategyFor(kClient kclient.Interface, deployment *kapi.ReplicationController) (strategy, error) {
+	config, err := deployutil.DecodeDeploymentConfig(deployment, latest.Codec)
+	if err != nil {
+		return nil, err
+	}
+	switch config.Template.Strategy.Type {
+	case deployapi.DeploymentStrategyTypeRecreate:
+		return recreate.NewRecreateDeploymentStrategy(kClient, latest.Codec), nil
+	case deployapi.DeploymentStrategyTypeRolling:
+		return rolling.NewRollingDeploymentStrategy(kClient, latest.Codec), nil
+	default:
+		return nil, fmt.Errorf("unsupported strategy type %q of deployment %s", config.Template.Strategy.Type, deployment.Name)
+	}
 }
 
 // getDeployerContext finds the target deployment and any deployments it considers to be prior to the
@@ -161,3 +212,15 @@ func (r *realReplicationControllerGetter) Get(namespace, name string) (*kapi.Rep
 func (r *realReplicationControllerGetter) List(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
 	return r.kClient.ReplicationControllers(namespace).List(selector)
 }
+
+type realReplicationControllerUpdater struct {
+	kClient kclient.Interface
+}
+
+func (r *realReplicationControllerUpdater) Get(namespace, name string) (*kapi.ReplicationController, error) {
+	return r.kClient.ReplicationControllers(namespace).Get(name)
+}
+
+func (r *realReplicationControllerUpdater) Update(namespace string, controller *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+	return r.kClient.ReplicationControllers(namespace).Update(controller)
+}
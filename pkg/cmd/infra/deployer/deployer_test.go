@@ -1,6 +1,7 @@
 package deployer
 
 import (
+	"fmt"
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
@@ -9,9 +10,38 @@ import (
 
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deploytest "github.com/openshift/origin/pkg/deploy/api/test"
+	"github.com/openshift/origin/pkg/deploy/strategy/recreate"
+	"github.com/openshift/origin/pkg/deploy/strategy/rolling"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
 )
 
+func TestStrategyFor(t *testing.T) {
+	config := deploytest.OkDeploymentConfig(1)
+	deployment, _ := deployutil.MakeDeployment(config, kapi.Codec)
+	s, err := strategyFor(nil, deployment)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.(*recreate.RecreateDeploymentStrategy); !ok {
+		t.Errorf("expected the Recreate strategy, got %#v", s)
+	}
+
+	config.Template.Strategy = deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeRolling}
+	deployment, _ = deployutil.MakeDeployment(config, kapi.Codec)
+	s, err = strategyFor(nil, deployment)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.(*rolling.RollingDeploymentStrategy); !ok {
+		t.Errorf("expected the Rolling strategy, got %#v", s)
+	}
+	config.Template.Strategy = deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeCustom}
+	deployment, _ = deployutil.MakeDeployment(config, kapi.Codec)
+	if s, err = strategyFor(nil, deployment); err == nil {
+		t.Errorf("expected an error for the Custom strategy, got %#v", s)
+	}
+}
+
 func TestGetDeploymentContextMissingDeployment(t *testing.T) {
 	getter := &testReplicationControllerGetter{
 		getFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
@@ -174,6 +204,44 @@ func TestGetDeploymentContextInvalidPriorDeployment(t *testing.T) {
 	}
 }
 
+func TestRecordFailureReason(t *testing.T) {
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	var updated *kapi.ReplicationController
+	updater := &testReplicationControllerUpdater{
+		getFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+			copied := *deployment
+			return &copied, nil
+		},
+		updateFunc: func(namespace string, controller *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+			updated = controller
+			return controller, nil
+		},
+	}
+
+	if err := recordFailureReason(updater, deployment, fmt.Errorf("pods not ready: config-1-abcde")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated == nil {
+		t.Fatalf("expected the deployment to be updated")
+	}
+	if e, a := "pods not ready: config-1-abcde", updated.Annotations[deployapi.DeploymentStatusReasonAnnotation]; e != a {
+		t.Errorf("expected reason %q, got %q", e, a)
+	}
+}
+
+type testReplicationControllerUpdater struct {
+	getFunc    func(namespace, name string) (*kapi.ReplicationController, error)
+	updateFunc func(namespace string, controller *kapi.ReplicationController) (*kapi.ReplicationController, error)
+}
+
+func (t *testReplicationControllerUpdater) Get(namespace, name string) (*kapi.ReplicationController, error) {
+	return t.getFunc(namespace, name)
+}
+
+func (t *testReplicationControllerUpdater) Update(namespace string, controller *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+	return t.updateFunc(namespace, controller)
+}
+
 type testReplicationControllerGetter struct {
 	getFunc  func(namespace, name string) (*kapi.ReplicationController, error)
 	listFunc func(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error)
@@ -11,6 +11,7 @@ import (
 	"github.com/openshift/origin/pkg/cmd/experimental/generate"
 	"github.com/openshift/origin/pkg/cmd/experimental/policy"
 	"github.com/openshift/origin/pkg/cmd/experimental/project"
+	"github.com/openshift/origin/pkg/cmd/experimental/prune"
 	exregistry "github.com/openshift/origin/pkg/cmd/experimental/registry"
 	exrouter "github.com/openshift/origin/pkg/cmd/experimental/router"
 	"github.com/openshift/origin/pkg/cmd/experimental/tokens"
@@ -97,6 +98,7 @@ func NewCommandOpenShift() *cobra.Command {
 		deployer.NewCommandDeployer("deploy"),
 		builder.NewCommandSTIBuilder("sti-build"),
 		builder.NewCommandDockerBuilder("docker-build"),
+		builder.NewCommandReceiveBuildInput("receive-build-input"),
 	)
 	root.AddCommand(infra)
 
@@ -124,5 +126,6 @@ func newExperimentalCommand(parentName, name string) *cobra.Command {
 	experimental.AddCommand(generate.NewCmdGenerate(f, subName, "generate", os.Stdout))
 	experimental.AddCommand(exrouter.NewCmdRouter(f, subName, "router", os.Stdout))
 	experimental.AddCommand(exregistry.NewCmdRegistry(f, subName, "registry", os.Stdout))
+	experimental.AddCommand(prune.NewCmdPrune(f, subName, "prune", os.Stdout))
 	return experimental
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/resource"
 	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/clientcmd"
 
@@ -203,3 +205,17 @@ func getAPIClientCertCAs(options MasterConfig) ([]*x509.Certificate, error) {
 
 	return apiClientCertCAs.Roots, nil
 }
+
+// ParseResourceLimits returns the compute resource limits of config, whose quantities are
+// strings like 500m or 1Gi.
+func ParseResourceLimits(limits map[string]string) (kapi.ResourceList, error) {
+	list := kapi.ResourceList{}
+	for name, value := range limits {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q of resource %s: %v", value, name, err)
+		}
+		list[kapi.ResourceName(name)] = *quantity
+	}
+	return list, nil
+}
@@ -65,6 +65,28 @@ type MasterConfig struct {
 	ImageConfig ImageConfig `json:"imageConfig"`
 
 	PolicyConfig PolicyConfig
+
+	// BuildDefaults, if present, are the compute resource limits, node selector and Git proxies
+	// of the builds that do not set them
+	BuildDefaults *BuildDefaultsConfig `json:"buildDefaults,omitempty"`
+
+	// BinaryBuildMaxInputBytes is the largest content, in bytes, accepted to start a binary
+	// build. Defaults to 500MiB.
+	BinaryBuildMaxInputBytes int64 `json:"binaryBuildMaxInputBytes,omitempty"`
+}
+
+type BuildDefaultsConfig struct {
+	// ResourceLimits are the default compute resource limits of build pods, like cpu: 500m
+	// and memory: 1Gi
+	ResourceLimits map[string]string `json:"resourceLimits,omitempty"`
+	// NodeSelector is the default node selector of build pods
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// GitHTTPProxy is the default proxy of Git sources over http
+	GitHTTPProxy string `json:"gitHTTPProxy,omitempty"`
+	// GitHTTPSProxy is the default proxy of Git sources over https
+	GitHTTPSProxy string `json:"gitHTTPSProxy,omitempty"`
+	// GitNoProxy is the default list of hosts reached without the proxies
+	GitNoProxy string `json:"gitNoProxy,omitempty"`
 }
 
 type PolicyConfig struct {
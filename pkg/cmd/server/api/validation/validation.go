@@ -8,6 +8,7 @@ import (
 	errs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	kvalidation "github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
 
+	buildvalidation "github.com/openshift/origin/pkg/build/api/validation"
 	"github.com/openshift/origin/pkg/cmd/server/api"
 )
 
@@ -114,6 +115,14 @@ func ValidateMasterConfig(config *api.MasterConfig) errs.ValidationErrorList {
 
 	allErrs = append(allErrs, ValidatePolicyConfig(config.PolicyConfig).Prefix("policyConfig")...)
 
+	if config.BuildDefaults != nil {
+		allErrs = append(allErrs, ValidateBuildDefaultsConfig(config.BuildDefaults).Prefix("buildDefaults")...)
+	}
+
+	if config.BinaryBuildMaxInputBytes < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("binaryBuildMaxInputBytes", config.BinaryBuildMaxInputBytes, "must not be negative"))
+	}
+
 	allErrs = append(allErrs, ValidateKubeConfig(config.MasterClients.DeployerKubeConfig, "deployerKubeConfig").Prefix("masterClients")...)
 	allErrs = append(allErrs, ValidateKubeConfig(config.MasterClients.OpenShiftLoopbackKubeConfig, "openShiftLoopbackKubeConfig").Prefix("masterClients")...)
 	allErrs = append(allErrs, ValidateKubeConfig(config.MasterClients.KubernetesKubeConfig, "kubernetesKubeConfig").Prefix("masterClients")...)
@@ -121,6 +130,23 @@ func ValidateMasterConfig(config *api.MasterConfig) errs.ValidationErrorList {
 	return allErrs
 }
 
+func ValidateBuildDefaultsConfig(config *api.BuildDefaultsConfig) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+
+	limits, err := api.ParseResourceLimits(config.ResourceLimits)
+	if err != nil {
+		allErrs = append(allErrs, errs.NewFieldInvalid("resourceLimits", config.ResourceLimits, err.Error()))
+	} else {
+		allErrs = append(allErrs, buildvalidation.ValidateResourceLimits(limits).Prefix("resourceLimits")...)
+	}
+	allErrs = append(allErrs, kvalidation.ValidateLabels(config.NodeSelector, "nodeSelector")...)
+	allErrs = append(allErrs, buildvalidation.ValidateProxyURL("gitHTTPProxy", config.GitHTTPProxy)...)
+	allErrs = append(allErrs, buildvalidation.ValidateProxyURL("gitHTTPSProxy", config.GitHTTPSProxy)...)
+	allErrs = append(allErrs, buildvalidation.ValidateNoProxy("gitNoProxy", config.GitNoProxy)...)
+
+	return allErrs
+}
+
 func ValidatePolicyConfig(config api.PolicyConfig) errs.ValidationErrorList {
 	allErrs := errs.ValidationErrorList{}
 
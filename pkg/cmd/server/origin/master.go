@@ -29,16 +29,21 @@ import (
 	"github.com/openshift/origin/pkg/api/latest"
 	"github.com/openshift/origin/pkg/api/v1beta1"
 	"github.com/openshift/origin/pkg/assets"
+	"github.com/openshift/origin/pkg/build/binary"
 	buildclient "github.com/openshift/origin/pkg/build/client"
 	buildcontrollerfactory "github.com/openshift/origin/pkg/build/controller/factory"
 	buildstrategy "github.com/openshift/origin/pkg/build/controller/strategy"
+	buildnotification "github.com/openshift/origin/pkg/build/notification"
 	buildregistry "github.com/openshift/origin/pkg/build/registry/build"
 	buildconfigregistry "github.com/openshift/origin/pkg/build/registry/buildconfig"
 	buildlogregistry "github.com/openshift/origin/pkg/build/registry/buildlog"
 	buildetcd "github.com/openshift/origin/pkg/build/registry/etcd"
 	"github.com/openshift/origin/pkg/build/webhook"
+	"github.com/openshift/origin/pkg/build/webhook/bitbucket"
 	"github.com/openshift/origin/pkg/build/webhook/generic"
 	"github.com/openshift/origin/pkg/build/webhook/github"
+	"github.com/openshift/origin/pkg/build/webhook/gitlab"
+	"github.com/openshift/origin/pkg/build/webhook/gogs"
 	osclient "github.com/openshift/origin/pkg/client"
 	cmdutil "github.com/openshift/origin/pkg/cmd/util"
 	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
@@ -150,6 +155,11 @@ func (c *MasterConfig) WebHookClient() *osclient.Client {
 	return c.OSClient
 }
 
+// BinaryBuildClients returns the client objects used to start builds from uploaded content
+func (c *MasterConfig) BinaryBuildClients() (*osclient.Client, *kclient.Client) {
+	return c.OSClient, c.KubernetesClient
+}
+
 // BuildControllerClients returns the build controller client objects
 func (c *MasterConfig) BuildControllerClients() (*osclient.Client, *kclient.Client) {
 	return c.OSClient, c.KubernetesClient
@@ -301,6 +311,8 @@ func (c *MasterConfig) InstallProtectedAPI(container *restful.Container) []strin
 			root = svc
 		case OpenShiftAPIPrefixV1Beta1:
 			svc.Doc("OpenShift REST API, version v1beta1").ApiVersion("v1beta1")
+			c.installBinaryBuildRoute(svc)
+			c.installBuildLogRoute(svc)
 		}
 	}
 	if root == nil {
@@ -314,6 +326,39 @@ func (c *MasterConfig) InstallProtectedAPI(container *restful.Container) []strin
 	}
 }
 
+// installBinaryBuildRoute adds the endpoint that starts builds from uploaded content
+// TODO: replace with a build config subresource once the API server supports them
+func (c *MasterConfig) installBinaryBuildRoute(svc *restful.WebService) {
+	osClient, kubeClient := c.BinaryBuildClients()
+	controller := binary.NewController(osClient, kubeClient, &c.KubeClientConfig, v1beta1.Codec)
+	controller.ContextMapper = c.getRequestContextMapper()
+	controller.Authorizer = c.Authorizer
+	if c.Options.BinaryBuildMaxInputBytes > 0 {
+		controller.MaxInputSize = c.Options.BinaryBuildMaxInputBytes
+	}
+	controller.Run()
+	svc.Route(svc.POST("/buildConfigs/{name}/instantiatebinary").To(controller.ServeRequest).
+		Doc("start a build from content uploaded in the request body").
+		Param(svc.PathParameter("name", "name of the build config")).
+		Param(svc.QueryParameter("asFile", "save the uploaded content as a file with this name instead of extracting it")).
+		Consumes("application/octet-stream").
+		Produces(restful.MIME_JSON))
+}
+
+// installBuildLogRoute adds the endpoint that serves stored and live build logs
+// TODO: replace the buildLogs redirector once the API server supports streaming subresources
+func (c *MasterConfig) installBuildLogRoute(svc *restful.WebService) {
+	buildEtcd := buildetcd.New(c.EtcdHelper)
+	handler := buildlogregistry.NewHandler(buildEtcd, buildEtcd, c.BuildLogClient(), v1beta1.Codec)
+	svc.Route(svc.GET("/buildLogs/{name}").To(handler.ServeRequest).
+		Doc("read the log of a build").
+		Param(svc.PathParameter("name", "name of the build")).
+		Param(svc.QueryParameter("follow", "keep streaming the log until the build finishes")).
+		Param(svc.QueryParameter("tailLines", "number of lines from the end of the log to return")).
+		Param(svc.QueryParameter("sinceTime", "only return lines logged after this RFC3339 time")).
+		Produces("text/plain"))
+}
+
 func (c *MasterConfig) InstallUnprotectedAPI(container *restful.Container) []string {
 	bcClient, _ := c.BuildControllerClients()
 	handler := webhook.NewController(
@@ -321,8 +366,11 @@ func (c *MasterConfig) InstallUnprotectedAPI(container *restful.Container) []str
 		buildclient.NewOSClientBuildClient(bcClient),
 		bcClient.ImageRepositories(kapi.NamespaceAll).(osclient.ImageRepositoryNamespaceGetter),
 		map[string]webhook.Plugin{
-			"generic": generic.New(),
-			"github":  github.New(),
+			"generic":   generic.New(),
+			"github":    github.New(),
+			"gitlab":    gitlab.New(),
+			"bitbucket": bitbucket.New(),
+			"gogs":      gogs.New(),
 		})
 
 	// TODO: go-restfulize this
@@ -653,36 +701,88 @@ func (c *MasterConfig) RunBuildController() {
 			// TODO: this will be set to --storage-version (the internal schema we use)
 			Codec: v1beta1.Codec,
 		},
+		BuildDefaults: c.buildDefaults(),
 	}
 
 	controller := factory.Create()
 	controller.Run()
 }
 
+// buildDefaults returns the configured defaults of build pods, or nil if there are none.
+func (c *MasterConfig) buildDefaults() *buildstrategy.BuildDefaults {
+	config := c.Options.BuildDefaults
+	if config == nil {
+		return nil
+	}
+	limits, err := configapi.ParseResourceLimits(config.ResourceLimits)
+	if err != nil {
+		glog.Fatalf("Invalid build defaults: %v", err)
+	}
+	return &buildstrategy.BuildDefaults{
+		Resources:     kapi.ResourceRequirements{Limits: limits},
+		NodeSelector:  config.NodeSelector,
+		GitHTTPProxy:  config.GitHTTPProxy,
+		GitHTTPSProxy: config.GitHTTPSProxy,
+		GitNoProxy:    config.GitNoProxy,
+	}
+}
+
 // RunBuildPodController starts the build/pod status sync loop for build status
 func (c *MasterConfig) RunBuildPodController() {
 	osclient, kclient := c.BuildControllerClients()
+	notifier := buildnotification.NewNotifier(buildclient.NewOSClientBuildConfigClient(osclient), buildclient.NewKubeClientSecretGetter(kclient), buildclient.NewOSClientBuildClient(osclient))
 	factory := buildcontrollerfactory.BuildPodControllerFactory{
 		OSClient:     osclient,
 		KubeClient:   kclient,
 		BuildUpdater: buildclient.NewOSClientBuildClient(osclient),
+		LogSaver:     buildlogregistry.NewLogSaver(c.BuildLogClient(), buildetcd.New(c.EtcdHelper)),
+		Notifier:     notifier,
 	}
 	controller := factory.Create()
 	controller.Run()
+	notifier.Run()
 }
 
 // RunBuildImageChangeTriggerController starts the build image change trigger controller process.
 func (c *MasterConfig) RunBuildImageChangeTriggerController() {
 	bcClient, _ := c.BuildControllerClients()
-	bcUpdater := buildclient.NewOSClientBuildConfigClient(bcClient)
+	bcGetterUpdater := buildclient.NewOSClientBuildConfigClient(bcClient)
 	bCreator := buildclient.NewOSClientBuildClient(bcClient)
-	factory := buildcontrollerfactory.ImageChangeControllerFactory{Client: bcClient, BuildCreator: bCreator, BuildConfigUpdater: bcUpdater}
+	factory := buildcontrollerfactory.ImageChangeControllerFactory{Client: bcClient, BuildCreator: bCreator, BuildConfigGetter: bcGetterUpdater, BuildConfigUpdater: bcGetterUpdater}
+	factory.Create().Run()
+}
+
+// RunBuildConfigChangeTriggerController starts the controller building BuildConfigs with a
+// ConfigChange trigger when they are created or changed.
+func (c *MasterConfig) RunBuildConfigChangeTriggerController() {
+	bcClient, _ := c.BuildControllerClients()
+	factory := buildcontrollerfactory.ConfigChangeControllerFactory{
+		Client:             bcClient,
+		BuildCreator:       buildclient.NewOSClientBuildClient(bcClient),
+		BuildConfigGetter:  buildclient.NewOSClientBuildConfigClient(bcClient),
+		BuildConfigUpdater: buildclient.NewOSClientBuildConfigClient(bcClient),
+	}
+	factory.Create().Run()
+}
+
+// RunBuildSCMPollTriggerController starts the controller polling the source repositories of
+// BuildConfigs with an SCMPoll trigger.
+func (c *MasterConfig) RunBuildSCMPollTriggerController() {
+	bcClient, _ := c.BuildControllerClients()
+	factory := buildcontrollerfactory.SCMPollControllerFactory{
+		Client:             bcClient,
+		BuildCreator:       buildclient.NewOSClientBuildClient(bcClient),
+		BuildConfigGetter:  buildclient.NewOSClientBuildConfigClient(bcClient),
+		BuildConfigUpdater: buildclient.NewOSClientBuildConfigClient(bcClient),
+		BuildDefaults:      c.buildDefaults(),
+		Interval:           time.Minute,
+	}
 	factory.Create().Run()
 }
 
 // RunDeploymentController starts the deployment controller process.
 func (c *MasterConfig) RunDeploymentController() error {
-	_, kclient := c.DeploymentControllerClients()
+	osclient, kclient := c.DeploymentControllerClients()
 
 	_, kclientConfig, err := configapi.GetKubeClient(c.Options.MasterClients.OpenShiftLoopbackKubeConfig)
 	if err != nil {
@@ -696,6 +796,7 @@ func (c *MasterConfig) RunDeploymentController() error {
 	env = append(env, clientcmd.EnvVarsFromConfig(c.DeployerClientConfig())...)
 
 	factory := deploycontroller.DeploymentControllerFactory{
+		Client:                osclient,
 		KubeClient:            kclient,
 		Codec:                 latest.Codec,
 		Environment:           env,
@@ -710,9 +811,11 @@ func (c *MasterConfig) RunDeploymentController() error {
 
 // RunDeployerPodController starts the deployer pod controller process.
 func (c *MasterConfig) RunDeployerPodController() {
-	_, kclient := c.DeploymentControllerClients()
+	osclient, kclient := c.DeploymentControllerClients()
 	factory := deployerpodcontroller.DeployerPodControllerFactory{
+		Client:     osclient,
 		KubeClient: kclient,
+		Codec:      latest.Codec,
 	}
 
 	controller := factory.Create()
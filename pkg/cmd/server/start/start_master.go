@@ -403,6 +403,8 @@ func StartMaster(openshiftMasterConfig *configapi.MasterConfig) error {
 	openshiftConfig.RunBuildController()
 	openshiftConfig.RunBuildPodController()
 	openshiftConfig.RunBuildImageChangeTriggerController()
+	openshiftConfig.RunBuildSCMPollTriggerController()
+	openshiftConfig.RunBuildConfigChangeTriggerController()
 	if err := openshiftConfig.RunDeploymentController(); err != nil {
 		return err
 	}
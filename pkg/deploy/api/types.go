@@ -44,6 +44,9 @@ const (
 	DeploymentStatusComplete DeploymentStatus = "Complete"
 	// DeploymentStatusFailed means the deployment finished with an error.
 	DeploymentStatusFailed DeploymentStatus = "Failed"
+	// DeploymentStatusCancelled means the deployment was cancelled before it finished. The
+	// deployer is stopped and the prior deployment is restored.
+	DeploymentStatusCancelled DeploymentStatus = "Cancelled"
 )
 
 // DeploymentStrategy describes how to perform a deployment.
@@ -52,6 +55,14 @@ type DeploymentStrategy struct {
 	Type DeploymentStrategyType `json:"type,omitempty"`
 	// CustomParams are the input to the Custom deployment strategy.
 	CustomParams *CustomDeploymentStrategyParams `json:"customParams,omitempty"`
+	// RecreateParams are the input to the Recreate deployment strategy.
+	RecreateParams *RecreateDeploymentStrategyParams `json:"recreateParams,omitempty"`
+	// RollingParams are the input to the Rolling deployment strategy.
+	RollingParams *RollingDeploymentStrategyParams `json:"rollingParams,omitempty"`
+	// Pre is a lifecycle hook which is executed before the deployment is scaled up.
+	Pre *LifecycleHook `json:"pre,omitempty"`
+	// Post is a lifecycle hook which is executed after the previous deployments are scaled down.
+	Post *LifecycleHook `json:"post,omitempty"`
 }
 
 // DeploymentStrategyType refers to a specific DeploymentStrategy implementation.
@@ -62,6 +73,9 @@ const (
 	DeploymentStrategyTypeRecreate DeploymentStrategyType = "Recreate"
 	// DeploymentStrategyTypeCustom is a user defined strategy.
 	DeploymentStrategyTypeCustom DeploymentStrategyType = "Custom"
+	// DeploymentStrategyTypeRolling replaces the pods of previous deployments in steps, without
+	// an outage.
+	DeploymentStrategyTypeRolling DeploymentStrategyType = "Rolling"
 )
 
 // CustomParams are the input to the Custom deployment strategy.
@@ -74,6 +88,66 @@ type CustomDeploymentStrategyParams struct {
 	Command []string `json:"command,omitempty"`
 }
 
+// RecreateDeploymentStrategyParams are the input to the Recreate deployment strategy.
+type RecreateDeploymentStrategyParams struct {
+	// TimeoutSeconds is the time to wait for the pods of the new deployment to become ready
+	// before the deployment fails, 600 by default. It also bounds each run of a lifecycle hook
+	// pod, which fails when it does not complete in time.
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+}
+
+// RollingDeploymentStrategyParams are the input to the Rolling deployment strategy.
+type RollingDeploymentStrategyParams struct {
+	// IntervalSeconds is the time to wait between the steps of the deployment, 1 by default.
+	IntervalSeconds *int64 `json:"intervalSeconds,omitempty"`
+	// TimeoutSeconds is the time to wait for the new pods of a step to become ready before the
+	// deployment is aborted, 600 by default. It also bounds each run of a lifecycle hook pod,
+	// which fails when it does not complete in time.
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+	// MaxSurge is the number of pods that may run above the desired replica count during the
+	// deployment.
+	MaxSurge int `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the number of pods that may be unavailable below the desired replica
+	// count during the deployment. When both MaxSurge and MaxUnavailable are zero, MaxSurge
+	// defaults to 1.
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+}
+
+// LifecycleHook defines a specific deployment lifecycle action.
+type LifecycleHook struct {
+	// FailurePolicy specifies what action to take if the hook fails.
+	FailurePolicy LifecycleHookFailurePolicy `json:"failurePolicy"`
+	// ExecNewPod specifies the action to take.
+	ExecNewPod *ExecNewPodHook `json:"execNewPod,omitempty"`
+}
+
+// LifecycleHookFailurePolicy describes possibles actions to take if a hook fails.
+type LifecycleHookFailurePolicy string
+
+const (
+	// LifecycleHookFailurePolicyRetry means retry the hook, waiting longer after each failure,
+	// until it succeeds. The deployment fails after 10 failed attempts.
+	LifecycleHookFailurePolicyRetry LifecycleHookFailurePolicy = "Retry"
+	// LifecycleHookFailurePolicyAbort means abort the deployment, which is then marked failed.
+	// It is not allowed for Post hooks, which run once the deployment completed.
+	LifecycleHookFailurePolicyAbort LifecycleHookFailurePolicy = "Abort"
+	// LifecycleHookFailurePolicyIgnore means ignore the failure and continue the deployment.
+	LifecycleHookFailurePolicyIgnore LifecycleHookFailurePolicy = "Ignore"
+)
+
+// ExecNewPodHook is a hook implementation which runs a command in a new pod based on the
+// specified container, which is assumed to be part of the deployment template.
+type ExecNewPodHook struct {
+	// Command is the action command and its arguments.
+	Command []string `json:"command"`
+	// Env is a set of environment variables to supply to the hook pod's container, in addition
+	// to the environment of the container.
+	Env []kapi.EnvVar `json:"env,omitempty"`
+	// ContainerName is the name of a container in the deployment pod template whose image,
+	// environment and volume mounts are used for the hook pod's container.
+	ContainerName string `json:"containerName"`
+}
+
 // A DeploymentList is a collection of deployments.
 // DEPRECATED: Like Deployment, this is no longer used.
 type DeploymentList struct {
@@ -104,6 +178,32 @@ const (
 	// annotation value is the LatestVersion value of the DeploymentConfig which was the basis for
 	// the deployment.
 	DeploymentVersionAnnotation = "deploymentVersion"
+	// DeploymentStatusReasonAnnotation is an annotation on a deployment (a ReplicationController).
+	// The annotation value describes why the deployment has its DeploymentStatus, such as the
+	// error which made it fail.
+	DeploymentStatusReasonAnnotation = "deploymentStatusReason"
+	// PreHookPodAnnotation and PostHookPodAnnotation are annotations on a deployment (a
+	// ReplicationController). Their values are the names of the last pods which executed the
+	// pre and post lifecycle hooks of the deployment.
+	PreHookPodAnnotation  = "preHookPod"
+	PostHookPodAnnotation = "postHookPod"
+	// PreHookPodPhaseAnnotation and PostHookPodPhaseAnnotation are annotations on a deployment
+	// (a ReplicationController). Their values are the final phases of the pods named by
+	// PreHookPodAnnotation and PostHookPodAnnotation.
+	PreHookPodPhaseAnnotation  = "preHookPodPhase"
+	PostHookPodPhaseAnnotation = "postHookPodPhase"
+	// DeploymentCancelledAnnotation is an annotation on a deployment (a ReplicationController).
+	// A deployment is cancelled when the value of the annotation is DeploymentCancelledAnnotationValue.
+	DeploymentCancelledAnnotation = "deploymentCancelled"
+	// DeploymentCancelledAnnotationValue is the value of DeploymentCancelledAnnotation which
+	// requests the cancellation of a deployment.
+	DeploymentCancelledAnnotationValue = "true"
+	// DeploymentRetryAnnotation is an annotation on a deployment (a ReplicationController). A
+	// failed deployment is run again when the value of the annotation is DeploymentRetryAnnotationValue.
+	DeploymentRetryAnnotation = "deploymentRetry"
+	// DeploymentRetryAnnotationValue is the value of DeploymentRetryAnnotation which requests a
+	// failed deployment to be run again.
+	DeploymentRetryAnnotationValue = "true"
 	// DeploymentLabel is the name of a label used to correlate a deployment with the Pod created
 	// to execute the deployment logic.
 	// TODO: This is a workaround for upstream's lack of annotation support on PodTemplate. Once
@@ -133,6 +233,16 @@ type DeploymentConfig struct {
 	// The reasons for the update to this deployment config.
 	// This could be based on a change made by the user or caused by an automatic trigger
 	Details *DeploymentDetails `json:"details,omitempty"`
+	// RollbackPolicy determines what happens when a deployment of this config fails.
+	RollbackPolicy *DeploymentRollbackPolicy `json:"rollbackPolicy,omitempty"`
+}
+
+// DeploymentRollbackPolicy describes how a DeploymentConfig recovers from a failed deployment.
+type DeploymentRollbackPolicy struct {
+	// Automatic means that when the latest deployment fails, the last complete deployment is
+	// reinstated and the image change triggers of the config are disabled, so that the failed
+	// deployment is not triggered again. A failed rollback is not rolled back again.
+	Automatic bool `json:"automatic,omitempty"`
 }
 
 // DeploymentTemplate contains all the necessary information to create a deployment from a
@@ -192,12 +302,35 @@ type DeploymentDetails struct {
 	Causes []*DeploymentCause `json:"causes,omitempty"`
 }
 
+// DeploymentCauseType is the type of a DeploymentCause.
+type DeploymentCauseType string
+
+const (
+	// DeploymentCauseTypeImageChange is the cause of a deployment created by an ImageChange trigger.
+	DeploymentCauseTypeImageChange DeploymentCauseType = "ImageChange"
+	// DeploymentCauseTypeConfigChange is the cause of a deployment created by a ConfigChange trigger.
+	DeploymentCauseTypeConfigChange DeploymentCauseType = "ConfigChange"
+	// DeploymentCauseTypeRollback is the cause of a deployment which automatically reinstates a
+	// previous deployment after a deployment failed.
+	DeploymentCauseTypeRollback DeploymentCauseType = "Rollback"
+)
+
 // DeploymentCause captures information about a particular cause of a deployment.
 type DeploymentCause struct {
 	// The type of the trigger that resulted in the creation of a new deployment
-	Type DeploymentTriggerType `json:"type"`
+	Type DeploymentCauseType `json:"type"`
 	// The image trigger details, if this trigger was fired based on an image change
 	ImageTrigger *DeploymentCauseImageTrigger `json:"imageTrigger,omitempty"`
+	// The rollback details, if this deployment reinstates a previous deployment
+	Rollback *DeploymentCauseRollback `json:"rollback,omitempty"`
+}
+
+// DeploymentCauseRollback describes an automatic rollback after a deployment failed.
+type DeploymentCauseRollback struct {
+	// FailedDeployment is the name of the deployment which failed.
+	FailedDeployment string `json:"failedDeployment,omitempty"`
+	// RestoredDeployment is the name of the complete deployment which is reinstated.
+	RestoredDeployment string `json:"restoredDeployment,omitempty"`
 }
 
 type DeploymentCauseImageTrigger struct {
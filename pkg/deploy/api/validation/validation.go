@@ -1,6 +1,9 @@
 package validation
 
 import (
+	"fmt"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
@@ -13,7 +16,7 @@ import (
 //       upstream and fix when it goes in.
 
 func ValidateDeployment(deployment *deployapi.Deployment) errors.ValidationErrorList {
-	errs := validateDeploymentStrategy(&deployment.Strategy).Prefix("strategy")
+	errs := validateDeploymentStrategy(&deployment.Strategy, deployment.ControllerTemplate.Template).Prefix("strategy")
 	if len(deployment.Name) == 0 {
 		errs = append(errs, errors.NewFieldRequired("name"))
 	} else if !util.IsDNS1123Subdomain(deployment.Name) {
@@ -46,7 +49,7 @@ func ValidateDeploymentConfig(config *deployapi.DeploymentConfig) errors.Validat
 	for i := range config.Triggers {
 		errs = append(errs, validateTrigger(&config.Triggers[i]).PrefixIndex(i).Prefix("triggers")...)
 	}
-	errs = append(errs, validateDeploymentStrategy(&config.Template.Strategy).Prefix("template.strategy")...)
+	errs = append(errs, validateDeploymentStrategy(&config.Template.Strategy, config.Template.ControllerTemplate.Template).Prefix("template.strategy")...)
 	errs = append(errs, validation.ValidateReplicationControllerSpec(&config.Template.ControllerTemplate).Prefix("template.controllerTemplate")...)
 	return errs
 }
@@ -69,7 +72,7 @@ func ValidateDeploymentConfigRollback(rollback *deployapi.DeploymentConfigRollba
 	return result
 }
 
-func validateDeploymentStrategy(strategy *deployapi.DeploymentStrategy) errors.ValidationErrorList {
+func validateDeploymentStrategy(strategy *deployapi.DeploymentStrategy, template *kapi.PodTemplateSpec) errors.ValidationErrorList {
 	errs := errors.ValidationErrorList{}
 
 	if len(strategy.Type) == 0 {
@@ -83,6 +86,26 @@ func validateDeploymentStrategy(strategy *deployapi.DeploymentStrategy) errors.V
 		} else {
 			errs = append(errs, validateCustomParams(strategy.CustomParams).Prefix("customParams")...)
 		}
+	case deployapi.DeploymentStrategyTypeRecreate:
+		if strategy.RecreateParams != nil {
+			errs = append(errs, validateRecreateParams(strategy.RecreateParams).Prefix("recreateParams")...)
+		}
+	case deployapi.DeploymentStrategyTypeRolling:
+		if strategy.RollingParams != nil {
+			errs = append(errs, validateRollingParams(strategy.RollingParams).Prefix("rollingParams")...)
+		}
+	}
+
+	if strategy.Pre != nil {
+		errs = append(errs, validateLifecycleHook(strategy.Pre, template).Prefix("pre")...)
+	}
+	if strategy.Post != nil {
+		postErrs := validateLifecycleHook(strategy.Post, template)
+		// the previous deployments are already scaled down when the Post hook runs
+		if strategy.Post.FailurePolicy == deployapi.LifecycleHookFailurePolicyAbort {
+			postErrs = append(postErrs, errors.NewFieldInvalid("failurePolicy", strategy.Post.FailurePolicy, "post hooks run once the deployment completed and cannot abort it"))
+		}
+		errs = append(errs, postErrs.Prefix("post")...)
 	}
 
 	return errs
@@ -98,6 +121,88 @@ func validateCustomParams(params *deployapi.CustomDeploymentStrategyParams) erro
 	return errs
 }
 
+func validateRecreateParams(params *deployapi.RecreateDeploymentStrategyParams) errors.ValidationErrorList {
+	errs := errors.ValidationErrorList{}
+
+	if params.TimeoutSeconds != nil && *params.TimeoutSeconds < 1 {
+		errs = append(errs, errors.NewFieldInvalid("timeoutSeconds", *params.TimeoutSeconds, "must be at least 1"))
+	}
+
+	return errs
+}
+
+func validateRollingParams(params *deployapi.RollingDeploymentStrategyParams) errors.ValidationErrorList {
+	errs := errors.ValidationErrorList{}
+
+	if params.IntervalSeconds != nil && *params.IntervalSeconds < 1 {
+		errs = append(errs, errors.NewFieldInvalid("intervalSeconds", *params.IntervalSeconds, "must be at least 1"))
+	}
+	if params.TimeoutSeconds != nil && *params.TimeoutSeconds < 1 {
+		errs = append(errs, errors.NewFieldInvalid("timeoutSeconds", *params.TimeoutSeconds, "must be at least 1"))
+	}
+	if params.MaxSurge < 0 {
+		errs = append(errs, errors.NewFieldInvalid("maxSurge", params.MaxSurge, "must not be negative"))
+	}
+	if params.MaxUnavailable < 0 {
+		errs = append(errs, errors.NewFieldInvalid("maxUnavailable", params.MaxUnavailable, "must not be negative"))
+	}
+
+	return errs
+}
+
+func validateLifecycleHook(hook *deployapi.LifecycleHook, template *kapi.PodTemplateSpec) errors.ValidationErrorList {
+	errs := errors.ValidationErrorList{}
+
+	switch hook.FailurePolicy {
+	case deployapi.LifecycleHookFailurePolicyRetry, deployapi.LifecycleHookFailurePolicyAbort, deployapi.LifecycleHookFailurePolicyIgnore:
+	case "":
+		errs = append(errs, errors.NewFieldRequired("failurePolicy"))
+	default:
+		errs = append(errs, errors.NewFieldNotSupported("failurePolicy", hook.FailurePolicy))
+	}
+
+	if hook.ExecNewPod == nil {
+		errs = append(errs, errors.NewFieldRequired("execNewPod"))
+	} else {
+		errs = append(errs, validateExecNewPod(hook.ExecNewPod, template).Prefix("execNewPod")...)
+	}
+
+	return errs
+}
+
+func validateExecNewPod(hook *deployapi.ExecNewPodHook, template *kapi.PodTemplateSpec) errors.ValidationErrorList {
+	errs := errors.ValidationErrorList{}
+
+	if len(hook.Command) == 0 {
+		errs = append(errs, errors.NewFieldRequired("command"))
+	}
+
+	if len(hook.ContainerName) == 0 {
+		errs = append(errs, errors.NewFieldRequired("containerName"))
+	} else if template != nil {
+		found := false
+		for _, container := range template.Spec.Containers {
+			if container.Name == hook.ContainerName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, errors.NewFieldInvalid("containerName", hook.ContainerName, "must be the name of a container of the deployment template"))
+		}
+	}
+
+	for i, env := range hook.Env {
+		if len(env.Name) == 0 {
+			errs = append(errs, errors.NewFieldRequired(fmt.Sprintf("env[%d].name", i)))
+		} else if !util.IsCIdentifier(env.Name) {
+			errs = append(errs, errors.NewFieldInvalid(fmt.Sprintf("env[%d].name", i), env.Name, "must be a C identifier"))
+		}
+	}
+
+	return errs
+}
+
 func validateTrigger(trigger *deployapi.DeploymentTriggerPolicy) errors.ValidationErrorList {
 	errs := errors.ValidationErrorList{}
 
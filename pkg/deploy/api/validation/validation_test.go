@@ -79,6 +79,7 @@ func TestValidateDeploymentConfigOK(t *testing.T) {
 }
 
 func TestValidateDeploymentConfigMissingFields(t *testing.T) {
+	zero := int64(0)
 	errorCases := map[string]struct {
 		D api.DeploymentConfig
 		T errors.ValidationErrorType
@@ -227,6 +228,89 @@ func TestValidateDeploymentConfigMissingFields(t *testing.T) {
 			errors.ValidationErrorTypeRequired,
 			"template.strategy.customParams.image",
 		},
+		"invalid template.strategy.recreateParams.timeoutSeconds": {
+			api.DeploymentConfig{
+				ObjectMeta: kapi.ObjectMeta{Name: "foo", Namespace: "bar"},
+				Triggers:   manualTrigger(),
+				Template: api.DeploymentTemplate{
+					Strategy: api.DeploymentStrategy{
+						Type:           api.DeploymentStrategyTypeRecreate,
+						RecreateParams: &api.RecreateDeploymentStrategyParams{TimeoutSeconds: &zero},
+					},
+					ControllerTemplate: test.OkControllerTemplate(),
+				},
+			},
+			errors.ValidationErrorTypeInvalid,
+			"template.strategy.recreateParams.timeoutSeconds",
+		},
+		"invalid template.strategy.rollingParams.maxSurge": {
+			api.DeploymentConfig{
+				ObjectMeta: kapi.ObjectMeta{Name: "foo", Namespace: "bar"},
+				Triggers:   manualTrigger(),
+				Template: api.DeploymentTemplate{
+					Strategy: api.DeploymentStrategy{
+						Type:          api.DeploymentStrategyTypeRolling,
+						RollingParams: &api.RollingDeploymentStrategyParams{MaxSurge: -1},
+					},
+					ControllerTemplate: test.OkControllerTemplate(),
+				},
+			},
+			errors.ValidationErrorTypeInvalid,
+			"template.strategy.rollingParams.maxSurge",
+		},
+		"missing template.strategy.pre.failurePolicy": {
+			api.DeploymentConfig{
+				ObjectMeta: kapi.ObjectMeta{Name: "foo", Namespace: "bar"},
+				Triggers:   manualTrigger(),
+				Template: api.DeploymentTemplate{
+					Strategy: api.DeploymentStrategy{
+						Type: api.DeploymentStrategyTypeRecreate,
+						Pre: &api.LifecycleHook{
+							ExecNewPod: &api.ExecNewPodHook{Command: []string{"migrate"}, ContainerName: "container1"},
+						},
+					},
+					ControllerTemplate: test.OkControllerTemplate(),
+				},
+			},
+			errors.ValidationErrorTypeRequired,
+			"template.strategy.pre.failurePolicy",
+		},
+		"unknown template.strategy.post.execNewPod.containerName": {
+			api.DeploymentConfig{
+				ObjectMeta: kapi.ObjectMeta{Name: "foo", Namespace: "bar"},
+				Triggers:   manualTrigger(),
+				Template: api.DeploymentTemplate{
+					Strategy: api.DeploymentStrategy{
+						Type: api.DeploymentStrategyTypeRecreate,
+						Post: &api.LifecycleHook{
+							FailurePolicy: api.LifecycleHookFailurePolicyIgnore,
+							ExecNewPod:    &api.ExecNewPodHook{Command: []string{"notify"}, ContainerName: "other"},
+						},
+					},
+					ControllerTemplate: test.OkControllerTemplate(),
+				},
+			},
+			errors.ValidationErrorTypeInvalid,
+			"template.strategy.post.execNewPod.containerName",
+		},
+		"invalid template.strategy.post.failurePolicy": {
+			api.DeploymentConfig{
+				ObjectMeta: kapi.ObjectMeta{Name: "foo", Namespace: "bar"},
+				Triggers:   manualTrigger(),
+				Template: api.DeploymentTemplate{
+					Strategy: api.DeploymentStrategy{
+						Type: api.DeploymentStrategyTypeRecreate,
+						Post: &api.LifecycleHook{
+							FailurePolicy: api.LifecycleHookFailurePolicyAbort,
+							ExecNewPod:    &api.ExecNewPodHook{Command: []string{"notify"}, ContainerName: "container1"},
+						},
+					},
+					ControllerTemplate: test.OkControllerTemplate(),
+				},
+			},
+			errors.ValidationErrorTypeInvalid,
+			"template.strategy.post.failurePolicy",
+		},
 	}
 
 	for k, v := range errorCases {
@@ -101,7 +101,7 @@ func (c *DeploymentConfigChangeController) generateDeployment(config *deployapi.
 	causes := []*deployapi.DeploymentCause{}
 	causes = append(causes,
 		&deployapi.DeploymentCause{
-			Type: deployapi.DeploymentTriggerOnConfigChange,
+			Type: deployapi.DeploymentCauseTypeConfigChange,
 		})
 	newConfig.Details = &deployapi.DeploymentDetails{
 		Causes: causes,
@@ -80,7 +80,7 @@ func TestHandle_newConfigTriggers(t *testing.T) {
 		t.Fatalf("expected config change details to be set")
 	} else if updated.Details.Causes == nil {
 		t.Fatalf("expected config change causes to be set")
-	} else if updated.Details.Causes[0].Type != deployapi.DeploymentTriggerOnConfigChange {
+	} else if updated.Details.Causes[0].Type != deployapi.DeploymentCauseTypeConfigChange {
 		t.Fatalf("expected config change cause to be set to config change trigger, got %s", updated.Details.Causes[0].Type)
 	}
 }
@@ -131,7 +131,7 @@ func TestHandle_changeWithTemplateDiff(t *testing.T) {
 		t.Fatalf("expected config change details to be set")
 	} else if updated.Details.Causes == nil {
 		t.Fatalf("expected config change causes to be set")
-	} else if updated.Details.Causes[0].Type != deployapi.DeploymentTriggerOnConfigChange {
+	} else if updated.Details.Causes[0].Type != deployapi.DeploymentCauseTypeConfigChange {
 		t.Fatalf("expected config change cause to be set to config change trigger, got %s", updated.Details.Causes[0].Type)
 	}
 }
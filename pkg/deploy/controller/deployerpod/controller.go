@@ -8,15 +8,24 @@ import (
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
 )
 
 // DeployerPodController keeps a deployment's status in sync with the deployer pod
 // handling the deployment.
 //
+// When a deployment becomes Failed, the last complete deployment of its config is
+// reinstated if the config asks for automatic rollbacks.
+//
+// The status of a deployment which is cancelled is left to the DeploymentController,
+// which deletes the deployer pod and marks the deployment cancelled.
+//
 // Use the DeployerPodControllerFactory to create this controller.
 type DeployerPodController struct {
 	// deploymentClient provides access to deployments.
 	deploymentClient deploymentClient
+	// rollbacker reinstates the last complete deployment of failed deployments, if set.
+	rollbacker rollbacker
 }
 
 // Handle syncs pod's status with any associated deployment.
@@ -36,6 +45,14 @@ func (c *DeployerPodController) Handle(pod *kapi.Pod) error {
 	currentStatus := statusFor(deployment)
 	nextStatus := currentStatus
 
+	if deployutil.IsDeploymentCancelled(deployment) {
+		switch currentStatus {
+		case deployapi.DeploymentStatusNew, deployapi.DeploymentStatusPending, deployapi.DeploymentStatusRunning:
+			glog.V(4).Infof("Ignoring pod %s of cancelled deployment %s", pod.Name, labelForDeployment(deployment))
+			return nil
+		}
+	}
+
 	switch pod.Status.Phase {
 	case kapi.PodRunning:
 		nextStatus = deployapi.DeploymentStatusRunning
@@ -55,6 +72,12 @@ func (c *DeployerPodController) Handle(pod *kapi.Pod) error {
 			return fmt.Errorf("couldn't update deployment %s to status %s: %v", labelForDeployment(deployment), nextStatus, err)
 		}
 		glog.V(2).Infof("Updated deployment %s status from %s to %s", labelForDeployment(deployment), currentStatus, nextStatus)
+
+		if nextStatus == deployapi.DeploymentStatusFailed && c.rollbacker != nil {
+			if err := c.rollbacker.Rollback(deployment); err != nil {
+				return fmt.Errorf("couldn't roll back failed deployment %s: %v", labelForDeployment(deployment), err)
+			}
+		}
 	}
 
 	return nil
@@ -70,6 +93,11 @@ func statusFor(deployment *kapi.ReplicationController) deployapi.DeploymentStatu
 	return deployapi.DeploymentStatus(deployment.Annotations[deployapi.DeploymentStatusAnnotation])
 }
 
+// rollbacker reinstates the last complete deployment of the config of a failed deployment.
+type rollbacker interface {
+	Rollback(failed *kapi.ReplicationController) error
+}
+
 // deploymentClient abstracts access to deployments.
 type deploymentClient interface {
 	getDeployment(namespace, name string) (*kapi.ReplicationController, error)
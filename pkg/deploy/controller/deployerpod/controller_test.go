@@ -1,6 +1,7 @@
 package deployerpod
 
 import (
+	"reflect"
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
@@ -176,6 +177,85 @@ func okPod() *kapi.Pod {
 	}
 }
 
+// TestHandle_podTerminatedFailRollback ensures that a deployment marked failed
+// is rolled back, once its status is updated.
+func TestHandle_podTerminatedFailRollback(t *testing.T) {
+	var events []string
+
+	controller := &DeployerPodController{
+		deploymentClient: &deploymentClientImpl{
+			getDeploymentFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+				config := deploytest.OkDeploymentConfig(1)
+				deployment, _ := deployutil.MakeDeployment(config, kapi.Codec)
+				deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusRunning)
+				return deployment, nil
+			},
+			updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				events = append(events, "update "+string(statusFor(deployment)))
+				return deployment, nil
+			},
+		},
+		rollbacker: &testRollbacker{func(failed *kapi.ReplicationController) error {
+			events = append(events, "rollback "+failed.Name)
+			return nil
+		}},
+	}
+
+	if err := controller.Handle(failedPod()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{"update Failed", "rollback config-1"}, events; !reflect.DeepEqual(e, a) {
+		t.Fatalf("expected %v, got %v", e, a)
+	}
+
+	// a completed deployment is not rolled back
+	events = nil
+	if err := controller.Handle(succeededPod()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{"update Complete"}, events; !reflect.DeepEqual(e, a) {
+		t.Fatalf("expected %v, got %v", e, a)
+	}
+}
+
+// TestHandle_cancelledDeployment ensures that the status of a cancelled
+// deployment is left to the deployment controller, whatever the phase of the pod.
+func TestHandle_cancelledDeployment(t *testing.T) {
+	for _, pod := range []*kapi.Pod{runningPod(), failedPod()} {
+		cancelled, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(2), kapi.Codec)
+		cancelled.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusRunning)
+		cancelled.Annotations[deployapi.DeploymentCancelledAnnotation] = deployapi.DeploymentCancelledAnnotationValue
+
+		controller := &DeployerPodController{
+			deploymentClient: &deploymentClientImpl{
+				getDeploymentFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+					return cancelled, nil
+				},
+				updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+					t.Fatalf("%s pod: unexpected update of deployment %s", pod.Status.Phase, deployment.Name)
+					return nil, nil
+				},
+			},
+			rollbacker: &testRollbacker{func(failed *kapi.ReplicationController) error {
+				t.Fatalf("unexpected rollback of %s", failed.Name)
+				return nil
+			}},
+		}
+
+		if err := controller.Handle(pod); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+type testRollbacker struct {
+	rollbackFunc func(failed *kapi.ReplicationController) error
+}
+
+func (r *testRollbacker) Rollback(failed *kapi.ReplicationController) error {
+	return r.rollbackFunc(failed)
+}
+
 func succeededPod() *kapi.Pod {
 	p := okPod()
 	p.Status.Phase = kapi.PodSucceeded
@@ -13,8 +13,10 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 
+	osclient "github.com/openshift/origin/pkg/client"
 	controller "github.com/openshift/origin/pkg/controller"
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployrollback "github.com/openshift/origin/pkg/deploy/rollback"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
 )
 
@@ -22,8 +24,13 @@ import (
 // pods from a queue populated from a watch of all pods filtered by a cache of
 // deployments associated with pods.
 type DeployerPodControllerFactory struct {
+	// Client is an OpenShift client, used to roll back failed deployments. Failed deployments
+	// are not rolled back if it is nil.
+	Client osclient.Interface
 	// KubeClient is a Kubernetes client.
 	KubeClient kclient.Interface
+	// Codec is used to decode DeploymentConfigs of deployments.
+	Codec runtime.Codec
 }
 
 // Create creates a DeployerPodController.
@@ -67,6 +74,10 @@ func (factory *DeployerPodControllerFactory) Create() controller.RunnableControl
 		},
 	}
 
+	if factory.Client != nil {
+		podController.rollbacker = deployrollback.NewAutomaticRollbacker(factory.Client, factory.KubeClient, factory.Codec)
+	}
+
 	return &controller.RetryController{
 		Queue: podQueue,
 		RetryManager: controller.NewQueueRetryManager(
@@ -2,11 +2,13 @@ package deployment
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/golang/glog"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
@@ -20,7 +22,15 @@ import (
 // When the deployment enters a terminal status:
 //
 //   1. If the deployment finished normally, the deployer pod is deleted.
-//   2. If the deployment failed, the deployer pod is not deleted.
+//   2. If the deployment failed, the deployer pod is not deleted. When a retry
+//      of the deployment is requested, its deployer and hook pods are deleted
+//      and the deployment becomes New again.
+//
+// A new deployment which is cancelled before its deployer pod is created is
+// marked cancelled without creating a deployer pod. When a deployment in
+// progress is cancelled, its deployer pod is deleted, the last complete
+// deployment prior to it is scaled back up, the cancelled deployment is scaled
+// down and its status becomes Cancelled.
 //
 // Use the DeploymentControllerFactory to create this controller.
 type DeploymentController struct {
@@ -47,27 +57,38 @@ func (c *DeploymentController) Handle(deployment *kapi.ReplicationController) er
 
 	switch currentStatus {
 	case deployapi.DeploymentStatusNew:
+		if deployutil.IsDeploymentCancelled(deployment) {
+			nextStatus = deployapi.DeploymentStatusCancelled
+			break
+		}
+
 		podTemplate, err := c.makeDeployerPod(deployment)
 		if err != nil {
 			return fatalError(fmt.Sprintf("couldn't make deployer pod for %s: %v", labelForDeployment(deployment), err))
 		}
 
+		// Deployer pods have generated names, so a pod which already exists is never a deployer
+		// pod of this deployment, and is not adopted; the creation is retried instead.
 		deploymentPod, err := c.podClient.createPod(deployment.Namespace, podTemplate)
 		if err != nil {
-			// If the pod already exists, it's possible that a previous CreatePod succeeded but
-			// the deployment state update failed and now we're re-entering.
-			if !kerrors.IsAlreadyExists(err) {
-				return fmt.Errorf("couldn't create deployer pod for %s: %v", labelForDeployment(deployment), err)
-			}
-		} else {
-			glog.V(2).Infof("Created pod %s for deployment %s", deploymentPod.Name, labelForDeployment(deployment))
+			return fmt.Errorf("couldn't create deployer pod for %s: %v", labelForDeployment(deployment), err)
 		}
+		glog.V(2).Infof("Created pod %s for deployment %s", deploymentPod.Name, labelForDeployment(deployment))
 
 		deployment.Annotations[deployapi.DeploymentPodAnnotation] = deploymentPod.Name
 		nextStatus = deployapi.DeploymentStatusPending
 	case deployapi.DeploymentStatusPending,
-		deployapi.DeploymentStatusRunning,
-		deployapi.DeploymentStatusFailed:
+		deployapi.DeploymentStatusRunning:
+		if deployutil.IsDeploymentCancelled(deployment) {
+			return c.cancel(deployment)
+		}
+		glog.V(4).Infof("Ignoring deployment %s (status %s)", labelForDeployment(deployment), currentStatus)
+	case deployapi.DeploymentStatusFailed:
+		if deployutil.IsDeploymentRetried(deployment) {
+			return c.retry(deployment)
+		}
+		glog.V(4).Infof("Ignoring deployment %s (status %s)", labelForDeployment(deployment), currentStatus)
+	case deployapi.DeploymentStatusCancelled:
 		glog.V(4).Infof("Ignoring deployment %s (status %s)", labelForDeployment(deployment), currentStatus)
 	case deployapi.DeploymentStatusComplete:
 		// Automatically clean up successful pods
@@ -95,6 +116,120 @@ func (c *DeploymentController) Handle(deployment *kapi.ReplicationController) er
 	return nil
 }
 
+// cancel stops the deployer pod of deployment, restores the deployment prior to it and marks
+// it cancelled. The deployer pod is deleted first, so that its strategy cannot scale the prior
+// deployment down again once restored. The status is updated last, so that the cancellation is
+// retried until every step succeeded; the deleted pod is then not found, which is ignored.
+func (c *DeploymentController) cancel(deployment *kapi.ReplicationController) error {
+	if podName := deployment.Annotations[deployapi.DeploymentPodAnnotation]; len(podName) > 0 {
+		if err := c.podClient.deletePod(deployment.Namespace, podName); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("couldn't delete deployer pod %s/%s of cancelled deployment %s: %v", deployment.Namespace, podName, labelForDeployment(deployment), err)
+		}
+		glog.V(4).Infof("Deleted deployer pod %s/%s of cancelled deployment %s", deployment.Namespace, podName, labelForDeployment(deployment))
+	}
+
+	if err := c.restorePriorDeployment(deployment); err != nil {
+		return fmt.Errorf("couldn't restore the deployment prior to cancelled deployment %s: %v", labelForDeployment(deployment), err)
+	}
+
+	currentStatus := statusFor(deployment)
+	deployment.Spec.Replicas = 0
+	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusCancelled)
+	if _, err := c.deploymentClient.updateDeployment(deployment.Namespace, deployment); err != nil {
+		return fmt.Errorf("couldn't update deployment %s to status %s: %v", labelForDeployment(deployment), deployapi.DeploymentStatusCancelled, err)
+	}
+	glog.V(2).Infof("Updated deployment %s status from %s to %s", labelForDeployment(deployment), currentStatus, deployapi.DeploymentStatusCancelled)
+	return nil
+}
+
+// retry deletes the deployer pod and the hook pods of the failed deployment and makes it new
+// again, so that a new deployer pod runs it. The annotations of the failed attempt are removed
+// along with the retry request. The status is updated last, so that the retry is repeated until
+// every step succeeded; the deleted pods are then not found, which is ignored.
+func (c *DeploymentController) retry(deployment *kapi.ReplicationController) error {
+	for _, annotation := range []string{
+		deployapi.DeploymentPodAnnotation,
+		deployapi.PreHookPodAnnotation,
+		deployapi.PostHookPodAnnotation,
+	} {
+		podName := deployment.Annotations[annotation]
+		if len(podName) == 0 {
+			continue
+		}
+		if err := c.podClient.deletePod(deployment.Namespace, podName); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("couldn't delete pod %s/%s of retried deployment %s: %v", deployment.Namespace, podName, labelForDeployment(deployment), err)
+		}
+		glog.V(4).Infof("Deleted pod %s/%s of retried deployment %s", deployment.Namespace, podName, labelForDeployment(deployment))
+	}
+
+	for _, annotation := range []string{
+		deployapi.DeploymentPodAnnotation,
+		deployapi.DeploymentStatusReasonAnnotation,
+		deployapi.DeploymentCancelledAnnotation,
+		deployapi.DeploymentRetryAnnotation,
+		deployapi.PreHookPodAnnotation,
+		deployapi.PreHookPodPhaseAnnotation,
+		deployapi.PostHookPodAnnotation,
+		deployapi.PostHookPodPhaseAnnotation,
+	} {
+		delete(deployment.Annotations, annotation)
+	}
+	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusNew)
+	if _, err := c.deploymentClient.updateDeployment(deployment.Namespace, deployment); err != nil {
+		return fmt.Errorf("couldn't update deployment %s to status %s: %v", labelForDeployment(deployment), deployapi.DeploymentStatusNew, err)
+	}
+	glog.V(2).Infof("Updated deployment %s status from %s to %s", labelForDeployment(deployment), deployapi.DeploymentStatusFailed, deployapi.DeploymentStatusNew)
+	return nil
+}
+
+// restorePriorDeployment scales the last complete deployment of the config of deployment, with
+// a version below the version of deployment, back to the replica count of its config. Nothing is
+// done if there is no such deployment.
+func (c *DeploymentController) restorePriorDeployment(deployment *kapi.ReplicationController) error {
+	configName := deployment.Annotations[deployapi.DeploymentConfigAnnotation]
+	version, err := strconv.Atoi(deployment.Annotations[deployapi.DeploymentVersionAnnotation])
+	if err != nil {
+		return fmt.Errorf("couldn't determine the version of deployment %s: %v", labelForDeployment(deployment), err)
+	}
+
+	deployments, err := c.deploymentClient.listDeployments(deployment.Namespace, labels.Everything())
+	if err != nil {
+		return err
+	}
+	var prior *kapi.ReplicationController
+	priorVersion := 0
+	for i := range deployments.Items {
+		candidate := &deployments.Items[i]
+		if candidate.Annotations[deployapi.DeploymentConfigAnnotation] != configName || statusFor(candidate) != deployapi.DeploymentStatusComplete {
+			continue
+		}
+		v, err := strconv.Atoi(candidate.Annotations[deployapi.DeploymentVersionAnnotation])
+		if err != nil || v >= version || v <= priorVersion {
+			continue
+		}
+		prior, priorVersion = candidate, v
+	}
+	if prior == nil {
+		glog.V(4).Infof("No complete deployment prior to cancelled deployment %s to restore", labelForDeployment(deployment))
+		return nil
+	}
+
+	config, err := c.decodeConfig(prior)
+	if err != nil {
+		return err
+	}
+	replicas := config.Template.ControllerTemplate.Replicas
+	if prior.Spec.Replicas == replicas {
+		return nil
+	}
+	prior.Spec.Replicas = replicas
+	if _, err := c.deploymentClient.updateDeployment(prior.Namespace, prior); err != nil {
+		return err
+	}
+	glog.V(2).Infof("Restored deployment %s to %d replicas", labelForDeployment(prior), replicas)
+	return nil
+}
+
 // makeDeployerPod creates a pod which implements deployment behavior. The pod is correlated to
 // the deployment with an annotation.
 func (c *DeploymentController) makeDeployerPod(deployment *kapi.ReplicationController) (*kapi.Pod, error) {
@@ -155,6 +290,7 @@ func statusFor(deployment *kapi.ReplicationController) deployapi.DeploymentStatu
 type deploymentClient interface {
 	getDeployment(namespace, name string) (*kapi.ReplicationController, error)
 	updateDeployment(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error)
+	listDeployments(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error)
 }
 
 // podClient abstracts access to pods.
@@ -167,6 +303,7 @@ type podClient interface {
 type deploymentClientImpl struct {
 	getDeploymentFunc    func(namespace, name string) (*kapi.ReplicationController, error)
 	updateDeploymentFunc func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error)
+	listDeploymentsFunc  func(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error)
 }
 
 func (i *deploymentClientImpl) getDeployment(namespace, name string) (*kapi.ReplicationController, error) {
@@ -177,6 +314,10 @@ func (i *deploymentClientImpl) updateDeployment(namespace string, deployment *ka
 	return i.updateDeploymentFunc(namespace, deployment)
 }
 
+func (i *deploymentClientImpl) listDeployments(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
+	return i.listDeploymentsFunc(namespace, selector)
+}
+
 // podClientImpl is a pluggable podClient.
 type podClientImpl struct {
 	createPodFunc func(namespace string, pod *kapi.Pod) (*kapi.Pod, error)
@@ -2,10 +2,12 @@ package deployment
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 
 	api "github.com/openshift/origin/pkg/api/latest"
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
@@ -179,9 +181,8 @@ func TestHandle_createPodFail(t *testing.T) {
 	}
 }
 
-// TestHandle_createPodAlreadyExists ensures that attempts to create a
-// deployer pod which  was already created don't result in an error
-// (effectively skipping the handling as redundant).
+// TestHandle_createPodAlreadyExists ensures that a deployer pod whose name
+// is already taken is not adopted, and that its creation is retried.
 func TestHandle_createPodAlreadyExists(t *testing.T) {
 	controller := &DeploymentController{
 		decodeConfig: func(deployment *kapi.ReplicationController) (*deployapi.DeploymentConfig, error) {
@@ -203,20 +204,22 @@ func TestHandle_createPodAlreadyExists(t *testing.T) {
 		},
 	}
 
-	// Verify no-op
 	config := deploytest.OkDeploymentConfig(1)
 	deployment, _ := deployutil.MakeDeployment(config, kapi.Codec)
-	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusPending)
+	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusNew)
 	err := controller.Handle(deployment)
 
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if _, isFatal := err.(fatalError); isFatal {
+		t.Fatalf("expected a retryable error, got %v", err)
 	}
 }
 
-// TestHandle_noop ensures that pending, running, and failed states result in
-// no action by the controller (as these represent in-progress or terminal
-// states).
+// TestHandle_noop ensures that pending, running, failed and cancelled states
+// result in no action by the controller (as these represent in-progress or
+// terminal states).
 func TestHandle_noop(t *testing.T) {
 	controller := &DeploymentController{
 		decodeConfig: func(deployment *kapi.ReplicationController) (*deployapi.DeploymentConfig, error) {
@@ -248,6 +251,7 @@ func TestHandle_noop(t *testing.T) {
 		deployapi.DeploymentStatusPending,
 		deployapi.DeploymentStatusRunning,
 		deployapi.DeploymentStatusFailed,
+		deployapi.DeploymentStatusCancelled,
 	}
 	for _, status := range noopStatus {
 		deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(status)
@@ -259,6 +263,175 @@ func TestHandle_noop(t *testing.T) {
 	}
 }
 
+// TestHandle_cancelledNew ensures that a new deployment which is cancelled is
+// marked cancelled without creating a deployer pod.
+func TestHandle_cancelledNew(t *testing.T) {
+	var updatedDeployment *kapi.ReplicationController
+	controller := &DeploymentController{
+		deploymentClient: &deploymentClientImpl{
+			updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				updatedDeployment = deployment
+				return deployment, nil
+			},
+		},
+		podClient: &podClientImpl{
+			createPodFunc: func(namespace string, pod *kapi.Pod) (*kapi.Pod, error) {
+				t.Fatalf("unexpected call to create pod")
+				return nil, nil
+			},
+		},
+	}
+
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusNew)
+	deployment.Annotations[deployapi.DeploymentCancelledAnnotation] = deployapi.DeploymentCancelledAnnotationValue
+	if err := controller.Handle(deployment); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updatedDeployment == nil {
+		t.Fatalf("expected deployment update")
+	}
+	if e, a := deployapi.DeploymentStatusCancelled, statusFor(updatedDeployment); e != a {
+		t.Fatalf("expected updated deployment status %s, got %s", e, a)
+	}
+}
+
+// TestHandle_cancelledDeployment ensures that the deployer pod of a cancelled
+// deployment in progress is deleted before the prior deployment is restored,
+// and that the deployment is marked cancelled last, even when the pod is gone.
+func TestHandle_cancelledDeployment(t *testing.T) {
+	for _, deleteErr := range []error{nil, kerrors.NewNotFound("pod", "deploy-pod")} {
+		var events []string
+
+		prior, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+		prior.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusComplete)
+		prior.Spec.Replicas = 0
+		cancelled, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(2), kapi.Codec)
+		cancelled.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusRunning)
+		cancelled.Annotations[deployapi.DeploymentCancelledAnnotation] = deployapi.DeploymentCancelledAnnotationValue
+		cancelled.Annotations[deployapi.DeploymentPodAnnotation] = "deploy-pod"
+		cancelled.Spec.Replicas = 1
+
+		controller := &DeploymentController{
+			deploymentClient: &deploymentClientImpl{
+				updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+					events = append(events, fmt.Sprintf("update %s %s %d", deployment.Name, statusFor(deployment), deployment.Spec.Replicas))
+					return deployment, nil
+				},
+				listDeploymentsFunc: func(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
+					return &kapi.ReplicationControllerList{Items: []kapi.ReplicationController{*prior, *cancelled}}, nil
+				},
+			},
+			podClient: &podClientImpl{
+				deletePodFunc: func(namespace, name string) error {
+					events = append(events, "delete "+name)
+					return deleteErr
+				},
+			},
+			decodeConfig: func(deployment *kapi.ReplicationController) (*deployapi.DeploymentConfig, error) {
+				return deployutil.DecodeDeploymentConfig(deployment, kapi.Codec)
+			},
+		}
+
+		if err := controller.Handle(cancelled); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{
+			"delete deploy-pod",
+			"update config-1 Complete 1",
+			"update config-2 Cancelled 0",
+		}
+		if !reflect.DeepEqual(expected, events) {
+			t.Errorf("expected %v, got %v", expected, events)
+		}
+	}
+}
+
+// TestHandle_cancelledDeploymentRetried ensures that a cancelled deployment is
+// not marked cancelled until the prior deployment was restored.
+func TestHandle_cancelledDeploymentRetried(t *testing.T) {
+	cancelled, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(2), kapi.Codec)
+	cancelled.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusPending)
+	cancelled.Annotations[deployapi.DeploymentCancelledAnnotation] = deployapi.DeploymentCancelledAnnotationValue
+	cancelled.Annotations[deployapi.DeploymentPodAnnotation] = "deploy-pod"
+
+	controller := &DeploymentController{
+		deploymentClient: &deploymentClientImpl{
+			updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				t.Fatalf("unexpected update of deployment %s", deployment.Name)
+				return nil, nil
+			},
+			listDeploymentsFunc: func(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
+				return nil, fmt.Errorf("unavailable")
+			},
+		},
+		podClient: &podClientImpl{
+			deletePodFunc: func(namespace, name string) error {
+				return nil
+			},
+		},
+	}
+
+	if err := controller.Handle(cancelled); err == nil {
+		t.Fatalf("expected the cancellation to be retried")
+	}
+}
+
+// TestHandle_failedRetried ensures that a failed deployment whose retry is
+// requested has its pods deleted and becomes new again.
+func TestHandle_failedRetried(t *testing.T) {
+	var updatedDeployment *kapi.ReplicationController
+	deletedPods := []string{}
+	controller := &DeploymentController{
+		deploymentClient: &deploymentClientImpl{
+			updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				updatedDeployment = deployment
+				return deployment, nil
+			},
+		},
+		podClient: &podClientImpl{
+			deletePodFunc: func(namespace, name string) error {
+				deletedPods = append(deletedPods, name)
+				if name == "prehook" {
+					return kerrors.NewNotFound("Pod", name)
+				}
+				return nil
+			},
+		},
+	}
+
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusFailed)
+	deployment.Annotations[deployapi.DeploymentRetryAnnotation] = deployapi.DeploymentRetryAnnotationValue
+	deployment.Annotations[deployapi.DeploymentPodAnnotation] = "deployer"
+	deployment.Annotations[deployapi.DeploymentStatusReasonAnnotation] = "failed"
+	deployment.Annotations[deployapi.PreHookPodAnnotation] = "prehook"
+	deployment.Annotations[deployapi.PreHookPodPhaseAnnotation] = string(kapi.PodFailed)
+	if err := controller.Handle(deployment); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{"deployer", "prehook"}, deletedPods; !reflect.DeepEqual(e, a) {
+		t.Fatalf("expected deleted pods %v, got %v", e, a)
+	}
+	if updatedDeployment == nil {
+		t.Fatalf("expected deployment update")
+	}
+	if e, a := deployapi.DeploymentStatusNew, statusFor(updatedDeployment); e != a {
+		t.Fatalf("expected updated deployment status %s, got %s", e, a)
+	}
+	for _, annotation := range []string{
+		deployapi.DeploymentRetryAnnotation,
+		deployapi.DeploymentPodAnnotation,
+		deployapi.DeploymentStatusReasonAnnotation,
+		deployapi.PreHookPodAnnotation,
+		deployapi.PreHookPodPhaseAnnotation,
+	} {
+		if value, ok := updatedDeployment.Annotations[annotation]; ok {
+			t.Errorf("expected annotation %s to be removed, got %q", annotation, value)
+		}
+	}
+}
+
 // TestHandle_cleanupPodOk ensures that deployer pods are cleaned up for
 // deployments in a completed state.
 func TestHandle_cleanupPodOk(t *testing.T) {
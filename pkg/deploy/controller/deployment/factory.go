@@ -13,6 +13,7 @@ import (
 	kutil "github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 
+	osclient "github.com/openshift/origin/pkg/client"
 	controller "github.com/openshift/origin/pkg/controller"
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
@@ -21,6 +22,9 @@ import (
 // DeploymentControllerFactory can create a DeploymentController that creates
 // deployer pods in a configurable way.
 type DeploymentControllerFactory struct {
+	// Client is an OpenShift client, used to roll back failed deployments. Failed deployments
+	// are not rolled back if it is nil.
+	Client osclient.Interface
 	// KubeClient is a Kubernetes client.
 	KubeClient kclient.Interface
 	// Codec is used for encoding/decoding.
@@ -52,6 +56,9 @@ func (factory *DeploymentControllerFactory) Create() controller.RunnableControll
 			updateDeploymentFunc: func(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
 				return factory.KubeClient.ReplicationControllers(namespace).Update(deployment)
 			},
+			listDeploymentsFunc: func(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
+				return factory.KubeClient.ReplicationControllers(namespace).List(selector)
+			},
 		},
 		podClient: &podClientImpl{
 			createPodFunc: func(namespace string, pod *kapi.Pod) (*kapi.Pod, error) {
@@ -94,9 +101,10 @@ func (factory *DeploymentControllerFactory) Create() controller.RunnableControll
 
 // makeContainer creates containers in the following way:
 //
-//   1. For the Recreate strategy, use the factory's RecreateStrategyImage as
-//      the container image, and the factory's Environment as the container
-//      environment.
+//   1. For the Recreate and Rolling strategies, use the factory's
+//      RecreateStrategyImage as the container image, and the factory's
+//      Environment as the container environment. The deployer in the image
+//      selects the strategy from the deployment.
 //   2. For all Custom strategy, use the strategy's image for the container
 //      image, and use the combination of the factory's Environment and the
 //      strategy's environment as the container environment.
@@ -111,7 +119,7 @@ func (factory *DeploymentControllerFactory) makeContainer(strategy *deployapi.De
 
 	// Every strategy type should be handled here.
 	switch strategy.Type {
-	case deployapi.DeploymentStrategyTypeRecreate:
+	case deployapi.DeploymentStrategyTypeRecreate, deployapi.DeploymentStrategyTypeRolling:
 		// Use the factory-configured image.
 		return &kapi.Container{
 			Image: factory.RecreateStrategyImage,
@@ -155,7 +155,7 @@ func (c *ImageChangeController) regenerate(imageRepo *imageapi.ImageRepository,
 
 		causes = append(causes,
 			&deployapi.DeploymentCause{
-				Type: deployapi.DeploymentTriggerOnImageChange,
+				Type: deployapi.DeploymentCauseTypeImageChange,
 				ImageTrigger: &deployapi.DeploymentCauseImageTrigger{
 					RepositoryName: repoName,
 					Tag:            trigger.Tag,
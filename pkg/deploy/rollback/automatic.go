@@ -0,0 +1,188 @@
+package rollback
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/glog"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+
+	osclient "github.com/openshift/origin/pkg/client"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+// AutomaticRollbacker reinstates the last complete deployment of a DeploymentConfig whose latest
+// deployment failed, if the RollbackPolicy of the config asks for it. The image change triggers
+// of the config are disabled, as they would otherwise deploy the failed image again.
+type AutomaticRollbacker struct {
+	// generator generates the rollback of a config.
+	generator generator
+	// client is used to get and update configs and to list deployments.
+	client automaticRollbackClient
+	// codec is used to decode the DeploymentConfigs of deployments.
+	codec runtime.Codec
+	// recorder records events about rollbacks on the config.
+	recorder record.EventRecorder
+}
+
+// NewAutomaticRollbacker makes an AutomaticRollbacker using the provided clients.
+func NewAutomaticRollbacker(client osclient.Interface, kClient kclient.Interface, codec runtime.Codec) *AutomaticRollbacker {
+	return &AutomaticRollbacker{
+		generator: &RollbackGenerator{},
+		client:    &realAutomaticRollbackClient{client, kClient},
+		codec:     codec,
+		recorder:  record.FromSource(kapi.EventSource{Component: "deployment-rollback"}),
+	}
+}
+
+// Rollback reinstates the last complete deployment of the config of failed, which is a failed
+// deployment. Nothing is done unless the config opts in to automatic rollbacks and failed is
+// still its latest deployment, so that observing the same failure several times rolls back once.
+// A failed deployment made by an automatic rollback is not rolled back again.
+func (r *AutomaticRollbacker) Rollback(failed *kapi.ReplicationController) error {
+	failedConfig, err := deployutil.DecodeDeploymentConfig(failed, r.codec)
+	if err != nil {
+		return fmt.Errorf("couldn't decode the config of deployment %s/%s: %v", failed.Namespace, failed.Name, err)
+	}
+
+	config, err := r.client.getDeploymentConfig(failed.Namespace, failedConfig.Name)
+	if err != nil {
+		return fmt.Errorf("couldn't get config %s/%s: %v", failed.Namespace, failedConfig.Name, err)
+	}
+	if config.RollbackPolicy == nil || !config.RollbackPolicy.Automatic {
+		return nil
+	}
+	if config.LatestVersion != failedConfig.LatestVersion {
+		glog.V(4).Infof("Ignoring failed deployment %s/%s; it is not the latest deployment of config %s", failed.Namespace, failed.Name, config.Name)
+		return nil
+	}
+	// Rolling back a failed rollback would reinstate an older deployment after each failure.
+	if isRollback(failedConfig) {
+		r.recorder.Eventf(config, "rollbackSkipped", "Deployment %s failed while rolling back; no further rollback is attempted", failed.Name)
+		return nil
+	}
+
+	target, err := r.lastCompleteDeployment(config, failedConfig.LatestVersion)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		r.recorder.Eventf(config, "rollbackSkipped", "Deployment %s failed and no complete deployment could be reinstated", failed.Name)
+		return nil
+	}
+	targetConfig, err := deployutil.DecodeDeploymentConfig(target, r.codec)
+	if err != nil {
+		return fmt.Errorf("couldn't decode the config of deployment %s/%s: %v", target.Namespace, target.Name, err)
+	}
+
+	rollback, err := r.generator.GenerateRollback(config, targetConfig, &deployapi.DeploymentConfigRollbackSpec{
+		From:                   kapi.ObjectReference{Namespace: target.Namespace, Name: target.Name},
+		IncludeTemplate:        true,
+		IncludeReplicationMeta: true,
+		IncludeStrategy:        true,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't generate the rollback of config %s/%s: %v", config.Namespace, config.Name, err)
+	}
+	disableImageChangeTriggers(rollback)
+	rollback.Details = &deployapi.DeploymentDetails{
+		Message: fmt.Sprintf("automatic rollback of failed deployment %s to %s", failed.Name, target.Name),
+		Causes: []*deployapi.DeploymentCause{
+			{
+				Type:     deployapi.DeploymentCauseTypeRollback,
+				Rollback: &deployapi.DeploymentCauseRollback{FailedDeployment: failed.Name, RestoredDeployment: target.Name},
+			},
+		},
+	}
+
+	// A conflict means the config changed since the failure was observed, and the failure will
+	// be observed again if the config is still at the failed version.
+	if _, err := r.client.updateDeploymentConfig(config.Namespace, rollback); err != nil {
+		return fmt.Errorf("couldn't roll back config %s/%s: %v", config.Namespace, config.Name, err)
+	}
+	glog.V(2).Infof("Rolled back config %s/%s from failed deployment %s to %s", config.Namespace, config.Name, failed.Name, target.Name)
+	r.recorder.Eventf(config, "rolledBack", "Deployment %s failed; reinstated deployment %s and disabled the image change triggers", failed.Name, target.Name)
+	return nil
+}
+
+// lastCompleteDeployment returns the complete deployment of config with the highest version
+// below version, or nil if there is none.
+func (r *AutomaticRollbacker) lastCompleteDeployment(config *deployapi.DeploymentConfig, version int) (*kapi.ReplicationController, error) {
+	deployments, err := r.client.listDeployments(config.Namespace, labels.SelectorFromSet(labels.Set{deployapi.DeploymentConfigLabel: config.Name}))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list the deployments of config %s/%s: %v", config.Namespace, config.Name, err)
+	}
+
+	var last *kapi.ReplicationController
+	lastVersion := 0
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if deployment.Annotations[deployapi.DeploymentConfigAnnotation] != config.Name {
+			continue
+		}
+		if deployapi.DeploymentStatus(deployment.Annotations[deployapi.DeploymentStatusAnnotation]) != deployapi.DeploymentStatusComplete {
+			continue
+		}
+		v, err := strconv.Atoi(deployment.Annotations[deployapi.DeploymentVersionAnnotation])
+		if err != nil || v >= version || v <= lastVersion {
+			continue
+		}
+		last, lastVersion = deployment, v
+	}
+	return last, nil
+}
+
+// isRollback returns true if config was deployed by an automatic rollback.
+func isRollback(config *deployapi.DeploymentConfig) bool {
+	if config.Details == nil {
+		return false
+	}
+	for _, cause := range config.Details.Causes {
+		if cause.Type == deployapi.DeploymentCauseTypeRollback {
+			return true
+		}
+	}
+	return false
+}
+
+// disableImageChangeTriggers makes the image change triggers of config manual.
+func disableImageChangeTriggers(config *deployapi.DeploymentConfig) {
+	for i := range config.Triggers {
+		if params := config.Triggers[i].ImageChangeParams; params != nil {
+			params.Automatic = false
+		}
+	}
+}
+
+type generator interface {
+	GenerateRollback(from, to *deployapi.DeploymentConfig, spec *deployapi.DeploymentConfigRollbackSpec) (*deployapi.DeploymentConfig, error)
+}
+
+type automaticRollbackClient interface {
+	getDeploymentConfig(namespace, name string) (*deployapi.DeploymentConfig, error)
+	updateDeploymentConfig(namespace string, config *deployapi.DeploymentConfig) (*deployapi.DeploymentConfig, error)
+	listDeployments(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error)
+}
+
+type realAutomaticRollbackClient struct {
+	client  osclient.Interface
+	kClient kclient.Interface
+}
+
+func (r *realAutomaticRollbackClient) getDeploymentConfig(namespace, name string) (*deployapi.DeploymentConfig, error) {
+	return r.client.DeploymentConfigs(namespace).Get(name)
+}
+
+func (r *realAutomaticRollbackClient) updateDeploymentConfig(namespace string, config *deployapi.DeploymentConfig) (*deployapi.DeploymentConfig, error) {
+	return r.client.DeploymentConfigs(namespace).Update(config)
+}
+
+func (r *realAutomaticRollbackClient) listDeployments(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
+	return r.kClient.ReplicationControllers(namespace).List(selector)
+}
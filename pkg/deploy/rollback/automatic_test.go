@@ -0,0 +1,182 @@
+package rollback
+
+import (
+	"fmt"
+	"testing"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+
+	api "github.com/openshift/origin/pkg/api/latest"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deploytest "github.com/openshift/origin/pkg/deploy/api/test"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+type testAutomaticRollbackClient struct {
+	config      *deployapi.DeploymentConfig
+	updated     *deployapi.DeploymentConfig
+	deployments []kapi.ReplicationController
+}
+
+func (c *testAutomaticRollbackClient) getDeploymentConfig(namespace, name string) (*deployapi.DeploymentConfig, error) {
+	return c.config, nil
+}
+
+func (c *testAutomaticRollbackClient) updateDeploymentConfig(namespace string, config *deployapi.DeploymentConfig) (*deployapi.DeploymentConfig, error) {
+	c.updated = config
+	return config, nil
+}
+
+func (c *testAutomaticRollbackClient) listDeployments(namespace string, selector labels.Selector) (*kapi.ReplicationControllerList, error) {
+	list := &kapi.ReplicationControllerList{}
+	for _, deployment := range c.deployments {
+		if selector.Matches(labels.Set(deployment.Labels)) {
+			list.Items = append(list.Items, deployment)
+		}
+	}
+	return list, nil
+}
+
+type testRecorder struct {
+	events []string
+}
+
+func (r *testRecorder) Event(object runtime.Object, reason, message string) {
+	r.events = append(r.events, reason)
+}
+
+func (r *testRecorder) Eventf(object runtime.Object, reason, messageFmt string, args ...interface{}) {
+	r.events = append(r.events, reason)
+}
+
+// testDeployment makes the deployment of version of config, with a distinct image, in status.
+func testDeployment(version int, status deployapi.DeploymentStatus) *kapi.ReplicationController {
+	config := deploytest.OkDeploymentConfig(version)
+	config.Template.ControllerTemplate.Template.Spec.Containers[0].Image = fmt.Sprintf("registry:8080/repo1:v%d", version)
+	deployment, _ := deployutil.MakeDeployment(config, api.Codec)
+	deployment.Annotations[deployapi.DeploymentStatusAnnotation] = string(status)
+	return deployment
+}
+
+func TestAutomaticRollback(t *testing.T) {
+	failed := testDeployment(3, deployapi.DeploymentStatusFailed)
+	config, _ := deployutil.DecodeDeploymentConfig(failed, api.Codec)
+	config.RollbackPolicy = &deployapi.DeploymentRollbackPolicy{Automatic: true}
+	client := &testAutomaticRollbackClient{
+		config: config,
+		deployments: []kapi.ReplicationController{
+			*testDeployment(1, deployapi.DeploymentStatusComplete),
+			*testDeployment(2, deployapi.DeploymentStatusComplete),
+			*failed,
+		},
+	}
+	recorder := &testRecorder{}
+	rollbacker := &AutomaticRollbacker{generator: &RollbackGenerator{}, client: client, codec: api.Codec, recorder: recorder}
+
+	if err := rollbacker.Rollback(failed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rollback := client.updated
+	if rollback == nil {
+		t.Fatalf("expected the config to be rolled back")
+	}
+	if e, a := 4, rollback.LatestVersion; e != a {
+		t.Errorf("expected version %d, got %d", e, a)
+	}
+	if e, a := "registry:8080/repo1:v2", rollback.Template.ControllerTemplate.Template.Spec.Containers[0].Image; e != a {
+		t.Errorf("expected the template of the last complete deployment with image %s, got %s", e, a)
+	}
+	if rollback.Triggers[0].ImageChangeParams.Automatic {
+		t.Errorf("expected the image change trigger to be disabled")
+	}
+	if rollback.Details == nil || len(rollback.Details.Causes) != 1 {
+		t.Fatalf("expected a rollback cause, got %#v", rollback.Details)
+	}
+	cause := rollback.Details.Causes[0]
+	if cause.Type != deployapi.DeploymentCauseTypeRollback || cause.Rollback == nil || cause.Rollback.FailedDeployment != failed.Name || cause.Rollback.RestoredDeployment != "config-2" {
+		t.Errorf("unexpected cause %#v", cause)
+	}
+	if len(recorder.events) != 1 || recorder.events[0] != "rolledBack" {
+		t.Errorf("expected a rollback event, got %v", recorder.events)
+	}
+}
+
+func TestAutomaticRollbackIgnored(t *testing.T) {
+	failed := testDeployment(2, deployapi.DeploymentStatusFailed)
+	complete := testDeployment(1, deployapi.DeploymentStatusComplete)
+
+	optedOut, _ := deployutil.DecodeDeploymentConfig(failed, api.Codec)
+	superseded, _ := deployutil.DecodeDeploymentConfig(failed, api.Codec)
+	superseded.RollbackPolicy = &deployapi.DeploymentRollbackPolicy{Automatic: true}
+	superseded.LatestVersion = 3
+
+	for name, config := range map[string]*deployapi.DeploymentConfig{"opted out": optedOut, "superseded": superseded} {
+		client := &testAutomaticRollbackClient{config: config, deployments: []kapi.ReplicationController{*complete, *failed}}
+		rollbacker := &AutomaticRollbacker{generator: &RollbackGenerator{}, client: client, codec: api.Codec, recorder: &testRecorder{}}
+		if err := rollbacker.Rollback(failed); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if client.updated != nil {
+			t.Errorf("%s: unexpected rollback %#v", name, client.updated)
+		}
+	}
+}
+
+func TestAutomaticRollbackOfRollbackIgnored(t *testing.T) {
+	config := deploytest.OkDeploymentConfig(3)
+	config.RollbackPolicy = &deployapi.DeploymentRollbackPolicy{Automatic: true}
+	config.Details = &deployapi.DeploymentDetails{
+		Causes: []*deployapi.DeploymentCause{
+			{
+				Type:     deployapi.DeploymentCauseTypeRollback,
+				Rollback: &deployapi.DeploymentCauseRollback{FailedDeployment: "config-2", RestoredDeployment: "config-1"},
+			},
+		},
+	}
+	failed, _ := deployutil.MakeDeployment(config, api.Codec)
+	failed.Annotations[deployapi.DeploymentStatusAnnotation] = string(deployapi.DeploymentStatusFailed)
+	client := &testAutomaticRollbackClient{
+		config: config,
+		deployments: []kapi.ReplicationController{
+			*testDeployment(1, deployapi.DeploymentStatusComplete),
+			*testDeployment(2, deployapi.DeploymentStatusFailed),
+			*failed,
+		},
+	}
+	recorder := &testRecorder{}
+	rollbacker := &AutomaticRollbacker{generator: &RollbackGenerator{}, client: client, codec: api.Codec, recorder: recorder}
+
+	if err := rollbacker.Rollback(failed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.updated != nil {
+		t.Errorf("unexpected rollback of a failed rollback %#v", client.updated)
+	}
+	if len(recorder.events) != 1 || recorder.events[0] != "rollbackSkipped" {
+		t.Errorf("expected an event about the skipped rollback, got %v", recorder.events)
+	}
+}
+
+func TestAutomaticRollbackWithoutCompleteDeployment(t *testing.T) {
+	failed := testDeployment(2, deployapi.DeploymentStatusFailed)
+	config, _ := deployutil.DecodeDeploymentConfig(failed, api.Codec)
+	config.RollbackPolicy = &deployapi.DeploymentRollbackPolicy{Automatic: true}
+	client := &testAutomaticRollbackClient{
+		config:      config,
+		deployments: []kapi.ReplicationController{*testDeployment(1, deployapi.DeploymentStatusFailed), *failed},
+	}
+	recorder := &testRecorder{}
+	rollbacker := &AutomaticRollbacker{generator: &RollbackGenerator{}, client: client, codec: api.Codec, recorder: recorder}
+
+	if err := rollbacker.Rollback(failed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.updated != nil {
+		t.Errorf("unexpected rollback %#v", client.updated)
+	}
+	if len(recorder.events) != 1 || recorder.events[0] != "rollbackSkipped" {
+		t.Errorf("expected an event about the skipped rollback, got %v", recorder.events)
+	}
+}
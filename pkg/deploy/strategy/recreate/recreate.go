@@ -12,19 +12,36 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 
 	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	"github.com/openshift/origin/pkg/deploy/strategy/support"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
 )
 
+// defaultTimeoutSeconds is the default time to wait for the pods of a new deployment to be ready.
+const defaultTimeoutSeconds int64 = 600
+
 // RecreateDeploymentStrategy is a simple strategy appropriate as a default. Its behavior is to increase the
 // replica count of the new deployment to 1, and to decrease the replica count of previous deployments
 // to zero.
 //
-// A failure to disable any existing deployments will be considered a deployment failure.
+// The new deployment is only considered active once its pods are running and ready. If they
+// are not ready within the timeout of the strategy, the new deployment is scaled back to zero,
+// the deployment fails and the previous deployments are left enabled. A failure to disable any existing deployments will be considered
+// a deployment failure.
+//
+// The Pre lifecycle hook of the strategy is executed before the new deployment is scaled up, and
+// the Post hook after the previous deployments are disabled.
+//
+// If the deployment is cancelled before the previous deployments are disabled, the new deployment
+// is scaled back to zero and the previous deployments are left untouched.
 type RecreateDeploymentStrategy struct {
 	// client is used to interact with ReplicatonControllers.
-	client replicationControllerClient
+	client support.ReplicationControllerClient
 	// codec is used to decode DeploymentConfigs contained in deployments.
 	codec runtime.Codec
+	// hookExecutor executes the lifecycle hooks of the strategy.
+	hookExecutor support.LifecycleHookExecutor
+	// readiness is used to wait for the pods of the new deployment to be ready.
+	readiness support.PodReadinessWaiter
 
 	retryTimeout time.Duration
 	retryPeriod  time.Duration
@@ -32,8 +49,10 @@ type RecreateDeploymentStrategy struct {
 
 func NewRecreateDeploymentStrategy(client kclient.Interface, codec runtime.Codec) *RecreateDeploymentStrategy {
 	return &RecreateDeploymentStrategy{
-		client:       &realReplicationController{client},
+		client:       &support.RealReplicationController{Client: client},
 		codec:        codec,
+		hookExecutor: support.NewHookExecutor(client),
+		readiness:    support.NewReadinessWaiter(client),
 		retryTimeout: 10 * time.Second,
 		retryPeriod:  1 * time.Second,
 	}
@@ -48,10 +67,41 @@ func (s *RecreateDeploymentStrategy) Deploy(deployment *kapi.ReplicationControll
 		return fmt.Errorf("Couldn't decode DeploymentConfig from deployment %s: %v", deployment.Name, err)
 	}
 
-	if err = s.updateReplicas(deployment.Namespace, deployment.Name, deploymentConfig.Template.ControllerTemplate.Replicas); err != nil {
+	timeout := time.Duration(defaultTimeoutSeconds) * time.Second
+	if params := deploymentConfig.Template.Strategy.RecreateParams; params != nil && params.TimeoutSeconds != nil {
+		timeout = time.Duration(*params.TimeoutSeconds) * time.Second
+	}
+
+	if pre := deploymentConfig.Template.Strategy.Pre; pre != nil {
+		if err = s.hookExecutor.Execute(pre, deployment, support.PreHook, timeout); err != nil {
+			return fmt.Errorf("Pre hook failed: %v", err)
+		}
+	}
+
+	if s.cancelled(deployment) {
+		return fmt.Errorf("Deployment %s was cancelled", deployment.Name)
+	}
+
+	replicas := deploymentConfig.Template.ControllerTemplate.Replicas
+	if err = s.updateReplicas(deployment.Namespace, deployment.Name, replicas); err != nil {
 		return err
 	}
 
+	if _, err = s.readiness.WaitForReadyPods(deployment, replicas, timeout); err != nil {
+		// the pods that are not ready must not keep running next to the previous deployments
+		if scaleErr := s.updateReplicas(deployment.Namespace, deployment.Name, 0); scaleErr != nil {
+			glog.Errorf("%v", scaleErr)
+		}
+		return fmt.Errorf("Deployment %s failed: %v", deployment.Name, err)
+	}
+
+	if s.cancelled(deployment) {
+		if err = s.updateReplicas(deployment.Namespace, deployment.Name, 0); err != nil {
+			glog.Errorf("%v", err)
+		}
+		return fmt.Errorf("Deployment %s was cancelled", deployment.Name)
+	}
+
 	// For this simple deploy, disable previous replication controllers.
 	glog.Infof("Found %d prior deployments to disable", len(oldDeployments))
 	allProcessed := true
@@ -66,6 +116,12 @@ func (s *RecreateDeploymentStrategy) Deploy(deployment *kapi.ReplicationControll
 		return fmt.Errorf("Failed to disable all prior deployments for new deployment %s", deployment.Name)
 	}
 
+	if post := deploymentConfig.Template.Strategy.Post; post != nil {
+		if err = s.hookExecutor.Execute(post, deployment, support.PostHook, timeout); err != nil {
+			return fmt.Errorf("Post hook failed: %v", err)
+		}
+	}
+
 	glog.Infof("Deployment %s successfully made active", deployment.Name)
 	return nil
 }
@@ -81,12 +137,12 @@ func (s *RecreateDeploymentStrategy) updateReplicas(namespace, name string, repl
 		case <-timeout:
 			return fmt.Errorf("Couldn't successfully update deployment %s/%s replica count to %d (timeout exceeded)", namespace, name, replicaCount)
 		default:
-			if deployment, err = s.client.getReplicationController(namespace, name); err != nil {
+			if deployment, err = s.client.GetReplicationController(namespace, name); err != nil {
 				glog.Errorf("Couldn't get deployment %s/%s: %v", namespace, name, err)
 			} else {
 				deployment.Spec.Replicas = replicaCount
 				glog.Infof("Updating deployment %s/%s replica count to %d", namespace, name, replicaCount)
-				if _, err = s.client.updateReplicationController(namespace, deployment); err == nil {
+				if _, err = s.client.UpdateReplicationController(namespace, deployment); err == nil {
 					return nil
 				}
 				// For conflict errors, retry immediately
@@ -101,19 +157,13 @@ func (s *RecreateDeploymentStrategy) updateReplicas(namespace, name string, repl
 	}
 }
 
-type replicationControllerClient interface {
-	getReplicationController(namespace, name string) (*kapi.ReplicationController, error)
-	updateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error)
-}
-
-type realReplicationController struct {
-	client kclient.Interface
-}
-
-func (r realReplicationController) getReplicationController(namespace string, name string) (*kapi.ReplicationController, error) {
-	return r.client.ReplicationControllers(namespace).Get(name)
-}
-
-func (r realReplicationController) updateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
-	return r.client.ReplicationControllers(namespace).Update(ctrl)
+// cancelled returns true if the cancellation of deployment was requested since the deployment
+// started.
+func (s *RecreateDeploymentStrategy) cancelled(deployment *kapi.ReplicationController) bool {
+	current, err := s.client.GetReplicationController(deployment.Namespace, deployment.Name)
+	if err != nil {
+		glog.Errorf("Couldn't get deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+		return false
+	}
+	return deployutil.IsDeploymentCancelled(current)
 }
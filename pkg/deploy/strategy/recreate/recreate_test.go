@@ -2,13 +2,17 @@ package recreate
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 
 	api "github.com/openshift/origin/pkg/api/latest"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
 	deploytest "github.com/openshift/origin/pkg/deploy/api/test"
+	"github.com/openshift/origin/pkg/deploy/strategy/support"
 	deployutil "github.com/openshift/origin/pkg/deploy/util"
 )
 
@@ -18,6 +22,7 @@ func TestFirstDeployment(t *testing.T) {
 
 	strategy := &RecreateDeploymentStrategy{
 		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
 		retryTimeout: 1 * time.Second,
 		retryPeriod:  1 * time.Millisecond,
 		client: &testControllerClient{
@@ -56,6 +61,7 @@ func TestSecondDeploymentSuccessfulRetries(t *testing.T) {
 
 	strategy := &RecreateDeploymentStrategy{
 		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
 		retryTimeout: 1 * time.Second,
 		retryPeriod:  1 * time.Millisecond,
 		client: &testControllerClient{
@@ -109,6 +115,7 @@ func TestSecondDeploymentFailedInitialRetries(t *testing.T) {
 
 	strategy := &RecreateDeploymentStrategy{
 		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
 		retryTimeout: 1 * time.Millisecond,
 		retryPeriod:  1 * time.Millisecond,
 		client: &testControllerClient{
@@ -153,6 +160,7 @@ func TestSecondDeploymentFailedDisableRetries(t *testing.T) {
 
 	strategy := &RecreateDeploymentStrategy{
 		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
 		retryTimeout: 1 * time.Millisecond,
 		retryPeriod:  1 * time.Millisecond,
 		client: &testControllerClient{
@@ -197,15 +205,217 @@ func TestSecondDeploymentFailedDisableRetries(t *testing.T) {
 	}
 }
 
+func TestDeploymentWithHooks(t *testing.T) {
+	var events []string
+	oldDeployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	newConfig := deploytest.OkDeploymentConfig(2)
+	hook := &deployapi.LifecycleHook{
+		FailurePolicy: deployapi.LifecycleHookFailurePolicyAbort,
+		ExecNewPod:    &deployapi.ExecNewPodHook{Command: []string{"migrate"}, ContainerName: "container1"},
+	}
+	post := *hook
+	post.FailurePolicy = deployapi.LifecycleHookFailurePolicyIgnore
+	newConfig.Template.Strategy.Pre = hook
+	newConfig.Template.Strategy.Post = &post
+	newDeployment, _ := deployutil.MakeDeployment(newConfig, kapi.Codec)
+
+	strategy := &RecreateDeploymentStrategy{
+		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
+		retryTimeout: 1 * time.Second,
+		retryPeriod:  1 * time.Millisecond,
+		client: &testControllerClient{
+			getReplicationControllerFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+				if name == oldDeployment.Name {
+					return oldDeployment, nil
+				}
+				return newDeployment, nil
+			},
+			updateReplicationControllerFunc: func(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				events = append(events, fmt.Sprintf("scale %s to %d", ctrl.Name, ctrl.Spec.Replicas))
+				return ctrl, nil
+			},
+		},
+		hookExecutor: &testHookExecutor{
+			executeFunc: func(hook *deployapi.LifecycleHook, deployment *kapi.ReplicationController, hookType support.HookType, timeout time.Duration) error {
+				if timeout != time.Duration(defaultTimeoutSeconds)*time.Second {
+					t.Errorf("expected the %s hook to be bounded by the strategy timeout, got %v", hookType, timeout)
+				}
+				events = append(events, fmt.Sprintf("%s hook", hookType))
+				return nil
+			},
+		},
+	}
+
+	err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}})
+	if err != nil {
+		t.Fatalf("unexpected deploy error: %#v", err)
+	}
+
+	expected := []string{
+		"pre hook",
+		fmt.Sprintf("scale %s to 1", newDeployment.Name),
+		fmt.Sprintf("scale %s to 0", oldDeployment.Name),
+		"post hook",
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+}
+
+func TestDeploymentPreHookAborts(t *testing.T) {
+	config := deploytest.OkDeploymentConfig(1)
+	config.Template.Strategy.Pre = &deployapi.LifecycleHook{
+		FailurePolicy: deployapi.LifecycleHookFailurePolicyAbort,
+		ExecNewPod:    &deployapi.ExecNewPodHook{Command: []string{"migrate"}, ContainerName: "container1"},
+	}
+	deployment, _ := deployutil.MakeDeployment(config, kapi.Codec)
+
+	strategy := &RecreateDeploymentStrategy{
+		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
+		retryTimeout: 1 * time.Second,
+		retryPeriod:  1 * time.Millisecond,
+		client: &testControllerClient{
+			getReplicationControllerFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+				return deployment, nil
+			},
+			updateReplicationControllerFunc: func(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				t.Fatalf("unexpected update of %s", ctrl.Name)
+				return nil, nil
+			},
+		},
+		hookExecutor: &testHookExecutor{
+			executeFunc: func(hook *deployapi.LifecycleHook, deployment *kapi.ReplicationController, hookType support.HookType, timeout time.Duration) error {
+				return fmt.Errorf("hook failed")
+			},
+		},
+	}
+
+	if err := strategy.Deploy(deployment, []kapi.ObjectReference{}); err == nil {
+		t.Fatalf("expected a deploy error")
+	}
+}
+
+func TestDeploymentFailsWhenPodsAreNotReady(t *testing.T) {
+	var updatedControllers []string
+	oldDeployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	newConfig := deploytest.OkDeploymentConfig(2)
+	timeout := int64(30)
+	newConfig.Template.Strategy.RecreateParams = &deployapi.RecreateDeploymentStrategyParams{TimeoutSeconds: &timeout}
+	newDeployment, _ := deployutil.MakeDeployment(newConfig, kapi.Codec)
+
+	waiter := &testReadinessWaiter{err: fmt.Errorf("pods not ready: %s-abcde", newDeployment.Name)}
+	strategy := &RecreateDeploymentStrategy{
+		codec:        api.Codec,
+		readiness:    waiter,
+		retryTimeout: 1 * time.Second,
+		retryPeriod:  1 * time.Millisecond,
+		client: &testControllerClient{
+			getReplicationControllerFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+				if name == oldDeployment.Name {
+					return oldDeployment, nil
+				}
+				return newDeployment, nil
+			},
+			updateReplicationControllerFunc: func(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				updatedControllers = append(updatedControllers, fmt.Sprintf("scale %s to %d", ctrl.Name, ctrl.Spec.Replicas))
+				return ctrl, nil
+			},
+		},
+	}
+
+	err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}})
+	if err == nil || !strings.Contains(err.Error(), newDeployment.Name+"-abcde") {
+		t.Fatalf("expected an error naming the pod which is not ready, got %v", err)
+	}
+	if e, a := 30*time.Second, waiter.timeout; e != a {
+		t.Errorf("expected to wait %v, waited %v", e, a)
+	}
+	expected := []string{
+		fmt.Sprintf("scale %s to 1", newDeployment.Name),
+		fmt.Sprintf("scale %s to 0", newDeployment.Name),
+	}
+	if !reflect.DeepEqual(expected, updatedControllers) {
+		t.Errorf("expected the new deployment to be scaled back to zero and the old deployment to be left enabled, got %v", updatedControllers)
+	}
+}
+
+func TestDeploymentCancelledWhilePodsStart(t *testing.T) {
+	var events []string
+	oldDeployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	newDeployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(2), kapi.Codec)
+	scaledUp := false
+
+	strategy := &RecreateDeploymentStrategy{
+		codec:        api.Codec,
+		readiness:    &testReadinessWaiter{},
+		retryTimeout: 1 * time.Second,
+		retryPeriod:  1 * time.Millisecond,
+		client: &testControllerClient{
+			getReplicationControllerFunc: func(namespace, name string) (*kapi.ReplicationController, error) {
+				if name == oldDeployment.Name {
+					return oldDeployment, nil
+				}
+				// the deployment is cancelled once its pods are started
+				copied := *newDeployment
+				if scaledUp {
+					copied.Annotations = map[string]string{deployapi.DeploymentCancelledAnnotation: deployapi.DeploymentCancelledAnnotationValue}
+				}
+				return &copied, nil
+			},
+			updateReplicationControllerFunc: func(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+				events = append(events, fmt.Sprintf("scale %s to %d", ctrl.Name, ctrl.Spec.Replicas))
+				scaledUp = true
+				return ctrl, nil
+			},
+		},
+	}
+
+	err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("expected the deployment to be cancelled, got %v", err)
+	}
+	expected := []string{
+		fmt.Sprintf("scale %s to 1", newDeployment.Name),
+		fmt.Sprintf("scale %s to 0", newDeployment.Name),
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+}
+
+// testReadinessWaiter reports the pods of deployments as ready, unless err is set.
+type testReadinessWaiter struct {
+	err     error
+	timeout time.Duration
+}
+
+func (t *testReadinessWaiter) WaitForReadyPods(deployment *kapi.ReplicationController, count int, timeout time.Duration) (int, error) {
+	t.timeout = timeout
+	if t.err != nil {
+		return 0, t.err
+	}
+	return count, nil
+}
+
+type testHookExecutor struct {
+	executeFunc func(hook *deployapi.LifecycleHook, deployment *kapi.ReplicationController, hookType support.HookType, timeout time.Duration) error
+}
+
+func (t *testHookExecutor) Execute(hook *deployapi.LifecycleHook, deployment *kapi.ReplicationController, hookType support.HookType, timeout time.Duration) error {
+	return t.executeFunc(hook, deployment, hookType, timeout)
+}
+
 type testControllerClient struct {
 	getReplicationControllerFunc    func(namespace, name string) (*kapi.ReplicationController, error)
 	updateReplicationControllerFunc func(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error)
 }
 
-func (t *testControllerClient) getReplicationController(namespace, name string) (*kapi.ReplicationController, error) {
+func (t *testControllerClient) GetReplicationController(namespace, name string) (*kapi.ReplicationController, error) {
 	return t.getReplicationControllerFunc(namespace, name)
 }
 
-func (t *testControllerClient) updateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+func (t *testControllerClient) UpdateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
 	return t.updateReplicationControllerFunc(namespace, ctrl)
 }
@@ -0,0 +1,225 @@
+package rolling
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	"github.com/openshift/origin/pkg/deploy/strategy/support"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+const (
+	defaultIntervalSeconds int64 = 1
+	defaultTimeoutSeconds  int64 = 600
+	// maxConflictRetries bounds the retries of a replica count update which conflicts with
+	// another update of the same deployment.
+	maxConflictRetries = 5
+)
+
+// RollingDeploymentStrategy replaces the pods of previous deployments with the pods of a new
+// deployment in steps. Each step scales the new deployment up, within MaxSurge pods above the
+// desired replica count, waits for the new pods to become ready and then scales the previous
+// deployments down, within MaxUnavailable pods below the desired replica count.
+//
+// If the new pods of a step do not become ready before the timeout, or the deployment is
+// cancelled between two steps, the deployment is aborted:
+// the previous deployments are scaled back to their original replica counts and the new
+// deployment to zero, so that the previous deployment keeps serving.
+//
+// The Pre lifecycle hook of the strategy is executed before the first step, and the Post hook
+// after the last one.
+type RollingDeploymentStrategy struct {
+	// client is used to interact with ReplicatonControllers.
+	client support.ReplicationControllerClient
+	// readiness is used to wait for the pods of the new deployment to be ready.
+	readiness support.PodReadinessWaiter
+	// codec is used to decode DeploymentConfigs contained in deployments.
+	codec runtime.Codec
+	// hookExecutor executes the lifecycle hooks of the strategy.
+	hookExecutor support.LifecycleHookExecutor
+	// sleep waits between the steps of the deployment and the readiness checks of a step.
+	sleep func(time.Duration)
+}
+
+func NewRollingDeploymentStrategy(client kclient.Interface, codec runtime.Codec) *RollingDeploymentStrategy {
+	return &RollingDeploymentStrategy{
+		client:       &support.RealReplicationController{Client: client},
+		readiness:    support.NewReadinessWaiter(client),
+		codec:        codec,
+		hookExecutor: support.NewHookExecutor(client),
+		sleep:        time.Sleep,
+	}
+}
+
+// Deploy replaces the pods of oldDeployments with the pods of deployment.
+func (s *RollingDeploymentStrategy) Deploy(deployment *kapi.ReplicationController, oldDeployments []kapi.ObjectReference) error {
+	config, err := deployutil.DecodeDeploymentConfig(deployment, s.codec)
+	if err != nil {
+		return fmt.Errorf("Couldn't decode DeploymentConfig from deployment %s: %v", deployment.Name, err)
+	}
+
+	params := config.Template.Strategy.RollingParams
+	if params == nil {
+		params = &deployapi.RollingDeploymentStrategyParams{}
+	}
+	interval := time.Duration(defaultIntervalSeconds) * time.Second
+	if params.IntervalSeconds != nil {
+		interval = time.Duration(*params.IntervalSeconds) * time.Second
+	}
+	timeout := time.Duration(defaultTimeoutSeconds) * time.Second
+	if params.TimeoutSeconds != nil {
+		timeout = time.Duration(*params.TimeoutSeconds) * time.Second
+	}
+	maxSurge, maxUnavailable := params.MaxSurge, params.MaxUnavailable
+	if maxSurge == 0 && maxUnavailable == 0 {
+		maxSurge = 1
+	}
+	desired := config.Template.ControllerTemplate.Replicas
+
+	// Record the replica counts of the previous deployments to restore them on abort.
+	old := []*kapi.ReplicationController{}
+	original := map[string]int{}
+	oldTotal := 0
+	for _, ref := range oldDeployments {
+		rc, err := s.client.GetReplicationController(ref.Namespace, ref.Name)
+		if err != nil {
+			return fmt.Errorf("Couldn't get prior deployment %s/%s: %v", ref.Namespace, ref.Name, err)
+		}
+		old = append(old, rc)
+		original[rc.Name] = rc.Spec.Replicas
+		oldTotal += rc.Spec.Replicas
+	}
+
+	if pre := config.Template.Strategy.Pre; pre != nil {
+		if err := s.hookExecutor.Execute(pre, deployment, support.PreHook, timeout); err != nil {
+			return fmt.Errorf("Pre hook failed: %v", err)
+		}
+	}
+
+	newReplicas := 0
+	for newReplicas < desired || oldTotal > 0 {
+		progressed := false
+
+		if s.cancelled(deployment) {
+			return s.abort(deployment, old, original, fmt.Errorf("the deployment was cancelled"))
+		}
+
+		// Scale up the new deployment within the surge above the desired replica count.
+		target := desired + maxSurge - oldTotal
+		if target > desired {
+			target = desired
+		}
+		if target > newReplicas {
+			if err := s.updateReplicas(deployment.Namespace, deployment.Name, target); err != nil {
+				return s.abort(deployment, old, original, err)
+			}
+			newReplicas = target
+			progressed = true
+		}
+
+		ready, err := s.readiness.WaitForReadyPods(deployment, newReplicas, timeout)
+		if err != nil {
+			return s.abort(deployment, old, original, err)
+		}
+
+		// Scale down the previous deployments within the pods allowed to be unavailable.
+		excess := ready + oldTotal - (desired - maxUnavailable)
+		if excess > oldTotal {
+			excess = oldTotal
+		}
+		for _, rc := range old {
+			if excess <= 0 {
+				break
+			}
+			if rc.Spec.Replicas == 0 {
+				continue
+			}
+			count := rc.Spec.Replicas - excess
+			if count < 0 {
+				count = 0
+			}
+			if err := s.updateReplicas(rc.Namespace, rc.Name, count); err != nil {
+				return s.abort(deployment, old, original, err)
+			}
+			excess -= rc.Spec.Replicas - count
+			oldTotal -= rc.Spec.Replicas - count
+			rc.Spec.Replicas = count
+			progressed = true
+		}
+
+		if !progressed {
+			return s.abort(deployment, old, original, fmt.Errorf("no progress could be made with a maximum surge of %d and a maximum of %d unavailable pods", maxSurge, maxUnavailable))
+		}
+		if newReplicas < desired || oldTotal > 0 {
+			s.sleep(interval)
+		}
+	}
+
+	if post := config.Template.Strategy.Post; post != nil {
+		if err := s.hookExecutor.Execute(post, deployment, support.PostHook, timeout); err != nil {
+			return fmt.Errorf("Post hook failed: %v", err)
+		}
+	}
+
+	glog.Infof("Deployment %s successfully made active", deployment.Name)
+	return nil
+}
+
+// abort restores the original replica counts of the previous deployments, scales deployment
+// to zero and returns the reason of the abort.
+func (s *RollingDeploymentStrategy) abort(deployment *kapi.ReplicationController, old []*kapi.ReplicationController, original map[string]int, reason error) error {
+	glog.Errorf("Aborting deployment %s: %v", deployment.Name, reason)
+	restored := true
+	for _, rc := range old {
+		if err := s.updateReplicas(rc.Namespace, rc.Name, original[rc.Name]); err != nil {
+			glog.Errorf("%v", err)
+			restored = false
+		}
+	}
+	if !restored {
+		return fmt.Errorf("Deployment %s aborted and the prior deployments could not be restored: %v", deployment.Name, reason)
+	}
+	if err := s.updateReplicas(deployment.Namespace, deployment.Name, 0); err != nil {
+		glog.Errorf("%v", err)
+	}
+	return fmt.Errorf("Deployment %s aborted and the prior deployments restored: %v", deployment.Name, reason)
+}
+
+// cancelled returns true if the cancellation of deployment was requested since the deployment
+// started.
+func (s *RollingDeploymentStrategy) cancelled(deployment *kapi.ReplicationController) bool {
+	current, err := s.client.GetReplicationController(deployment.Namespace, deployment.Name)
+	if err != nil {
+		glog.Errorf("Couldn't get deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+		return false
+	}
+	return deployutil.IsDeploymentCancelled(current)
+}
+
+// updateReplicas sets the replica count of the given deployment, retrying on conflicts.
+func (s *RollingDeploymentStrategy) updateReplicas(namespace, name string, replicaCount int) error {
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		var deployment *kapi.ReplicationController
+		if deployment, err = s.client.GetReplicationController(namespace, name); err != nil {
+			break
+		}
+		deployment.Spec.Replicas = replicaCount
+		glog.Infof("Updating deployment %s/%s replica count to %d", namespace, name, replicaCount)
+		if _, err = s.client.UpdateReplicationController(namespace, deployment); err == nil || !kerrors.IsConflict(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Couldn't update deployment %s/%s replica count to %d: %v", namespace, name, replicaCount, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package rolling
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+
+	api "github.com/openshift/origin/pkg/api/latest"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deploytest "github.com/openshift/origin/pkg/deploy/api/test"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+// fakeCluster holds deployments by name and records the replica counts they are updated to.
+type fakeCluster struct {
+	controllers map[string]*kapi.ReplicationController
+	updates     map[string][]int
+	waits       []time.Duration
+	// readyPods returns the number of ready pods of a deployment.
+	readyPods func(rc *kapi.ReplicationController) int
+}
+
+func newFakeCluster(rcs ...*kapi.ReplicationController) *fakeCluster {
+	c := &fakeCluster{controllers: map[string]*kapi.ReplicationController{}, updates: map[string][]int{}}
+	for _, rc := range rcs {
+		c.controllers[rc.Name] = rc
+	}
+	return c
+}
+
+func (c *fakeCluster) GetReplicationController(namespace, name string) (*kapi.ReplicationController, error) {
+	copied := *c.controllers[name]
+	return &copied, nil
+}
+
+func (c *fakeCluster) UpdateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+	c.controllers[ctrl.Name] = ctrl
+	c.updates[ctrl.Name] = append(c.updates[ctrl.Name], ctrl.Spec.Replicas)
+	return ctrl, nil
+}
+
+func (c *fakeCluster) WaitForReadyPods(deployment *kapi.ReplicationController, count int, timeout time.Duration) (int, error) {
+	c.waits = append(c.waits, timeout)
+	ready := c.readyPods(c.controllers[deployment.Name])
+	if ready < count {
+		return ready, fmt.Errorf("%d of %d pods were ready within %v", ready, count, timeout)
+	}
+	return ready, nil
+}
+
+func rollingDeployment(version, replicas int, params *deployapi.RollingDeploymentStrategyParams) *kapi.ReplicationController {
+	config := deploytest.OkDeploymentConfig(version)
+	config.Template.Strategy = deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeRolling, RollingParams: params}
+	config.Template.ControllerTemplate.Replicas = replicas
+	deployment, _ := deployutil.MakeDeployment(config, kapi.Codec)
+	return deployment
+}
+
+func TestRollingDeploySteps(t *testing.T) {
+	oldDeployment := rollingDeployment(1, 3, nil)
+	oldDeployment.Spec.Replicas = 3
+	newDeployment := rollingDeployment(2, 3, nil)
+	cluster := newFakeCluster(oldDeployment, newDeployment)
+	cluster.readyPods = func(rc *kapi.ReplicationController) int { return rc.Spec.Replicas }
+
+	sleeps := 0
+	strategy := &RollingDeploymentStrategy{
+		client:    cluster,
+		readiness: cluster,
+		codec:     api.Codec,
+		sleep:     func(time.Duration) { sleeps++ },
+	}
+	if err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}}); err != nil {
+		t.Fatalf("unexpected deploy error: %v", err)
+	}
+
+	// with the default surge of 1 pod, each step replaces one pod
+	if e, a := []int{1, 2, 3}, cluster.updates[newDeployment.Name]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the new deployment to be scaled up to %v, got %v", e, a)
+	}
+	if e, a := []int{2, 1, 0}, cluster.updates[oldDeployment.Name]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the old deployment to be scaled down to %v, got %v", e, a)
+	}
+	if sleeps != 2 {
+		t.Errorf("expected to wait between the 3 steps, waited %d times", sleeps)
+	}
+}
+
+func TestRollingDeployMaxUnavailable(t *testing.T) {
+	oldDeployment := rollingDeployment(1, 4, nil)
+	oldDeployment.Spec.Replicas = 4
+	newDeployment := rollingDeployment(2, 4, &deployapi.RollingDeploymentStrategyParams{MaxUnavailable: 2})
+	cluster := newFakeCluster(oldDeployment, newDeployment)
+	cluster.readyPods = func(rc *kapi.ReplicationController) int { return rc.Spec.Replicas }
+
+	strategy := &RollingDeploymentStrategy{client: cluster, readiness: cluster, codec: api.Codec, sleep: func(time.Duration) {}}
+	if err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}}); err != nil {
+		t.Fatalf("unexpected deploy error: %v", err)
+	}
+
+	// the old deployment is scaled down first, as no pod may run above the desired count
+	if e, a := []int{2, 0}, cluster.updates[oldDeployment.Name]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the old deployment to be scaled down to %v, got %v", e, a)
+	}
+	if e, a := []int{2, 4}, cluster.updates[newDeployment.Name]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the new deployment to be scaled up to %v, got %v", e, a)
+	}
+}
+
+func TestRollingDeployAbortsWhenPodsAreNotReady(t *testing.T) {
+	oldDeployment := rollingDeployment(1, 2, nil)
+	oldDeployment.Spec.Replicas = 2
+	timeout := int64(10)
+	newDeployment := rollingDeployment(2, 2, &deployapi.RollingDeploymentStrategyParams{TimeoutSeconds: &timeout})
+	cluster := newFakeCluster(oldDeployment, newDeployment)
+	cluster.readyPods = func(rc *kapi.ReplicationController) int {
+		if rc.Name == newDeployment.Name {
+			return 0
+		}
+		return rc.Spec.Replicas
+	}
+
+	strategy := &RollingDeploymentStrategy{client: cluster, readiness: cluster, codec: api.Codec, sleep: func(time.Duration) {}}
+	err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}})
+	if err == nil || !strings.Contains(err.Error(), "prior deployments restored") {
+		t.Fatalf("expected the deployment to be aborted, got %v", err)
+	}
+	if e, a := []time.Duration{10 * time.Second}, cluster.waits; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected to wait for the timeout of the step %v, waited %v", e, a)
+	}
+	if e, a := 2, cluster.controllers[oldDeployment.Name].Spec.Replicas; e != a {
+		t.Errorf("expected the old deployment to keep %d replicas, got %d", e, a)
+	}
+	if e, a := 0, cluster.controllers[newDeployment.Name].Spec.Replicas; e != a {
+		t.Errorf("expected the new deployment to be scaled to %d, got %d", e, a)
+	}
+}
+
+func TestRollingDeployCancelled(t *testing.T) {
+	oldDeployment := rollingDeployment(1, 3, nil)
+	oldDeployment.Spec.Replicas = 3
+	newDeployment := rollingDeployment(2, 3, nil)
+	cluster := newFakeCluster(oldDeployment, newDeployment)
+	cluster.readyPods = func(rc *kapi.ReplicationController) int { return rc.Spec.Replicas }
+
+	// cancel the deployment during the wait after the first step
+	strategy := &RollingDeploymentStrategy{client: cluster, readiness: cluster, codec: api.Codec}
+	strategy.sleep = func(time.Duration) {
+		cluster.controllers[newDeployment.Name].Annotations[deployapi.DeploymentCancelledAnnotation] = deployapi.DeploymentCancelledAnnotationValue
+	}
+	err := strategy.Deploy(newDeployment, []kapi.ObjectReference{{Namespace: oldDeployment.Namespace, Name: oldDeployment.Name}})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("expected the deployment to be cancelled, got %v", err)
+	}
+	if e, a := []int{2, 3}, cluster.updates[oldDeployment.Name]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the old deployment to be scaled to %v, got %v", e, a)
+	}
+	if e, a := []int{1, 0}, cluster.updates[newDeployment.Name]; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the new deployment to be scaled to %v, got %v", e, a)
+	}
+}
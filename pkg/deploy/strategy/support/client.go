@@ -0,0 +1,25 @@
+package support
+
+import (
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+// ReplicationControllerClient gets and updates the ReplicationControllers of deployments.
+type ReplicationControllerClient interface {
+	GetReplicationController(namespace, name string) (*kapi.ReplicationController, error)
+	UpdateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error)
+}
+
+// RealReplicationController is a ReplicationControllerClient using a Kubernetes client.
+type RealReplicationController struct {
+	Client kclient.Interface
+}
+
+func (r RealReplicationController) GetReplicationController(namespace string, name string) (*kapi.ReplicationController, error) {
+	return r.Client.ReplicationControllers(namespace).Get(name)
+}
+
+func (r RealReplicationController) UpdateReplicationController(namespace string, ctrl *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+	return r.Client.ReplicationControllers(namespace).Update(ctrl)
+}
@@ -0,0 +1,274 @@
+package support
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+// HookType identifies the point of a deployment at which a lifecycle hook is executed.
+type HookType string
+
+const (
+	PreHook  HookType = "pre"
+	PostHook HookType = "post"
+)
+
+// maxConflictRetries bounds the retries of an annotation update which conflicts with another
+// update of the same deployment.
+const maxConflictRetries = 5
+
+// maxHookAttempts bounds the runs of a hook with the Retry failure policy, which keeps failing.
+const maxHookAttempts = 10
+
+// maxRetryPeriod is the longest time waited before retrying a failed hook. The wait doubles
+// after each failure up to it.
+const maxRetryPeriod = 1 * time.Minute
+
+// LifecycleHookExecutor executes the lifecycle hooks of deployment strategies.
+type LifecycleHookExecutor interface {
+	Execute(hook *deployapi.LifecycleHook, deployment *kapi.ReplicationController, hookType HookType, timeout time.Duration) error
+}
+
+// HookExecutor executes a deployment lifecycle hook in a new pod and records the result of the
+// pod as annotations on the deployment.
+type HookExecutor struct {
+	// pods is used to create, get and delete hook pods.
+	pods hookPodClient
+	// deployments is used to annotate deployments with the results of hook pods.
+	deployments hookDeploymentClient
+	// sleep waits between the checks of a running hook pod and the retries of a failed hook.
+	sleep func(time.Duration)
+	// pollPeriod is the time between two checks of a running hook pod.
+	pollPeriod time.Duration
+	// retryPeriod is the time to wait before the first retry of a failed hook.
+	retryPeriod time.Duration
+}
+
+// NewHookExecutor makes a HookExecutor using client.
+func NewHookExecutor(client kclient.Interface) *HookExecutor {
+	return &HookExecutor{
+		pods:        &realHookPodClient{client},
+		deployments: &realHookDeploymentClient{client},
+		sleep:       time.Sleep,
+		pollPeriod:  1 * time.Second,
+		retryPeriod: 1 * time.Second,
+	}
+}
+
+// Execute runs hook in a new pod made from the container template of deployment and waits for
+// the pod to complete. A pod which does not complete within timeout fails. A failure of the pod
+// is handled according to the failure policy of hook: the hook is retried with a growing wait
+// until it succeeds or maxHookAttempts runs failed, ignored, or returned as an error.
+func (e *HookExecutor) Execute(hook *deployapi.LifecycleHook, deployment *kapi.ReplicationController, hookType HookType, timeout time.Duration) error {
+	if hook.ExecNewPod == nil {
+		return fmt.Errorf("the %s hook of deployment %s has no action", hookType, deployment.Name)
+	}
+
+	retryPeriod := e.retryPeriod
+	for attempt := 1; ; attempt++ {
+		err := e.executeExecNewPod(hook.ExecNewPod, deployment, hookType, timeout)
+		if err == nil {
+			glog.Infof("The %s hook of deployment %s succeeded", hookType, deployment.Name)
+			return nil
+		}
+
+		switch hook.FailurePolicy {
+		case deployapi.LifecycleHookFailurePolicyIgnore:
+			glog.Infof("Ignoring the failure of the %s hook of deployment %s: %v", hookType, deployment.Name, err)
+			return nil
+		case deployapi.LifecycleHookFailurePolicyRetry:
+			if attempt == maxHookAttempts {
+				return fmt.Errorf("the %s hook of deployment %s failed %d times: %v", hookType, deployment.Name, attempt, err)
+			}
+			glog.Infof("Retrying the %s hook of deployment %s in %s: %v", hookType, deployment.Name, retryPeriod, err)
+			e.sleep(retryPeriod)
+			if retryPeriod *= 2; retryPeriod > maxRetryPeriod {
+				retryPeriod = maxRetryPeriod
+			}
+		default:
+			return fmt.Errorf("the %s hook of deployment %s failed: %v", hookType, deployment.Name, err)
+		}
+	}
+}
+
+// executeExecNewPod creates a pod for hook, waits up to timeout for it to complete, records the
+// result on deployment and deletes the pod. An error is returned if the pod could not be run or
+// did not succeed in time.
+func (e *HookExecutor) executeExecNewPod(hook *deployapi.ExecNewPodHook, deployment *kapi.ReplicationController, hookType HookType, timeout time.Duration) error {
+	podSpec, err := makeHookPod(hook, deployment, hookType)
+	if err != nil {
+		return err
+	}
+	pod, err := e.pods.createPod(deployment.Namespace, podSpec)
+	if err != nil {
+		return fmt.Errorf("couldn't create hook pod: %v", err)
+	}
+	glog.Infof("Created %s hook pod %s for deployment %s", hookType, pod.Name, deployment.Name)
+	defer func() {
+		if err := e.pods.deletePod(pod.Namespace, pod.Name); err != nil && !kerrors.IsNotFound(err) {
+			glog.Errorf("Couldn't delete hook pod %s: %v", pod.Name, err)
+		}
+	}()
+
+	phase, err := e.waitForCompletion(pod, timeout)
+	if err != nil {
+		return err
+	}
+	if err := e.recordResult(deployment, hookType, pod.Name, phase); err != nil {
+		glog.Errorf("Couldn't record the result of hook pod %s on deployment %s: %v", pod.Name, deployment.Name, err)
+	}
+	if phase != kapi.PodSucceeded {
+		return fmt.Errorf("hook pod %s %s", pod.Name, phase)
+	}
+	return nil
+}
+
+// waitForCompletion waits up to timeout for pod to succeed or fail and returns its final phase.
+func (e *HookExecutor) waitForCompletion(pod *kapi.Pod, timeout time.Duration) (kapi.PodPhase, error) {
+	for waited := time.Duration(0); ; waited += e.pollPeriod {
+		current, err := e.pods.getPod(pod.Namespace, pod.Name)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return "", fmt.Errorf("hook pod %s was deleted", pod.Name)
+			}
+			glog.Errorf("Couldn't get hook pod %s: %v", pod.Name, err)
+		} else if phase := current.Status.Phase; phase == kapi.PodSucceeded || phase == kapi.PodFailed {
+			return phase, nil
+		}
+		if waited >= timeout {
+			return "", fmt.Errorf("hook pod %s did not complete within %v", pod.Name, timeout)
+		}
+		e.sleep(e.pollPeriod)
+	}
+}
+
+// recordResult annotates deployment with the name and final phase of the hook pod.
+func (e *HookExecutor) recordResult(deployment *kapi.ReplicationController, hookType HookType, podName string, phase kapi.PodPhase) error {
+	podAnnotation, phaseAnnotation := deployapi.PreHookPodAnnotation, deployapi.PreHookPodPhaseAnnotation
+	if hookType == PostHook {
+		podAnnotation, phaseAnnotation = deployapi.PostHookPodAnnotation, deployapi.PostHookPodPhaseAnnotation
+	}
+
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		var current *kapi.ReplicationController
+		if current, err = e.deployments.getDeployment(deployment.Namespace, deployment.Name); err != nil {
+			return err
+		}
+		if current.Annotations == nil {
+			current.Annotations = map[string]string{}
+		}
+		current.Annotations[podAnnotation] = podName
+		current.Annotations[phaseAnnotation] = string(phase)
+		if _, err = e.deployments.updateDeployment(deployment.Namespace, current); err == nil || !kerrors.IsConflict(err) {
+			break
+		}
+	}
+	return err
+}
+
+// makeHookPod makes a pod which runs the command of hook in a container based on the container
+// of the deployment template named by hook. The pod is not labelled like the pods of the
+// deployment, so that it is never selected by the deployment or its services.
+func makeHookPod(hook *deployapi.ExecNewPodHook, deployment *kapi.ReplicationController, hookType HookType) (*kapi.Pod, error) {
+	if deployment.Spec.Template == nil {
+		return nil, fmt.Errorf("deployment %s has no pod template", deployment.Name)
+	}
+	var base *kapi.Container
+	for i := range deployment.Spec.Template.Spec.Containers {
+		if deployment.Spec.Template.Spec.Containers[i].Name == hook.ContainerName {
+			base = &deployment.Spec.Template.Spec.Containers[i]
+			break
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("no container named %s in the template of deployment %s", hook.ContainerName, deployment.Name)
+	}
+
+	// Variables of the hook override the ones of the container with the same name.
+	env := []kapi.EnvVar{}
+	overridden := map[string]bool{}
+	for _, v := range hook.Env {
+		overridden[v.Name] = true
+	}
+	for _, v := range base.Env {
+		if !overridden[v.Name] {
+			env = append(env, v)
+		}
+	}
+	env = append(env, hook.Env...)
+
+	return &kapi.Pod{
+		ObjectMeta: kapi.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-%shook-", deployment.Name, hookType),
+			Annotations: map[string]string{
+				deployapi.DeploymentAnnotation: deployment.Name,
+			},
+		},
+		Spec: kapi.PodSpec{
+			Containers: []kapi.Container{
+				{
+					Name:            "lifecycle",
+					Image:           base.Image,
+					Command:         hook.Command,
+					WorkingDir:      base.WorkingDir,
+					Env:             env,
+					Resources:       base.Resources,
+					VolumeMounts:    base.VolumeMounts,
+					ImagePullPolicy: base.ImagePullPolicy,
+				},
+			},
+			Volumes:       deployment.Spec.Template.Spec.Volumes,
+			RestartPolicy: kapi.RestartPolicyNever,
+			DNSPolicy:     deployment.Spec.Template.Spec.DNSPolicy,
+			NodeSelector:  deployment.Spec.Template.Spec.NodeSelector,
+		},
+	}, nil
+}
+
+type hookPodClient interface {
+	createPod(namespace string, pod *kapi.Pod) (*kapi.Pod, error)
+	getPod(namespace, name string) (*kapi.Pod, error)
+	deletePod(namespace, name string) error
+}
+
+type realHookPodClient struct {
+	client kclient.Interface
+}
+
+func (r realHookPodClient) createPod(namespace string, pod *kapi.Pod) (*kapi.Pod, error) {
+	return r.client.Pods(namespace).Create(pod)
+}
+
+func (r realHookPodClient) getPod(namespace, name string) (*kapi.Pod, error) {
+	return r.client.Pods(namespace).Get(name)
+}
+
+func (r realHookPodClient) deletePod(namespace, name string) error {
+	return r.client.Pods(namespace).Delete(name)
+}
+
+type hookDeploymentClient interface {
+	getDeployment(namespace, name string) (*kapi.ReplicationController, error)
+	updateDeployment(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error)
+}
+
+type realHookDeploymentClient struct {
+	client kclient.Interface
+}
+
+func (r realHookDeploymentClient) getDeployment(namespace, name string) (*kapi.ReplicationController, error) {
+	return r.client.ReplicationControllers(namespace).Get(name)
+}
+
+func (r realHookDeploymentClient) updateDeployment(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+	return r.client.ReplicationControllers(namespace).Update(deployment)
+}
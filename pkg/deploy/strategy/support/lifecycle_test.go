@@ -0,0 +1,196 @@
+package support
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deploytest "github.com/openshift/origin/pkg/deploy/api/test"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+// fakeHookClient runs hook pods to the phases of phases, in order, and holds a deployment.
+type fakeHookClient struct {
+	phases     []kapi.PodPhase
+	created    []*kapi.Pod
+	deleted    []string
+	deployment *kapi.ReplicationController
+}
+
+func (c *fakeHookClient) createPod(namespace string, pod *kapi.Pod) (*kapi.Pod, error) {
+	created := *pod
+	created.Name = fmt.Sprintf("%s%d", pod.GenerateName, len(c.created))
+	c.created = append(c.created, &created)
+	return &created, nil
+}
+
+func (c *fakeHookClient) getPod(namespace, name string) (*kapi.Pod, error) {
+	pod := *c.created[len(c.created)-1]
+	pod.Status.Phase = c.phases[len(c.created)-1]
+	return &pod, nil
+}
+
+func (c *fakeHookClient) deletePod(namespace, name string) error {
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func (c *fakeHookClient) getDeployment(namespace, name string) (*kapi.ReplicationController, error) {
+	copied := *c.deployment
+	return &copied, nil
+}
+
+func (c *fakeHookClient) updateDeployment(namespace string, deployment *kapi.ReplicationController) (*kapi.ReplicationController, error) {
+	c.deployment = deployment
+	return deployment, nil
+}
+
+func newTestExecutor(client *fakeHookClient) *HookExecutor {
+	return &HookExecutor{pods: client, deployments: client, sleep: func(time.Duration) {}}
+}
+
+func okHook(policy deployapi.LifecycleHookFailurePolicy) *deployapi.LifecycleHook {
+	return &deployapi.LifecycleHook{
+		FailurePolicy: policy,
+		ExecNewPod: &deployapi.ExecNewPodHook{
+			Command:       []string{"/bin/migrate", "--up"},
+			Env:           []kapi.EnvVar{{Name: "ENV1", Value: "HOOK1"}, {Name: "HOOK", Value: "pre"}},
+			ContainerName: "container1",
+		},
+	}
+}
+
+func TestHookExecutorSucceeds(t *testing.T) {
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	client := &fakeHookClient{phases: []kapi.PodPhase{kapi.PodSucceeded}, deployment: deployment}
+
+	if err := newTestExecutor(client).Execute(okHook(deployapi.LifecycleHookFailurePolicyAbort), deployment, PreHook, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.created) != 1 {
+		t.Fatalf("expected a hook pod, got %d", len(client.created))
+	}
+	pod := client.created[0]
+	container := pod.Spec.Containers[0]
+	if e, a := "registry:8080/repo1:ref1", container.Image; e != a {
+		t.Errorf("expected the image of the template container %s, got %s", e, a)
+	}
+	if e, a := []string{"/bin/migrate", "--up"}, container.Command; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected command %v, got %v", e, a)
+	}
+	if e, a := []kapi.EnvVar{{Name: "ENV1", Value: "HOOK1"}, {Name: "HOOK", Value: "pre"}}, container.Env; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected environment %v, got %v", e, a)
+	}
+	if pod.Spec.RestartPolicy != kapi.RestartPolicyNever {
+		t.Errorf("expected the hook pod never to be restarted, got %v", pod.Spec.RestartPolicy)
+	}
+	if len(pod.Labels) != 0 {
+		t.Errorf("expected the hook pod not to be selected by the deployment, got labels %v", pod.Labels)
+	}
+	if e, a := pod.Name, client.deployment.Annotations[deployapi.PreHookPodAnnotation]; e != a {
+		t.Errorf("expected hook pod %s to be recorded, got %s", e, a)
+	}
+	if e, a := string(kapi.PodSucceeded), client.deployment.Annotations[deployapi.PreHookPodPhaseAnnotation]; e != a {
+		t.Errorf("expected phase %s to be recorded, got %s", e, a)
+	}
+	if e, a := []string{pod.Name}, client.deleted; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the hook pod to be deleted, got %v", a)
+	}
+}
+
+func TestHookExecutorFailurePolicies(t *testing.T) {
+	cases := map[deployapi.LifecycleHookFailurePolicy]struct {
+		phases      []kapi.PodPhase
+		expectError bool
+		expectPods  int
+	}{
+		deployapi.LifecycleHookFailurePolicyAbort:  {[]kapi.PodPhase{kapi.PodFailed}, true, 1},
+		deployapi.LifecycleHookFailurePolicyIgnore: {[]kapi.PodPhase{kapi.PodFailed}, false, 1},
+		deployapi.LifecycleHookFailurePolicyRetry:  {[]kapi.PodPhase{kapi.PodFailed, kapi.PodFailed, kapi.PodSucceeded}, false, 3},
+	}
+	for policy, c := range cases {
+		deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+		client := &fakeHookClient{phases: c.phases, deployment: deployment}
+		err := newTestExecutor(client).Execute(okHook(policy), deployment, PostHook, time.Minute)
+		if c.expectError != (err != nil) {
+			t.Errorf("%s: unexpected error %v", policy, err)
+		}
+		if len(client.created) != c.expectPods {
+			t.Errorf("%s: expected %d hook pods, got %d", policy, c.expectPods, len(client.created))
+		}
+		last := c.phases[len(c.phases)-1]
+		if e, a := string(last), client.deployment.Annotations[deployapi.PostHookPodPhaseAnnotation]; e != a {
+			t.Errorf("%s: expected phase %s to be recorded, got %s", policy, e, a)
+		}
+	}
+}
+
+func TestHookExecutorRetryBackoff(t *testing.T) {
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	phases := []kapi.PodPhase{}
+	for i := 0; i < maxHookAttempts+1; i++ {
+		phases = append(phases, kapi.PodFailed)
+	}
+	client := &fakeHookClient{phases: phases, deployment: deployment}
+	waits := []time.Duration{}
+	executor := &HookExecutor{
+		pods:        client,
+		deployments: client,
+		sleep:       func(d time.Duration) { waits = append(waits, d) },
+		retryPeriod: 10 * time.Second,
+	}
+
+	if err := executor.Execute(okHook(deployapi.LifecycleHookFailurePolicyRetry), deployment, PreHook, time.Minute); err == nil {
+		t.Errorf("expected an error once the hook failed %d times", maxHookAttempts)
+	}
+	if len(client.created) != maxHookAttempts || len(client.deleted) != maxHookAttempts {
+		t.Errorf("expected %d hook pods to be run and deleted, got %d and %d", maxHookAttempts, len(client.created), len(client.deleted))
+	}
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second}
+	for len(expected) < maxHookAttempts-1 {
+		expected = append(expected, maxRetryPeriod)
+	}
+	if !reflect.DeepEqual(waits, expected) {
+		t.Errorf("expected the waits between retries %v, got %v", expected, waits)
+	}
+}
+
+// runningHookClient never completes the hook pods it creates.
+type runningHookClient struct {
+	fakeHookClient
+}
+
+func (c *runningHookClient) getPod(namespace, name string) (*kapi.Pod, error) {
+	pod := *c.created[len(c.created)-1]
+	pod.Status.Phase = kapi.PodRunning
+	return &pod, nil
+}
+
+func TestHookExecutorTimeout(t *testing.T) {
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	client := &runningHookClient{fakeHookClient{deployment: deployment}}
+	polls := 0
+	executor := &HookExecutor{
+		pods:        client,
+		deployments: client,
+		sleep:       func(time.Duration) { polls++ },
+		pollPeriod:  time.Second,
+	}
+
+	if err := executor.Execute(okHook(deployapi.LifecycleHookFailurePolicyAbort), deployment, PreHook, 10*time.Second); err == nil {
+		t.Errorf("expected an error for a hook pod which did not complete in time")
+	}
+	if polls != 10 {
+		t.Errorf("expected the hook pod to be checked for 10 seconds, slept %d times", polls)
+	}
+	if len(client.deleted) != 1 {
+		t.Errorf("expected the hook pod to be deleted, got %v", client.deleted)
+	}
+	if _, ok := client.deployment.Annotations[deployapi.PreHookPodPhaseAnnotation]; ok {
+		t.Errorf("expected no phase to be recorded for a hook pod which did not complete")
+	}
+}
@@ -0,0 +1,110 @@
+package support
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// PodReadinessWaiter waits for the pods of deployments to be ready.
+type PodReadinessWaiter interface {
+	WaitForReadyPods(deployment *kapi.ReplicationController, count int, timeout time.Duration) (int, error)
+}
+
+// ReadinessWaiter waits for the pods of a deployment to be running and ready.
+type ReadinessWaiter struct {
+	// pods is used to list the pods selected by a deployment.
+	pods podLister
+	// sleep waits between two checks of the pods.
+	sleep func(time.Duration)
+	// pollPeriod is the time between two checks of the pods.
+	pollPeriod time.Duration
+}
+
+// NewReadinessWaiter makes a ReadinessWaiter using client.
+func NewReadinessWaiter(client kclient.Interface) *ReadinessWaiter {
+	return &ReadinessWaiter{
+		pods:       &realPodLister{client},
+		sleep:      time.Sleep,
+		pollPeriod: 1 * time.Second,
+	}
+}
+
+// WaitForReadyPods waits up to timeout for count pods selected by deployment to be ready and
+// returns the number of ready pods. If they are not ready in time, the error names the pods
+// which are not ready.
+func (w *ReadinessWaiter) WaitForReadyPods(deployment *kapi.ReplicationController, count int, timeout time.Duration) (int, error) {
+	selector := labels.SelectorFromSet(deployment.Spec.Selector)
+	var notReady []string
+	ready := 0
+	for waited := time.Duration(0); ; waited += w.pollPeriod {
+		pods, err := w.pods.listPods(deployment.Namespace, selector)
+		if err != nil {
+			glog.Errorf("Couldn't list the pods of deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+		} else {
+			ready, notReady = 0, []string{}
+			for i := range pods.Items {
+				if IsPodReady(&pods.Items[i]) {
+					ready++
+				} else {
+					notReady = append(notReady, pods.Items[i].Name)
+				}
+			}
+			if ready >= count {
+				return ready, nil
+			}
+			glog.Infof("Waiting for %d pods of deployment %s/%s to be ready, %d are ready", count, deployment.Namespace, deployment.Name, ready)
+		}
+		if waited >= timeout {
+			return ready, readinessTimeoutError(deployment, count, ready, notReady, timeout)
+		}
+		w.sleep(w.pollPeriod)
+	}
+}
+
+// readinessTimeoutError describes the pods of deployment which did not become ready.
+func readinessTimeoutError(deployment *kapi.ReplicationController, count, ready int, notReady []string, timeout time.Duration) error {
+	msg := fmt.Sprintf("%d of %d pods of deployment %s were ready within %v", ready, count, deployment.Name, timeout)
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		msg += fmt.Sprintf("; pods not ready: %s", strings.Join(notReady, ", "))
+	}
+	if missing := count - ready - len(notReady); missing > 0 {
+		msg += fmt.Sprintf("; %d pods were not created", missing)
+	}
+	return errors.New(msg)
+}
+
+// IsPodReady returns true if pod is running and passes its readiness checks. Pods reported
+// without a Ready condition are considered ready once running.
+func IsPodReady(pod *kapi.Pod) bool {
+	if pod.Status.Phase != kapi.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == kapi.PodReady {
+			return condition.Status == kapi.ConditionFull
+		}
+	}
+	return true
+}
+
+type podLister interface {
+	listPods(namespace string, selector labels.Selector) (*kapi.PodList, error)
+}
+
+type realPodLister struct {
+	client kclient.Interface
+}
+
+func (r realPodLister) listPods(namespace string, selector labels.Selector) (*kapi.PodList, error) {
+	return r.client.Pods(namespace).List(selector)
+}
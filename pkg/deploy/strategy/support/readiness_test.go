@@ -0,0 +1,94 @@
+package support
+
+import (
+	"testing"
+	"time"
+
+	kapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+
+	deploytest "github.com/openshift/origin/pkg/deploy/api/test"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+type fakePodLister struct {
+	pods     []kapi.Pod
+	selector labels.Selector
+}
+
+func (l *fakePodLister) listPods(namespace string, selector labels.Selector) (*kapi.PodList, error) {
+	l.selector = selector
+	return &kapi.PodList{Items: l.pods}, nil
+}
+
+func testPod(name string, phase kapi.PodPhase, ready kapi.ConditionStatus) kapi.Pod {
+	pod := kapi.Pod{ObjectMeta: kapi.ObjectMeta{Name: name}, Status: kapi.PodStatus{Phase: phase}}
+	if len(ready) > 0 {
+		pod.Status.Conditions = []kapi.PodCondition{{Type: kapi.PodReady, Status: ready}}
+	}
+	return pod
+}
+
+func TestIsPodReady(t *testing.T) {
+	cases := []struct {
+		pod      kapi.Pod
+		expected bool
+	}{
+		{testPod("pending", kapi.PodPending, ""), false},
+		{testPod("running", kapi.PodRunning, ""), true},
+		{testPod("ready", kapi.PodRunning, kapi.ConditionFull), true},
+		{testPod("unready", kapi.PodRunning, kapi.ConditionNone), false},
+		{testPod("failed", kapi.PodFailed, ""), false},
+	}
+	for _, c := range cases {
+		if e, a := c.expected, IsPodReady(&c.pod); e != a {
+			t.Errorf("%s: expected ready to be %t, got %t", c.pod.Name, e, a)
+		}
+	}
+}
+
+func TestWaitForReadyPods(t *testing.T) {
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	lister := &fakePodLister{pods: []kapi.Pod{
+		testPod("pod-1", kapi.PodRunning, kapi.ConditionFull),
+		testPod("pod-2", kapi.PodRunning, kapi.ConditionFull),
+	}}
+	w := &ReadinessWaiter{pods: lister, sleep: func(time.Duration) { t.Fatalf("unexpected wait") }, pollPeriod: time.Second}
+
+	ready, err := w.WaitForReadyPods(deployment, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ready != 2 {
+		t.Errorf("expected 2 ready pods, got %d", ready)
+	}
+	if !lister.selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+		t.Errorf("expected the pods of the deployment to be listed, got selector %v", lister.selector)
+	}
+}
+
+func TestWaitForReadyPodsTimeout(t *testing.T) {
+	deployment, _ := deployutil.MakeDeployment(deploytest.OkDeploymentConfig(1), kapi.Codec)
+	lister := &fakePodLister{pods: []kapi.Pod{
+		testPod("pod-2", kapi.PodRunning, kapi.ConditionNone),
+		testPod("pod-1", kapi.PodRunning, kapi.ConditionFull),
+		testPod("pod-3", kapi.PodPending, ""),
+	}}
+	var waited time.Duration
+	w := &ReadinessWaiter{pods: lister, sleep: func(d time.Duration) { waited += d }, pollPeriod: time.Second}
+
+	ready, err := w.WaitForReadyPods(deployment, 4, 5*time.Second)
+	if err == nil {
+		t.Fatalf("expected a timeout error")
+	}
+	if ready != 1 {
+		t.Errorf("expected 1 ready pod, got %d", ready)
+	}
+	if waited != 5*time.Second {
+		t.Errorf("expected to wait for the timeout, waited %v", waited)
+	}
+	expected := "1 of 4 pods of deployment config-1 were ready within 5s; pods not ready: pod-2, pod-3; 1 pods were not created"
+	if err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+}
@@ -21,6 +21,16 @@ func LatestDeploymentNameForConfig(config *deployapi.DeploymentConfig) string {
 	return config.Name + "-" + strconv.Itoa(config.LatestVersion)
 }
 
+// IsDeploymentCancelled returns true if the cancellation of deployment was requested.
+func IsDeploymentCancelled(deployment *api.ReplicationController) bool {
+	return deployment.Annotations[deployapi.DeploymentCancelledAnnotation] == deployapi.DeploymentCancelledAnnotationValue
+}
+
+// IsDeploymentRetried returns true if a retry of the failed deployment was requested.
+func IsDeploymentRetried(deployment *api.ReplicationController) bool {
+	return deployment.Annotations[deployapi.DeploymentRetryAnnotation] == deployapi.DeploymentRetryAnnotationValue
+}
+
 func DeployerPodNameForDeployment(deployment *api.ReplicationController) string {
 	return fmt.Sprintf("deploy-%s", deployment.Name)
 }
@@ -94,6 +104,8 @@ func MakeDeployment(config *deployapi.DeploymentConfig, codec runtime.Codec) (*a
 	for k, v := range config.Labels {
 		controllerLabels[k] = v
 	}
+	// Label the deployment with its config, so that the deployments of a config can be listed.
+	controllerLabels[deployapi.DeploymentConfigLabel] = config.Name
 
 	// Ensure that pods created by this deployment controller can be safely associated back
 	// to the controller, and that multiple deployment controllers for the same config don't
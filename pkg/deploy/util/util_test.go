@@ -125,6 +125,10 @@ func TestMakeDeploymentOk(t *testing.T) {
 		t.Fatalf("expected deployment replicas to be 0")
 	}
 
+	if e, a := config.Name, deployment.Labels[deployapi.DeploymentConfigLabel]; e != a {
+		t.Fatalf("expected deployment label DeploymentConfigLabel=%s, got %s", e, a)
+	}
+
 	if e, a := config.Name, deployment.Spec.Template.Labels[deployapi.DeploymentConfigLabel]; e != a {
 		t.Fatalf("expected label DeploymentConfigLabel=%s, got %s", e, a)
 	}
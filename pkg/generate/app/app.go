@@ -325,11 +325,14 @@ func (r *BuildRef) BuildConfig() (*buildapi.BuildConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	triggers := append(sourceTriggers, strategyTriggers...)
+	// build the application right away, without waiting for a push or image change
+	triggers = append(triggers, buildapi.BuildTriggerPolicy{Type: buildapi.ConfigChangeBuildTriggerType})
 	return &buildapi.BuildConfig{
 		ObjectMeta: kapi.ObjectMeta{
 			Name: name,
 		},
-		Triggers: append(sourceTriggers, strategyTriggers...),
+		Triggers: triggers,
 		Parameters: buildapi.BuildParameters{
 			Source:   *source,
 			Strategy: *strategy,
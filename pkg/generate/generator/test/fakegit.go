@@ -4,6 +4,7 @@ type FakeGit struct {
 	RootDir        string
 	GitURL         string
 	Ref            string
+	RemoteCommit   string
 	CloneCalled    bool
 	CheckoutCalled bool
 }
@@ -29,3 +30,7 @@ func (g *FakeGit) Checkout(dir string, ref string) error {
 	g.CheckoutCalled = true
 	return nil
 }
+
+func (g *FakeGit) GetRemoteCommit(url string, ref string, env []string) (string, error) {
+	return g.RemoteCommit, nil
+}
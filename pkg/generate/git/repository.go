@@ -4,14 +4,16 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
 	"unicode"
 )
 
-// execCmdFunc is a function that executes an external command
-type execCmdFunc func(dir, name string, args ...string) (string, string, error)
+// execCmdFunc is a function that executes an external command, adding env to the environment
+// of the process
+type execCmdFunc func(dir string, env []string, name string, args ...string) (string, string, error)
 
 // Repository represents a git source repository
 type Repository interface {
@@ -20,6 +22,7 @@ type Repository interface {
 	GetRef(dir string) string
 	Clone(dir string, url string) error
 	Checkout(dir string, ref string) error
+	GetRemoteCommit(url string, ref string, env []string) (string, error)
 }
 
 type repository struct {
@@ -35,7 +38,7 @@ func NewRepository() Repository {
 
 // GetRootDir obtains the directory root for a Git repository
 func (r *repository) GetRootDir(location string) (string, error) {
-	dir, _, err := r.exec(location, "git", "rev-parse", "--git-dir")
+	dir, _, err := r.exec(location, nil, "git", "rev-parse", "--git-dir")
 	if err != nil {
 		return "", err
 	}
@@ -61,7 +64,7 @@ var (
 
 // GetOriginURL returns the origin branch URL for the git repository
 func (r *repository) GetOriginURL(location string) (string, bool, error) {
-	text, _, err := r.exec(location, "git", "config", "--get-regexp", "^remote\\..*\\.url$")
+	text, _, err := r.exec(location, nil, "git", "config", "--get-regexp", "^remote\\..*\\.url$")
 	if err != nil {
 		return "", false, err
 	}
@@ -87,7 +90,7 @@ func (r *repository) GetOriginURL(location string) (string, bool, error) {
 
 // GetRef retrieves the current branch reference for the git repository
 func (r *repository) GetRef(location string) string {
-	branch, _, err := r.exec(location, "git", "symbolic-ref", "-q", "--short", "HEAD")
+	branch, _, err := r.exec(location, nil, "git", "symbolic-ref", "-q", "--short", "HEAD")
 	if err != nil {
 		branch = ""
 	}
@@ -96,24 +99,66 @@ func (r *repository) GetRef(location string) string {
 
 // Clone clones a remote git repository to a local directory
 func (r *repository) Clone(location string, url string) error {
-	_, _, err := r.exec("", "git", "clone", "--recursive", url, location)
+	_, _, err := r.exec("", nil, "git", "clone", "--recursive", url, location)
 	return err
 }
 
 // Checkout switches to the given ref for the git repository
 func (r *repository) Checkout(location string, ref string) error {
-	_, _, err := r.exec(location, "git", "checkout", ref)
+	_, _, err := r.exec(location, nil, "git", "checkout", ref)
 	return err
 }
 
+// remoteProtocols are the transports git may use to reach remote repositories. Others, like
+// ext, run arbitrary commands or read local files.
+const remoteProtocols = "http:https:git:ssh"
+
+// GetRemoteCommit returns the commit a ref of the remote repository at url points to, using
+// HEAD when ref is empty. Branches take precedence over tags of the same name, and the commit
+// of an annotated tag is returned rather than the tag object. env is added to the environment
+// of git, to set proxies.
+func (r *repository) GetRemoteCommit(url string, ref string, env []string) (string, error) {
+	if strings.HasPrefix(url, "-") {
+		return "", fmt.Errorf("invalid repository URL %s", url)
+	}
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+	env = append(append([]string{}, env...), "GIT_ALLOW_PROTOCOL="+remoteProtocols)
+	out, errOut, err := r.exec("", env, "git", "ls-remote", "--", url, ref)
+	if err != nil {
+		return "", fmt.Errorf("unable to list the refs of %s: %v %s", url, err, errOut)
+	}
+
+	commits := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewBufferString(out))
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) == 2 {
+			commits[fields[1]] = fields[0]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	for _, name := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref} {
+		if commit, ok := commits[name]; ok {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("ref %s was not found in %s", ref, url)
+}
+
 // execCmd executes an external command in the given directory.
 // The command's standard out and error are trimmed and returned as strings
-func execCmd(dir, name string, args ...string) (stdout, stderr string, err error) {
+func execCmd(dir string, env []string, name string, args ...string) (stdout, stderr string, err error) {
 	cmdOut := &bytes.Buffer{}
 	cmdErr := &bytes.Buffer{}
 
 	cmd := exec.Command(name, args...)
 	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	cmd.Stdout = cmdOut
 	cmd.Stderr = cmdErr
 
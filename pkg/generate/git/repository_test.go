@@ -1,6 +1,9 @@
 package git
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -100,8 +103,60 @@ func TestCheckout(t *testing.T) {
 	}
 }
 
+func TestGetRemoteCommit(t *testing.T) {
+	refs := "1111111111111111111111111111111111111111\tHEAD\n" +
+		"2222222222222222222222222222222222222222\trefs/heads/v1\n" +
+		"3333333333333333333333333333333333333333\trefs/tags/v1\n" +
+		"4444444444444444444444444444444444444444\trefs/tags/v2\n" +
+		"5555555555555555555555555555555555555555\trefs/tags/v2^{}\n" +
+		"6666666666666666666666666666666666666666\trefs/tags/v3\n"
+	tests := map[string]string{
+		"":   "1111111111111111111111111111111111111111",
+		"v1": "2222222222222222222222222222222222222222",
+		"v2": "5555555555555555555555555555555555555555",
+		"v3": "6666666666666666666666666666666666666666",
+	}
+	for ref, expected := range tests {
+		r := &repository{exec: makeExecFunc(refs, nil)}
+		result, err := r.GetRemoteCommit("https://test/url/to/repository", ref, nil)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", ref, err)
+		}
+		if result != expected {
+			t.Errorf("%q: unexpected result: %s. Expected: %s", ref, result, expected)
+		}
+	}
+
+	r := &repository{exec: makeExecFunc(refs, nil)}
+	if _, err := r.GetRemoteCommit("https://test/url/to/repository", "missing", nil); err == nil {
+		t.Errorf("Expected an error for a missing ref")
+	}
+}
+
+func TestGetRemoteCommitArguments(t *testing.T) {
+	var env, args []string
+	r := &repository{exec: func(dir string, cmdEnv []string, name string, cmdArgs ...string) (string, string, error) {
+		env, args = cmdEnv, cmdArgs
+		return "1111111111111111111111111111111111111111\tHEAD\n", "", nil
+	}}
+	if _, err := r.GetRemoteCommit("https://test/url/to/repository", "", []string{"https_proxy=http://proxy:3128"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"ls-remote", "--", "https://test/url/to/repository", "HEAD"}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected arguments %v, got %v", expected, args)
+	}
+	if expected := []string{"https_proxy=http://proxy:3128", "GIT_ALLOW_PROTOCOL=http:https:git:ssh"}; !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected environment %v, got %v", expected, env)
+	}
+
+	r = &repository{exec: makeExecFunc("", fmt.Errorf("git must not run"))}
+	if _, err := r.GetRemoteCommit("--upload-pack=touch /tmp/test", "", nil); err == nil || !strings.Contains(err.Error(), "invalid repository URL") {
+		t.Errorf("Expected an invalid repository URL error, got %v", err)
+	}
+}
+
 func makeExecFunc(output string, err error) execCmdFunc {
-	return func(dir, name string, args ...string) (out string, errout string, resultErr error) {
+	return func(dir string, env []string, name string, args ...string) (out string, errout string, resultErr error) {
 		out = output
 		resultErr = err
 		return
@@ -51,4 +51,5 @@ type DockerConfig struct {
 	NetworkDisabled bool                `json:"NetworkDisabled,omitempty"`
 	SecurityOpts    []string            `json:"SecurityOpts,omitempty"`
 	OnBuild         []string            `json:"OnBuild,omitempty"`
+	Labels          map[string]string   `json:"Labels,omitempty"`
 }